@@ -2,24 +2,18 @@
 
 import { Command } from 'commander';
 import { MCPServer } from './server/mcp-server';
-import { ServerConfig } from './types/config';
-import { loadGrafanaConfig, validateGrafanaConfig } from './config/environment';
+import { GrafanaConfig, RateLimitConfig, ServerConfig } from './types/config';
+import * as fs from 'fs';
+import {
+  ENV_FILE,
+  loadGrafanaConfig,
+  reloadEnvFile,
+  validateGrafanaConfig,
+} from './config/environment';
 import { TOOL_CATEGORIES } from './types';
-
-// Import tool registrations
-import { registerSearchTools } from './tools/search';
-import { registerDashboardTools } from './tools/dashboard';
-import { registerDatasourceTools } from './tools/datasource';
-import { registerPrometheusTools } from './tools/prometheus';
-import { registerLokiTools } from './tools/loki';
-import { registerIncidentTools } from './tools/incident';
-import { registerAlertingTools } from './tools/alerting';
-import { registerOncallTools } from './tools/oncall';
-import { registerAdminTools } from './tools/admin';
-import { registerSiftTools } from './tools/sift';
-import { registerPyroscopeTools } from './tools/pyroscope';
-import { registerNavigationTools } from './tools/navigation';
-import { registerAssertsTools } from './tools/asserts';
+import { registerTools } from './tools';
+import { createInProcessClient } from './server/in-process';
+import { startMockGrafana } from './server/mock-grafana';
 
 const program = new Command();
 
@@ -30,10 +24,55 @@ program
 
 // Transport options
 program
-  .option('-t, --transport <type>', 'Transport type (stdio, sse, streamable-http)', 'stdio')
+  .option(
+    '-t, --transport <type>',
+    'Transport type (stdio, sse, streamable-http, websocket)',
+    'stdio'
+  )
   .option('-a, --address <address>', 'Server address for HTTP transports', '127.0.0.1')
   .option('-p, --port <port>', 'Server port for HTTP transports', '3000')
-  .option('--path <path>', 'Server path for SSE transport', '/events');
+  .option('--path <path>', 'Server path for SSE transport', '/events')
+  .option(
+    '--base-path <prefix>',
+    'Prefix of all HTTP endpoints behind a reverse proxy, e.g. /mcp/grafana (env: BASE_PATH)',
+    process.env.BASE_PATH
+  )
+  .option(
+    '--session-store <store>',
+    'Where streamable HTTP sessions are kept for resuming: memory or a redis:// URL ' +
+      '(env: SESSION_STORE)',
+    process.env.SESSION_STORE || 'memory'
+  )
+  .option(
+    '--session-ttl <minutes>',
+    'Minutes after which idle HTTP sessions expire (env: SESSION_TTL)',
+    process.env.SESSION_TTL || '30'
+  )
+  .option(
+    '--keep-alive <seconds>',
+    'Seconds between keep-alive pings on SSE event streams, 0 to disable (env: SSE_KEEP_ALIVE)',
+    process.env.SSE_KEEP_ALIVE || '15'
+  )
+  .option(
+    '--stateless',
+    'Serve streamable HTTP without sessions, for load balancers and serverless (env: STATELESS)'
+  )
+  .option(
+    '--cors-allowed-origins <origins>',
+    'Comma-separated origins browser clients may connect from, "*" for any ' +
+      '(env: CORS_ALLOWED_ORIGINS)',
+    process.env.CORS_ALLOWED_ORIGINS
+  )
+  .option(
+    '--cors-allowed-headers <headers>',
+    'Comma-separated request headers to allow (env: CORS_ALLOWED_HEADERS)',
+    process.env.CORS_ALLOWED_HEADERS
+  )
+  .option(
+    '--cors-allowed-methods <methods>',
+    'Comma-separated request methods to allow (env: CORS_ALLOWED_METHODS)',
+    process.env.CORS_ALLOWED_METHODS
+  );
 
 // Tool category options
 TOOL_CATEGORIES.forEach(category => {
@@ -43,113 +82,335 @@ TOOL_CATEGORIES.forEach(category => {
   );
 });
 
+program.option(
+  '--disable-write',
+  'Disable tools that create, update, or delete Grafana resources (env: DISABLE_WRITE)'
+);
+
+program.option(
+  '--dry-run',
+  'Make write tools report the changes they would make without applying them (env: DRY_RUN)'
+);
+
+program.option(
+  '--max-result-size <chars>',
+  'Return tool results larger than this as MCP resources, 0 to disable (env: MAX_RESULT_SIZE)',
+  process.env.MAX_RESULT_SIZE || '50000'
+);
+
+program.option(
+  '--shutdown-timeout <seconds>',
+  'Time to let in-flight tool calls finish when shutting down (env: SHUTDOWN_TIMEOUT)',
+  process.env.SHUTDOWN_TIMEOUT || '30'
+);
+
+program.option(
+  '--rate-limit <calls>',
+  'Maximum tool calls per minute per client and tool category (env: RATE_LIMIT)',
+  process.env.RATE_LIMIT
+);
+
+program.option(
+  '--rate-limit-categories <limits>',
+  'Per-category limits overriding --rate-limit, e.g. prometheus:30,loki:10 ' +
+    '(env: RATE_LIMIT_CATEGORIES)',
+  process.env.RATE_LIMIT_CATEGORIES
+);
+
+program.option(
+  '--instructions <text>',
+  'Instructions for clients describing this deployment, e.g. naming conventions and ' +
+    'important datasource UIDs (env: MCP_INSTRUCTIONS)',
+  process.env.MCP_INSTRUCTIONS
+);
+
+program.option(
+  '--instructions-file <path>',
+  'Read the instructions from a file (env: MCP_INSTRUCTIONS_FILE)',
+  process.env.MCP_INSTRUCTIONS_FILE
+);
+
+program.option(
+  '--alert-webhook-port <port>',
+  'Receive Grafana alert webhooks on this port for list_recent_alert_events ' +
+    '(env: ALERT_WEBHOOK_PORT)',
+  process.env.ALERT_WEBHOOK_PORT
+);
+
+program.option(
+  '--alert-webhook-address <address>',
+  'Address of the alert webhook receiver (default: --address; env: ALERT_WEBHOOK_ADDRESS)',
+  process.env.ALERT_WEBHOOK_ADDRESS
+);
+
+program.option(
+  '--alert-webhook-token <token>',
+  'Bearer token the webhook contact point must send (env: ALERT_WEBHOOK_TOKEN)',
+  process.env.ALERT_WEBHOOK_TOKEN
+);
+
+program.option(
+  '--watch-config',
+  'Reload the configuration like SIGHUP when the .env or token file changes (env: WATCH_CONFIG)'
+);
+
 // Grafana options
 program
   .option('--grafana-url <url>', 'Grafana instance URL (overrides GRAFANA_URL env var)')
   .option('--grafana-token <token>', 'Grafana service account token (overrides env var)')
-  .option('--debug', 'Enable debug logging', false);
+  .option('--debug', 'Enable debug logging', false)
+  .option(
+    '--mock',
+    'Serve canned data from a built-in fake Grafana instead of a real instance (env: MOCK)'
+  );
 
-// Parse command line arguments
-program.parse();
-const options = program.opts();
+// Helper function to start the fake Grafana when mock mode is enabled, returning its URL
+async function startMock(): Promise<string | undefined> {
+  if (!program.opts().mock && process.env.MOCK !== 'true') {
+    return undefined;
+  }
+  const mock = await startMockGrafana();
+  console.error(`Mock mode: serving canned Grafana data from ${mock.url}`);
+  return mock.url;
+}
 
-async function main() {
-  try {
-    // Load configuration
-    const grafanaConfig = loadGrafanaConfig();
-    
-    // Override with CLI options
-    if (options.grafanaUrl) {
-      grafanaConfig.url = options.grafanaUrl;
+// Helper function to split a comma-separated option
+function splitList(value: string): string[] {
+  return value.split(',').map(item => item.trim()).filter(item => item);
+}
+
+// Helper function to parse the rate limit options, e.g. "60" and "prometheus:30,loki:10"
+function buildRateLimitConfig(
+  perMinute?: string,
+  categories?: string
+): RateLimitConfig | undefined {
+  if (!perMinute && !categories) {
+    return undefined;
+  }
+  const config: RateLimitConfig = { categories: {} };
+  if (perMinute) {
+    config.perMinute = parseLimit(perMinute, '--rate-limit');
+  }
+  for (const entry of (categories || '').split(',').filter(entry => entry.trim())) {
+    const [category, limit] = entry.split(':').map(part => part.trim());
+    if (!TOOL_CATEGORIES.some(known => known.name === category)) {
+      throw new Error(`Unknown tool category "${category}" in --rate-limit-categories`);
     }
-    if (options.grafanaToken) {
-      grafanaConfig.serviceAccountToken = options.grafanaToken;
+    config.categories[category] = parseLimit(limit, `--rate-limit-categories ${category}`);
+  }
+  return config;
+}
+
+function parseLimit(value: string | undefined, option: string): number {
+  const limit = Number(value);
+  if (!Number.isFinite(limit) || limit <= 0) {
+    throw new Error(`${option} must be a positive number of calls per minute`);
+  }
+  return limit;
+}
+
+// Helper function to build the server configuration from the command line and environment
+function buildServerConfig(requireGrafana: boolean, mockUrl?: string): ServerConfig {
+  const options = program.opts();
+  
+  // Load configuration
+  const grafanaConfig = loadGrafanaConfig();
+  
+  // Override with CLI options
+  if (options.grafanaUrl) {
+    grafanaConfig.url = options.grafanaUrl;
+  }
+  if (options.grafanaToken) {
+    grafanaConfig.serviceAccountToken = options.grafanaToken;
+  }
+  if (options.debug) {
+    grafanaConfig.debug = true;
+  }
+  if (mockUrl) {
+    grafanaConfig.url = mockUrl;
+    grafanaConfig.serviceAccountToken = 'mock';
+  }
+  
+  // Determine enabled tools; categories can also be disabled from the environment,
+  // which is re-read when the configuration is reloaded
+  const enabledTools = new Set<string>();
+  const disabledByEnv = (process.env.DISABLED_CATEGORIES || '').split(',').map(name => name.trim());
+  TOOL_CATEGORIES.forEach(category => {
+    const disableKey = `disable${category.name.charAt(0).toUpperCase() + category.name.slice(1)}`;
+    if (!options[disableKey] && !disabledByEnv.includes(category.name)) {
+      enabledTools.add(category.name);
     }
-    if (options.debug) {
-      grafanaConfig.debug = true;
+  });
+  
+  return {
+    transport: options.transport as ServerConfig['transport'],
+    address: options.address,
+    port: parseInt(options.port),
+    path: options.path,
+    basePath: options.basePath,
+    sessionStore: options.sessionStore,
+    sessionTtl: parseFloat(options.sessionTtl),
+    stateless: options.stateless || process.env.STATELESS === 'true',
+    keepAlive: parseFloat(options.keepAlive),
+    cors: options.corsAllowedOrigins
+      ? {
+        allowedOrigins: splitList(options.corsAllowedOrigins),
+        allowedHeaders: options.corsAllowedHeaders && splitList(options.corsAllowedHeaders),
+        allowedMethods: options.corsAllowedMethods && splitList(options.corsAllowedMethods),
+      }
+      : undefined,
+    enabledTools,
+    disableWrite: options.disableWrite || process.env.DISABLE_WRITE === 'true',
+    dryRun: options.dryRun || process.env.DRY_RUN === 'true',
+    maxResultSize: parseInt(options.maxResultSize),
+    shutdownTimeout: parseFloat(options.shutdownTimeout),
+    rateLimit: buildRateLimitConfig(options.rateLimit, options.rateLimitCategories),
+    alertWebhook: options.alertWebhookPort
+      ? {
+        port: parseInt(options.alertWebhookPort),
+        address: options.alertWebhookAddress || options.address,
+        token: options.alertWebhookToken,
+      }
+      : undefined,
+    instructions: options.instructionsFile
+      ? fs.readFileSync(options.instructionsFile, 'utf8').trim()
+      : options.instructions,
+    // Commands that never contact Grafana work without a complete configuration
+    grafanaConfig: requireGrafana
+      ? validateGrafanaConfig(grafanaConfig)
+      : grafanaConfig as GrafanaConfig,
+  };
+}
+
+// Print the registered tools, for security review and client configuration
+function listTools(commandOptions: { json?: boolean }) {
+  const server = new MCPServer(buildServerConfig(false));
+  registerTools(server);
+  const tools = server.describeTools();
+  
+  if (commandOptions.json) {
+    console.log(JSON.stringify(tools, null, 2));
+    return;
+  }
+  
+  const rows = tools.map(tool => [
+    tool.name,
+    tool.category || '-',
+    tool.access,
+    tool.enabled ? 'yes' : 'no',
+  ]);
+  const header = ['TOOL', 'CATEGORY', 'ACCESS', 'ENABLED'];
+  const widths = header.map((title, i) => Math.max(title.length, ...rows.map(row => row[i].length)));
+  for (const row of [header, ...rows]) {
+    console.log(row.map((cell, i) => cell.padEnd(widths[i])).join('  ').trimEnd());
+  }
+  console.log(`\n${tools.length} tools, ${tools.filter(tool => tool.enabled).length} enabled`);
+}
+
+// Invoke a single tool and print its result, for scripting and debugging
+async function callTool(name: string, commandOptions: { args: string }) {
+  try {
+    let args: any;
+    try {
+      args = JSON.parse(commandOptions.args);
+    } catch (error: any) {
+      throw new Error(`--args must be a JSON object: ${error.message}`);
+    }
+    if (!args || typeof args !== 'object' || Array.isArray(args)) {
+      throw new Error('--args must be a JSON object');
     }
     
-    // Validate configuration
-    const validatedConfig = validateGrafanaConfig(grafanaConfig);
+    // The in-process client is closed before printing, so large results are printed in full
+    // instead of as a link to a resource that no longer exists
+    const serverConfig = { ...buildServerConfig(true, await startMock()), maxResultSize: 0 };
+    const server = new MCPServer(serverConfig);
+    registerTools(server, ...serverConfig.enabledTools);
     
-    // Determine enabled tools
-    const enabledTools = new Set<string>();
-    TOOL_CATEGORIES.forEach(category => {
-      const disableKey = `disable${category.name.charAt(0).toUpperCase() + category.name.slice(1)}`;
-      if (!options[disableKey]) {
-        enabledTools.add(category.name);
-      }
-    });
+    const client = await createInProcessClient(server);
+    const result: any = await client.callTool({ name, arguments: args });
+    await client.close();
     
-    // Create server configuration
-    const serverConfig: ServerConfig = {
-      transport: options.transport as 'stdio' | 'sse' | 'streamable-http',
-      address: options.address,
-      port: parseInt(options.port),
-      path: options.path,
-      enabledTools,
-      grafanaConfig: validatedConfig,
-    };
+    for (const content of result.content || []) {
+      if (content.type === 'text') {
+        console.log(content.text);
+      } else if (content.type === 'resource_link') {
+        console.log(`Resource: ${content.uri}`);
+      }
+    }
+    process.exit(result.isError ? 1 : 0);
+  } catch (error: any) {
+    console.error(`Failed to call tool "${name}":`, error.message);
+    process.exit(1);
+  }
+}
+
+async function main() {
+  try {
+    const mockUrl = await startMock();
+    const serverConfig = buildServerConfig(true, mockUrl);
+    const enabledTools = serverConfig.enabledTools;
     
     // Create and configure server
     const server = new MCPServer(serverConfig);
     
-    // Register tools based on enabled categories
-    if (enabledTools.has('search')) {
-      registerSearchTools(server);
-    }
-    if (enabledTools.has('dashboard')) {
-      registerDashboardTools(server);
-    }
-    if (enabledTools.has('datasource')) {
-      registerDatasourceTools(server);
-    }
-    if (enabledTools.has('prometheus')) {
-      registerPrometheusTools(server);
-    }
-    if (enabledTools.has('loki')) {
-      registerLokiTools(server);
-    }
-    if (enabledTools.has('incident')) {
-      registerIncidentTools(server);
-    }
-    if (enabledTools.has('alerting')) {
-      registerAlertingTools(server);
-    }
-    if (enabledTools.has('oncall')) {
-      registerOncallTools(server);
-    }
-    if (enabledTools.has('admin')) {
-      registerAdminTools(server);
-    }
-    if (enabledTools.has('sift')) {
-      registerSiftTools(server);
-    }
-    if (enabledTools.has('pyroscope')) {
-      registerPyroscopeTools(server);
-    }
-    if (enabledTools.has('navigation')) {
-      registerNavigationTools(server);
-    }
-    if (enabledTools.has('asserts')) {
-      registerAssertsTools(server);
-    }
+    // Register every category; the enabled ones are listed and callable, and a reload can
+    // enable others
+    registerTools(server);
     
-    // Handle shutdown signals
-    process.on('SIGINT', async () => {
+    // Handle shutdown signals: drain in-flight tool calls, exit at once on a second signal
+    let shuttingDown = false;
+    const shutdown = async () => {
+      if (shuttingDown) {
+        process.exit(1);
+      }
+      shuttingDown = true;
       console.log('\nShutting down MCP server...');
-      await server.stop();
+      await server.shutdown((serverConfig.shutdownTimeout ?? 30) * 1000);
       process.exit(0);
-    });
+    };
+    process.on('SIGINT', shutdown);
+    process.on('SIGTERM', shutdown);
     
-    process.on('SIGTERM', async () => {
-      await server.stop();
-      process.exit(0);
-    });
+    // Reload credentials, TLS files, and enabled tools without dropping sessions
+    const reload = async () => {
+      try {
+        reloadEnvFile();
+        await server.reloadConfig(buildServerConfig(true, mockUrl));
+      } catch (error: any) {
+        console.error('Failed to reload configuration, keeping the current one:', error.message);
+      }
+    };
+    process.on('SIGHUP', reload);
+    if (program.opts().watchConfig || process.env.WATCH_CONFIG === 'true') {
+      // Polling also notices secrets that Kubernetes swaps in through symlinks
+      const files = [ENV_FILE, process.env.GRAFANA_SERVICE_ACCOUNT_TOKEN_FILE];
+      for (const file of files.filter((file): file is string => !!file)) {
+        fs.watchFile(file, { interval: 2000 }, (current, previous) => {
+          if (current.mtimeMs !== previous.mtimeMs) {
+            reload();
+          }
+        });
+      }
+    }
     
     // Start the server
-    console.log(`Starting MCP Grafana server with ${options.transport} transport...`);
+    console.log(`Starting MCP Grafana server with ${serverConfig.transport} transport...`);
     console.log(`Enabled tool categories: ${Array.from(enabledTools).join(', ')}`);
+    if (serverConfig.disableWrite) {
+      console.error('Write tools are disabled');
+    }
+    if (serverConfig.dryRun) {
+      console.error('Write tools run in dry-run mode');
+    }
+    if (serverConfig.alertWebhook && !serverConfig.alertWebhook.token) {
+      console.error('Warning: the alert webhook receiver accepts payloads without a token');
+    }
+    if (serverConfig.stateless) {
+      if (serverConfig.transport !== 'streamable-http') {
+        throw new Error('--stateless requires the streamable-http transport');
+      }
+      console.error('Serving streamable HTTP without sessions');
+    }
     
     await server.start();
     
@@ -159,4 +420,22 @@ async function main() {
   }
 }
 
-main();
\ No newline at end of file
+const toolsCommand = program
+  .command('tools')
+  .description('Inspect the tools provided by this server');
+toolsCommand
+  .command('list')
+  .description('List all tools with their category, access, and whether they are enabled')
+  .option('--json', 'Print full descriptions and input schemas as JSON')
+  .action(listTools);
+
+program
+  .command('call <tool>')
+  .description('Call a single tool and print its result')
+  .option('--args <json>', 'The tool arguments as a JSON object', '{}')
+  .action(callTool);
+
+program.action(main);
+
+// Parse command line arguments
+program.parseAsync();
\ No newline at end of file
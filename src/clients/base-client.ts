@@ -1,7 +1,214 @@
 import axios, { AxiosInstance, AxiosRequestConfig } from 'axios';
 import { GrafanaConfig } from '../types/config';
+import * as http from 'http';
 import * as https from 'https';
-import * as fs from 'fs';
+import * as tls from 'tls';
+import { createHash } from 'crypto';
+import { applyDryRun } from './dry-run';
+import { applyCassette } from './cassette';
+import { applyRequestContext } from './request-context';
+import { readTlsFile } from './tls-files';
+
+export interface AuthConfig {
+  headers: Record<string, string>;
+  auth?: { username: string; password: string };
+}
+
+// Build the authentication part of a request config for Grafana and its plugin APIs
+export function buildAuthConfig(config: GrafanaConfig): AuthConfig {
+  const headers: Record<string, string> = {};
+  const authConfig: AuthConfig = { headers };
+
+  if (config.accessToken && config.idToken) {
+    // On-behalf-of auth for Grafana Cloud: act as the user identified by the ID token
+    headers['X-Access-Token'] = config.accessToken;
+    headers['X-Grafana-Id'] = config.idToken;
+  } else if (config.serviceAccountToken) {
+    headers['Authorization'] = `Bearer ${config.serviceAccountToken}`;
+  } else if (config.apiKey) {
+    headers['Authorization'] = `Bearer ${config.apiKey}`;
+  } else if (config.username && config.password) {
+    authConfig.auth = {
+      username: config.username,
+      password: config.password,
+    };
+  } else if (config.accessToken) {
+    headers['Authorization'] = `Bearer ${config.accessToken}`;
+  }
+
+  return authConfig;
+}
+
+// Agents shared by all clients with the same settings, so parallel tool calls reuse pooled
+// connections instead of each client opening its own
+const sharedAgents: Map<string, { fingerprint: string; agent: http.Agent }> = new Map();
+
+// Connection pool settings of the agents; keep-alive is on unless disabled
+function agentOptions(config: GrafanaConfig): http.AgentOptions {
+  const pool = config.connectionPool;
+  return {
+    keepAlive: pool?.keepAlive ?? true,
+    maxSockets: pool?.maxConnsPerHost || Infinity,
+    maxFreeSockets: pool?.maxIdleConnsPerHost ?? 10,
+    // Idle pooled connections are closed after this long
+    timeout: pool?.idleTimeout ?? 90000,
+  };
+}
+
+// Returns the agent shared under a key, replacing it when its options changed. A replaced
+// agent is left to finish its requests and close its idle connections.
+function sharedAgent<T extends http.Agent>(
+  key: string,
+  options: object,
+  create: () => T
+): T {
+  const fingerprint = createHash('sha256').update(JSON.stringify(options)).digest('hex');
+  const shared = sharedAgents.get(key);
+  if (shared?.fingerprint === fingerprint) {
+    return shared.agent as T;
+  }
+  const agent = create();
+  sharedAgents.set(key, { fingerprint, agent });
+  return agent;
+}
+
+// Agent for plain HTTP Grafana URLs
+export function createHttpAgent(config: GrafanaConfig): http.Agent {
+  const options = agentOptions(config);
+  return sharedAgent('http', options, () => new http.Agent(options));
+}
+
+// Build an HTTPS agent from the TLS configuration. Rotated TLS files replace the shared agent,
+// while requests in flight finish on the previous one.
+export function createHttpsAgent(config: GrafanaConfig): https.Agent {
+  const options: https.AgentOptions = agentOptions(config);
+  const tlsConfig = config.tlsConfig;
+
+  if (tlsConfig) {
+    options.rejectUnauthorized = !tlsConfig.skipVerify;
+    const cert = tlsConfig.cert || (tlsConfig.certFile && readTlsFile(tlsConfig.certFile));
+    const key = tlsConfig.key || (tlsConfig.keyFile && readTlsFile(tlsConfig.keyFile));
+    if (cert && key) {
+      options.cert = cert;
+      options.key = key;
+    }
+    const ca = tlsConfig.ca || (tlsConfig.caFile && readTlsFile(tlsConfig.caFile).toString());
+    if (tlsConfig.useSystemCa) {
+      options.ca = [...systemCertificates(), ...(ca ? [ca] : [])];
+    } else if (ca) {
+      options.ca = ca;
+    }
+  }
+
+  return sharedAgent('https', options, () => new https.Agent(options));
+}
+
+let systemCa: string[] | undefined;
+
+// Certificates of the operating system's trust store, read once
+function systemCertificates(): string[] {
+  const getCACertificates = (tls as any).getCACertificates;
+  if (typeof getCACertificates !== 'function') {
+    throw new Error(
+      `TLS_USE_SYSTEM_CA requires Node.js 22.15 or later (running ${process.version})`
+    );
+  }
+  systemCa ??= getCACertificates('system') as string[];
+  return systemCa;
+}
+
+// Resolves the base URL of a client for a Grafana listening on a unix socket, such as
+// unix:///var/run/grafana/grafana.sock in sidecar deployments: requests then go to
+// http://localhost over the socket
+export function resolveGrafanaTarget(
+  config: GrafanaConfig,
+  baseURL: string
+): { baseURL: string; socketPath?: string } {
+  const root = config.url.replace(/\/$/, '');
+  if (!root.startsWith('unix://') || !baseURL.startsWith(root)) {
+    return { baseURL };
+  }
+  return {
+    baseURL: `http://localhost${baseURL.slice(root.length)}`,
+    socketPath: root.slice('unix://'.length),
+  };
+}
+
+// Adds dry-run mode, cassette record/replay, and request correlation to an axios instance
+export function instrumentClient(client: AxiosInstance): AxiosInstance {
+  return applyRequestContext(applyCassette(applyDryRun(client)));
+}
+
+// Configured axios instances kept for reuse
+const MAX_CACHED_CLIENTS = 100;
+
+// Axios instances keyed by target, credentials, and settings, so tool calls with the same
+// credentials reuse one instead of configuring a new client every time
+const clientCache: Map<string, { client: AxiosInstance; agents: http.Agent[] }> = new Map();
+
+// Returns the cached axios instance for a request config, or creates it. setup runs once per
+// new instance, e.g. to add interceptors. Instances are replaced along with their agents, such
+// as after a certificate rotation.
+function cachedClient(
+  kind: string,
+  axiosConfig: AxiosRequestConfig,
+  setup?: (client: AxiosInstance) => void
+): AxiosInstance {
+  const agents = [axiosConfig.httpAgent, axiosConfig.httpsAgent];
+  // Credentials only enter the key as part of a hash
+  const key = createHash('sha256')
+    .update(
+      JSON.stringify([
+        kind,
+        axiosConfig.baseURL,
+        axiosConfig.socketPath,
+        axiosConfig.timeout,
+        axiosConfig.headers,
+        axiosConfig.auth,
+      ])
+    )
+    .digest('hex');
+
+  const cached = clientCache.get(key);
+  clientCache.delete(key);
+  if (cached && cached.agents.every((agent, i) => agent === agents[i])) {
+    clientCache.set(key, cached);
+    return cached.client;
+  }
+
+  const client = instrumentClient(axios.create(axiosConfig));
+  setup?.(client);
+  clientCache.set(key, { client, agents });
+  if (clientCache.size > MAX_CACHED_CLIENTS) {
+    // Maps iterate in insertion order, and used entries are moved to the end
+    const oldest = clientCache.keys().next().value;
+    if (oldest !== undefined) clientCache.delete(oldest);
+  }
+  return client;
+}
+
+// Create an axios instance for a Grafana plugin API (OnCall, Incident, Sift, ...)
+export function createPluginClient(
+  config: GrafanaConfig,
+  baseURL: string,
+  timeout = 30000
+): AxiosInstance {
+  const authConfig = buildAuthConfig(config);
+
+  return cachedClient('plugin', {
+    ...resolveGrafanaTarget(config, baseURL),
+    timeout,
+    headers: {
+      'User-Agent': 'mcp-grafana/1.0.0',
+      'Content-Type': 'application/json',
+      ...config.extraHeaders,
+      ...authConfig.headers,
+    },
+    auth: authConfig.auth,
+    httpAgent: createHttpAgent(config),
+    httpsAgent: createHttpsAgent(config),
+  });
+}
 
 export abstract class BaseClient {
   protected client: AxiosInstance;
@@ -10,89 +217,62 @@ export abstract class BaseClient {
   constructor(config: GrafanaConfig, baseURL?: string) {
     this.config = config;
     
+    const authConfig = buildAuthConfig(config);
     const axiosConfig: AxiosRequestConfig = {
-      baseURL: baseURL || config.url,
+      ...resolveGrafanaTarget(config, baseURL || config.url),
       timeout: 30000,
       headers: {
         'User-Agent': 'mcp-grafana/1.0.0',
+        ...config.extraHeaders,
+        ...authConfig.headers,
       },
+      auth: authConfig.auth,
+      httpAgent: createHttpAgent(config),
+      httpsAgent: createHttpsAgent(config),
     };
 
-    // Set up authentication
-    if (config.serviceAccountToken) {
-      axiosConfig.headers!['Authorization'] = `Bearer ${config.serviceAccountToken}`;
-    } else if (config.apiKey) {
-      axiosConfig.headers!['Authorization'] = `Bearer ${config.apiKey}`;
-    } else if (config.username && config.password) {
-      axiosConfig.auth = {
-        username: config.username,
-        password: config.password,
-      };
-    } else if (config.accessToken) {
-      axiosConfig.headers!['Authorization'] = `Bearer ${config.accessToken}`;
-    }
-
-    // Set up ID token for on-behalf-of auth
-    if (config.idToken) {
-      axiosConfig.headers!['X-Id-Token'] = config.idToken;
-    }
-
-    // Set up TLS configuration
-    if (config.tlsConfig) {
-      const httpsAgent = new https.Agent({
-        rejectUnauthorized: !config.tlsConfig.skipVerify,
-      });
-
-      if (config.tlsConfig.certFile && config.tlsConfig.keyFile) {
-        httpsAgent.options.cert = fs.readFileSync(config.tlsConfig.certFile);
-        httpsAgent.options.key = fs.readFileSync(config.tlsConfig.keyFile);
+    this.client = cachedClient(config.debug ? 'grafana-debug' : 'grafana', axiosConfig, client => {
+      // Add debug logging if enabled
+      if (config.debug) {
+        this.addDebugLogging(client);
       }
+    });
+  }
 
-      if (config.tlsConfig.caFile) {
-        httpsAgent.options.ca = fs.readFileSync(config.tlsConfig.caFile);
+  private addDebugLogging(client: AxiosInstance) {
+    client.interceptors.request.use(
+      (request) => {
+        console.log('Request:', {
+          method: request.method,
+          url: request.url,
+          headers: this.sanitizeHeaders(request.headers),
+        });
+        return request;
+      },
+      (error) => {
+        console.error('Request Error:', error);
+        return Promise.reject(error);
       }
+    );
 
-      axiosConfig.httpsAgent = httpsAgent;
-    }
-
-    this.client = axios.create(axiosConfig);
-
-    // Add debug logging if enabled
-    if (config.debug) {
-      this.client.interceptors.request.use(
-        (request) => {
-          console.log('Request:', {
-            method: request.method,
-            url: request.url,
-            headers: this.sanitizeHeaders(request.headers),
-          });
-          return request;
-        },
-        (error) => {
-          console.error('Request Error:', error);
-          return Promise.reject(error);
-        }
-      );
-
-      this.client.interceptors.response.use(
-        (response) => {
-          console.log('Response:', {
-            status: response.status,
-            statusText: response.statusText,
-            url: response.config.url,
-          });
-          return response;
-        },
-        (error) => {
-          console.error('Response Error:', {
-            status: error.response?.status,
-            statusText: error.response?.statusText,
-            data: error.response?.data,
-          });
-          return Promise.reject(error);
-        }
-      );
-    }
+    client.interceptors.response.use(
+      (response) => {
+        console.log('Response:', {
+          status: response.status,
+          statusText: response.statusText,
+          url: response.config.url,
+        });
+        return response;
+      },
+      (error) => {
+        console.error('Response Error:', {
+          status: error.response?.status,
+          statusText: error.response?.statusText,
+          data: error.response?.data,
+        });
+        return Promise.reject(error);
+      }
+    );
   }
 
   private sanitizeHeaders(headers: any): any {
@@ -100,8 +280,16 @@ export abstract class BaseClient {
     if (sanitized.Authorization) {
       sanitized.Authorization = '[REDACTED]';
     }
-    if (sanitized['X-Id-Token']) {
-      sanitized['X-Id-Token'] = '[REDACTED]';
+    // Static headers often carry gateway credentials
+    const secretHeaders = [
+      'X-Access-Token',
+      'X-Grafana-Id',
+      ...Object.keys(this.config.extraHeaders || {}),
+    ];
+    for (const header of secretHeaders) {
+      if (sanitized[header]) {
+        sanitized[header] = '[REDACTED]';
+      }
     }
     return sanitized;
   }
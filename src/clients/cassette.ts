@@ -0,0 +1,183 @@
+import { AsyncLocalStorage } from 'async_hooks';
+import { promises as fs } from 'fs';
+import { AxiosError, AxiosInstance, InternalAxiosRequestConfig } from 'axios';
+
+// Record/replay of Grafana HTTP traffic, so tool handlers can be tested deterministically
+// against captured responses. Record a cassette once against a real (or the mock) Grafana,
+// commit it as a fixture, and replay it in tests without network access.
+
+export interface CassetteInteraction {
+  request: {
+    method: string;
+    url: string;
+    params?: Record<string, any>;
+    body?: any;
+  };
+  response: {
+    status: number;
+    headers?: Record<string, string>;
+    data: any;
+  };
+}
+
+export type CassetteMode = 'record' | 'replay';
+
+export interface CassetteOptions {
+  // Query parameters left out when matching requests, e.g. time ranges computed from "now"
+  ignoreParams?: string[];
+}
+
+export class Cassette {
+  readonly mode: CassetteMode;
+  readonly interactions: CassetteInteraction[];
+  private options: CassetteOptions;
+  private used: Set<number> = new Set();
+
+  constructor(
+    mode: CassetteMode,
+    interactions: CassetteInteraction[] = [],
+    options: CassetteOptions = {}
+  ) {
+    this.mode = mode;
+    this.interactions = interactions;
+    this.options = options;
+  }
+
+  // Loads a cassette file for replay
+  static async load(file: string, options: CassetteOptions = {}): Promise<Cassette> {
+    const content = JSON.parse(await fs.readFile(file, 'utf8'));
+    return new Cassette('replay', content.interactions || [], options);
+  }
+
+  // Writes the recorded interactions to a cassette file
+  async save(file: string): Promise<void> {
+    await fs.writeFile(file, JSON.stringify({ interactions: this.interactions }, null, 2) + '\n');
+  }
+
+  record(interaction: CassetteInteraction) {
+    this.interactions.push(interaction);
+  }
+
+  // Finds the recorded response for a request. Identical requests replay their recordings in
+  // order, and the last one is repeated once all have been used.
+  match(request: CassetteInteraction['request']): CassetteInteraction['response'] {
+    const key = this.requestKey(request);
+    let last: number | undefined;
+    for (let i = 0; i < this.interactions.length; i++) {
+      if (this.requestKey(this.interactions[i].request) !== key) continue;
+      if (!this.used.has(i)) {
+        this.used.add(i);
+        return this.interactions[i].response;
+      }
+      last = i;
+    }
+    if (last !== undefined) {
+      return this.interactions[last].response;
+    }
+    throw new Error(`No recorded response for ${request.method} ${request.url} (${key})`);
+  }
+
+  private requestKey(request: CassetteInteraction['request']): string {
+    const ignored = new Set(this.options.ignoreParams || []);
+    const params = Object.entries(request.params || {})
+      .filter(([name, value]) => !ignored.has(name) && value !== undefined)
+      .sort(([a], [b]) => a.localeCompare(b));
+    return JSON.stringify([request.method, request.url, params, request.body ?? null]);
+  }
+}
+
+const activeCassette = new AsyncLocalStorage<Cassette>();
+
+// Runs a function (typically a tool handler) with its Grafana requests recorded to or
+// replayed from a cassette
+export async function withCassette<T>(cassette: Cassette, fn: () => Promise<T>): Promise<T> {
+  return activeCassette.run(cassette, fn);
+}
+
+function describeRequest(request: InternalAxiosRequestConfig): CassetteInteraction['request'] {
+  const url = /^https?:\/\//.test(request.url || '')
+    ? request.url || ''
+    : `${(request.baseURL || '').replace(/\/$/, '')}${request.url || ''}`;
+  let body = request.data;
+  if (typeof body === 'string') {
+    try {
+      body = JSON.parse(body);
+    } catch {
+      // Keep non-JSON bodies as they are
+    }
+  }
+  return {
+    method: (request.method || 'get').toUpperCase(),
+    // Cassettes are shared, so they never contain the host or credentials of the recording
+    url: url.replace(/^https?:\/\/[^/]+/, ''),
+    params: request.params,
+    body,
+  };
+}
+
+// Makes an axios instance record to or replay from the active cassette
+export function applyCassette(client: AxiosInstance): AxiosInstance {
+  client.interceptors.request.use(request => {
+    const cassette = activeCassette.getStore();
+    if (cassette?.mode !== 'replay') {
+      return request;
+    }
+
+    const described = describeRequest(request);
+    request.adapter = async config => {
+      const recorded = cassette.match(described);
+      const response = {
+        data: recorded.data,
+        status: recorded.status,
+        statusText: 'OK (replayed)',
+        headers: recorded.headers || {},
+        config,
+      };
+      if (recorded.status >= 400) {
+        throw new AxiosError(
+          `Request failed with status code ${recorded.status}`,
+          AxiosError.ERR_BAD_RESPONSE,
+          config,
+          undefined,
+          response
+        );
+      }
+      return response;
+    };
+    return request;
+  });
+
+  const recordResponse = (
+    config: InternalAxiosRequestConfig | undefined,
+    status: number,
+    data: any,
+    headers: any
+  ) => {
+    const cassette = activeCassette.getStore();
+    if (cassette?.mode !== 'record' || !config) return;
+    const contentType = headers?.['content-type'];
+    cassette.record({
+      request: describeRequest(config),
+      response: {
+        status,
+        headers: contentType ? { 'content-type': String(contentType) } : undefined,
+        data,
+      },
+    });
+  };
+
+  client.interceptors.response.use(
+    response => {
+      recordResponse(response.config, response.status, response.data, response.headers);
+      return response;
+    },
+    error => {
+      if (error.response) {
+        const { status, data, headers } = error.response;
+        recordResponse(error.config, status, data, headers);
+      }
+      return Promise.reject(error);
+    }
+  );
+  return client;
+}
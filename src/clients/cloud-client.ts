@@ -0,0 +1,111 @@
+import axios, { AxiosInstance } from 'axios';
+import { instrumentClient } from './base-client';
+import { GrafanaConfig } from '../types/config';
+
+export interface AccessPolicy {
+  id: string;
+  name: string;
+  displayName?: string;
+  scopes: string[];
+  realms: { type: string; identifier: string; labelPolicies?: any[] }[];
+  [key: string]: any;
+}
+
+export interface CloudToken {
+  id: string;
+  accessPolicyId: string;
+  name: string;
+  displayName?: string;
+  expiresAt?: string;
+  key?: string;
+  [key: string]: any;
+}
+
+export class CloudClient {
+  private client: AxiosInstance;
+  private region: string;
+
+  constructor(config: GrafanaConfig) {
+    if (!config.cloudApiKey) {
+      throw new Error('GRAFANA_CLOUD_API_KEY is required for Grafana Cloud API tools');
+    }
+    if (!config.cloudRegion) {
+      throw new Error('GRAFANA_CLOUD_REGION is required for Grafana Cloud API tools');
+    }
+
+    this.region = config.cloudRegion;
+    this.client = instrumentClient(axios.create({
+      baseURL: config.cloudApiUrl || 'https://grafana.com/api',
+      timeout: 30000,
+      headers: {
+        'User-Agent': 'mcp-grafana/1.0.0',
+        Authorization: `Bearer ${config.cloudApiKey}`,
+      },
+    }));
+  }
+
+  async listStacks(orgSlug?: string): Promise<any[]> {
+    try {
+      const path = orgSlug ? `/orgs/${orgSlug}/instances` : '/instances';
+      const response = await this.client.get(path);
+      return response.data.items || [];
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async listAccessPolicies(name?: string): Promise<AccessPolicy[]> {
+    try {
+      const response = await this.client.get('/v1/accesspolicies', {
+        params: { region: this.region, name },
+      });
+      return response.data.items || [];
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async createAccessPolicy(policy: Partial<AccessPolicy>): Promise<AccessPolicy> {
+    try {
+      const response = await this.client.post('/v1/accesspolicies', policy, {
+        params: { region: this.region },
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async listTokens(accessPolicyId?: string): Promise<CloudToken[]> {
+    try {
+      const response = await this.client.get('/v1/tokens', {
+        params: { region: this.region, accessPolicyId },
+      });
+      return response.data.items || [];
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async createToken(token: Partial<CloudToken>): Promise<CloudToken> {
+    try {
+      const response = await this.client.post('/v1/tokens', token, {
+        params: { region: this.region },
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  private handleError(error: any): never {
+    if (error.response) {
+      const message = error.response.data?.message || error.response.statusText;
+      throw new Error(`Grafana Cloud API error (${error.response.status}): ${message}`);
+    } else if (error.request) {
+      throw new Error('No response from Grafana Cloud API');
+    } else {
+      throw new Error(`Request error: ${error.message}`);
+    }
+  }
+}
@@ -0,0 +1,67 @@
+import { AsyncLocalStorage } from 'async_hooks';
+import { AxiosInstance } from 'axios';
+
+export interface DryRunRequest {
+  method: string;
+  url: string;
+  params?: any;
+  body?: any;
+}
+
+// POST endpoints that only read, such as datasource queries, which go through during a dry run
+const READ_ONLY_POST_PATHS = [/\/api\/ds\/query$/];
+
+// Requests a write tool would have sent, collected while it runs in dry-run mode
+const recorder = new AsyncLocalStorage<DryRunRequest[]>();
+
+// Runs a tool handler in dry-run mode: reads go through, writes are recorded instead of sent
+export async function withDryRun<T>(
+  fn: () => Promise<T>
+): Promise<{ result?: T; error?: any; requests: DryRunRequest[] }> {
+  const requests: DryRunRequest[] = [];
+  try {
+    const result = await recorder.run(requests, fn);
+    return { result, requests };
+  } catch (error) {
+    return { error, requests };
+  }
+}
+
+// Makes an axios instance record instead of send state-changing requests during a dry run
+export function applyDryRun(client: AxiosInstance): AxiosInstance {
+  client.interceptors.request.use(request => {
+    const requests = recorder.getStore();
+    const method = (request.method || 'get').toLowerCase();
+    if (!requests || method === 'get' || method === 'head') {
+      return request;
+    }
+
+    const url = /^https?:\/\//.test(request.url || '')
+      ? request.url || ''
+      : `${(request.baseURL || '').replace(/\/$/, '')}${request.url || ''}`;
+    const path = url.split('?')[0];
+    if (method === 'post' && READ_ONLY_POST_PATHS.some(pattern => pattern.test(path))) {
+      return request;
+    }
+    let body = request.data;
+    if (typeof body === 'string') {
+      try {
+        body = JSON.parse(body);
+      } catch {
+        // Keep non-JSON bodies as they are
+      }
+    }
+    requests.push({ method: method.toUpperCase(), url, params: request.params, body });
+
+    // Answer locally so the request never reaches the server
+    request.adapter = async config => ({
+      data: {},
+      status: 200,
+      statusText: 'OK (dry run)',
+      headers: {},
+      config,
+    });
+    return request;
+  });
+  return client;
+}
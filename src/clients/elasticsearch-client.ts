@@ -0,0 +1,63 @@
+import { BaseClient } from './base-client';
+import { GrafanaConfig } from '../types/config';
+
+export interface ElasticsearchHit {
+  _index: string;
+  _id: string;
+  _source?: Record<string, any>;
+  sort?: any[];
+}
+
+export interface EsqlResult {
+  columns: { name: string; type: string }[];
+  values: any[][];
+}
+
+export class ElasticsearchClient extends BaseClient {
+  constructor(config: GrafanaConfig, datasourceUid: string) {
+    // Use Grafana proxy endpoint for Elasticsearch requests
+    super(config, `${config.url}/api/datasources/proxy/uid/${datasourceUid}`);
+  }
+
+  async search(index: string, body: any): Promise<{ total: number; hits: ElasticsearchHit[] }> {
+    try {
+      const response = await this.client.post(`/${encodeURIComponent(index)}/_search`, body);
+      const total = response.data.hits?.total;
+      return {
+        // Elasticsearch 7+ reports the total as an object, older versions as a number
+        total: typeof total === 'object' ? total.value : total || 0,
+        hits: response.data.hits?.hits || [],
+      };
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Runs an ES|QL query (Elasticsearch 8.11+)
+  async esql(query: string, filter?: any): Promise<EsqlResult> {
+    try {
+      const response = await this.client.post('/_query', { query, filter });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getMapping(index: string): Promise<Record<string, any>> {
+    try {
+      const response = await this.client.get(`/${encodeURIComponent(index)}/_mapping`);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  protected handleError(error: any): never {
+    // Elasticsearch reports the reason of failed requests in error.reason
+    const reason = error.response?.data?.error?.reason;
+    if (reason) {
+      throw new Error(`Elasticsearch error (${error.response.status}): ${reason}`);
+    }
+    return super.handleError(error);
+  }
+}
@@ -50,6 +50,34 @@ export class GrafanaClient extends BaseClient {
     super(config);
   }
 
+  // Instance methods
+  async getHealth(): Promise<any> {
+    try {
+      const response = await this.client.get('/api/health');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getFrontendSettings(): Promise<any> {
+    try {
+      const response = await this.client.get('/api/frontend/settings');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async listPlugins(params?: Record<string, any>): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/plugins', { params });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
   // Dashboard methods
   async searchDashboards(query: string): Promise<any[]> {
     try {
@@ -62,6 +90,18 @@ export class GrafanaClient extends BaseClient {
     }
   }
 
+  async search(params: Record<string, any>): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/search', {
+        params,
+        paramsSerializer: { indexes: null },
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
   async getDashboardByUid(uid: string): Promise<Dashboard> {
     try {
       const response = await this.client.get(`/api/dashboards/uid/${uid}`);
@@ -71,10 +111,80 @@ export class GrafanaClient extends BaseClient {
     }
   }
 
-  async updateDashboard(dashboard: Dashboard, message?: string): Promise<any> {
+  async getDashboardWithMeta(uid: string): Promise<{ dashboard: Dashboard; meta: any }> {
+    try {
+      const response = await this.client.get(`/api/dashboards/uid/${uid}`);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Saved versions of a dashboard, newest first
+  async getDashboardVersions(uid: string, limit: number): Promise<any[]> {
+    try {
+      const response = await this.client.get(
+        `/api/dashboards/uid/${encodeURIComponent(uid)}/versions`,
+        { params: { limit } }
+      );
+      // Grafana 11 wraps the versions in a page with a continue token
+      return Array.isArray(response.data) ? response.data : response.data?.versions || [];
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getDashboardVersion(uid: string, version: number): Promise<any> {
+    try {
+      const response = await this.client.get(
+        `/api/dashboards/uid/${encodeURIComponent(uid)}/versions/${version}`
+      );
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Renders a panel of a saved dashboard as a PNG image; requires the image renderer plugin or
+  // service. Rendering can take far longer than other requests.
+  async renderPanel(uid: string, params: Record<string, any>): Promise<Buffer> {
+    try {
+      const response = await this.client.get(`/render/d-solo/${encodeURIComponent(uid)}/_`, {
+        params,
+        paramsSerializer: { indexes: null },
+        responseType: 'arraybuffer',
+        timeout: 120000,
+      });
+      return Buffer.from(response.data);
+    } catch (error: any) {
+      // Error bodies arrive as bytes too; Grafana explains missing renderers in them
+      if (error.response?.data instanceof ArrayBuffer || Buffer.isBuffer(error.response?.data)) {
+        const text = Buffer.from(error.response.data).toString();
+        try {
+          error.response.data = JSON.parse(text);
+        } catch {
+          error.response.data = { message: text.slice(0, 500) || undefined };
+        }
+      }
+      this.handleError(error);
+    }
+  }
+
+  // Returns a folder with its ancestors in parents, nearest last, on Grafana with nested folders
+  async getFolder(uid: string): Promise<any> {
+    try {
+      const response = await this.client.get(`/api/folders/${encodeURIComponent(uid)}`);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async updateDashboard(dashboard: Dashboard, message?: string, folderUid?: string): Promise<any> {
     try {
       const response = await this.client.post('/api/dashboards/db', {
         dashboard,
+        folderUid,
         message: message || 'Updated via MCP',
         overwrite: true,
       });
@@ -84,6 +194,122 @@ export class GrafanaClient extends BaseClient {
     }
   }
 
+  async importDashboard(body: any): Promise<any> {
+    try {
+      const response = await this.client.post('/api/dashboards/import', body);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // grafana.com catalog methods (proxied through Grafana)
+  async getCatalogDashboard(id: number, revision?: number): Promise<any> {
+    try {
+      if (revision !== undefined) {
+        const response = await this.client.get(
+          `/api/gnet/dashboards/${id}/revisions/${revision}/download`
+        );
+        return response.data;
+      }
+      const response = await this.client.get(`/api/gnet/dashboards/${id}`);
+      return response.data.json;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Star methods
+  async starDashboard(uid: string): Promise<void> {
+    try {
+      await this.client.post(`/api/user/stars/dashboard/uid/${uid}`);
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async unstarDashboard(uid: string): Promise<void> {
+    try {
+      await this.client.delete(`/api/user/stars/dashboard/uid/${uid}`);
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Public dashboard methods
+  async listPublicDashboards(page: number = 1, perPage: number = 1000): Promise<any> {
+    try {
+      const response = await this.client.get('/api/dashboards/public-dashboards', {
+        params: { page, perpage: perPage },
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getPublicDashboard(dashboardUid: string): Promise<any> {
+    try {
+      const response = await this.client.get(`/api/dashboards/uid/${dashboardUid}/public-dashboards`);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async createPublicDashboard(dashboardUid: string, publicDashboard: any): Promise<any> {
+    try {
+      const response = await this.client.post(
+        `/api/dashboards/uid/${dashboardUid}/public-dashboards`,
+        publicDashboard
+      );
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async updatePublicDashboard(dashboardUid: string, uid: string, publicDashboard: any): Promise<any> {
+    try {
+      const response = await this.client.patch(
+        `/api/dashboards/uid/${dashboardUid}/public-dashboards/${uid}`,
+        publicDashboard
+      );
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Snapshot methods
+  async createSnapshot(snapshot: any): Promise<any> {
+    try {
+      const response = await this.client.post('/api/snapshots', snapshot);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async listSnapshots(query?: string, limit?: number): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/dashboard/snapshots', {
+        params: { query, limit },
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async deleteSnapshot(key: string): Promise<void> {
+    try {
+      await this.client.delete(`/api/snapshots/${encodeURIComponent(key)}`);
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
   // Datasource methods
   async listDatasources(type?: string): Promise<Datasource[]> {
     try {
@@ -118,6 +344,60 @@ export class GrafanaClient extends BaseClient {
     }
   }
 
+  // Runs queries against one or more datasources through Grafana's query API
+  async queryDatasources(body: any): Promise<any> {
+    try {
+      const response = await this.client.post('/api/ds/query', body);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Query history methods
+  async searchQueryHistory(params: Record<string, any>): Promise<any> {
+    try {
+      const response = await this.client.get('/api/query-history', {
+        params,
+        paramsSerializer: { indexes: null },
+      });
+      return response.data.result;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async starQuery(uid: string): Promise<any> {
+    try {
+      const response = await this.client.post(`/api/query-history/star/${uid}`);
+      return response.data.result;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async unstarQuery(uid: string): Promise<any> {
+    try {
+      const response = await this.client.delete(`/api/query-history/star/${uid}`);
+      return response.data.result;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Annotation methods
+  async listAnnotations(params: Record<string, any>): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/annotations', {
+        params,
+        paramsSerializer: { indexes: null },
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
   // Alert methods
   async listAlertRules(filters?: any): Promise<AlertRule[]> {
     try {
@@ -148,6 +428,168 @@ export class GrafanaClient extends BaseClient {
     }
   }
 
+  async getAlertStateHistory(params: Record<string, any>): Promise<any> {
+    try {
+      const response = await this.client.get('/api/v1/rules/history', { params });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async evaluateAlertQueries(body: any): Promise<any> {
+    try {
+      const response = await this.client.post('/api/v1/eval', body);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async backtestAlertRule(body: any): Promise<any> {
+    try {
+      const response = await this.client.post('/api/v1/rule/backtest', body);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async testContactPoint(body: any): Promise<any> {
+    try {
+      const response = await this.client.post(
+        '/api/alertmanager/grafana/config/api/v1/receivers/test',
+        body
+      );
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async exportAlertingResource(path: string, params?: Record<string, any>): Promise<string> {
+    try {
+      const response = await this.client.get(`/api/v1/provisioning/${path}`, {
+        params,
+        responseType: 'text',
+        transformResponse: [(data) => data],
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Mute timing methods
+  async listMuteTimings(): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/v1/provisioning/mute-timings');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async createMuteTiming(muteTiming: any): Promise<any> {
+    try {
+      const response = await this.client.post('/api/v1/provisioning/mute-timings', muteTiming);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async updateMuteTiming(name: string, muteTiming: any): Promise<any> {
+    try {
+      const response = await this.client.put(
+        `/api/v1/provisioning/mute-timings/${encodeURIComponent(name)}`,
+        muteTiming
+      );
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async deleteMuteTiming(name: string): Promise<void> {
+    try {
+      await this.client.delete(`/api/v1/provisioning/mute-timings/${encodeURIComponent(name)}`);
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Alertmanager methods
+  async listAlertmanagerAlerts(params?: Record<string, any>): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/alertmanager/grafana/api/v2/alerts', {
+        params,
+        paramsSerializer: { indexes: null },
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async listAlertmanagerAlertGroups(params?: Record<string, any>): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/alertmanager/grafana/api/v2/alerts/groups', {
+        params,
+        paramsSerializer: { indexes: null },
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Reporting methods (Grafana Enterprise)
+  async listReports(): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/reports');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getReport(id: number): Promise<any> {
+    try {
+      const response = await this.client.get(`/api/reports/${id}`);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async createReport(report: any): Promise<any> {
+    try {
+      const response = await this.client.post('/api/reports', report);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async updateReport(id: number, report: any): Promise<any> {
+    try {
+      const response = await this.client.put(`/api/reports/${id}`, report);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async sendReport(body: any): Promise<any> {
+    try {
+      const response = await this.client.post('/api/reports/email', body);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
   // Admin methods
   async listTeams(query?: string): Promise<Team[]> {
     try {
@@ -168,4 +610,210 @@ export class GrafanaClient extends BaseClient {
       this.handleError(error);
     }
   }
+
+  async listOrgs(query?: string): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/orgs', {
+        params: { query },
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getCurrentOrg(): Promise<any> {
+    try {
+      const response = await this.client.get('/api/org');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getAdminStats(): Promise<any> {
+    try {
+      const response = await this.client.get('/api/admin/stats');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Service account methods
+  async searchServiceAccounts(query?: string): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/serviceaccounts/search', {
+        params: { query, perpage: 1000 },
+      });
+      return response.data.serviceAccounts;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async createServiceAccount(serviceAccount: any): Promise<any> {
+    try {
+      const response = await this.client.post('/api/serviceaccounts', serviceAccount);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async listServiceAccountTokens(serviceAccountId: number): Promise<any[]> {
+    try {
+      const response = await this.client.get(`/api/serviceaccounts/${serviceAccountId}/tokens`);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async createServiceAccountToken(serviceAccountId: number, token: any): Promise<any> {
+    try {
+      const response = await this.client.post(
+        `/api/serviceaccounts/${serviceAccountId}/tokens`,
+        token
+      );
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async deleteServiceAccountToken(serviceAccountId: number, tokenId: number): Promise<void> {
+    try {
+      await this.client.delete(`/api/serviceaccounts/${serviceAccountId}/tokens/${tokenId}`);
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Signed-in user methods
+  async getCurrentUser(): Promise<any> {
+    try {
+      const response = await this.client.get('/api/user');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getCurrentUserOrgs(): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/user/orgs');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getCurrentUserPermissions(): Promise<Record<string, string[]>> {
+    try {
+      const response = await this.client.get('/api/access-control/user/permissions');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Auth provider methods
+  async getLdapStatus(): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/admin/ldap/status');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getLdapUser(username: string): Promise<any> {
+    try {
+      const response = await this.client.get(`/api/admin/ldap/${encodeURIComponent(username)}`);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async syncLdapUser(userId: number): Promise<any> {
+    try {
+      const response = await this.client.post(`/api/admin/ldap/sync/${userId}`);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async listSsoSettings(): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/v1/sso-settings');
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Preference methods
+  async getPreferences(path: string): Promise<any> {
+    try {
+      const response = await this.client.get(`${path}/preferences`);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async updatePreferences(path: string, preferences: any): Promise<any> {
+    try {
+      const response = await this.client.put(`${path}/preferences`, preferences);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  // Access control methods
+  async listRoles(includeHidden?: boolean): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/access-control/roles', {
+        params: { includeHidden },
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async listUserRoles(userId: number): Promise<any[]> {
+    try {
+      const response = await this.client.get(`/api/access-control/users/${userId}/roles`);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async searchUserPermissions(params: Record<string, any>): Promise<Record<string, Record<string, string[]>>> {
+    try {
+      const response = await this.client.get('/api/access-control/users/permissions/search', {
+        params,
+      });
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getResourcePermissions(resource: string, uid: string): Promise<any[]> {
+    try {
+      const response = await this.client.get(
+        `/api/access-control/${resource}/${encodeURIComponent(uid)}`
+      );
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
 }
\ No newline at end of file
@@ -0,0 +1,279 @@
+import * as http from 'http';
+import * as https from 'https';
+import { Duplex } from 'stream';
+import { createHash, randomBytes } from 'crypto';
+import { GrafanaConfig } from '../types/config';
+import {
+  buildAuthConfig,
+  createHttpAgent,
+  createHttpsAgent,
+  resolveGrafanaTarget,
+} from './base-client';
+import {
+  HANDSHAKE_GUID,
+  MAX_MESSAGE_SIZE,
+  OPCODE_BINARY,
+  OPCODE_CLOSE,
+  OPCODE_CONTINUATION,
+  OPCODE_PING,
+  OPCODE_PONG,
+  OPCODE_TEXT,
+  encodeFrame,
+  parseFrame,
+} from '../server/websocket';
+
+// Client for Grafana Live, the pub/sub of Grafana on /api/live/ws. It speaks the JSON protocol
+// of Centrifuge, the library behind Grafana Live: commands and replies are JSON objects, several
+// of them separated by newlines in one text frame.
+
+export interface LiveEvent {
+  channel: string;
+  data: unknown;
+  // Data sent with the subscription, such as the schema and recent values of a stream
+  initial?: boolean;
+}
+
+interface PendingCommand {
+  resolve: (reply: any) => void;
+  reject: (error: Error) => void;
+}
+
+export class GrafanaLiveClient {
+  // Called for each publication on a subscribed channel
+  onevent?: (event: LiveEvent) => void;
+  // Called once the connection closed; with an error unless close() closed it
+  onclose?: (error?: Error) => void;
+
+  private config: GrafanaConfig;
+  private socket?: Duplex;
+  private buffer = Buffer.alloc(0);
+  private fragments: Buffer[] = [];
+  private pending: Map<number, PendingCommand> = new Map();
+  private nextId = 1;
+  private closed = false;
+
+  constructor(config: GrafanaConfig) {
+    this.config = config;
+  }
+
+  // Opens the WebSocket connection and authenticates it with the configured credentials
+  async connect(timeoutMs = 10000): Promise<void> {
+    const root = this.config.url.replace(/\/$/, '');
+    const target = resolveGrafanaTarget(this.config, `${root}/api/live/ws`);
+    const url = new URL(target.baseURL);
+    const authConfig = buildAuthConfig(this.config);
+    const key = randomBytes(16).toString('base64');
+    const secure = url.protocol === 'https:';
+
+    this.socket = await new Promise<Duplex>((resolve, reject) => {
+      const request = (secure ? https : http).request({
+        hostname: url.hostname,
+        port: url.port || undefined,
+        path: url.pathname + url.search,
+        socketPath: target.socketPath,
+        agent: secure ? createHttpsAgent(this.config) : createHttpAgent(this.config),
+        auth: authConfig.auth && `${authConfig.auth.username}:${authConfig.auth.password}`,
+        headers: {
+          'User-Agent': 'mcp-grafana/1.0.0',
+          ...this.config.extraHeaders,
+          ...authConfig.headers,
+          Connection: 'Upgrade',
+          Upgrade: 'websocket',
+          'Sec-WebSocket-Version': '13',
+          'Sec-WebSocket-Key': key,
+        },
+      });
+      const timer = setTimeout(
+        () => request.destroy(new Error('Grafana Live connection timed out')),
+        timeoutMs
+      );
+      request.on('upgrade', (response, socket, head) => {
+        clearTimeout(timer);
+        const accept = createHash('sha1').update(key + HANDSHAKE_GUID).digest('base64');
+        if (response.headers['sec-websocket-accept'] !== accept) {
+          socket.destroy();
+          reject(new Error('Grafana Live returned an invalid WebSocket handshake'));
+          return;
+        }
+        this.buffer = Buffer.from(head);
+        resolve(socket);
+      });
+      request.on('response', response => {
+        clearTimeout(timer);
+        response.resume();
+        reject(
+          new Error(
+            `Grafana Live connection failed (${response.statusCode}): ${response.statusMessage}`
+          )
+        );
+      });
+      request.on('error', error => {
+        clearTimeout(timer);
+        reject(error);
+      });
+      request.end();
+    });
+
+    this.socket.on('data', chunk => this.receive(chunk));
+    this.socket.on('error', error => this.finish(error));
+    this.socket.on('close', () => this.finish(new Error('Grafana Live connection closed')));
+    if (this.buffer.length > 0) {
+      this.receive(Buffer.alloc(0));
+    }
+
+    await this.command({ connect: {} }, timeoutMs);
+  }
+
+  // Subscribes to a channel such as grafana/dashboard/uid/<uid> or ds/<uid>/<path>
+  async subscribe(channel: string, timeoutMs = 10000): Promise<void> {
+    let reply: any;
+    try {
+      reply = await this.command({ subscribe: { channel } }, timeoutMs);
+    } catch (error: any) {
+      throw new Error(`Failed to subscribe to ${channel}: ${error.message}`);
+    }
+    if (reply.subscribe?.data !== undefined) {
+      this.onevent?.({ channel, data: reply.subscribe.data, initial: true });
+    }
+    for (const publication of reply.subscribe?.publications || []) {
+      this.onevent?.({ channel, data: publication.data });
+    }
+  }
+
+  close() {
+    if (this.closed) return;
+    // 1000: normal closure
+    const payload = Buffer.alloc(2);
+    payload.writeUInt16BE(1000);
+    this.write(OPCODE_CLOSE, payload);
+    this.socket?.end();
+    this.finish();
+  }
+
+  // Sends a command and waits for the reply with its id
+  private command(body: object, timeoutMs: number): Promise<any> {
+    const id = this.nextId++;
+    return new Promise((resolve, reject) => {
+      const timer = setTimeout(() => {
+        this.pending.delete(id);
+        reject(new Error('Grafana Live did not reply in time'));
+      }, timeoutMs);
+      this.pending.set(id, {
+        resolve: reply => {
+          clearTimeout(timer);
+          resolve(reply);
+        },
+        reject: error => {
+          clearTimeout(timer);
+          reject(error);
+        },
+      });
+      this.send({ id, ...body });
+    });
+  }
+
+  private send(message: object) {
+    this.write(OPCODE_TEXT, Buffer.from(JSON.stringify(message)));
+  }
+
+  private write(opcode: number, payload: Buffer) {
+    if (!this.socket || this.socket.destroyed) return;
+    this.socket.write(encodeFrame(opcode, payload, true));
+  }
+
+  private finish(error?: Error) {
+    if (this.closed) return;
+    this.closed = true;
+    for (const command of this.pending.values()) {
+      command.reject(error || new Error('Grafana Live connection closed'));
+    }
+    this.pending.clear();
+    this.socket?.destroy();
+    this.onclose?.(error);
+  }
+
+  private receive(chunk: Buffer) {
+    this.buffer = Buffer.concat([this.buffer, chunk]);
+    for (;;) {
+      const frame = parseFrame(this.buffer, false);
+      if (frame === undefined) return;
+      if (frame instanceof Error) {
+        this.finish(frame);
+        return;
+      }
+      this.buffer = this.buffer.subarray(frame.end);
+      this.handleFrame(frame.fin, frame.opcode, frame.payload);
+      if (this.closed) return;
+    }
+  }
+
+  private handleFrame(fin: boolean, opcode: number, payload: Buffer) {
+    switch (opcode) {
+      case OPCODE_PING:
+        this.write(OPCODE_PONG, payload);
+        return;
+      case OPCODE_PONG:
+        return;
+      case OPCODE_CLOSE: {
+        const reason = payload.subarray(2).toString('utf8');
+        this.finish(new Error(`Grafana Live closed the connection${reason ? `: ${reason}` : ''}`));
+        return;
+      }
+      case OPCODE_TEXT:
+      case OPCODE_BINARY:
+      case OPCODE_CONTINUATION:
+        break;
+      default:
+        this.finish(new Error(`Unknown WebSocket opcode ${opcode}`));
+        return;
+    }
+
+    this.fragments.push(payload);
+    const size = this.fragments.reduce((total, fragment) => total + fragment.length, 0);
+    if (size > MAX_MESSAGE_SIZE) {
+      this.finish(new Error(`Grafana Live message exceeds ${MAX_MESSAGE_SIZE} bytes`));
+      return;
+    }
+    if (!fin) return;
+
+    const text = Buffer.concat(this.fragments).toString('utf8');
+    this.fragments = [];
+    for (const line of text.split('\n')) {
+      if (!line.trim()) continue;
+      let reply: any;
+      try {
+        reply = JSON.parse(line);
+      } catch (error) {
+        this.finish(new Error(`Invalid Grafana Live message: ${(error as Error).message}`));
+        return;
+      }
+      this.handleReply(reply);
+    }
+  }
+
+  private handleReply(reply: any) {
+    // An empty reply is a ping, which the server expects to be answered with an empty command
+    if (Object.keys(reply).length === 0) {
+      this.send({});
+      return;
+    }
+
+    if (reply.id !== undefined) {
+      const command = this.pending.get(reply.id);
+      this.pending.delete(reply.id);
+      if (reply.error) {
+        command?.reject(new Error(`${reply.error.message} (code ${reply.error.code})`));
+      } else {
+        command?.resolve(reply);
+      }
+      return;
+    }
+
+    const push = reply.push;
+    if (push?.pub) {
+      this.onevent?.({ channel: push.channel, data: push.pub.data });
+    } else if (push?.disconnect) {
+      this.finish(new Error(`Grafana Live disconnected: ${push.disconnect.reason}`));
+    }
+  }
+}
@@ -8,6 +8,11 @@ export interface LokiLogEntry {
   value?: string;
 }
 
+export interface LokiMetricSeries {
+  metric: Record<string, string>;
+  values: [number, string][];
+}
+
 export interface LokiStats {
   streams: number;
   chunks: number;
@@ -59,6 +64,31 @@ export class LokiClient extends BaseClient {
     }
   }
 
+  async queryMetrics(
+    query: string,
+    start: string,
+    end: string,
+    step: string
+  ): Promise<LokiMetricSeries[]> {
+    try {
+      const response = await this.client.get('/loki/api/v1/query_range', {
+        params: { query, start, end, step },
+      });
+
+      if (response.data.status !== 'success') {
+        throw new Error(`Loki query failed: ${response.data.error || 'Unknown error'}`);
+      }
+
+      if (response.data.data.resultType !== 'matrix') {
+        throw new Error(`Expected a metric query, got ${response.data.data.resultType} result`);
+      }
+
+      return response.data.data.result;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
   async queryStats(query: string, start?: string, end?: string): Promise<LokiStats> {
     try {
       const params: any = { query };
@@ -140,4 +140,19 @@ export class PrometheusClient extends BaseClient {
       this.handleError(error);
     }
   }
-}
\ No newline at end of file
+
+  // Rule groups loaded by Prometheus or Mimir; type is "record" or "alert" to list only one kind
+  async getRules(type?: string): Promise<any[]> {
+    try {
+      const response = await this.client.get('/api/v1/rules', { params: { type } });
+
+      if (response.data.status !== 'success') {
+        throw new Error(`Failed to get rules: ${response.data.error}`);
+      }
+
+      return response.data.data.groups || [];
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+}
@@ -0,0 +1,64 @@
+import { AsyncLocalStorage } from 'async_hooks';
+import { randomBytes, randomUUID } from 'crypto';
+import { AxiosInstance } from 'axios';
+
+// Correlation of a tool call across systems: its request ID and W3C trace context travel on
+// every outbound Grafana request the call makes
+
+export interface RequestContext {
+  requestId: string;
+  traceId: string;
+  // Span ID of the tool call, the parent of the outbound requests
+  spanId: string;
+  traceFlags: string;
+  traceState?: string;
+}
+
+export const REQUEST_ID_HEADER = 'x-request-id';
+
+const TRACEPARENT_PATTERN = /^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$/;
+
+type IncomingHeaders = Record<string, string | string[] | undefined>;
+
+const activeContext = new AsyncLocalStorage<RequestContext>();
+
+function header(headers: IncomingHeaders | undefined, name: string): string | undefined {
+  const value = headers?.[name];
+  return Array.isArray(value) ? value[0] : value;
+}
+
+// Creates the context of a tool call, continuing the caller's request ID and trace if given
+export function createRequestContext(headers?: IncomingHeaders): RequestContext {
+  const traceparent = TRACEPARENT_PATTERN.exec(header(headers, 'traceparent') || '');
+  return {
+    requestId: header(headers, REQUEST_ID_HEADER) || randomUUID(),
+    traceId: traceparent?.[1] || randomBytes(16).toString('hex'),
+    spanId: randomBytes(8).toString('hex'),
+    traceFlags: traceparent?.[3] || '01',
+    traceState: traceparent ? header(headers, 'tracestate') : undefined,
+  };
+}
+
+// Runs a function with the context applied to the Grafana requests it makes
+export function withRequestContext<T>(context: RequestContext, fn: () => Promise<T>): Promise<T> {
+  return activeContext.run(context, fn);
+}
+
+// Makes an axios instance send the request ID and trace context of the active tool call
+export function applyRequestContext(client: AxiosInstance): AxiosInstance {
+  client.interceptors.request.use(request => {
+    const context = activeContext.getStore();
+    if (context) {
+      request.headers.set('X-Request-ID', context.requestId);
+      request.headers.set(
+        'traceparent',
+        `00-${context.traceId}-${context.spanId}-${context.traceFlags}`
+      );
+      if (context.traceState) {
+        request.headers.set('tracestate', context.traceState);
+      }
+    }
+    return request;
+  });
+  return client;
+}
@@ -0,0 +1,64 @@
+import { BaseClient } from './base-client';
+import { GrafanaConfig } from '../types/config';
+
+export interface RulerRule {
+  record?: string;
+  alert?: string;
+  expr: string;
+  for?: string;
+  labels?: Record<string, string>;
+  annotations?: Record<string, string>;
+}
+
+export interface RulerRuleGroup {
+  name: string;
+  interval?: string;
+  rules: RulerRule[];
+}
+
+export class RulerClient extends BaseClient {
+  constructor(config: GrafanaConfig, datasourceUid: string) {
+    // Use Grafana ruler proxy endpoint for Mimir/Loki/Cortex rulers
+    super(config, `${config.url}/api/ruler/${datasourceUid}/api/v1`);
+  }
+
+  async listRules(namespace?: string): Promise<Record<string, RulerRuleGroup[]>> {
+    try {
+      const path = namespace ? `/rules/${encodeURIComponent(namespace)}` : '/rules';
+      const response = await this.client.get(path);
+      return response.data || {};
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async getRuleGroup(namespace: string, group: string): Promise<RulerRuleGroup> {
+    try {
+      const response = await this.client.get(
+        `/rules/${encodeURIComponent(namespace)}/${encodeURIComponent(group)}`
+      );
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async setRuleGroup(namespace: string, group: RulerRuleGroup): Promise<any> {
+    try {
+      const response = await this.client.post(`/rules/${encodeURIComponent(namespace)}`, group);
+      return response.data;
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+
+  async deleteRuleGroup(namespace: string, group: string): Promise<void> {
+    try {
+      await this.client.delete(
+        `/rules/${encodeURIComponent(namespace)}/${encodeURIComponent(group)}`
+      );
+    } catch (error) {
+      this.handleError(error);
+    }
+  }
+}
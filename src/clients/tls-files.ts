@@ -0,0 +1,38 @@
+import * as fs from 'fs';
+
+// TLS files are read once and watched for rotation, e.g. by cert-manager renewals, so HTTPS
+// agents are rebuilt with the new certificates without reading the files for every client.
+
+// Polling also notices secrets that Kubernetes swaps in through symlinks
+const POLL_INTERVAL_MS = 5000;
+
+const contents: Map<string, Buffer> = new Map();
+const listeners: ((path: string) => void)[] = [];
+
+// Returns the current content of a TLS file
+export function readTlsFile(path: string): Buffer {
+  const cached = contents.get(path);
+  if (cached) {
+    return cached;
+  }
+  const content = fs.readFileSync(path);
+  contents.set(path, content);
+  fs.watchFile(path, { interval: POLL_INTERVAL_MS, persistent: false }, (current, previous) => {
+    if (current.mtimeMs === previous.mtimeMs && current.ino === previous.ino) {
+      return;
+    }
+    try {
+      contents.set(path, fs.readFileSync(path));
+    } catch {
+      // Keep the previous content while the file is being replaced
+      return;
+    }
+    listeners.forEach(listener => listener(path));
+  });
+  return content;
+}
+
+// Registers a listener called after a TLS file in use changed
+export function onTlsFileChange(listener: (path: string) => void) {
+  listeners.push(listener);
+}
@@ -1,7 +1,75 @@
 import { GrafanaConfig } from '../types/config';
+import { validateTimezone } from '../utils/time';
+import { BUILTIN_SCRUB_RULES, validateLogScrubConfig } from '../utils/scrub';
 import * as dotenv from 'dotenv';
+import * as fs from 'fs';
 
-dotenv.config();
+// File with environment variables, loaded at startup and again on reload
+export const ENV_FILE = '.env';
+
+// Variables set in the real environment take precedence over the .env file, also on reload
+const processEnvKeys = new Set(Object.keys(process.env));
+
+dotenv.config({ path: ENV_FILE });
+
+// Re-reads the .env file, so changed values are picked up by the next loadGrafanaConfig()
+export function reloadEnvFile() {
+  if (!fs.existsSync(ENV_FILE)) {
+    return;
+  }
+  const parsed = dotenv.parse(fs.readFileSync(ENV_FILE));
+  for (const [key, value] of Object.entries(parsed)) {
+    if (!processEnvKeys.has(key)) {
+      process.env[key] = value;
+    }
+  }
+}
+
+// Helper function to parse a comma-separated environment variable
+function parseList(value: string | undefined): string[] {
+  return (value || '')
+    .split(',')
+    .map(item => item.trim())
+    .filter(item => item);
+}
+
+// Helper function to parse an optional non-negative number environment variable
+function parseNumber(name: string): number | undefined {
+  const value = process.env[name];
+  if (!value) {
+    return undefined;
+  }
+  const number = parseFloat(value);
+  if (!(number >= 0)) {
+    throw new Error(`${name} must be a non-negative number, got "${value}"`);
+  }
+  return number;
+}
+
+// Helper function to read a PEM block from an environment variable, where line breaks may be
+// escaped as \n because many platforms only support single-line values
+function parsePem(value: string | undefined): string | undefined {
+  return value ? value.replace(/\\n/g, '\n') : undefined;
+}
+
+// Helper function to parse a JSON object of header names and values
+function parseHeaders(value: string): Record<string, string> {
+  let headers: unknown;
+  try {
+    headers = JSON.parse(value);
+  } catch (error: any) {
+    throw new Error(`GRAFANA_EXTRA_HEADERS must be a JSON object: ${error.message}`);
+  }
+  if (
+    !headers ||
+    typeof headers !== 'object' ||
+    Array.isArray(headers) ||
+    Object.values(headers).some(header => typeof header !== 'string')
+  ) {
+    throw new Error('GRAFANA_EXTRA_HEADERS must be a JSON object of header names and values');
+  }
+  return headers as Record<string, string>;
+}
 
 export function loadGrafanaConfig(): Partial<GrafanaConfig> {
   const config: Partial<GrafanaConfig> = {
@@ -10,9 +78,13 @@ export function loadGrafanaConfig(): Partial<GrafanaConfig> {
     url: process.env.GRAFANA_URL || '',
   };
 
-  // Service account token (preferred)
+  // Service account token (preferred), directly or from a file such as a mounted secret
   if (process.env.GRAFANA_SERVICE_ACCOUNT_TOKEN) {
     config.serviceAccountToken = process.env.GRAFANA_SERVICE_ACCOUNT_TOKEN;
+  } else if (process.env.GRAFANA_SERVICE_ACCOUNT_TOKEN_FILE) {
+    config.serviceAccountToken = fs
+      .readFileSync(process.env.GRAFANA_SERVICE_ACCOUNT_TOKEN_FILE, 'utf8')
+      .trim();
   }
   
   // Legacy API key (deprecated)
@@ -38,21 +110,102 @@ export function loadGrafanaConfig(): Partial<GrafanaConfig> {
     config.idToken = process.env.GRAFANA_ID_TOKEN;
   }
 
-  // TLS config
+  // Static headers for every Grafana request, e.g. for Cloudflare Access or API gateways
+  if (process.env.GRAFANA_EXTRA_HEADERS) {
+    config.extraHeaders = parseHeaders(process.env.GRAFANA_EXTRA_HEADERS);
+  }
+
+  // Time zone for time parameters without an offset
+  if (process.env.GRAFANA_TIMEZONE) {
+    config.timezone = process.env.GRAFANA_TIMEZONE;
+  }
+
+  // Redaction of log lines returned by log query tools
+  if (process.env.LOG_SCRUB_RULES || process.env.LOG_SCRUB_PATTERNS) {
+    const rules = parseList(process.env.LOG_SCRUB_RULES);
+    config.logScrubbing = {
+      rules: rules.includes('all') ? Object.keys(BUILTIN_SCRUB_RULES) : rules,
+      patterns: process.env.LOG_SCRUB_PATTERNS ? JSON.parse(process.env.LOG_SCRUB_PATTERNS) : [],
+    };
+  }
+
+  // Datasources the query tools may target
+  const datasourcePolicy = {
+    allowedUids: parseList(process.env.DATASOURCE_ALLOWED_UIDS),
+    allowedTypes: parseList(process.env.DATASOURCE_ALLOWED_TYPES),
+    deniedUids: parseList(process.env.DATASOURCE_DENIED_UIDS),
+    deniedTypes: parseList(process.env.DATASOURCE_DENIED_TYPES),
+  };
+  if (Object.values(datasourcePolicy).some(list => list.length > 0)) {
+    config.datasourcePolicy = datasourcePolicy;
+  }
+
+  // Guard and limits of SQL datasource queries
+  if (process.env.SQL_ALLOW_WRITES || process.env.SQL_MAX_ROWS || process.env.SQL_MAX_BYTES) {
+    config.sqlQuery = {
+      allowWrites: process.env.SQL_ALLOW_WRITES === 'true',
+      maxRows: parseNumber('SQL_MAX_ROWS') ?? 1000,
+      maxBytes: parseNumber('SQL_MAX_BYTES') ?? 1024 * 1024,
+    };
+  }
+
+  // Folders that dashboard write tools may change
+  if (process.env.WRITE_ALLOWED_FOLDER_UIDS) {
+    config.writeFolderUids = parseList(process.env.WRITE_ALLOWED_FOLDER_UIDS);
+  }
+
+  // Grafana Cloud API
+  if (process.env.GRAFANA_CLOUD_API_KEY) {
+    config.cloudApiKey = process.env.GRAFANA_CLOUD_API_KEY;
+    config.cloudRegion = process.env.GRAFANA_CLOUD_REGION;
+    config.cloudApiUrl = process.env.GRAFANA_CLOUD_API_URL;
+  }
+
+  // Grafana Fleet Management API
+  if (process.env.FLEET_MANAGEMENT_URL) {
+    config.fleetManagementUrl = process.env.FLEET_MANAGEMENT_URL;
+    config.fleetManagementUser = process.env.FLEET_MANAGEMENT_USER;
+    config.fleetManagementToken = process.env.FLEET_MANAGEMENT_TOKEN;
+  }
+
+  // TLS config, from files or PEM blocks
   if (
     process.env.TLS_CERT_FILE ||
     process.env.TLS_KEY_FILE ||
     process.env.TLS_CA_FILE ||
+    process.env.TLS_CERT ||
+    process.env.TLS_KEY ||
+    process.env.TLS_CA ||
+    process.env.TLS_USE_SYSTEM_CA ||
     process.env.TLS_SKIP_VERIFY
   ) {
     config.tlsConfig = {
       certFile: process.env.TLS_CERT_FILE,
       keyFile: process.env.TLS_KEY_FILE,
       caFile: process.env.TLS_CA_FILE,
+      cert: parsePem(process.env.TLS_CERT),
+      key: parsePem(process.env.TLS_KEY),
+      ca: parsePem(process.env.TLS_CA),
+      useSystemCa: process.env.TLS_USE_SYSTEM_CA === 'true',
       skipVerify: process.env.TLS_SKIP_VERIFY === 'true',
     };
   }
 
+  // Connection pool of the Grafana clients
+  if (
+    process.env.GRAFANA_MAX_CONNS_PER_HOST ||
+    process.env.GRAFANA_MAX_IDLE_CONNS_PER_HOST ||
+    process.env.GRAFANA_IDLE_CONN_TIMEOUT ||
+    process.env.GRAFANA_KEEP_ALIVE
+  ) {
+    config.connectionPool = {
+      maxConnsPerHost: parseNumber('GRAFANA_MAX_CONNS_PER_HOST'),
+      maxIdleConnsPerHost: parseNumber('GRAFANA_MAX_IDLE_CONNS_PER_HOST'),
+      idleTimeout: (parseNumber('GRAFANA_IDLE_CONN_TIMEOUT') ?? 90) * 1000,
+      keepAlive: process.env.GRAFANA_KEEP_ALIVE !== 'false',
+    };
+  }
+
   return config;
 }
 
@@ -76,5 +229,13 @@ export function validateGrafanaConfig(config: Partial<GrafanaConfig>): GrafanaCo
     );
   }
 
+  if (config.timezone) {
+    validateTimezone(config.timezone);
+  }
+
+  if (config.logScrubbing) {
+    validateLogScrubConfig(config.logScrubbing);
+  }
+
   return config as GrafanaConfig;
 }
\ No newline at end of file
@@ -0,0 +1,18 @@
+// Public API for embedding the Grafana MCP server in other Node.js programs
+
+export {
+  MCPServer,
+  createToolResult,
+  createErrorResult,
+  isWriteTool,
+} from './server/mcp-server';
+export { createInProcessClient } from './server/in-process';
+export type { ToolDefinition, ToolContext, ToolCall, ToolMiddleware } from './server/mcp-server';
+export type { ServerConfig, GrafanaConfig } from './types/config';
+export { loadGrafanaConfig, validateGrafanaConfig } from './config/environment';
+export { TOOL_CATEGORIES } from './types';
+export type { ToolCategory } from './types';
+export { registerTools, TOOL_REGISTRARS } from './tools';
+export { Cassette, withCassette } from './clients/cassette';
+export type { CassetteInteraction, CassetteMode, CassetteOptions } from './clients/cassette';
+export { startMockGrafana } from './server/mock-grafana';
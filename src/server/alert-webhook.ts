@@ -0,0 +1,188 @@
+import * as http from 'http';
+import { createHash, randomUUID, timingSafeEqual } from 'crypto';
+import pino from 'pino';
+import { AlertWebhookConfig } from '../types/config';
+
+// Receiver for the payloads of Grafana's webhook contact point, which keeps recent alert events
+// for the list_recent_alert_events tool and announces them to connected clients
+
+// Endpoint the webhook contact point posts to
+export const ALERT_WEBHOOK_PATH = '/alerts/webhook';
+
+// Larger payloads are rejected; Grafana truncates notifications with many alerts well below this
+const MAX_PAYLOAD_BYTES = 5 * 1024 * 1024;
+
+// One alert of a notification, with the notification it arrived in
+export interface AlertEvent {
+  id: string;
+  receivedAt: number;
+  receiver?: string;
+  // Title of the notification, as rendered by the contact point's template
+  title?: string;
+  status: 'firing' | 'resolved';
+  alertname?: string;
+  labels: Record<string, string>;
+  annotations: Record<string, string>;
+  startsAt?: string;
+  endsAt?: string;
+  fingerprint?: string;
+  values?: Record<string, number>;
+  generatorURL?: string;
+  dashboardURL?: string;
+  panelURL?: string;
+  silenceURL?: string;
+}
+
+// In-memory store of the most recent alert events, oldest first
+export class AlertEventStore {
+  private events: AlertEvent[] = [];
+  private maxEvents: number;
+
+  constructor(maxEvents = 500) {
+    this.maxEvents = maxEvents;
+  }
+
+  // Stores the alerts of a webhook payload and returns them as events
+  add(payload: any): AlertEvent[] {
+    const receivedAt = Date.now();
+    const added: AlertEvent[] = (payload.alerts as any[]).map(alert => ({
+      id: randomUUID(),
+      receivedAt,
+      receiver: payload.receiver,
+      title: payload.title,
+      status: alert.status === 'resolved' ? 'resolved' : 'firing',
+      alertname: alert.labels?.alertname,
+      labels: alert.labels || {},
+      annotations: alert.annotations || {},
+      startsAt: alert.startsAt,
+      endsAt: alert.endsAt,
+      fingerprint: alert.fingerprint,
+      values: alert.values || undefined,
+      generatorURL: alert.generatorURL || undefined,
+      dashboardURL: alert.dashboardURL || undefined,
+      panelURL: alert.panelURL || undefined,
+      silenceURL: alert.silenceURL || undefined,
+    }));
+    this.events.push(...added);
+    if (this.events.length > this.maxEvents) {
+      this.events.splice(0, this.events.length - this.maxEvents);
+    }
+    return added;
+  }
+
+  list(): AlertEvent[] {
+    return [...this.events];
+  }
+}
+
+// Alert events received by this process, shared by the receiver and the tool reading them
+export const alertEvents = new AlertEventStore();
+
+// HTTP listener for the webhook contact point. It runs beside any MCP transport, stdio
+// included, on a port of its own.
+export class AlertWebhookServer {
+  private config: AlertWebhookConfig;
+  private store: AlertEventStore;
+  private onEvents: (events: AlertEvent[]) => void;
+  private logger: pino.Logger;
+  private httpServer: http.Server;
+
+  constructor(
+    config: AlertWebhookConfig,
+    store: AlertEventStore,
+    onEvents: (events: AlertEvent[]) => void,
+    logger: pino.Logger
+  ) {
+    this.config = config;
+    this.store = store;
+    this.onEvents = onEvents;
+    this.logger = logger;
+    this.httpServer = http.createServer((request, response) => {
+      this.handle(request, response).catch(error => {
+        this.logger.warn({ error: error.message }, 'Rejected alert webhook payload');
+        if (!response.headersSent) {
+          sendJson(response, 400, { error: error.message });
+        }
+      });
+    });
+  }
+
+  async listen(): Promise<void> {
+    await new Promise<void>((resolve, reject) => {
+      this.httpServer.once('error', reject);
+      this.httpServer.listen(this.config.port, this.config.address || '127.0.0.1', () =>
+        resolve()
+      );
+    });
+  }
+
+  async close(): Promise<void> {
+    await new Promise<void>(resolve => this.httpServer.close(() => resolve()));
+  }
+
+  private async handle(request: http.IncomingMessage, response: http.ServerResponse) {
+    const url = new URL(request.url || '/', 'http://localhost');
+    if (url.pathname !== ALERT_WEBHOOK_PATH) {
+      sendJson(response, 404, { error: 'Not found' });
+      return;
+    }
+    if (request.method !== 'POST') {
+      response.setHeader('Allow', 'POST');
+      sendJson(response, 405, { error: 'Only POST is supported' });
+      return;
+    }
+    if (this.config.token && !tokenMatches(request.headers.authorization, this.config.token)) {
+      sendJson(response, 401, { error: 'Invalid or missing bearer token' });
+      return;
+    }
+
+    const payload = await readPayload(request);
+    if (!payload || !Array.isArray(payload.alerts)) {
+      throw new Error('Expected a Grafana webhook payload with an alerts list');
+    }
+    const events = this.store.add(payload);
+    this.logger.debug(
+      { receiver: payload.receiver, alerts: events.length },
+      'Received alert webhook payload'
+    );
+    sendJson(response, 200, { received: events.length });
+    this.onEvents(events);
+  }
+}
+
+// Compares the bearer token of a request with the expected one in constant time
+function tokenMatches(authorization: string | undefined, token: string): boolean {
+  const match = /^Bearer\s+(.+)$/i.exec(authorization || '');
+  if (!match) return false;
+  const digest = (value: string) => createHash('sha256').update(value).digest();
+  return timingSafeEqual(digest(match[1].trim()), digest(token));
+}
+
+function readPayload(request: http.IncomingMessage): Promise<any> {
+  return new Promise((resolve, reject) => {
+    const chunks: Buffer[] = [];
+    let size = 0;
+    request.on('data', (chunk: Buffer) => {
+      size += chunk.length;
+      if (size > MAX_PAYLOAD_BYTES) {
+        reject(new Error(`Payload exceeds ${MAX_PAYLOAD_BYTES} bytes`));
+        request.destroy();
+        return;
+      }
+      chunks.push(chunk);
+    });
+    request.on('error', reject);
+    request.on('end', () => {
+      try {
+        resolve(JSON.parse(Buffer.concat(chunks).toString()));
+      } catch (error) {
+        reject(new Error(`Invalid JSON body: ${(error as Error).message}`));
+      }
+    });
+  });
+}
+
+function sendJson(response: http.ServerResponse, status: number, body: any) {
+  response.writeHead(status, { 'Content-Type': 'application/json' });
+  response.end(JSON.stringify(body));
+}
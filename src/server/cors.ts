@@ -0,0 +1,78 @@
+import * as http from 'http';
+import { CorsConfig } from '../types/config';
+
+// Headers browser-based MCP clients send, allowed unless configured otherwise
+export const DEFAULT_CORS_HEADERS = [
+  'Content-Type',
+  'Authorization',
+  'Mcp-Session-Id',
+  'Mcp-Protocol-Version',
+  'Last-Event-ID',
+  'X-Request-ID',
+  'X-Grafana-Timezone',
+  'X-Mcp-Enabled-Tools',
+  'traceparent',
+  'tracestate',
+];
+
+export const DEFAULT_CORS_METHODS = ['GET', 'POST', 'DELETE', 'OPTIONS'];
+
+// Response headers browsers may read; clients need the session ID of streamable HTTP
+const EXPOSED_HEADERS = ['Mcp-Session-Id', 'Retry-After'];
+
+// Matches an origin against a pattern: exact, "*" for any, or with wildcards such as
+// "https://*.example.com"
+function originMatches(pattern: string, origin: string): boolean {
+  if (pattern === '*') return true;
+  const regex = new RegExp(
+    `^${pattern.split('*').map(part => part.replace(/[.+?^${}()|[\]\\]/g, '\\$&')).join('[^/]*')}$`
+  );
+  return regex.test(origin);
+}
+
+// Returns whether browsers may connect from an origin
+export function isOriginAllowed(config: CorsConfig | undefined, origin: string): boolean {
+  return !!config && config.allowedOrigins.some(pattern => originMatches(pattern, origin));
+}
+
+// Sets the CORS headers of a response. Returns true if the request was a preflight request,
+// which is then answered.
+export function applyCors(
+  config: CorsConfig | undefined,
+  request: http.IncomingMessage,
+  response: http.ServerResponse
+): boolean {
+  const origin = request.headers.origin;
+  if (!config || !origin) {
+    return false;
+  }
+  if (!isOriginAllowed(config, origin)) {
+    // Browsers block the response without CORS headers; preflights get a definite answer
+    if (request.method === 'OPTIONS') {
+      response.writeHead(403);
+      response.end();
+      return true;
+    }
+    return false;
+  }
+
+  response.setHeader('Access-Control-Allow-Origin', origin);
+  response.setHeader('Vary', 'Origin');
+  response.setHeader('Access-Control-Expose-Headers', EXPOSED_HEADERS.join(', '));
+
+  if (request.method !== 'OPTIONS') {
+    return false;
+  }
+  response.setHeader(
+    'Access-Control-Allow-Methods',
+    (config.allowedMethods || DEFAULT_CORS_METHODS).join(', ')
+  );
+  response.setHeader(
+    'Access-Control-Allow-Headers',
+    (config.allowedHeaders || DEFAULT_CORS_HEADERS).join(', ')
+  );
+  response.setHeader('Access-Control-Max-Age', '600');
+  response.writeHead(204);
+  response.end();
+  return true;
+}
@@ -0,0 +1,175 @@
+import { createHash } from 'crypto';
+import { GrafanaClient } from '../clients/grafana-client';
+import { GrafanaConfig } from '../types/config';
+import { mapConcurrent } from '../utils/concurrency';
+import { dashboardPanels } from '../utils/dashboard';
+
+// Index of the panel queries of every dashboard, so dashboards can be searched by what they
+// query. An index is built on first use for each Grafana instance and credentials, since they
+// decide which dashboards are visible, and rebuilt once it is older than INDEX_TTL_MS.
+
+export const INDEX_TTL_MS = 10 * 60 * 1000;
+
+// Indexes kept at once; the least recently built one is dropped first
+const MAX_INDEXES = 20;
+
+// Dashboards listed per search request while building an index
+const SEARCH_PAGE_SIZE = 1000;
+
+// Query fields that describe the query rather than hold its text
+const IGNORED_QUERY_FIELDS = new Set([
+  'refId',
+  'datasource',
+  'hide',
+  'key',
+  'editorMode',
+  'editorType',
+  'format',
+  'legendFormat',
+  'interval',
+  'intervalFactor',
+  'queryType',
+  'resultFormat',
+]);
+
+export interface IndexedQuery {
+  refId?: string;
+  datasource?: string;
+  // The strings of the query joined by newlines, e.g. a PromQL expression or SQL statement
+  text: string;
+}
+
+export interface IndexedPanel {
+  id?: number;
+  title?: string;
+  type?: string;
+  queries: IndexedQuery[];
+}
+
+export interface IndexedDashboard {
+  uid: string;
+  title: string;
+  folder?: string;
+  url?: string;
+  panels: IndexedPanel[];
+}
+
+export interface DashboardIndex {
+  dashboards: IndexedDashboard[];
+  builtAt: number;
+  // Dashboards that could not be loaded, such as ones deleted while the index was built
+  failed: number;
+}
+
+// Collects the strings of a query, skipping fields that only describe it
+function queryStrings(node: any, key?: string): string[] {
+  if (key && IGNORED_QUERY_FIELDS.has(key)) return [];
+  if (typeof node === 'string') return node.trim() ? [node] : [];
+  if (Array.isArray(node)) return node.flatMap(item => queryStrings(item));
+  if (node && typeof node === 'object') {
+    return Object.entries(node).flatMap(([name, value]) => queryStrings(value, name));
+  }
+  return [];
+}
+
+function indexDashboard(hit: any, dashboard: any): IndexedDashboard {
+  return {
+    uid: hit.uid,
+    title: dashboard.title || hit.title,
+    folder: hit.folderTitle,
+    url: hit.url,
+    panels: dashboardPanels(dashboard).map(panel => ({
+      id: panel.id,
+      title: panel.title,
+      type: panel.type,
+      queries: (panel.targets || []).map((target: any) => {
+        const datasource = target.datasource || panel.datasource;
+        return {
+          refId: target.refId,
+          datasource: typeof datasource === 'string' ? datasource : datasource?.uid,
+          text: queryStrings(target).join('\n'),
+        };
+      }).filter((query: IndexedQuery) => query.text !== ''),
+    })),
+  };
+}
+
+async function buildIndex(
+  config: GrafanaConfig,
+  onProgress?: (done: number, total: number) => void
+): Promise<DashboardIndex> {
+  const client = new GrafanaClient(config);
+  const hits: any[] = [];
+  for (let page = 1; ; page++) {
+    const batch = await client.search({ type: 'dash-db', limit: SEARCH_PAGE_SIZE, page });
+    hits.push(...batch);
+    if (batch.length < SEARCH_PAGE_SIZE) break;
+  }
+
+  let done = 0;
+  let failed = 0;
+  const dashboards = await mapConcurrent(hits, 10, async hit => {
+    try {
+      return indexDashboard(hit, await client.getDashboardByUid(hit.uid));
+    } catch {
+      failed++;
+      return undefined;
+    } finally {
+      onProgress?.(++done, hits.length);
+    }
+  });
+  return {
+    dashboards: dashboards.filter((dashboard): dashboard is IndexedDashboard => !!dashboard),
+    builtAt: Date.now(),
+    failed,
+  };
+}
+
+export class DashboardIndexCache {
+  private indexes: Map<string, { promise: Promise<DashboardIndex>; startedAt: number }> =
+    new Map();
+
+  // Returns the index of an instance, building it first if it is missing, stale, or a refresh
+  // is requested. Concurrent calls share one build.
+  async get(
+    config: GrafanaConfig,
+    options: { refresh?: boolean; onProgress?: (done: number, total: number) => void } = {}
+  ): Promise<DashboardIndex> {
+    const key = indexKey(config);
+    const entry = this.indexes.get(key);
+    if (entry && !options.refresh && Date.now() - entry.startedAt < INDEX_TTL_MS) {
+      return entry.promise;
+    }
+
+    const promise = buildIndex(config, options.onProgress);
+    this.indexes.delete(key);
+    this.indexes.set(key, { promise, startedAt: Date.now() });
+    if (this.indexes.size > MAX_INDEXES) {
+      this.indexes.delete(this.indexes.keys().next().value as string);
+    }
+    // A failed build is not kept, so the next call tries again
+    promise.catch(() => {
+      if (this.indexes.get(key)?.promise === promise) this.indexes.delete(key);
+    });
+    return promise;
+  }
+}
+
+// Identifies an instance and the credentials used with it without keeping the secrets
+function indexKey(config: GrafanaConfig): string {
+  return createHash('sha256')
+    .update(JSON.stringify([
+      config.url,
+      config.apiKey,
+      config.serviceAccountToken,
+      config.username,
+      config.password,
+      config.accessToken,
+      config.idToken,
+      config.extraHeaders,
+    ]))
+    .digest('hex');
+}
+
+// Dashboard indexes of this process, shared by all sessions
+export const dashboardIndexes = new DashboardIndexCache();
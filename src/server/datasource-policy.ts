@@ -0,0 +1,80 @@
+import { GrafanaClient } from '../clients/grafana-client';
+import { DatasourcePolicyConfig, GrafanaConfig } from '../types/config';
+
+// Argument names that reference datasources in tool input schemas
+const DATASOURCE_UID_KEYS = new Set(['datasourceUid', 'datasourceUids', 'data_source_uid']);
+
+// Server-side expressions are not a real datasource and are always allowed
+const EXPRESSION_UID = '__expr__';
+
+// Datasource types rarely change, so lookups are cached for a while
+const TYPE_CACHE_TTL_MS = 5 * 60 * 1000;
+const typeCache: Map<string, { type: string; expiresAt: number }> = new Map();
+
+function hasTypeRules(policy: DatasourcePolicyConfig): boolean {
+  return policy.allowedTypes.length > 0 || policy.deniedTypes.length > 0;
+}
+
+// Returns whether the policy permits a datasource; without a policy everything is allowed
+export function isDatasourceAllowed(
+  policy: DatasourcePolicyConfig | undefined,
+  datasource: { uid: string; type?: string }
+): boolean {
+  if (!policy || datasource.uid === EXPRESSION_UID) return true;
+
+  if (policy.deniedUids.includes(datasource.uid)) return false;
+  if (datasource.type && policy.deniedTypes.includes(datasource.type)) return false;
+  if (policy.allowedUids.length === 0 && policy.allowedTypes.length === 0) return true;
+
+  return (
+    policy.allowedUids.includes(datasource.uid) ||
+    (!!datasource.type && policy.allowedTypes.includes(datasource.type))
+  );
+}
+
+// Collects the datasource UIDs referenced anywhere in a tool's arguments
+export function collectDatasourceUids(args: unknown): string[] {
+  const uids = new Set<string>();
+  const visit = (value: unknown) => {
+    if (Array.isArray(value)) {
+      value.forEach(visit);
+    } else if (value && typeof value === 'object') {
+      for (const [key, item] of Object.entries(value)) {
+        if (DATASOURCE_UID_KEYS.has(key)) {
+          [item].flat().forEach(uid => typeof uid === 'string' && uids.add(uid));
+        } else {
+          visit(item);
+        }
+      }
+    }
+  };
+  visit(args);
+  return [...uids];
+}
+
+async function resolveType(config: GrafanaConfig, uid: string): Promise<string> {
+  const cached = typeCache.get(uid);
+  if (cached && cached.expiresAt > Date.now()) return cached.type;
+
+  const datasource = await new GrafanaClient(config).getDatasourceByUid(uid);
+  typeCache.set(uid, { type: datasource.type, expiresAt: Date.now() + TYPE_CACHE_TTL_MS });
+  return datasource.type;
+}
+
+// Throws a policy error if any of the datasources may not be used
+export async function checkDatasourceAccess(config: GrafanaConfig, uids: string[]): Promise<void> {
+  const policy = config.datasourcePolicy;
+  if (!policy) return;
+
+  for (const uid of uids) {
+    if (uid === EXPRESSION_UID) continue;
+    // Only look up the type when a type rule could change the outcome
+    const type = hasTypeRules(policy) ? await resolveType(config, uid) : undefined;
+    if (!isDatasourceAllowed(policy, { uid, type })) {
+      throw new Error(
+        `Datasource "${uid}"${type ? ` (type ${type})` : ''} is not allowed by the ` +
+          'datasource policy of this server'
+      );
+    }
+  }
+}
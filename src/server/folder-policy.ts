@@ -0,0 +1,81 @@
+import { GrafanaClient } from '../clients/grafana-client';
+import { GrafanaConfig } from '../types/config';
+
+// Name used in the folder allowlist for dashboards at the root ("General") level
+export const GENERAL_FOLDER = 'general';
+
+// Returns whether write tools may change resources in a folder; without a restriction all can
+export function isFolderWritable(config: GrafanaConfig, folderUid?: string): boolean {
+  if (!config.writeFolderUids) return true;
+  return config.writeFolderUids.includes(folderUid || GENERAL_FOLDER);
+}
+
+// Throws a policy error if write tools may not change resources in the folder
+export function checkFolderWrite(config: GrafanaConfig, folderUid: string | undefined, what: string) {
+  if (isFolderWritable(config, folderUid)) return;
+  throw new Error(
+    `Cannot change ${what} in folder "${folderUid || GENERAL_FOLDER}": write tools are ` +
+      `restricted to folders ${(config.writeFolderUids || []).join(', ')}`
+  );
+}
+
+// Looks up the folder of a dashboard, or reports that it does not exist yet
+export async function getDashboardFolder(
+  client: GrafanaClient,
+  uid: string
+): Promise<{ exists: boolean; folderUid?: string }> {
+  try {
+    const { meta } = await client.getDashboardWithMeta(uid);
+    return { exists: true, folderUid: meta?.folderUid || undefined };
+  } catch (error: any) {
+    if (error.message.includes('(404)')) {
+      return { exists: false };
+    }
+    throw error;
+  }
+}
+
+// Throws a policy error unless the dashboard's current folder is writable
+export async function checkDashboardWrite(
+  client: GrafanaClient,
+  config: GrafanaConfig,
+  uid: string
+): Promise<void> {
+  if (!config.writeFolderUids) return;
+  const { exists, folderUid } = await getDashboardFolder(client, uid);
+  if (exists) {
+    checkFolderWrite(config, folderUid, `dashboard "${uid}"`);
+  }
+}
+
+// Looks up the folder of the dashboard a save would overwrite. Grafana matches saved dashboards
+// by UID and by numeric ID, so both are checked; bodies with neither create a new dashboard.
+export async function getOverwrittenDashboards(
+  client: GrafanaClient,
+  ref: { uid?: string; id?: number }
+): Promise<{ uid: string; folderUid?: string }[]> {
+  const found: { uid: string; folderUid?: string }[] = [];
+  if (ref.uid) {
+    const { exists, folderUid } = await getDashboardFolder(client, ref.uid);
+    if (exists) found.push({ uid: ref.uid, folderUid });
+  }
+  if (ref.id) {
+    const hits = await client.search({ dashboardIds: [ref.id], type: 'dash-db' });
+    for (const hit of hits) {
+      if (hit.uid !== ref.uid) found.push({ uid: hit.uid, folderUid: hit.folderUid || undefined });
+    }
+  }
+  return found;
+}
+
+// Throws a policy error unless the folders of the dashboards a save would overwrite are writable
+export async function checkOverwriteWrite(
+  client: GrafanaClient,
+  config: GrafanaConfig,
+  ref: { uid?: string; id?: number }
+): Promise<void> {
+  if (!config.writeFolderUids) return;
+  for (const existing of await getOverwrittenDashboards(client, ref)) {
+    checkFolderWrite(config, existing.folderUid, `dashboard "${existing.uid}"`);
+  }
+}
@@ -0,0 +1,459 @@
+import * as http from 'http';
+import { Duplex } from 'stream';
+import { randomUUID } from 'crypto';
+import { Server } from '@modelcontextprotocol/sdk/server/index.js';
+import { SSEServerTransport } from '@modelcontextprotocol/sdk/server/sse.js';
+import { StreamableHTTPServerTransport } from '@modelcontextprotocol/sdk/server/streamableHttp.js';
+import { Transport } from '@modelcontextprotocol/sdk/shared/transport.js';
+import { isInitializeRequest } from '@modelcontextprotocol/sdk/types.js';
+import pino from 'pino';
+import { CorsConfig } from '../types/config';
+import { applyCors, isOriginAllowed } from './cors';
+import { REMOTE_ADDRESS_HEADER } from './rate-limit';
+import { MemoryEventStore, SessionStore, sessionHeaders } from './session-store';
+import { WebSocketServerTransport, acceptWebSocket, rejectUpgrade } from './websocket';
+
+// Endpoint the SSE transport tells clients to post their messages to
+const SSE_MESSAGE_PATH = '/message';
+
+// Endpoint of the streamable HTTP transport
+const STREAMABLE_HTTP_PATH = '/mcp';
+
+// Endpoint of the WebSocket transport
+const WEBSOCKET_PATH = '/ws';
+
+// Sessions are stored again when used after this long, extending their lifetime
+const SESSION_TOUCH_INTERVAL_MS = 60 * 1000;
+
+// Event streams whose keep-alive pings stay unsent this many times in a row are closed as dead
+const DEAD_STREAM_PINGS = 3;
+
+// Larger request bodies are rejected; MCP messages are far smaller
+const MAX_BODY_BYTES = 5 * 1024 * 1024;
+
+// Liveness/readiness endpoint; answers 503 while draining so load balancers stop routing here
+const HEALTH_PATH = '/healthz';
+
+export interface HttpTransportOptions {
+  transport: 'sse' | 'streamable-http' | 'websocket';
+  address: string;
+  port: number;
+  // Path of the SSE event stream
+  path: string;
+  // Prefix of all endpoints when served behind a reverse proxy, e.g. /mcp/grafana
+  basePath?: string;
+  cors?: CorsConfig;
+  // Where streamable HTTP sessions are kept for resuming after reconnects
+  sessionStore: SessionStore;
+  // Sessions idle for longer than this are closed
+  sessionTtlMs: number;
+  // Interval of keep-alive pings on event streams (0 disables them)
+  keepAliveMs?: number;
+  // Streamable HTTP without sessions, so any instance can serve any request
+  stateless?: boolean;
+}
+
+interface Session {
+  transport: Transport;
+  server: Server;
+  lastSeen: number;
+  lastStored: number;
+}
+
+// Serves MCP over HTTP, with one protocol server per client session
+export class HttpTransportServer {
+  private options: HttpTransportOptions;
+  private createSession: (headers: http.IncomingHttpHeaders) => Server;
+  private logger: pino.Logger;
+  private httpServer: http.Server;
+  private sessions: Map<string, Session> = new Map();
+  private accepting = true;
+  private eventStore: MemoryEventStore;
+  private sweeper: NodeJS.Timeout;
+
+  // createSession gets the headers of the request that starts the session
+  constructor(
+    options: HttpTransportOptions,
+    createSession: (headers: http.IncomingHttpHeaders) => Server,
+    logger: pino.Logger
+  ) {
+    this.options = options;
+    this.createSession = createSession;
+    this.logger = logger;
+    this.eventStore = new MemoryEventStore(options.sessionTtlMs);
+    this.sweeper = setInterval(() => this.closeIdleSessions(), SESSION_TOUCH_INTERVAL_MS);
+    this.sweeper.unref();
+    this.httpServer = http.createServer((request, response) => {
+      this.handle(request, response).catch(error => {
+        this.logger.error({ error: error.message }, 'Failed to handle HTTP request');
+        if (!response.headersSent) {
+          sendJson(response, 500, { error: error.message });
+        }
+      });
+    });
+    this.httpServer.on('upgrade', (request, socket) => {
+      this.handleUpgrade(request, socket).catch(error => {
+        this.logger.error({ error: error.message }, 'Failed to open WebSocket session');
+        socket.destroy();
+      });
+    });
+  }
+
+  get sessionCount(): number {
+    return this.sessions.size;
+  }
+
+  // Protocol servers of the open sessions, e.g. to notify them of changes
+  get sessionServers(): Server[] {
+    return [...this.sessions.values()].map(session => session.server);
+  }
+
+  async listen(): Promise<void> {
+    await new Promise<void>((resolve, reject) => {
+      this.httpServer.once('error', reject);
+      this.httpServer.listen(this.options.port, this.options.address, () => resolve());
+    });
+  }
+
+  // Refuses new sessions while existing ones keep working, so in-flight calls can finish
+  stopAccepting() {
+    this.accepting = false;
+  }
+
+  // Closes all sessions and the listener
+  async close(): Promise<void> {
+    this.accepting = false;
+    clearInterval(this.sweeper);
+    // Sessions are removed first so they stay in the store for resuming on another instance
+    const sessions = [...this.sessions.values()];
+    this.sessions.clear();
+    await Promise.allSettled(sessions.map(session => session.server.close()));
+    await new Promise<void>(resolve => {
+      this.httpServer.close(() => resolve());
+      // Open SSE streams would otherwise keep the listener alive
+      this.httpServer.closeAllConnections();
+    });
+  }
+
+  // Returns the URL of a request relative to the base path
+  private requestUrl(request: http.IncomingMessage): URL {
+    const url = new URL(request.url || '/', 'http://localhost');
+    // Proxies may forward requests with or without the prefix
+    const basePath = normalizeBasePath(this.options.basePath);
+    if (basePath && (url.pathname === basePath || url.pathname.startsWith(`${basePath}/`))) {
+      url.pathname = url.pathname.slice(basePath.length) || '/';
+    }
+    return url;
+  }
+
+  private async handle(request: http.IncomingMessage, response: http.ServerResponse) {
+    const url = this.requestUrl(request);
+    setRemoteAddress(request);
+
+    if (applyCors(this.options.cors, request, response)) {
+      return;
+    }
+
+    if (url.pathname === HEALTH_PATH) {
+      sendJson(response, this.accepting ? 200 : 503, {
+        status: this.accepting ? 'ok' : 'draining',
+        sessions: this.sessions.size,
+      });
+      return;
+    }
+
+    if (this.options.transport === 'sse') {
+      if (request.method === 'GET' && url.pathname === this.options.path) {
+        await this.openSseSession(request, response);
+        return;
+      }
+      if (request.method === 'POST' && url.pathname === SSE_MESSAGE_PATH) {
+        const session = this.sessions.get(url.searchParams.get('sessionId') || '');
+        if (!session) {
+          sendJson(response, 404, { error: 'Unknown or expired session' });
+          return;
+        }
+        session.lastSeen = Date.now();
+        await (session.transport as SSEServerTransport).handlePostMessage(
+          request,
+          response,
+          await readJsonBody(request)
+        );
+        return;
+      }
+    } else if (
+      this.options.transport === 'streamable-http' &&
+      url.pathname === STREAMABLE_HTTP_PATH
+    ) {
+      if (this.options.stateless) {
+        await this.handleStatelessRequest(request, response);
+      } else {
+        await this.handleStreamableHttp(request, response);
+      }
+      return;
+    }
+
+    sendJson(response, 404, { error: 'Not found' });
+  }
+
+  private async handleUpgrade(request: http.IncomingMessage, socket: Duplex) {
+    const url = this.requestUrl(request);
+    setRemoteAddress(request);
+    if (this.options.transport !== 'websocket' || url.pathname !== WEBSOCKET_PATH) {
+      rejectUpgrade(socket, 404, 'Not Found');
+      return;
+    }
+    // Browsers do not apply CORS to WebSockets, so their origin is checked here
+    const origin = request.headers.origin;
+    if (origin && !isOriginAllowed(this.options.cors, origin)) {
+      rejectUpgrade(socket, 403, 'Forbidden');
+      return;
+    }
+    if (!this.accepting) {
+      rejectUpgrade(socket, 503, 'Service Unavailable', { 'Retry-After': '5' });
+      return;
+    }
+    if (!acceptWebSocket(request, socket)) {
+      return;
+    }
+
+    const transport = new WebSocketServerTransport(socket, request.headers);
+    const server = this.createSession(request.headers);
+    const session = newSession(transport, server);
+    this.sessions.set(transport.sessionId, session);
+    socket.on('data', () => {
+      session.lastSeen = Date.now();
+    });
+    await this.connectSession(transport, server);
+    this.logger.debug({ sessionId: transport.sessionId }, 'MCP WebSocket session started');
+  }
+
+  private async openSseSession(request: http.IncomingMessage, response: http.ServerResponse) {
+    if (!this.accepting) {
+      sendDraining(response);
+      return;
+    }
+    // The endpoint is sent to the client, so it includes the prefix of the proxy
+    const endpoint = `${normalizeBasePath(this.options.basePath)}${SSE_MESSAGE_PATH}`;
+    const transport = new SSEServerTransport(endpoint, response);
+    const server = this.createSession(request.headers);
+    this.sessions.set(transport.sessionId, newSession(transport, server));
+    this.keepAlive(response);
+    await this.connectSession(transport, server);
+  }
+
+  private async handleStreamableHttp(
+    request: http.IncomingMessage,
+    response: http.ServerResponse
+  ) {
+    const body = request.method === 'POST' ? await readJsonBody(request) : undefined;
+    const sessionId = request.headers['mcp-session-id'];
+    let session = typeof sessionId === 'string' ? this.sessions.get(sessionId) : undefined;
+    if (!session && typeof sessionId === 'string' && this.accepting) {
+      session = await this.restoreSession(sessionId);
+    }
+
+    if (session && typeof sessionId === 'string') {
+      await this.touchSession(sessionId, session);
+      if (request.method === 'GET') {
+        this.keepAlive(response);
+      }
+      const transport = session.transport as StreamableHTTPServerTransport;
+      await transport.handleRequest(request, response, body);
+      return;
+    }
+    if (sessionId || request.method !== 'POST' || !isInitializeRequest(body)) {
+      sendJson(response, sessionId ? 404 : 400, {
+        jsonrpc: '2.0',
+        error: { code: -32000, message: sessionId ? 'Unknown or expired session' : 'No session' },
+        id: null,
+      });
+      return;
+    }
+    if (!this.accepting) {
+      sendDraining(response);
+      return;
+    }
+
+    const server = this.createSession(request.headers);
+    const transport: StreamableHTTPServerTransport = new StreamableHTTPServerTransport({
+      sessionIdGenerator: () => randomUUID(),
+      eventStore: this.eventStore,
+      onsessioninitialized: async id => {
+        this.sessions.set(id, newSession(transport, server));
+        this.logger.debug({ sessionId: id }, 'MCP session started');
+        await this.options.sessionStore.set({
+          id,
+          headers: sessionHeaders(request.headers),
+          createdAt: Date.now(),
+        });
+      },
+    });
+    await this.connectSession(transport, server);
+    await transport.handleRequest(request, response, body);
+  }
+
+  // Serves a request with a protocol server of its own that is discarded afterwards. All context
+  // comes from the request's headers; there is no event stream to open, resume, or terminate.
+  private async handleStatelessRequest(
+    request: http.IncomingMessage,
+    response: http.ServerResponse
+  ) {
+    if (request.method !== 'POST') {
+      response.setHeader('Allow', 'POST');
+      sendJson(response, 405, {
+        jsonrpc: '2.0',
+        error: { code: -32000, message: 'Only POST is supported in stateless mode' },
+        id: null,
+      });
+      return;
+    }
+    if (!this.accepting) {
+      sendDraining(response);
+      return;
+    }
+
+    const body = await readJsonBody(request);
+    const server = this.createSession(request.headers);
+    // Plain JSON responses, since serverless platforms may buffer streamed ones
+    const transport = new StreamableHTTPServerTransport({
+      sessionIdGenerator: undefined,
+      enableJsonResponse: true,
+    });
+    response.on('close', () => {
+      server.close().catch(() => undefined);
+    });
+    await server.connect(transport);
+    await transport.handleRequest(request, response, body);
+  }
+
+  // Recreates a session from the store, e.g. after the client reconnected to another instance
+  private async restoreSession(id: string): Promise<Session | undefined> {
+    const record = await this.options.sessionStore.get(id);
+    if (!record) return undefined;
+
+    const server = this.createSession(record.headers as http.IncomingHttpHeaders);
+    const transport = new StreamableHTTPServerTransport({
+      sessionIdGenerator: () => id,
+      eventStore: this.eventStore,
+    });
+    // The SDK cannot resume a session, so the new transport is marked as already initialized.
+    // This relies on a private field, which is why the SDK version is pinned exactly;
+    // test/test-session-restore.js checks it still works after upgrading.
+    transport.sessionId = id;
+    (transport as any)._initialized = true;
+
+    const session = newSession(transport, server);
+    this.sessions.set(id, session);
+    await this.connectSession(transport, server);
+    this.logger.debug({ sessionId: id }, 'MCP session restored');
+    return session;
+  }
+
+  // Extends the lifetime of a session in use, storing it at most once per interval
+  private async touchSession(id: string, session: Session) {
+    const now = Date.now();
+    session.lastSeen = now;
+    if (now - session.lastStored < SESSION_TOUCH_INTERVAL_MS) return;
+    session.lastStored = now;
+    const record = await this.options.sessionStore.get(id);
+    if (record) {
+      await this.options.sessionStore.set(record);
+    }
+  }
+
+  // Writes SSE comments to an event stream so proxies with idle timeouts keep it open. Streams
+  // whose pings cannot be sent, e.g. because the client vanished without closing the
+  // connection, are closed, which ends their session.
+  private keepAlive(response: http.ServerResponse) {
+    const intervalMs = this.options.keepAliveMs;
+    if (!intervalMs) return;
+    let unsent = 0;
+    const timer = setInterval(() => {
+      if (!response.headersSent) return;
+      unsent = response.writableNeedDrain ? unsent + 1 : 0;
+      if (unsent >= DEAD_STREAM_PINGS) {
+        this.logger.debug('Closing dead event stream');
+        response.destroy();
+        return;
+      }
+      response.write(': ping\n\n');
+    }, intervalMs);
+    timer.unref();
+    response.on('close', () => clearInterval(timer));
+  }
+
+  private closeIdleSessions() {
+    const cutoff = Date.now() - this.options.sessionTtlMs;
+    for (const session of this.sessions.values()) {
+      if (session.lastSeen < cutoff) {
+        session.transport.close().catch(() => undefined);
+      }
+    }
+  }
+
+  private async connectSession(transport: Transport, server: Server) {
+    transport.onclose = () => {
+      const id = transport.sessionId;
+      if (id && this.sessions.get(id)?.transport === transport) {
+        this.sessions.delete(id);
+        this.logger.debug({ sessionId: id }, 'MCP session closed');
+        this.options.sessionStore.delete(id).catch(error => {
+          this.logger.warn({ sessionId: id, error: error.message }, 'Failed to delete session');
+        });
+      }
+    };
+    await server.connect(transport);
+  }
+}
+
+// Returns a base path as "/prefix" without a trailing slash, or "" for none
+export function normalizeBasePath(basePath?: string): string {
+  const trimmed = (basePath || '').replace(/^\/+|\/+$/g, '');
+  return trimmed ? `/${trimmed}` : '';
+}
+
+function newSession(transport: Transport, server: Server): Session {
+  const now = Date.now();
+  return { transport, server, lastSeen: now, lastStored: now };
+}
+
+// Records the address a request came from for rate limiting, replacing any value the client sent
+function setRemoteAddress(request: http.IncomingMessage) {
+  request.headers[REMOTE_ADDRESS_HEADER] = request.socket.remoteAddress || 'unknown';
+}
+
+function sendJson(response: http.ServerResponse, status: number, body: any) {
+  response.writeHead(status, { 'Content-Type': 'application/json' });
+  response.end(JSON.stringify(body));
+}
+
+function sendDraining(response: http.ServerResponse) {
+  response.setHeader('Retry-After', '5');
+  sendJson(response, 503, { error: 'Server is shutting down; reconnect to another instance' });
+}
+
+function readJsonBody(request: http.IncomingMessage): Promise<any> {
+  return new Promise((resolve, reject) => {
+    const chunks: Buffer[] = [];
+    let size = 0;
+    request.on('data', (chunk: Buffer) => {
+      size += chunk.length;
+      if (size > MAX_BODY_BYTES) {
+        reject(new Error(`Request body exceeds ${MAX_BODY_BYTES} bytes`));
+        request.destroy();
+        return;
+      }
+      chunks.push(chunk);
+    });
+    request.on('error', reject);
+    request.on('end', () => {
+      const text = Buffer.concat(chunks).toString();
+      try {
+        resolve(text ? JSON.parse(text) : undefined);
+      } catch (error) {
+        reject(new Error(`Invalid JSON body: ${(error as Error).message}`));
+      }
+    });
+  });
+}
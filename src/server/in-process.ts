@@ -0,0 +1,17 @@
+import { Client } from '@modelcontextprotocol/sdk/client/index.js';
+import { InMemoryTransport } from '@modelcontextprotocol/sdk/inMemory.js';
+import { MCPServer } from './mcp-server';
+
+// Connects an MCP client to the server in the same process, so applications embedding the
+// server can call its tools without spawning a subprocess or opening a port
+export async function createInProcessClient(
+  server: MCPServer,
+  clientInfo = { name: 'mcp-grafana-in-process', version: '1.0.0' }
+): Promise<Client> {
+  const [clientTransport, serverTransport] = InMemoryTransport.createLinkedPair();
+  await server.connect(serverTransport);
+
+  const client = new Client(clientInfo);
+  await client.connect(clientTransport);
+  return client;
+}
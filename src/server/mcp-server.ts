@@ -1,9 +1,19 @@
+import { IncomingHttpHeaders } from 'http';
+import { randomUUID } from 'crypto';
 import { Server } from '@modelcontextprotocol/sdk/server/index.js';
 import { StdioServerTransport } from '@modelcontextprotocol/sdk/server/stdio.js';
+import { Transport } from '@modelcontextprotocol/sdk/shared/transport.js';
+import { RequestHandlerExtra } from '@modelcontextprotocol/sdk/shared/protocol.js';
 import { 
   ListToolsRequestSchema, 
   CallToolRequestSchema,
+  CallToolRequest,
+  ServerRequest,
+  ServerNotification,
+  ListResourcesRequestSchema,
+  ReadResourceRequestSchema,
   Tool,
+  ToolAnnotations,
   CallToolResult,
   TextContent
 } from '@modelcontextprotocol/sdk/types.js';
@@ -11,17 +21,103 @@ import { z } from 'zod';
 import { zodToJsonSchema } from 'zod-to-json-schema';
 import pino from 'pino';
 import { ServerConfig } from '../types/config';
+import { TOOL_CATEGORIES } from '../types';
+import { validateTimezone } from '../utils/time';
+import { ResultStore } from './result-store';
+import { checkDatasourceAccess, collectDatasourceUids } from './datasource-policy';
+import { withDryRun } from '../clients/dry-run';
+import { HttpTransportServer, normalizeBasePath } from './http-transport';
+import { RateLimiter, clientIdentity } from './rate-limit';
+import { clientToolFilter, isToolInFilter } from './tool-filter';
+import { createSessionStore } from './session-store';
+import { onTlsFileChange } from '../clients/tls-files';
+import { ALERT_WEBHOOK_PATH, AlertEvent, AlertWebhookServer, alertEvents } from './alert-webhook';
+import {
+  RequestContext,
+  createRequestContext,
+  withRequestContext,
+} from '../clients/request-context';
+
+// Header that lets HTTP clients override the configured time zone per request
+const TIMEZONE_HEADER = 'x-grafana-timezone';
+
+// Instructions sent to clients unless the operator configures their own
+export const DEFAULT_INSTRUCTIONS =
+  'This server provides access to a Grafana instance: dashboards, datasources, Prometheus ' +
+  'metrics, Loki logs, alerting, incidents, and more. Use the search tools to find ' +
+  'dashboards and the list_datasources tool to find datasource UIDs before querying.';
+
+// Characters of an oversized result kept inline as a preview
+const SPILLOVER_PREVIEW_SIZE = 2000;
 
 export interface ToolDefinition {
   name: string;
   description: string;
   inputSchema: z.ZodType<any>;
+  annotations?: ToolAnnotations;
+  // Category of tools added by embedders; enable it by adding it to ServerConfig.enabledTools
+  category?: string;
   handler: (params: any, context: ToolContext) => Promise<CallToolResult>;
 }
 
+// Severity of logging notifications sent to the client
+export type LogLevel = 'debug' | 'info' | 'notice' | 'warning' | 'error';
+
+// The client session of a tool call, for work that outlives the call
+export interface ToolSession {
+  id: string;
+  // Sends a logging notification to the client outside of any tool call
+  notify: (data: unknown, level?: LogLevel, logger?: string) => Promise<void>;
+  // Registers a function to run when the client disconnects
+  onClose: (listener: () => void) => void;
+}
+
 export interface ToolContext {
   config: ServerConfig;
   logger: pino.Logger;
+  // Reports progress to the client; a no-op if the client did not request progress
+  sendProgress: (progress: number, total?: number, message?: string) => Promise<void>;
+  // Streams intermediate data to the client as a logging notification while the tool runs
+  sendLog: (data: unknown, level?: LogLevel) => Promise<void>;
+  session: ToolSession;
+  // Aborted when the client cancels the tool call
+  signal?: AbortSignal;
+  // Time zone for parsing and formatting times: the request header override, else the configured one
+  timezone?: string;
+  // Correlates the call with outbound Grafana requests (X-Request-ID header) and its trace
+  // (traceparent header), e.g. for span attributes in middleware
+  requestId?: string;
+  traceId?: string;
+}
+
+export interface ToolDescription {
+  name: string;
+  description: string;
+  category?: string;
+  access: 'read' | 'write' | 'destructive';
+  // Whether the configuration lists the tool to clients
+  enabled: boolean;
+  inputSchema: any;
+  annotations?: ToolAnnotations;
+}
+
+export interface ToolCall {
+  name: string;
+  args: any;
+  context: ToolContext;
+}
+
+// Hooks around every tool call, for embedders adding authorization, quotas, or logging
+export interface ToolMiddleware {
+  // Runs before the tool with validated arguments; return replacement arguments, or throw to
+  // reject the call
+  before?: (call: ToolCall) => any | Promise<any>;
+  // Runs after the tool with its result or thrown error; return a result to replace either
+  after?: (
+    call: ToolCall,
+    result: CallToolResult | undefined,
+    error?: unknown
+  ) => CallToolResult | void | Promise<CallToolResult | void>;
 }
 
 export class MCPServer {
@@ -29,6 +125,14 @@ export class MCPServer {
   private tools: Map<string, ToolDefinition> = new Map();
   private config: ServerConfig;
   private logger: pino.Logger;
+  private results = new ResultStore();
+  private rateLimiter = new RateLimiter();
+  private middlewares: ToolMiddleware[] = [];
+  private httpTransport?: HttpTransportServer;
+  private alertWebhook?: AlertWebhookServer;
+  private inFlight: Set<Promise<CallToolResult>> = new Set();
+  private draining = false;
+  private shutdownHooks: (() => Promise<void> | void)[] = [];
 
   constructor(config: ServerConfig) {
     this.config = config;
@@ -42,88 +146,394 @@ export class MCPServer {
       } : undefined
     });
 
-    this.server = new Server(
+    this.server = this.createProtocolServer();
+
+    onTlsFileChange(path => {
+      this.logger.info({ path }, 'TLS file rotated; new Grafana connections use it');
+    });
+  }
+
+  // Creates a protocol server with the tool handlers; HTTP transports use one per session
+  // createProtocolServer gets the headers an HTTP client started its session with
+  private createProtocolServer(sessionHeaders?: IncomingHttpHeaders): Server {
+    const server = new Server(
       {
         name: 'mcp-grafana',
         version: '0.1.0',
       },
       {
         capabilities: {
-          tools: {},
+          tools: { listChanged: true },
+          resources: {},
+          // Tools stream partial results as logging notifications
+          logging: {},
         },
+        instructions: this.config.instructions || DEFAULT_INSTRUCTIONS,
       }
     );
 
-    this.setupHandlers();
+    this.setupHandlers(server, sessionHeaders);
+    return server;
   }
 
-  private setupHandlers() {
-    // List tools handler
-    this.server.setRequestHandler(ListToolsRequestSchema, async () => {
-      const tools: Tool[] = [];
-      
-      for (const [name, definition] of this.tools) {
-        // Check if tool category is enabled
-        const category = this.getToolCategory(name);
-        if (category && !this.config.enabledTools.has(category)) {
-          continue;
-        }
+  private setupHandlers(server: Server, sessionHeaders?: IncomingHttpHeaders) {
+    const session = this.createToolSession(server);
 
-        const jsonSchema = zodToJsonSchema(definition.inputSchema);
-        
-        tools.push({
-          name: definition.name,
-          description: definition.description,
-          inputSchema: jsonSchema as any,
-        });
-      }
+    // List tools handler
+    server.setRequestHandler(ListToolsRequestSchema, async (_request, extra) => {
+      const filter = this.toolFilter(extra.requestInfo?.headers, sessionHeaders);
+      const tools: Tool[] = this.describeTools()
+        .filter(tool => tool.enabled && isToolInFilter(filter, tool))
+        .map(tool => ({
+          name: tool.name,
+          description: tool.description,
+          inputSchema: tool.inputSchema,
+          annotations: tool.annotations,
+        }));
 
       return { tools };
     });
 
     // Call tool handler
-    this.server.setRequestHandler(CallToolRequestSchema, async (request) => {
-      const { name, arguments: args } = request.params;
-      
-      const tool = this.tools.get(name);
-      if (!tool) {
-        throw new Error(`Tool "${name}" not found`);
+    server.setRequestHandler(CallToolRequestSchema, async (request, extra) => {
+      if (this.draining) {
+        throw new Error('Server is shutting down; retry the call on another instance');
+      }
+      const call = this.callTool(request, extra, session, sessionHeaders);
+      this.inFlight.add(call);
+      try {
+        return await call;
+      } finally {
+        this.inFlight.delete(call);
       }
+    });
+
+    // Oversized tool results, kept as ephemeral resources of the session that produced them;
+    // stdio has a single session, which has no ID
+    server.setRequestHandler(ListResourcesRequestSchema, async (_request, extra) => {
+      return {
+        resources: this.results.list(extra.sessionId ?? '').map(result => ({
+          uri: result.uri,
+          name: result.name,
+          mimeType: result.mimeType,
+        })),
+      };
+    });
 
-      // Check if tool category is enabled
-      const category = this.getToolCategory(name);
-      if (category && !this.config.enabledTools.has(category)) {
-        throw new Error(`Tool category "${category}" is not enabled`);
+    server.setRequestHandler(ReadResourceRequestSchema, async (request, extra) => {
+      const result = this.results.get(extra.sessionId ?? '', request.params.uri);
+      if (!result) {
+        throw new Error(`Resource "${request.params.uri}" not found or expired`);
       }
+      return {
+        contents: [{ uri: result.uri, mimeType: result.mimeType, text: result.text }],
+      };
+    });
+  }
 
-      try {
-        // Validate input
-        const validatedArgs = tool.inputSchema.parse(args);
-        
-        // Execute tool handler
-        const context: ToolContext = {
-          config: this.config,
-          logger: this.logger.child({ tool: name }),
-        };
+  // Session handle for the tools called through a protocol server
+  private createToolSession(server: Server): ToolSession {
+    const listeners: (() => void)[] = [];
+    server.onclose = () => {
+      for (const listener of listeners.splice(0)) {
+        listener();
+      }
+    };
+    return {
+      id: randomUUID(),
+      notify: async (data, level = 'info', logger = 'mcp-grafana') => {
+        await server.sendLoggingMessage({ level, logger, data });
+      },
+      onClose: listener => {
+        listeners.push(listener);
+      },
+    };
+  }
 
-        const result = await tool.handler(validatedArgs, context);
-        
+  // Runs a tool call with its request ID and trace context; errors carry the request ID
+  private async callTool(
+    request: CallToolRequest,
+    extra: RequestHandlerExtra<ServerRequest, ServerNotification>,
+    session: ToolSession,
+    sessionHeaders?: IncomingHttpHeaders
+  ): Promise<CallToolResult> {
+    const requestContext = createRequestContext(extra.requestInfo?.headers);
+    const suffix = ` (request ID: ${requestContext.requestId})`;
+    try {
+      const result = await withRequestContext(requestContext, () =>
+        this.runToolCall(request, extra, requestContext, session, sessionHeaders)
+      );
+      if (!result.isError) {
         return result;
+      }
+      const content = result.content.map((item, i) =>
+        i === result.content.length - 1 && item.type === 'text'
+          ? { ...item, text: item.text + suffix }
+          : item
+      );
+      return { ...result, content };
+    } catch (error: any) {
+      throw new Error(`${error?.message ?? String(error)}${suffix}`);
+    }
+  }
+
+  // Validates, runs, and post-processes a tool call
+  private async runToolCall(
+    request: CallToolRequest,
+    extra: RequestHandlerExtra<ServerRequest, ServerNotification>,
+    requestContext: RequestContext,
+    session: ToolSession,
+    sessionHeaders?: IncomingHttpHeaders
+  ): Promise<CallToolResult> {
+    const { name, arguments: args } = request.params;
+    
+    const tool = this.tools.get(name);
+    if (!tool) {
+      throw new Error(`Tool "${name}" not found`);
+    }
+
+    // Check if tool category is enabled
+    const category = this.getToolCategory(name);
+    if (category && !this.config.enabledTools.has(category)) {
+      throw new Error(`Tool category "${category}" is not enabled`);
+    }
+
+    if (this.config.disableWrite && isWriteTool(tool)) {
+      throw new Error(`Tool "${name}" modifies Grafana and write tools are disabled`);
+    }
+
+    const filter = this.toolFilter(extra.requestInfo?.headers, sessionHeaders);
+    if (!isToolInFilter(filter, { name, category })) {
+      throw new Error(`Tool "${name}" is not in this client's X-Mcp-Enabled-Tools header`);
+    }
+
+    if (this.config.rateLimit) {
+      const client = clientIdentity(extra.requestInfo?.headers, extra.sessionId);
+      const { retryAfter } = this.rateLimiter.take(this.config.rateLimit, client, category);
+      if (retryAfter !== undefined) {
+        return createErrorResult(
+          `Rate limit exceeded for ${category ? `${category} tools` : `tool "${name}"`}: ` +
+            `retry after ${retryAfter} seconds`
+        );
+      }
+    }
+
+    try {
+      // Validate input
+      const validatedArgs = tool.inputSchema.parse(args);
+      
+      const headerTimezone = extra.requestInfo?.headers?.[TIMEZONE_HEADER];
+      const timezone = (Array.isArray(headerTimezone) ? headerTimezone[0] : headerTimezone)
+        || this.config.grafanaConfig.timezone;
+      if (timezone) {
+        validateTimezone(timezone);
+      }
+      
+      // Execute tool handler
+      const progressToken = request.params._meta?.progressToken;
+      const context: ToolContext = {
+        config: this.config,
+        logger: this.logger.child({
+          tool: name,
+          requestId: requestContext.requestId,
+          traceId: requestContext.traceId,
+        }),
+        sendProgress: async (progress, total, message) => {
+          if (progressToken === undefined) return;
+          await extra.sendNotification({
+            method: 'notifications/progress',
+            params: { progressToken, progress, total, message },
+          });
+        },
+        sendLog: async (data, level = 'info') => {
+          await extra.sendNotification({
+            method: 'notifications/message',
+            params: { level, logger: name, data },
+          });
+        },
+        session,
+        signal: extra.signal,
+        timezone,
+        requestId: requestContext.requestId,
+        traceId: requestContext.traceId,
+      };
+
+      // Middleware before hooks run in registration order, after hooks in reverse
+      const call: ToolCall = { name, args: validatedArgs, context };
+      for (const middleware of this.middlewares) {
+        const replaced = await middleware.before?.(call);
+        if (replaced !== undefined) {
+          call.args = replaced;
+        }
+      }
+      
+      let result: CallToolResult | undefined;
+      let failure: unknown;
+      try {
+        const dryRun = (args as any)?.dryRun === true;
+        result = await this.executeTool(tool, call.args, context, dryRun);
       } catch (error) {
-        if (error instanceof z.ZodError) {
-          throw new Error(`Invalid arguments for tool "${name}": ${error.message}`);
+        failure = error;
+      }
+      
+      for (const middleware of [...this.middlewares].reverse()) {
+        const replaced = await middleware.after?.(call, result, failure);
+        if (replaced) {
+          result = replaced;
+          failure = undefined;
         }
-        throw error;
       }
+      
+      if (failure !== undefined || !result) {
+        throw failure;
+      }
+      return this.spillLargeResult(name, result, extra.sessionId ?? '');
+    } catch (error) {
+      if (error instanceof z.ZodError) {
+        throw new Error(`Invalid arguments for tool "${name}": ${error.message}`);
+      }
+      throw error;
+    }
+  }
+
+  // Applies the datasource policy and dry-run mode, then runs the tool handler
+  private async executeTool(
+    tool: ToolDefinition,
+    args: any,
+    context: ToolContext,
+    requestedDryRun: boolean
+  ): Promise<CallToolResult> {
+    try {
+      await checkDatasourceAccess(this.config.grafanaConfig, collectDatasourceUids(args));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+    
+    // Tools with their own dryRun parameter are forced into it by the global setting;
+    // for the others, state-changing requests are recorded instead of sent
+    const dryRun = isWriteTool(tool) && (this.config.dryRun || requestedDryRun);
+    if (dryRun && hasDryRunParam(tool)) {
+      return tool.handler({ ...args, dryRun: true }, context);
+    } else if (dryRun) {
+      return this.dryRunTool(tool, args, context);
+    }
+    
+    return tool.handler(args, context);
+  }
+
+  // Runs a write tool without letting it change anything, and reports what it would send
+  private async dryRunTool(
+    tool: ToolDefinition,
+    args: any,
+    context: ToolContext
+  ): Promise<CallToolResult> {
+    const { result, error, requests } = await withDryRun(() => tool.handler(args, context));
+    
+    // Nothing recorded means the tool stopped before writing, e.g. on invalid input
+    if (requests.length === 0) {
+      if (error) throw error;
+      if (result?.isError) return result;
+    }
+    
+    return createToolResult({
+      dryRun: true,
+      tool: tool.name,
+      requests,
+      message: requests.length > 0
+        ? `Dry run: ${requests.length} request(s) were not sent to Grafana. ` +
+          'Call again without dryRun to apply them.'
+        : 'Dry run: the tool would not send any changes to Grafana.',
+    });
+  }
+
+  // Replaces a result over the size limit with a preview and a link to the full result
+  private spillLargeResult(
+    toolName: string,
+    result: CallToolResult,
+    sessionId: string
+  ): CallToolResult {
+    const limit = this.config.maxResultSize;
+    // Without sessions the resource could be requested from another instance, which lacks it
+    if (!limit || result.isError || this.config.stateless) return result;
+
+    const text = result.content
+      .filter((content): content is TextContent => content.type === 'text')
+      .map(content => content.text)
+      .join('\n');
+    if (text.length <= limit || result.content.some(content => content.type !== 'text')) {
+      return result;
+    }
+
+    const stored = this.results.put(sessionId, toolName, text);
+    this.logger.debug({ tool: toolName, size: text.length, uri: stored.uri }, 'Spilled large result');
+    return {
+      content: [
+        {
+          type: 'text',
+          text:
+            `The result is ${text.length} characters, over the ${limit} character limit. ` +
+            `The full result is available as resource ${stored.uri} for ` +
+            `${this.results.ttlMinutes} minutes. Narrow the query to get it inline. ` +
+            `Preview:\n\n${text.slice(0, SPILLOVER_PREVIEW_SIZE)}`,
+        },
+        {
+          type: 'resource_link',
+          uri: stored.uri,
+          name: stored.name,
+          mimeType: stored.mimeType,
+          description: `Full ${toolName} result (${text.length} characters)`,
+        },
+      ],
+    };
+  }
+
+  // Describes every registered tool, including the ones the configuration hides
+  describeTools(): ToolDescription[] {
+    return [...this.tools.values()].map(definition => {
+      const category = this.getToolCategory(definition.name);
+      const jsonSchema: any = zodToJsonSchema(definition.inputSchema);
+      if (isWriteTool(definition) && jsonSchema.properties && !hasDryRunParam(definition)) {
+        jsonSchema.properties.dryRun = {
+          type: 'boolean',
+          description: 'Validate and return the requests that would be sent to Grafana without sending them',
+        };
+      }
+
+      return {
+        name: definition.name,
+        description: definition.description,
+        category,
+        access: !isWriteTool(definition)
+          ? 'read'
+          : definition.annotations?.destructiveHint ? 'destructive' : 'write',
+        // Hidden when the category is disabled, and write tools in read-only mode
+        enabled: (!category || this.config.enabledTools.has(category))
+          && !(this.config.disableWrite && isWriteTool(definition)),
+        inputSchema: jsonSchema,
+        annotations: definition.annotations,
+      };
     });
   }
 
+  // Adds a middleware around every tool call; middlewares run in the order they are added
+  use(middleware: ToolMiddleware): this {
+    this.middlewares.push(middleware);
+    return this;
+  }
+
   registerTool(definition: ToolDefinition) {
     this.tools.set(definition.name, definition);
     this.logger.debug(`Registered tool: ${definition.name}`);
   }
 
   private getToolCategory(toolName: string): string | undefined {
+    const custom = this.tools.get(toolName)?.category;
+    if (custom) return custom;
+
+    // Prefer the explicit category listing
+    const listed = TOOL_CATEGORIES.find(category => category.tools.includes(toolName));
+    if (listed) return listed.name;
+
     // Map tool names to categories based on naming patterns
     if (toolName.startsWith('search_')) return 'search';
     if (toolName.includes('dashboard')) return 'dashboard';
@@ -143,6 +553,7 @@ export class MCPServer {
   }
 
   async start() {
+    await this.startAlertWebhook();
     switch (this.config.transport) {
       case 'stdio':
         await this.startStdio();
@@ -153,11 +564,20 @@ export class MCPServer {
       case 'streamable-http':
         await this.startStreamableHTTP();
         break;
+      case 'websocket':
+        await this.startHttp('websocket');
+        break;
       default:
         throw new Error(`Unsupported transport: ${this.config.transport}`);
     }
   }
 
+  // Connects the server to a transport supplied by an embedding application
+  async connect(transport: Transport) {
+    await this.server.connect(transport);
+    this.logger.debug('MCP server connected to a custom transport');
+  }
+
   private async startStdio() {
     const transport = new StdioServerTransport();
     await this.server.connect(transport);
@@ -165,22 +585,176 @@ export class MCPServer {
   }
 
   private async startSSE() {
-    // Note: SSE transport would need to be implemented or imported from MCP SDK
-    // For now, we'll throw an error
-    throw new Error('SSE transport not yet implemented in TypeScript version');
+    await this.startHttp('sse');
   }
 
   private async startStreamableHTTP() {
-    // Note: Streamable HTTP transport would need to be implemented
-    throw new Error('Streamable HTTP transport not yet implemented in TypeScript version');
+    await this.startHttp('streamable-http');
+  }
+
+  private async startHttp(transport: 'sse' | 'streamable-http' | 'websocket') {
+    const sessionTtlMs = (this.config.sessionTtl ?? 30) * 60 * 1000;
+    const options = {
+      transport,
+      address: this.config.address || '127.0.0.1',
+      port: this.config.port ?? 3000,
+      path: this.config.path || '/events',
+      basePath: this.config.basePath,
+      cors: this.config.cors,
+      sessionStore: createSessionStore(this.config.sessionStore || 'memory', sessionTtlMs),
+      sessionTtlMs,
+      stateless: this.config.stateless,
+      keepAliveMs: (this.config.keepAlive ?? 15) * 1000,
+    };
+    this.httpTransport = new HttpTransportServer(
+      options,
+      headers => this.createProtocolServer(headers),
+      this.logger
+    );
+    await this.httpTransport.listen();
+    const url = `http://${options.address}:${options.port}${normalizeBasePath(options.basePath)}`;
+    this.logger.info(`MCP server started with ${transport} transport on ${url}`);
+  }
+
+  // Starts the receiver of Grafana alert webhooks when configured
+  private async startAlertWebhook() {
+    const config = this.config.alertWebhook;
+    if (!config) return;
+    this.alertWebhook = new AlertWebhookServer(
+      config,
+      alertEvents,
+      events => this.announceAlertEvents(events),
+      this.logger
+    );
+    await this.alertWebhook.listen();
+    const url = `http://${config.address || '127.0.0.1'}:${config.port}${ALERT_WEBHOOK_PATH}`;
+    this.logger.info(`Alert webhook receiver listening on ${url}`);
+  }
+
+  // Announces received alerts to all connected clients as logging notifications
+  private announceAlertEvents(events: AlertEvent[]) {
+    const firing = events.filter(event => event.status === 'firing').length;
+    const servers = [this.server, ...(this.httpTransport?.sessionServers || [])];
+    for (const server of servers) {
+      // Servers without a connected client have nobody to notify
+      server
+        .sendLoggingMessage({
+          level: firing > 0 ? 'warning' : 'info',
+          logger: 'alert-webhook',
+          data: { firing, resolved: events.length - firing, alerts: events },
+        })
+        .catch(() => undefined);
+    }
+  }
+
+  // Applies a reloaded configuration without dropping sessions. Transport settings only take
+  // effect on restart; clients are notified if the set of enabled tools changed.
+  async reloadConfig(config: ServerConfig) {
+    const enabledBefore = this.enabledToolNames();
+    this.config = {
+      ...config,
+      transport: this.config.transport,
+      address: this.config.address,
+      port: this.config.port,
+      path: this.config.path,
+      basePath: this.config.basePath,
+      cors: this.config.cors,
+      sessionStore: this.config.sessionStore,
+      sessionTtl: this.config.sessionTtl,
+      stateless: this.config.stateless,
+      keepAlive: this.config.keepAlive,
+      alertWebhook: this.config.alertWebhook,
+    };
+    this.logger.level = config.grafanaConfig.debug ? 'debug' : 'info';
+    this.logger.info('Configuration reloaded');
+
+    if (this.enabledToolNames() !== enabledBefore) {
+      const servers = [this.server, ...(this.httpTransport?.sessionServers || [])];
+      // Servers without a connected client have nobody to notify
+      await Promise.allSettled(servers.map(server => server.sendToolListChanged()));
+    }
+  }
+
+  // Tools and categories the client limited itself to with the X-Mcp-Enabled-Tools header
+  private toolFilter(
+    requestHeaders: Record<string, string | string[] | undefined> | undefined,
+    sessionHeaders: IncomingHttpHeaders | undefined
+  ): Set<string> | undefined {
+    const tools = [...this.tools.values()];
+    return clientToolFilter(requestHeaders, sessionHeaders, {
+      tools: tools.map(tool => tool.name),
+      categories: [
+        ...TOOL_CATEGORIES.map(category => category.name),
+        ...tools.map(tool => tool.category).filter((category): category is string => !!category),
+      ],
+    });
+  }
+
+  private enabledToolNames(): string {
+    return this.describeTools()
+      .filter(tool => tool.enabled)
+      .map(tool => tool.name)
+      .join(',');
+  }
+
+  // Registers a function to run when the server stops, e.g. to flush telemetry exporters
+  onShutdown(hook: () => Promise<void> | void): this {
+    this.shutdownHooks.push(hook);
+    return this;
+  }
+
+  // Stops gracefully: refuses new sessions and tool calls, waits up to the timeout for
+  // in-flight tool calls to finish, then stops the server
+  async shutdown(timeoutMs: number) {
+    this.draining = true;
+    this.httpTransport?.stopAccepting();
+
+    if (this.inFlight.size > 0) {
+      this.logger.info(
+        `Waiting up to ${timeoutMs}ms for ${this.inFlight.size} tool call(s) to finish`
+      );
+      let timer: NodeJS.Timeout | undefined;
+      const deadline = new Promise<'timeout'>(resolve => {
+        timer = setTimeout(() => resolve('timeout'), timeoutMs);
+      });
+      const outcome = await Promise.race([Promise.allSettled([...this.inFlight]), deadline]);
+      clearTimeout(timer);
+      if (outcome === 'timeout') {
+        this.logger.warn(
+          `Shutdown deadline reached with ${this.inFlight.size} tool call(s) in flight`
+        );
+      }
+    }
+
+    await this.stop();
   }
 
   async stop() {
+    this.draining = true;
+    await this.httpTransport?.close();
+    await this.alertWebhook?.close();
     await this.server.close();
+    for (const hook of this.shutdownHooks) {
+      try {
+        await hook();
+      } catch (error: any) {
+        this.logger.error({ error: error.message }, 'Shutdown hook failed');
+      }
+    }
     this.logger.info('MCP server stopped');
   }
 }
 
+// Helper function to check whether a tool modifies state
+export function isWriteTool(definition: ToolDefinition): boolean {
+  return definition.annotations?.readOnlyHint === false;
+}
+
+// Helper function to check whether a tool implements dry runs itself
+function hasDryRunParam(definition: ToolDefinition): boolean {
+  return definition.inputSchema instanceof z.ZodObject && 'dryRun' in definition.inputSchema.shape;
+}
+
 // Helper function to create a tool result
 export function createToolResult(content: string | object): CallToolResult {
   if (typeof content === 'string') {
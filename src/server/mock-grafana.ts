@@ -0,0 +1,451 @@
+import * as http from 'http';
+import { AddressInfo } from 'net';
+
+// A small fake Grafana with canned dashboards, metrics, and logs, so the tools can be
+// exercised in demos and CI without a live instance. Only the core HTTP API, the Prometheus
+// and Loki datasource proxies, and alerting provisioning are served.
+
+interface MockResponse {
+  status?: number;
+  body: any;
+}
+
+type RouteHandler = (
+  match: RegExpMatchArray,
+  query: URLSearchParams,
+  body: any
+) => MockResponse;
+
+interface Route {
+  method: string;
+  pattern: RegExp;
+  handler: RouteHandler;
+}
+
+const FOLDERS = [{ id: 1, uid: 'demo', title: 'Demo' }];
+
+const DATASOURCES = [
+  { id: 1, uid: 'prometheus', name: 'Prometheus', type: 'prometheus', url: 'http://prometheus:9090', access: 'proxy', isDefault: true, jsonData: {} },
+  { id: 2, uid: 'loki', name: 'Loki', type: 'loki', url: 'http://loki:3100', access: 'proxy', isDefault: false, jsonData: {} },
+];
+
+const dashboards: Map<string, { dashboard: any; folderUid?: string; version: number }> = new Map([
+  ['checkout-overview', {
+    folderUid: 'demo',
+    version: 3,
+    dashboard: {
+      id: 1,
+      uid: 'checkout-overview',
+      title: 'Checkout Overview',
+      tags: ['checkout', 'demo'],
+      time: { from: 'now-6h', to: 'now' },
+      templating: { list: [{ name: 'service', type: 'custom', query: 'checkout,cart', current: { value: 'checkout' } }] },
+      panels: [
+        {
+          id: 1,
+          type: 'timeseries',
+          title: 'Request rate by status',
+          datasource: { type: 'prometheus', uid: 'prometheus' },
+          gridPos: { h: 8, w: 12, x: 0, y: 0 },
+          targets: [{ refId: 'A', expr: 'sum by (status) (rate(http_requests_total{service="$service"}[5m]))' }],
+        },
+        {
+          id: 2,
+          type: 'timeseries',
+          title: 'CPU usage',
+          datasource: { type: 'prometheus', uid: 'prometheus' },
+          gridPos: { h: 8, w: 12, x: 12, y: 0 },
+          targets: [{ refId: 'A', expr: 'sum(rate(node_cpu_seconds_total{mode!="idle"}[5m]))' }],
+        },
+        {
+          id: 3,
+          type: 'logs',
+          title: 'Checkout errors',
+          datasource: { type: 'loki', uid: 'loki' },
+          gridPos: { h: 8, w: 24, x: 0, y: 8 },
+          targets: [{ refId: 'A', expr: '{service="checkout"} |= "error"' }],
+        },
+      ],
+    },
+  }],
+  ['node-exporter', {
+    version: 1,
+    dashboard: {
+      id: 2,
+      uid: 'node-exporter',
+      title: 'Node Exporter',
+      tags: ['infrastructure'],
+      panels: [
+        {
+          id: 1,
+          type: 'stat',
+          title: 'Targets up',
+          datasource: { type: 'prometheus', uid: 'prometheus' },
+          targets: [{ refId: 'A', expr: 'sum(up)' }],
+        },
+      ],
+    },
+  }],
+]);
+
+// Series of the fake Prometheus: labels plus a deterministic shape over time
+const SERIES: { labels: Record<string, string>; value: (t: number) => number }[] = [
+  { labels: { __name__: 'http_requests_total', service: 'checkout', status: '200' }, value: t => 120 + 40 * Math.sin(t / 3600) },
+  { labels: { __name__: 'http_requests_total', service: 'checkout', status: '500' }, value: t => 2 + 2 * Math.max(0, Math.sin(t / 900)) },
+  { labels: { __name__: 'http_requests_total', service: 'cart', status: '200' }, value: t => 60 + 15 * Math.cos(t / 1800) },
+  { labels: { __name__: 'node_cpu_seconds_total', instance: 'node-1:9100', mode: 'user' }, value: t => 0.4 + 0.2 * Math.sin(t / 1200) },
+  { labels: { __name__: 'node_cpu_seconds_total', instance: 'node-1:9100', mode: 'idle' }, value: t => 3.2 - 0.2 * Math.sin(t / 1200) },
+  { labels: { __name__: 'up', job: 'node', instance: 'node-1:9100' }, value: () => 1 },
+  { labels: { __name__: 'up', job: 'checkout', instance: 'checkout:8080' }, value: () => 1 },
+];
+
+const METADATA: Record<string, { type: string; help: string; unit: string }[]> = {
+  http_requests_total: [{ type: 'counter', help: 'Total HTTP requests by service and status.', unit: '' }],
+  node_cpu_seconds_total: [{ type: 'counter', help: 'Seconds the CPUs spent in each mode.', unit: 'seconds' }],
+  up: [{ type: 'gauge', help: 'Whether the target is up.', unit: '' }],
+};
+
+const LOG_STREAMS = [
+  { labels: { service: 'checkout', level: 'info' }, line: (i: number) => `level=info msg="order placed" order_id=${1000 + i} duration_ms=${80 + (i % 7) * 11}` },
+  { labels: { service: 'checkout', level: 'error' }, line: (i: number) => `level=error msg="payment provider timeout" order_id=${1000 + i} retry=${i % 3}` },
+  { labels: { service: 'cart', level: 'info' }, line: (i: number) => `level=info msg="item added" cart_id=${500 + i}` },
+];
+
+const ALERT_RULES = [
+  {
+    uid: 'checkout-errors',
+    title: 'Checkout error rate high',
+    folderUID: 'demo',
+    ruleGroup: 'checkout',
+    condition: 'B',
+    labels: { severity: 'critical', team: 'payments' },
+    annotations: { summary: 'More than 1% of checkout requests fail' },
+    for: '5m',
+    data: [
+      { refId: 'A', datasourceUid: 'prometheus', model: { expr: 'sum(rate(http_requests_total{service="checkout",status="500"}[5m]))' } },
+      { refId: 'B', datasourceUid: '__expr__', model: { type: 'threshold', expression: 'A' } },
+    ],
+  },
+  {
+    uid: 'node-down',
+    title: 'Node down',
+    folderUID: 'demo',
+    ruleGroup: 'infrastructure',
+    condition: 'A',
+    labels: { severity: 'warning' },
+    annotations: { summary: 'A node exporter target is down' },
+    for: '1m',
+    data: [{ refId: 'A', datasourceUid: 'prometheus', model: { expr: 'up{job="node"} == 0' } }],
+  },
+];
+
+// Parses a time parameter of the Prometheus and Loki APIs (unix seconds, nanoseconds, or RFC3339)
+function parseApiTime(value: string | null, fallback: number): number {
+  if (!value) return fallback;
+  if (/^\d+(\.\d+)?$/.test(value)) {
+    const number = parseFloat(value);
+    return number > 1e12 ? number / 1e9 : number;
+  }
+  const ms = Date.parse(value);
+  return isNaN(ms) ? fallback : ms / 1000;
+}
+
+// Picks the series whose metric name appears in the query and whose labels match its
+// equality matchers; enough for the canned dashboards, not a PromQL engine
+function matchSeries(query: string) {
+  const matchers = [...query.matchAll(/(\w+)\s*=\s*"([^"]*)"/g)];
+  return SERIES.filter(series =>
+    query.includes(series.labels.__name__) &&
+    matchers.every(([, name, value]) => series.labels[name] === undefined || series.labels[name] === value)
+  );
+}
+
+function matchStreams(query: string) {
+  const matchers = [...query.matchAll(/(\w+)\s*=\s*"([^"]*)"/g)];
+  const contains = query.match(/\|=\s*"([^"]*)"/)?.[1];
+  return LOG_STREAMS.filter(stream =>
+    matchers.every(([, name, value]) => (stream.labels as any)[name] === value) &&
+    (!contains || stream.line(0).includes(contains))
+  );
+}
+
+function promSuccess(data: any): MockResponse {
+  return { body: { status: 'success', data } };
+}
+
+function notFound(message: string): MockResponse {
+  return { status: 404, body: { message } };
+}
+
+const DS_PROXY = '/api/datasources/proxy/uid/[^/]+';
+
+const ROUTES: Route[] = [
+  { method: 'GET', pattern: /^\/api\/health$/, handler: () => ({ body: { database: 'ok', version: '11.0.0-mock', commit: 'mock' } }) },
+  {
+    method: 'GET',
+    pattern: /^\/api\/frontend\/settings$/,
+    handler: () => ({ body: { buildInfo: { version: '11.0.0-mock', commit: 'mock', edition: 'Open Source', env: 'mock' }, featureToggles: {} } }),
+  },
+  { method: 'GET', pattern: /^\/api\/plugins$/, handler: () => ({ body: [] }) },
+  { method: 'GET', pattern: /^\/api\/user$/, handler: () => ({ body: { id: 1, login: 'mock', email: 'mock@example.com', name: 'Mock User', isGrafanaAdmin: true, orgId: 1 } }) },
+  { method: 'GET', pattern: /^\/api\/org$/, handler: () => ({ body: { id: 1, name: 'Mock Org' } }) },
+  { method: 'GET', pattern: /^\/api\/folders$/, handler: () => ({ body: FOLDERS }) },
+  {
+    method: 'GET',
+    pattern: /^\/api\/search$/,
+    handler: (_match, query) => {
+      const text = (query.get('query') || '').toLowerCase();
+      const results: any[] = [];
+      if (query.get('type') !== 'dash-db') {
+        results.push(...FOLDERS
+          .filter(folder => folder.title.toLowerCase().includes(text))
+          .map(folder => ({ ...folder, type: 'dash-folder', url: `/dashboards/f/${folder.uid}` })));
+      }
+      if (query.get('type') !== 'dash-folder') {
+        for (const { dashboard, folderUid } of dashboards.values()) {
+          if (!dashboard.title.toLowerCase().includes(text)) continue;
+          const tags = query.getAll('tag');
+          if (!tags.every(tag => (dashboard.tags || []).includes(tag))) continue;
+          const folder = FOLDERS.find(f => f.uid === folderUid);
+          results.push({
+            id: dashboard.id,
+            uid: dashboard.uid,
+            title: dashboard.title,
+            type: 'dash-db',
+            tags: dashboard.tags || [],
+            url: `/d/${dashboard.uid}`,
+            folderUid,
+            folderTitle: folder?.title,
+          });
+        }
+      }
+      return { body: results };
+    },
+  },
+  {
+    method: 'GET',
+    pattern: /^\/api\/dashboards\/uid\/([^/]+)$/,
+    handler: match => {
+      const stored = dashboards.get(match[1]);
+      if (!stored) return notFound('Dashboard not found');
+      const folder = FOLDERS.find(f => f.uid === stored.folderUid);
+      return {
+        body: {
+          dashboard: { ...stored.dashboard, version: stored.version },
+          meta: { folderUid: stored.folderUid || '', folderTitle: folder?.title || 'General', version: stored.version, url: `/d/${match[1]}` },
+        },
+      };
+    },
+  },
+  {
+    method: 'POST',
+    pattern: /^\/api\/dashboards\/db$/,
+    handler: (_match, _query, body) => {
+      const dashboard = body?.dashboard || {};
+      const uid = dashboard.uid || `mock-${dashboards.size + 1}`;
+      const existing = dashboards.get(uid);
+      const version = (existing?.version || 0) + 1;
+      dashboards.set(uid, {
+        dashboard: { ...dashboard, uid, id: existing?.dashboard.id || dashboards.size + 1 },
+        folderUid: body?.folderUid ?? existing?.folderUid,
+        version,
+      });
+      return { body: { status: 'success', uid, url: `/d/${uid}`, version } };
+    },
+  },
+  { method: 'GET', pattern: /^\/api\/datasources$/, handler: () => ({ body: DATASOURCES }) },
+  {
+    method: 'GET',
+    pattern: /^\/api\/datasources\/uid\/([^/]+)$/,
+    handler: match => {
+      const datasource = DATASOURCES.find(ds => ds.uid === match[1]);
+      return datasource ? { body: datasource } : notFound('Data source not found');
+    },
+  },
+  {
+    method: 'GET',
+    pattern: /^\/api\/datasources\/name\/([^/]+)$/,
+    handler: match => {
+      const datasource = DATASOURCES.find(ds => ds.name === decodeURIComponent(match[1]));
+      return datasource ? { body: datasource } : notFound('Data source not found');
+    },
+  },
+  { method: 'GET', pattern: /^\/api\/v1\/provisioning\/alert-rules$/, handler: () => ({ body: ALERT_RULES }) },
+  {
+    method: 'GET',
+    pattern: /^\/api\/v1\/provisioning\/alert-rules\/([^/]+)$/,
+    handler: match => {
+      const rule = ALERT_RULES.find(r => r.uid === match[1]);
+      return rule ? { body: rule } : notFound('Alert rule not found');
+    },
+  },
+  {
+    method: 'GET',
+    pattern: /^\/api\/v1\/provisioning\/contact-points$/,
+    handler: () => ({ body: [{ uid: 'email', name: 'Payments on-call', type: 'email', settings: { addresses: 'payments@example.com' } }] }),
+  },
+  {
+    method: 'GET',
+    pattern: new RegExp(`^${DS_PROXY}/api/v1/query$`),
+    handler: (_match, query) => {
+      const time = parseApiTime(query.get('time'), Date.now() / 1000);
+      return promSuccess({
+        resultType: 'vector',
+        result: matchSeries(query.get('query') || '').map(series => ({
+          metric: series.labels,
+          value: [time, series.value(time).toFixed(3)],
+        })),
+      });
+    },
+  },
+  {
+    method: 'GET',
+    pattern: new RegExp(`^${DS_PROXY}/api/v1/query_range$`),
+    handler: (_match, query) => {
+      const end = parseApiTime(query.get('end'), Date.now() / 1000);
+      const start = parseApiTime(query.get('start'), end - 3600);
+      const step = Math.max(parseFloat(query.get('step') || '60') || 60, 1);
+      return promSuccess({
+        resultType: 'matrix',
+        result: matchSeries(query.get('query') || '').map(series => {
+          const values: [number, string][] = [];
+          for (let t = Math.ceil(start / step) * step; t <= end && values.length < 11000; t += step) {
+            values.push([t, series.value(t).toFixed(3)]);
+          }
+          return { metric: series.labels, values };
+        }),
+      });
+    },
+  },
+  {
+    method: 'GET',
+    pattern: new RegExp(`^${DS_PROXY}/api/v1/labels$`),
+    handler: () => promSuccess([...new Set(SERIES.flatMap(series => Object.keys(series.labels)))].sort()),
+  },
+  {
+    method: 'GET',
+    pattern: new RegExp(`^${DS_PROXY}/api/v1/label/([^/]+)/values$`),
+    handler: match => promSuccess(
+      [...new Set(SERIES.map(series => series.labels[match[1]]).filter(value => value !== undefined))].sort()
+    ),
+  },
+  {
+    method: 'GET',
+    pattern: new RegExp(`^${DS_PROXY}/api/v1/metadata$`),
+    handler: (_match, query) => {
+      const metric = query.get('metric');
+      return promSuccess(metric ? { [metric]: METADATA[metric] || [] } : METADATA);
+    },
+  },
+  {
+    method: 'GET',
+    pattern: new RegExp(`^${DS_PROXY}/api/v1/series$`),
+    handler: (_match, query) => promSuccess(
+      query.getAll('match[]').flatMap(selector => matchSeries(selector).map(series => series.labels))
+    ),
+  },
+  {
+    method: 'GET',
+    pattern: new RegExp(`^${DS_PROXY}/loki/api/v1/query_range$`),
+    handler: (_match, query) => {
+      const logql = query.get('query') || '';
+      const end = parseApiTime(query.get('end'), Date.now() / 1000);
+      const start = parseApiTime(query.get('start'), end - 3600);
+      const streams = matchStreams(logql);
+
+      // Metric queries return one count series per stream
+      if (!logql.trim().startsWith('{')) {
+        const step = Math.max(parseFloat(query.get('step') || '60') || 60, 1);
+        return promSuccess({
+          resultType: 'matrix',
+          result: streams.map((stream, i) => {
+            const values: [number, string][] = [];
+            for (let t = Math.ceil(start / step) * step; t <= end && values.length < 11000; t += step) {
+              values.push([t, String(Math.round((i + 1) * 5 + 3 * Math.sin(t / 600)))]);
+            }
+            return { metric: stream.labels, values };
+          }),
+        });
+      }
+
+      const limit = parseInt(query.get('limit') || '100', 10);
+      return promSuccess({
+        resultType: 'streams',
+        result: streams.map(stream => {
+          const values: [string, string][] = [];
+          for (let i = 0; i < limit; i++) {
+            const t = end - i * 30;
+            if (t < start) break;
+            values.push([`${Math.floor(t * 1e3)}000000`, stream.line(i)]);
+          }
+          return { stream: stream.labels, values };
+        }),
+      });
+    },
+  },
+  {
+    method: 'GET',
+    pattern: new RegExp(`^${DS_PROXY}/loki/api/v1/labels$`),
+    handler: () => promSuccess(['level', 'service']),
+  },
+  {
+    method: 'GET',
+    pattern: new RegExp(`^${DS_PROXY}/loki/api/v1/label/([^/]+)/values$`),
+    handler: match => promSuccess(
+      [...new Set(LOG_STREAMS.map(stream => (stream.labels as any)[match[1]]).filter(Boolean))].sort()
+    ),
+  },
+  {
+    method: 'GET',
+    pattern: new RegExp(`^${DS_PROXY}/loki/api/v1/index/stats$`),
+    handler: (_match, query) => {
+      const streams = matchStreams(query.get('query') || '').length;
+      return promSuccess({ streams, chunks: streams * 12, entries: streams * 2880, bytes: streams * 350000 });
+    },
+  },
+];
+
+function readBody(request: http.IncomingMessage): Promise<any> {
+  return new Promise(resolve => {
+    const chunks: Buffer[] = [];
+    request.on('data', chunk => chunks.push(chunk));
+    request.on('end', () => {
+      const text = Buffer.concat(chunks).toString();
+      try {
+        resolve(text ? JSON.parse(text) : undefined);
+      } catch {
+        resolve(text);
+      }
+    });
+  });
+}
+
+// Starts the fake Grafana on a local port (random by default)
+export async function startMockGrafana(
+  port = 0
+): Promise<{ url: string; close: () => Promise<void> }> {
+  const server = http.createServer(async (request, response) => {
+    const url = new URL(request.url || '/', 'http://localhost');
+    const body = await readBody(request);
+
+    let result: MockResponse = notFound(`${url.pathname} is not available in mock mode`);
+    for (const route of ROUTES) {
+      const match = url.pathname.match(route.pattern);
+      if (match && route.method === request.method) {
+        result = route.handler(match, url.searchParams, body);
+        break;
+      }
+    }
+
+    response.writeHead(result.status || 200, { 'Content-Type': 'application/json' });
+    response.end(JSON.stringify(result.body));
+  });
+
+  await new Promise<void>(resolve => server.listen(port, '127.0.0.1', resolve));
+  const { port: boundPort } = server.address() as AddressInfo;
+
+  return {
+    url: `http://127.0.0.1:${boundPort}`,
+    close: () => new Promise(resolve => server.close(() => resolve())),
+  };
+}
@@ -0,0 +1,68 @@
+import { RateLimitConfig } from '../types/config';
+
+interface Bucket {
+  tokens: number;
+  updatedAt: number;
+}
+
+// Buckets beyond this many are pruned, dropping the ones that are full again
+const MAX_BUCKETS = 10000;
+
+// Token-bucket rate limiter for tool calls, per client identity and tool category. Each bucket
+// holds up to a minute's worth of calls and refills continuously.
+export class RateLimiter {
+  private buckets: Map<string, Bucket> = new Map();
+
+  // Takes a token for a call, or returns the seconds until one is available
+  take(config: RateLimitConfig, client: string, category?: string): { retryAfter?: number } {
+    const perMinute = limitFor(config, category);
+    if (!perMinute) return {};
+
+    const key = `${client}\0${category || ''}`;
+    const now = Date.now();
+    const refillPerMs = perMinute / 60000;
+    const bucket = this.buckets.get(key) || { tokens: perMinute, updatedAt: now };
+    bucket.tokens = Math.min(perMinute, bucket.tokens + (now - bucket.updatedAt) * refillPerMs);
+    bucket.updatedAt = now;
+
+    if (bucket.tokens < 1) {
+      this.buckets.set(key, bucket);
+      return { retryAfter: Math.ceil((1 - bucket.tokens) / refillPerMs / 1000) };
+    }
+    bucket.tokens -= 1;
+    this.buckets.set(key, bucket);
+    this.prune(config, now);
+    return {};
+  }
+
+  private prune(config: RateLimitConfig, now: number) {
+    if (this.buckets.size <= MAX_BUCKETS) return;
+    for (const [key, bucket] of this.buckets) {
+      const perMinute = limitFor(config, key.split('\0')[1] || undefined) || 0;
+      if (bucket.tokens + ((now - bucket.updatedAt) * perMinute) / 60000 >= perMinute) {
+        this.buckets.delete(key);
+      }
+    }
+  }
+}
+
+function limitFor(config: RateLimitConfig, category?: string): number | undefined {
+  return (category && config.categories[category]) || config.perMinute;
+}
+
+// Header the HTTP transport sets to the address a request came from; values sent by clients are
+// replaced, so it can be trusted
+export const REMOTE_ADDRESS_HEADER = 'x-mcp-remote-address';
+
+// Identifies the client of a tool call by its session, else by the address it connected from.
+// Headers such as Authorization are not used: the server does not verify them, so a client
+// could escape its limits by sending a different value with each call.
+export function clientIdentity(
+  headers: Record<string, string | string[] | undefined> | undefined,
+  sessionId?: string
+): string {
+  if (sessionId) return `session:${sessionId}`;
+  const address = headers?.[REMOTE_ADDRESS_HEADER];
+  const remote = Array.isArray(address) ? address[0] : address;
+  return remote ? `address:${remote}` : 'local';
+}
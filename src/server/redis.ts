@@ -0,0 +1,157 @@
+import * as net from 'net';
+import * as tls from 'tls';
+
+// Minimal Redis client speaking RESP over one connection, enough for the session store
+// without a dependency on a full client library. Supports redis:// and rediss:// URLs with
+// an optional password (or user and password) and database number.
+
+type Reply = string | number | null | Reply[];
+
+interface Pending {
+  resolve: (reply: Reply) => void;
+  reject: (error: Error) => void;
+}
+
+export class RedisClient {
+  private url: URL;
+  private socket?: net.Socket;
+  private connecting?: Promise<net.Socket>;
+  private pending: Pending[] = [];
+  private buffer = Buffer.alloc(0);
+
+  constructor(url: string) {
+    this.url = new URL(url);
+    if (this.url.protocol !== 'redis:' && this.url.protocol !== 'rediss:') {
+      throw new Error(`Unsupported Redis URL "${url}": use redis:// or rediss://`);
+    }
+  }
+
+  async command(...args: string[]): Promise<Reply> {
+    const socket = await this.connect();
+    return this.send(socket, args);
+  }
+
+  close() {
+    this.socket?.end();
+    this.socket = undefined;
+  }
+
+  private send(socket: net.Socket, args: string[]): Promise<Reply> {
+    return new Promise((resolve, reject) => {
+      this.pending.push({ resolve, reject });
+      const parts = args.map(arg => `$${Buffer.byteLength(arg)}\r\n${arg}\r\n`);
+      socket.write(`*${args.length}\r\n${parts.join('')}`);
+    });
+  }
+
+  private connect(): Promise<net.Socket> {
+    if (this.socket) return Promise.resolve(this.socket);
+    if (this.connecting) return this.connecting;
+
+    this.connecting = new Promise<net.Socket>((resolve, reject) => {
+      const port = parseInt(this.url.port || '6379', 10);
+      const host = this.url.hostname || '127.0.0.1';
+      const socket: net.Socket = this.url.protocol === 'rediss:'
+        ? tls.connect({ host, port, servername: host })
+        : net.connect({ host, port });
+
+      socket.once(this.url.protocol === 'rediss:' ? 'secureConnect' : 'connect', async () => {
+        try {
+          const password = decodeURIComponent(this.url.password);
+          const username = decodeURIComponent(this.url.username);
+          if (password) {
+            await this.send(socket, username ? ['AUTH', username, password] : ['AUTH', password]);
+          }
+          const database = this.url.pathname.replace('/', '');
+          if (database) {
+            await this.send(socket, ['SELECT', database]);
+          }
+          this.socket = socket;
+          resolve(socket);
+        } catch (error) {
+          socket.destroy();
+          reject(error);
+        }
+      });
+      socket.on('data', chunk => this.receive(chunk));
+      socket.on('error', error => this.fail(error));
+      socket.on('close', () => this.fail(new Error('Redis connection closed')));
+      socket.once('error', reject);
+    }).finally(() => {
+      this.connecting = undefined;
+    });
+    return this.connecting;
+  }
+
+  private fail(error: Error) {
+    this.socket = undefined;
+    this.buffer = Buffer.alloc(0);
+    for (const pending of this.pending.splice(0)) {
+      pending.reject(error);
+    }
+  }
+
+  private receive(chunk: Buffer) {
+    this.buffer = Buffer.concat([this.buffer, chunk]);
+    for (;;) {
+      let parsed: ReturnType<typeof parseReply>;
+      try {
+        parsed = parseReply(this.buffer, 0);
+      } catch (error) {
+        this.socket?.destroy();
+        this.fail(error as Error);
+        return;
+      }
+      if (!parsed) return;
+      this.buffer = this.buffer.subarray(parsed.end);
+      const pending = this.pending.shift();
+      if (parsed.reply instanceof Error) {
+        pending?.reject(parsed.reply);
+      } else {
+        pending?.resolve(parsed.reply);
+      }
+    }
+  }
+}
+
+// Parses one RESP reply starting at offset, or returns undefined if it is incomplete
+function parseReply(
+  buffer: Buffer,
+  offset: number
+): { reply: Reply | Error; end: number } | undefined {
+  const lineEnd = buffer.indexOf('\r\n', offset);
+  if (lineEnd === -1) return undefined;
+  const type = String.fromCharCode(buffer[offset]);
+  const line = buffer.toString('utf8', offset + 1, lineEnd);
+  const next = lineEnd + 2;
+
+  switch (type) {
+    case '+':
+      return { reply: line, end: next };
+    case '-':
+      return { reply: new Error(`Redis error: ${line}`), end: next };
+    case ':':
+      return { reply: parseInt(line, 10), end: next };
+    case '$': {
+      const length = parseInt(line, 10);
+      if (length === -1) return { reply: null, end: next };
+      if (buffer.length < next + length + 2) return undefined;
+      return { reply: buffer.toString('utf8', next, next + length), end: next + length + 2 };
+    }
+    case '*': {
+      const count = parseInt(line, 10);
+      if (count === -1) return { reply: null, end: next };
+      const items: Reply[] = [];
+      let end = next;
+      for (let i = 0; i < count; i++) {
+        const item = parseReply(buffer, end);
+        if (!item) return undefined;
+        items.push(item.reply instanceof Error ? null : item.reply);
+        end = item.end;
+      }
+      return { reply: items, end };
+    }
+    default:
+      throw new Error(`Unexpected Redis reply type "${type}"`);
+  }
+}
@@ -0,0 +1,74 @@
+import { randomUUID } from 'crypto';
+
+// URI scheme of the ephemeral resources holding oversized tool results
+export const RESULT_URI_PREFIX = 'grafana-result://';
+
+export interface StoredResult {
+  uri: string;
+  // The session whose tool call produced the result; only that session may list or read it
+  sessionId: string;
+  name: string;
+  mimeType: string;
+  text: string;
+  expiresAt: number;
+}
+
+// In-memory store of oversized tool results, exposed to clients as MCP resources of the session
+// that produced them. Entries expire after a TTL and the oldest are evicted once the store is
+// full.
+export class ResultStore {
+  private results: Map<string, StoredResult> = new Map();
+  private maxEntries: number;
+  private ttlMs: number;
+
+  constructor(maxEntries = 50, ttlMs = 30 * 60 * 1000) {
+    this.maxEntries = maxEntries;
+    this.ttlMs = ttlMs;
+  }
+
+  put(sessionId: string, toolName: string, text: string): StoredResult {
+    this.prune();
+    while (this.results.size >= this.maxEntries) {
+      const oldest = this.results.keys().next().value;
+      if (oldest === undefined) break;
+      this.results.delete(oldest);
+    }
+
+    const trimmed = text.trimStart();
+    const stored: StoredResult = {
+      uri: `${RESULT_URI_PREFIX}${randomUUID()}`,
+      sessionId,
+      name: `${toolName} result`,
+      mimeType: trimmed.startsWith('{') || trimmed.startsWith('[') ? 'application/json' : 'text/plain',
+      text,
+      expiresAt: Date.now() + this.ttlMs,
+    };
+    this.results.set(stored.uri, stored);
+    return stored;
+  }
+
+  // Results of other sessions are reported as missing, so their URIs reveal nothing
+  get(sessionId: string, uri: string): StoredResult | undefined {
+    this.prune();
+    const result = this.results.get(uri);
+    return result?.sessionId === sessionId ? result : undefined;
+  }
+
+  list(sessionId: string): StoredResult[] {
+    this.prune();
+    return [...this.results.values()].filter(result => result.sessionId === sessionId);
+  }
+
+  get ttlMinutes(): number {
+    return Math.round(this.ttlMs / 60000);
+  }
+
+  private prune() {
+    const now = Date.now();
+    for (const [uri, result] of this.results) {
+      if (result.expiresAt <= now) {
+        this.results.delete(uri);
+      }
+    }
+  }
+}
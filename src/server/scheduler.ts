@@ -0,0 +1,135 @@
+import { randomUUID } from 'crypto';
+
+// Periodic checks scheduled by clients, such as a query watched during a deploy. Each check
+// belongs to the session that scheduled it, runs until it expires, is cancelled, or the session
+// closes, and notifies the session when its outcome changes between breached and not breached.
+
+// Checks a session may have scheduled at once
+export const MAX_CHECKS_PER_SESSION = 10;
+
+export interface CheckOutcome {
+  breached: boolean;
+  // What the check found, such as the series beyond the threshold
+  details: unknown;
+}
+
+export interface CheckDefinition {
+  description: string;
+  intervalMs: number;
+  durationMs: number;
+  run: () => Promise<CheckOutcome>;
+  // Called when the outcome changed from the previous run, and when a run fails
+  onChange: (check: ScheduledCheck, outcome: CheckOutcome | undefined, error?: Error) => void;
+}
+
+export interface ScheduledCheck {
+  id: string;
+  sessionId: string;
+  description: string;
+  intervalMs: number;
+  createdAt: number;
+  expiresAt: number;
+  runs: number;
+  lastRunAt?: number;
+  breached?: boolean;
+  lastOutcome?: CheckOutcome;
+  lastError?: string;
+}
+
+interface Entry {
+  check: ScheduledCheck;
+  definition: CheckDefinition;
+  timer?: NodeJS.Timeout;
+}
+
+export class CheckScheduler {
+  private entries: Map<string, Entry> = new Map();
+
+  // Schedules a check and runs it once right away, returning it after the first run. A check
+  // whose first run fails is not scheduled.
+  async schedule(sessionId: string, definition: CheckDefinition): Promise<ScheduledCheck> {
+    if (this.list(sessionId).length >= MAX_CHECKS_PER_SESSION) {
+      throw new Error(
+        `A session can have at most ${MAX_CHECKS_PER_SESSION} scheduled checks; cancel one first`
+      );
+    }
+    const now = Date.now();
+    const entry: Entry = {
+      check: {
+        id: randomUUID(),
+        sessionId,
+        description: definition.description,
+        intervalMs: definition.intervalMs,
+        createdAt: now,
+        expiresAt: now + definition.durationMs,
+        runs: 0,
+      },
+      definition,
+    };
+    this.entries.set(entry.check.id, entry);
+    await this.run(entry, false);
+    if (entry.check.lastError !== undefined) {
+      this.cancel(sessionId, entry.check.id);
+      throw new Error(entry.check.lastError);
+    }
+    return entry.check;
+  }
+
+  list(sessionId: string): ScheduledCheck[] {
+    return [...this.entries.values()]
+      .map(entry => entry.check)
+      .filter(check => check.sessionId === sessionId);
+  }
+
+  cancel(sessionId: string, id: string): boolean {
+    const entry = this.entries.get(id);
+    if (!entry || entry.check.sessionId !== sessionId) return false;
+    clearTimeout(entry.timer);
+    this.entries.delete(id);
+    return true;
+  }
+
+  // Cancels the checks of a closed session
+  cancelSession(sessionId: string) {
+    for (const check of this.list(sessionId)) {
+      this.cancel(sessionId, check.id);
+    }
+  }
+
+  // Runs a check and schedules the next run; the first run only establishes the outcome
+  private async run(entry: Entry, notify: boolean) {
+    const { check, definition } = entry;
+    check.runs++;
+    check.lastRunAt = Date.now();
+    try {
+      const outcome = await definition.run();
+      const changed = check.breached !== undefined && outcome.breached !== check.breached;
+      check.breached = outcome.breached;
+      check.lastOutcome = outcome;
+      check.lastError = undefined;
+      if (notify && changed) {
+        definition.onChange(check, outcome);
+      }
+    } catch (error: any) {
+      // Only the first of consecutive failures is reported
+      const reported = check.lastError !== undefined;
+      check.lastError = error.message;
+      if (notify && !reported) {
+        definition.onChange(check, undefined, error);
+      }
+    }
+
+    if (!this.entries.has(check.id)) return;
+    if (Date.now() + check.intervalMs > check.expiresAt) {
+      this.entries.delete(check.id);
+      return;
+    }
+    entry.timer = setTimeout(() => {
+      this.run(entry, true).catch(() => undefined);
+    }, check.intervalMs);
+    entry.timer.unref();
+  }
+}
+
+// Checks scheduled on this server
+export const checkScheduler = new CheckScheduler();
@@ -0,0 +1,168 @@
+import { randomUUID } from 'crypto';
+import { EventStore } from '@modelcontextprotocol/sdk/server/streamableHttp.js';
+import { JSONRPCMessage } from '@modelcontextprotocol/sdk/types.js';
+import { RedisClient } from './redis';
+
+// Persistence of streamable HTTP sessions, so clients can resume them after reconnecting,
+// also to another instance when the store is shared
+
+export interface SessionRecord {
+  id: string;
+  // Per-session settings: the headers the session was started with (never credentials)
+  headers: Record<string, string>;
+  createdAt: number;
+}
+
+export interface SessionStore {
+  get(id: string): Promise<SessionRecord | undefined>;
+  // Stores a session, or extends its lifetime
+  set(record: SessionRecord): Promise<void>;
+  delete(id: string): Promise<void>;
+}
+
+// Headers kept as per-session settings; credentials are sent with every request instead
+export const SESSION_HEADERS = ['x-mcp-enabled-tools', 'x-grafana-timezone'];
+
+export function sessionHeaders(
+  headers: Record<string, string | string[] | undefined>
+): Record<string, string> {
+  const settings: Record<string, string> = {};
+  for (const name of SESSION_HEADERS) {
+    const value = headers[name];
+    if (value !== undefined) {
+      settings[name] = Array.isArray(value) ? value.join(',') : value;
+    }
+  }
+  return settings;
+}
+
+// Keeps sessions in memory until they have been idle for the TTL
+export class MemorySessionStore implements SessionStore {
+  private records: Map<string, { record: SessionRecord; expiresAt: number }> = new Map();
+  private ttlMs: number;
+
+  constructor(ttlMs: number) {
+    this.ttlMs = ttlMs;
+  }
+
+  async get(id: string): Promise<SessionRecord | undefined> {
+    const entry = this.records.get(id);
+    if (!entry || entry.expiresAt <= Date.now()) {
+      this.records.delete(id);
+      return undefined;
+    }
+    return entry.record;
+  }
+
+  async set(record: SessionRecord): Promise<void> {
+    const now = Date.now();
+    for (const [id, entry] of this.records) {
+      if (entry.expiresAt <= now) this.records.delete(id);
+    }
+    this.records.set(record.id, { record, expiresAt: now + this.ttlMs });
+  }
+
+  async delete(id: string): Promise<void> {
+    this.records.delete(id);
+  }
+}
+
+// Keeps sessions in Redis, shared by all instances behind a load balancer
+export class RedisSessionStore implements SessionStore {
+  private client: RedisClient;
+  private ttlMs: number;
+  private prefix: string;
+
+  constructor(url: string, ttlMs: number, prefix = 'mcp-grafana:session:') {
+    this.client = new RedisClient(url);
+    this.ttlMs = ttlMs;
+    this.prefix = prefix;
+  }
+
+  async get(id: string): Promise<SessionRecord | undefined> {
+    const value = await this.client.command('GET', this.prefix + id);
+    return typeof value === 'string' ? JSON.parse(value) : undefined;
+  }
+
+  async set(record: SessionRecord): Promise<void> {
+    await this.client.command(
+      'SET',
+      this.prefix + record.id,
+      JSON.stringify(record),
+      'PX',
+      String(this.ttlMs)
+    );
+  }
+
+  async delete(id: string): Promise<void> {
+    await this.client.command('DEL', this.prefix + id);
+  }
+}
+
+// Creates the store for a --session-store value: "memory" or a redis:// URL
+export function createSessionStore(spec: string, ttlMs: number): SessionStore {
+  if (spec === 'memory') {
+    return new MemorySessionStore(ttlMs);
+  }
+  if (/^rediss?:\/\//.test(spec)) {
+    return new RedisSessionStore(spec, ttlMs);
+  }
+  throw new Error(`Invalid session store "${spec}": use "memory" or a redis:// URL`);
+}
+
+// Most recent messages kept per stream for clients resuming with Last-Event-ID
+const MAX_EVENTS_PER_STREAM = 100;
+
+// Streams beyond this many are checked for expiry
+const MAX_STREAMS = 1000;
+
+interface StoredEvent {
+  id: string;
+  message: JSONRPCMessage;
+  at: number;
+}
+
+// Keeps recent server-to-client messages of this instance, so a client that lost its
+// connection can resume the stream without missing responses or notifications
+export class MemoryEventStore implements EventStore {
+  private streams: Map<string, StoredEvent[]> = new Map();
+  private ttlMs: number;
+
+  constructor(ttlMs: number) {
+    this.ttlMs = ttlMs;
+  }
+
+  async storeEvent(streamId: string, message: JSONRPCMessage): Promise<string> {
+    const id = `${streamId}_${randomUUID()}`;
+    const now = Date.now();
+    const events = (this.streams.get(streamId) || []).filter(
+      event => event.at > now - this.ttlMs
+    );
+    events.push({ id, message, at: now });
+    this.streams.set(streamId, events.slice(-MAX_EVENTS_PER_STREAM));
+
+    // Drop streams that have been quiet for longer than the TTL
+    if (this.streams.size > MAX_STREAMS) {
+      for (const [key, stream] of this.streams) {
+        if (stream[stream.length - 1].at <= now - this.ttlMs) this.streams.delete(key);
+      }
+    }
+    return id;
+  }
+
+  async replayEventsAfter(
+    lastEventId: string,
+    { send }: { send: (eventId: string, message: JSONRPCMessage) => Promise<void> }
+  ): Promise<string> {
+    const streamId = lastEventId.slice(0, lastEventId.lastIndexOf('_'));
+    const events = this.streams.get(streamId) || [];
+    const index = events.findIndex(event => event.id === lastEventId);
+    if (index === -1) {
+      throw new Error(`Event ${lastEventId} is unknown or expired`);
+    }
+    for (const event of events.slice(index + 1)) {
+      await send(event.id, event.message);
+    }
+    return streamId;
+  }
+}
@@ -0,0 +1,41 @@
+// Header with which HTTP clients limit the tools they see to a comma-separated list of tool
+// and category names. It only narrows the tools the server enables, never widens them.
+export const ENABLED_TOOLS_HEADER = 'x-mcp-enabled-tools';
+
+type HeaderMap = Record<string, string | string[] | undefined>;
+
+// Returns the tool filter of a client: its request header, else the header it started the
+// session with, or undefined if it sent none
+export function clientToolFilter(
+  requestHeaders: HeaderMap | undefined,
+  sessionHeaders: HeaderMap | undefined,
+  known: { tools: string[]; categories: string[] }
+): Set<string> | undefined {
+  const raw = requestHeaders?.[ENABLED_TOOLS_HEADER] ?? sessionHeaders?.[ENABLED_TOOLS_HEADER];
+  if (raw === undefined) {
+    return undefined;
+  }
+
+  const names = [raw]
+    .flat()
+    .flatMap(value => value.split(','))
+    .map(name => name.trim())
+    .filter(name => name);
+  const unknown = names.filter(
+    name => !known.tools.includes(name) && !known.categories.includes(name)
+  );
+  if (unknown.length > 0) {
+    throw new Error(
+      `Unknown tools or categories in the X-Mcp-Enabled-Tools header: ${unknown.join(', ')}`
+    );
+  }
+  return new Set(names);
+}
+
+// Returns whether a tool passes a client's filter
+export function isToolInFilter(
+  filter: Set<string> | undefined,
+  tool: { name: string; category?: string }
+): boolean {
+  return !filter || filter.has(tool.name) || (!!tool.category && filter.has(tool.category));
+}
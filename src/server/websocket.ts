@@ -0,0 +1,280 @@
+import * as http from 'http';
+import { Duplex } from 'stream';
+import { createHash, randomBytes, randomUUID } from 'crypto';
+import { Transport } from '@modelcontextprotocol/sdk/shared/transport.js';
+import { JSONRPCMessage, JSONRPCMessageSchema } from '@modelcontextprotocol/sdk/types.js';
+
+// Minimal WebSocket (RFC 6455) server transport for MCP, for clients whose proxies cut off
+// long-lived SSE responses. Messages are JSON-RPC messages in text frames, using the "mcp"
+// subprotocol of the SDK's WebSocket client. The framing is shared with the Grafana Live client.
+
+export const HANDSHAKE_GUID = '258EAFA5-E914-47DA-95CA-C5AB0DC85B11';
+
+const SUBPROTOCOL = 'mcp';
+
+// Messages larger than this close the connection
+export const MAX_MESSAGE_SIZE = 16 * 1024 * 1024;
+
+// Pings keep idle connections open through proxies that time them out
+const PING_INTERVAL_MS = 30 * 1000;
+
+// Handshake headers identifying a single request, which must not be reused for every message
+const PER_REQUEST_HEADERS = ['x-request-id', 'traceparent', 'tracestate'];
+
+export const OPCODE_CONTINUATION = 0x0;
+export const OPCODE_TEXT = 0x1;
+export const OPCODE_BINARY = 0x2;
+export const OPCODE_CLOSE = 0x8;
+export const OPCODE_PING = 0x9;
+export const OPCODE_PONG = 0xa;
+
+// Completes the handshake of an upgrade request, or answers it with an error and returns false
+export function acceptWebSocket(request: http.IncomingMessage, socket: Duplex): boolean {
+  const key = request.headers['sec-websocket-key'];
+  if (
+    request.method !== 'GET' ||
+    request.headers.upgrade?.toLowerCase() !== 'websocket' ||
+    request.headers['sec-websocket-version'] !== '13' ||
+    typeof key !== 'string'
+  ) {
+    rejectUpgrade(socket, 400, 'Bad Request');
+    return false;
+  }
+
+  const accept = createHash('sha1').update(key + HANDSHAKE_GUID).digest('base64');
+  const protocols = (request.headers['sec-websocket-protocol'] || '')
+    .split(',')
+    .map(protocol => protocol.trim());
+  socket.write(
+    [
+      'HTTP/1.1 101 Switching Protocols',
+      'Upgrade: websocket',
+      'Connection: Upgrade',
+      `Sec-WebSocket-Accept: ${accept}`,
+      ...(protocols.includes(SUBPROTOCOL) ? [`Sec-WebSocket-Protocol: ${SUBPROTOCOL}`] : []),
+      '',
+      '',
+    ].join('\r\n')
+  );
+  return true;
+}
+
+// Answers an upgrade request with an HTTP error and closes the socket
+export function rejectUpgrade(
+  socket: Duplex,
+  status: number,
+  reason: string,
+  headers: Record<string, string> = {}
+) {
+  const lines = Object.entries(headers).map(([name, value]) => `${name}: ${value}`);
+  socket.end(
+    [`HTTP/1.1 ${status} ${reason}`, 'Connection: close', 'Content-Length: 0', ...lines, '', '']
+      .join('\r\n')
+  );
+}
+
+// MCP transport over an accepted WebSocket connection. Every message is delivered with the
+// headers of the handshake, which carry the client's credentials and settings.
+export class WebSocketServerTransport implements Transport {
+  sessionId: string;
+  onclose?: () => void;
+  onerror?: (error: Error) => void;
+  onmessage?: Transport['onmessage'];
+
+  private socket: Duplex;
+  private headers: http.IncomingHttpHeaders;
+  private buffer = Buffer.alloc(0);
+  private fragments: Buffer[] = [];
+  private pinger?: NodeJS.Timeout;
+  private closed = false;
+
+  constructor(socket: Duplex, headers: http.IncomingHttpHeaders) {
+    this.sessionId = randomUUID();
+    this.socket = socket;
+    this.headers = Object.fromEntries(
+      Object.entries(headers).filter(([name]) => !PER_REQUEST_HEADERS.includes(name))
+    );
+  }
+
+  async start(): Promise<void> {
+    this.socket.on('data', chunk => this.receive(chunk));
+    this.socket.on('error', error => this.onerror?.(error));
+    this.socket.on('close', () => this.finish());
+    this.pinger = setInterval(
+      () => this.writeFrame(OPCODE_PING, Buffer.alloc(0)),
+      PING_INTERVAL_MS
+    );
+    this.pinger.unref();
+  }
+
+  async send(message: JSONRPCMessage): Promise<void> {
+    if (this.closed) {
+      throw new Error('WebSocket connection is closed');
+    }
+    this.writeFrame(OPCODE_TEXT, Buffer.from(JSON.stringify(message)));
+  }
+
+  async close(): Promise<void> {
+    if (!this.closed) {
+      // 1001: going away
+      const payload = Buffer.alloc(2);
+      payload.writeUInt16BE(1001);
+      this.writeFrame(OPCODE_CLOSE, payload);
+      this.socket.end();
+    }
+    this.finish();
+  }
+
+  private finish() {
+    if (this.closed) return;
+    this.closed = true;
+    clearInterval(this.pinger);
+    this.onclose?.();
+  }
+
+  private writeFrame(opcode: number, payload: Buffer) {
+    if (this.socket.destroyed) return;
+    this.socket.write(encodeFrame(opcode, payload));
+  }
+
+  private receive(chunk: Buffer) {
+    this.buffer = Buffer.concat([this.buffer, chunk]);
+    for (;;) {
+      const frame = parseFrame(this.buffer, true);
+      if (frame === undefined) return;
+      if (frame instanceof Error) {
+        this.fail(1002, frame);
+        return;
+      }
+      this.buffer = this.buffer.subarray(frame.end);
+      this.handleFrame(frame.fin, frame.opcode, frame.payload);
+      if (this.closed) return;
+    }
+  }
+
+  private handleFrame(fin: boolean, opcode: number, payload: Buffer) {
+    switch (opcode) {
+      case OPCODE_PING:
+        this.writeFrame(OPCODE_PONG, payload);
+        return;
+      case OPCODE_PONG:
+        return;
+      case OPCODE_CLOSE:
+        this.writeFrame(OPCODE_CLOSE, payload.subarray(0, 2));
+        this.socket.end();
+        this.finish();
+        return;
+      case OPCODE_TEXT:
+      case OPCODE_BINARY:
+      case OPCODE_CONTINUATION:
+        break;
+      default:
+        this.fail(1002, new Error(`Unknown WebSocket opcode ${opcode}`));
+        return;
+    }
+
+    this.fragments.push(payload);
+    const size = this.fragments.reduce((total, fragment) => total + fragment.length, 0);
+    if (size > MAX_MESSAGE_SIZE) {
+      this.fail(1009, new Error(`WebSocket message exceeds ${MAX_MESSAGE_SIZE} bytes`));
+      return;
+    }
+    if (!fin) return;
+
+    const text = Buffer.concat(this.fragments).toString('utf8');
+    this.fragments = [];
+    let message: JSONRPCMessage;
+    try {
+      message = JSONRPCMessageSchema.parse(JSON.parse(text));
+    } catch (error) {
+      this.onerror?.(new Error(`Invalid MCP message: ${(error as Error).message}`));
+      return;
+    }
+    this.onmessage?.(message, { requestInfo: { headers: this.headers } });
+  }
+
+  private fail(code: number, error: Error) {
+    this.onerror?.(error);
+    const payload = Buffer.alloc(2);
+    payload.writeUInt16BE(code);
+    this.writeFrame(OPCODE_CLOSE, payload);
+    this.socket.end();
+    this.finish();
+  }
+}
+
+// Encodes a final frame. Frames sent by clients must be masked, those sent by servers must not.
+export function encodeFrame(opcode: number, payload: Buffer, masked = false): Buffer {
+  const maskBit = masked ? 0x80 : 0;
+  let header: Buffer;
+  if (payload.length < 126) {
+    header = Buffer.from([0x80 | opcode, maskBit | payload.length]);
+  } else if (payload.length < 65536) {
+    header = Buffer.alloc(4);
+    header[0] = 0x80 | opcode;
+    header[1] = maskBit | 126;
+    header.writeUInt16BE(payload.length, 2);
+  } else {
+    header = Buffer.alloc(10);
+    header[0] = 0x80 | opcode;
+    header[1] = maskBit | 127;
+    header.writeBigUInt64BE(BigInt(payload.length), 2);
+  }
+  if (!masked) {
+    return Buffer.concat([header, payload]);
+  }
+
+  const mask = randomBytes(4);
+  const body = Buffer.from(payload);
+  for (let i = 0; i < body.length; i++) {
+    body[i] ^= mask[i % 4];
+  }
+  return Buffer.concat([header, mask, body]);
+}
+
+// Parses one frame at the start of the buffer; undefined if it is incomplete. Servers expect
+// masked frames from clients, and clients unmasked frames from servers.
+export function parseFrame(
+  buffer: Buffer,
+  expectMasked: boolean
+): { fin: boolean; opcode: number; payload: Buffer; end: number } | Error | undefined {
+  if (buffer.length < 2) return undefined;
+  const fin = (buffer[0] & 0x80) !== 0;
+  const opcode = buffer[0] & 0x0f;
+  const masked = (buffer[1] & 0x80) !== 0;
+  if (masked !== expectMasked) {
+    return new Error(
+      expectMasked
+        ? 'WebSocket frames from clients must be masked'
+        : 'WebSocket frames from servers must not be masked'
+    );
+  }
+
+  let length = buffer[1] & 0x7f;
+  let offset = 2;
+  if (length === 126) {
+    if (buffer.length < 4) return undefined;
+    length = buffer.readUInt16BE(2);
+    offset = 4;
+  } else if (length === 127) {
+    if (buffer.length < 10) return undefined;
+    const long = buffer.readBigUInt64BE(2);
+    if (long > BigInt(MAX_MESSAGE_SIZE)) {
+      return new Error(`WebSocket message exceeds ${MAX_MESSAGE_SIZE} bytes`);
+    }
+    length = Number(long);
+    offset = 10;
+  }
+
+  const maskLength = masked ? 4 : 0;
+  if (buffer.length < offset + maskLength + length) return undefined;
+  const start = offset + maskLength;
+  const payload = Buffer.from(buffer.subarray(start, start + length));
+  if (masked) {
+    const mask = buffer.subarray(offset, start);
+    for (let i = 0; i < payload.length; i++) {
+      payload[i] ^= mask[i % 4];
+    }
+  }
+  return { fin, opcode, payload, end: start + length };
+}
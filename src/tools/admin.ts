@@ -1,6 +1,7 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
 import { GrafanaClient } from '../clients/grafana-client';
+import { redactSecrets } from '../utils/redact';
 
 // Schema definitions
 const ListTeamsSchema = z.object({
@@ -9,6 +10,139 @@ const ListTeamsSchema = z.object({
 
 const ListUsersByOrgSchema = z.object({});
 
+const ListOrgsSchema = z.object({
+  query: z.string().optional().describe('Filter organizations by name'),
+});
+
+const ListServiceAccountsSchema = z.object({
+  query: z.string().optional().describe('Filter service accounts by name or login'),
+});
+
+const CreateServiceAccountSchema = z.object({
+  name: z.string().describe('The name of the service account'),
+  role: z.enum(['Viewer', 'Editor', 'Admin', 'None']).optional().describe('The org role of the service account (default: Viewer)'),
+  isDisabled: z.boolean().optional().describe('Whether to create the service account disabled'),
+});
+
+const ListServiceAccountTokensSchema = z.object({
+  serviceAccountId: z.number().describe('The ID of the service account'),
+});
+
+const CreateServiceAccountTokenSchema = z.object({
+  serviceAccountId: z.number().describe('The ID of the service account'),
+  name: z.string().describe('The name of the token'),
+  secondsToLive: z.number().optional().describe('Lifetime of the token in seconds (default: never expires)'),
+});
+
+const RevokeServiceAccountTokenSchema = z.object({
+  serviceAccountId: z.number().describe('The ID of the service account'),
+  tokenId: z.number().describe('The ID of the token to revoke'),
+});
+
+const ListRbacRolesSchema = z.object({
+  userId: z.number().optional().describe('Only list roles assigned to this user'),
+  includeHidden: z.boolean().optional().describe('Whether to include hidden (basic and managed) roles'),
+});
+
+const GetUserPermissionsSchema = z.object({
+  userId: z.number().describe('The ID of the user'),
+  action: z.string().optional().describe('Only include this action, e.g. "dashboards:read"'),
+  actionPrefix: z.string().optional().describe('Only include actions with this prefix, e.g. "dashboards:"'),
+  scope: z.string().optional().describe('Only include permissions on this scope, e.g. "dashboards:uid:abc123"'),
+});
+
+const GetResourcePermissionsSchema = z.object({
+  resource: z.enum(['dashboards', 'folders', 'datasources']).describe('The type of resource'),
+  uid: z.string().describe('The UID of the resource'),
+});
+
+const WhoamiSchema = z.object({});
+
+const CanISchema = z.object({
+  action: z.string().describe('The action to check, e.g. "dashboards:write" or "datasources:query"'),
+  scope: z.string().optional().describe('The scope to check, e.g. "dashboards:uid:abc123" or "datasources:uid:prom"'),
+  folderUid: z.string().optional().describe('Check the action on resources in this folder (shorthand for scope "folders:uid:<folderUid>")'),
+});
+
+const PreferenceScopeSchema = {
+  scope: z.enum(['org', 'team', 'user']).describe('Whose preferences: the current org, a team, or the authenticated user'),
+  teamId: z.number().optional().describe('The ID of the team (required for team scope)'),
+};
+
+const GetPreferencesSchema = z.object(PreferenceScopeSchema);
+
+const UpdatePreferencesSchema = z.object({
+  ...PreferenceScopeSchema,
+  homeDashboardUid: z.string().optional().describe('The UID of the home dashboard'),
+  theme: z.enum(['light', 'dark', 'system', '']).optional().describe('The UI theme; empty string resets to the default'),
+  timezone: z.string().optional().describe('The timezone, e.g. "utc", "browser", or "Europe/Berlin"; empty string resets to the default'),
+  weekStart: z.string().optional().describe('The first day of the week, e.g. "monday"'),
+});
+
+const GetLdapStatusSchema = z.object({
+  username: z.string().optional().describe('Also look up how this user is mapped from LDAP (login or email)'),
+});
+
+const SyncLdapUserSchema = z.object({
+  userId: z.number().describe('The ID of the Grafana user to sync from LDAP'),
+});
+
+const GetSsoSettingsSchema = z.object({
+  provider: z.string().optional().describe('Only return this provider, e.g. "github", "okta", "generic_oauth", "saml"'),
+});
+
+const GetServerStatsSchema = z.object({});
+
+const GetOrgUsageSchema = z.object({});
+
+// Results fetched per search request when counting dashboards and folders
+const SEARCH_PAGE_SIZE = 1000;
+
+// Helper function to resolve the API path of a preference scope
+function preferencesPath(scope: string, teamId?: number): string {
+  switch (scope) {
+    case 'org': return '/api/org';
+    case 'user': return '/api/user';
+    case 'team':
+      if (teamId === undefined) {
+        throw new Error('teamId is required for team preferences');
+      }
+      return `/api/teams/${teamId}`;
+    default:
+      throw new Error(`Unknown preference scope: ${scope}`);
+  }
+}
+
+// Helper function to list the scopes through which a permission on a resource can be granted,
+// the way Grafana resolves them: the resource itself, and for dashboards and folders every
+// folder containing them. Folders that cannot be looked up are left out.
+async function resolveScopes(client: GrafanaClient, scope: string): Promise<string[]> {
+  const dashboard = /^dashboards:uid:(.+)$/.exec(scope);
+  const folder = /^folders:uid:(.+)$/.exec(scope);
+  let folderUid = folder?.[1];
+  try {
+    if (dashboard) {
+      const { meta } = await client.getDashboardWithMeta(dashboard[1]);
+      folderUid = meta?.folderUid || undefined;
+    }
+    if (!folderUid) return [scope];
+    const { parents } = await client.getFolder(folderUid);
+    const ancestors = (parents || []).map((parent: any) => `folders:uid:${parent.uid}`);
+    return [...new Set([scope, `folders:uid:${folderUid}`, ...ancestors.reverse()])];
+  } catch {
+    return [scope];
+  }
+}
+
+// Helper function to check whether a granted scope covers a requested scope
+function scopeCovers(granted: string, requested: string): boolean {
+  if (granted === '' || granted === '*' || granted === requested) return true;
+  if (granted.endsWith('*')) {
+    return requested.startsWith(granted.slice(0, -1));
+  }
+  return false;
+}
+
 // Tool definitions
 export const listTeams: ToolDefinition = {
   name: 'list_teams',
@@ -61,7 +195,529 @@ export const listUsersByOrg: ToolDefinition = {
   },
 };
 
+export const listOrgs: ToolDefinition = {
+  name: 'list_orgs',
+  description: 'List Grafana organizations. Requires a server admin identity',
+  inputSchema: ListOrgsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const orgs = await client.listOrgs(params.query);
+      
+      return createToolResult(orgs.map(org => ({ id: org.id, name: org.name })));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listServiceAccounts: ToolDefinition = {
+  name: 'list_service_accounts',
+  description: 'List service accounts in the current organization with their role and token count',
+  inputSchema: ListServiceAccountsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const serviceAccounts = await client.searchServiceAccounts(params.query);
+      
+      const formatted = serviceAccounts.map(sa => ({
+        id: sa.id,
+        uid: sa.uid,
+        name: sa.name,
+        login: sa.login,
+        role: sa.role,
+        isDisabled: sa.isDisabled,
+        tokens: sa.tokens,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const createServiceAccount: ToolDefinition = {
+  name: 'create_service_account',
+  description: 'Create a service account in the current organization',
+  inputSchema: CreateServiceAccountSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const serviceAccount = await client.createServiceAccount({
+        name: params.name,
+        role: params.role || 'Viewer',
+        isDisabled: params.isDisabled || false,
+      });
+      
+      return createToolResult({
+        id: serviceAccount.id,
+        uid: serviceAccount.uid,
+        name: serviceAccount.name,
+        login: serviceAccount.login,
+        role: serviceAccount.role,
+        message: 'Service account created successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listServiceAccountTokens: ToolDefinition = {
+  name: 'list_service_account_tokens',
+  description: 'List the tokens of a service account (metadata only, never secrets)',
+  inputSchema: ListServiceAccountTokensSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const tokens = await client.listServiceAccountTokens(params.serviceAccountId);
+      
+      const formatted = tokens.map(token => ({
+        id: token.id,
+        name: token.name,
+        created: token.created,
+        expiration: token.expiration,
+        hasExpired: token.hasExpired,
+        lastUsedAt: token.lastUsedAt,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const createServiceAccountToken: ToolDefinition = {
+  name: 'create_service_account_token',
+  description: 'Issue a new token for a service account. The token secret is only returned once',
+  inputSchema: CreateServiceAccountTokenSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const token = await client.createServiceAccountToken(params.serviceAccountId, {
+        name: params.name,
+        secondsToLive: params.secondsToLive,
+      });
+      
+      return createToolResult({
+        id: token.id,
+        name: token.name,
+        key: token.key,
+        message: 'Token created. Store the key now; it cannot be retrieved again.',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const revokeServiceAccountToken: ToolDefinition = {
+  name: 'revoke_service_account_token',
+  description: 'Revoke a service account token. DESTRUCTIVE: anything using the token loses access immediately',
+  inputSchema: RevokeServiceAccountTokenSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      await client.deleteServiceAccountToken(params.serviceAccountId, params.tokenId);
+      
+      return createToolResult({
+        serviceAccountId: params.serviceAccountId,
+        tokenId: params.tokenId,
+        message: 'Token revoked successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listRbacRoles: ToolDefinition = {
+  name: 'list_rbac_roles',
+  description: 'List RBAC roles, or the roles assigned to a specific user, with the permissions each grants',
+  inputSchema: ListRbacRolesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const roles = params.userId !== undefined
+        ? await client.listUserRoles(params.userId)
+        : await client.listRoles(params.includeHidden);
+      
+      const formatted = roles.map(role => ({
+        uid: role.uid,
+        name: role.name,
+        displayName: role.displayName,
+        group: role.group,
+        description: role.description,
+        global: role.global,
+        permissions: role.permissions?.map((p: any) => p.scope ? `${p.action} on ${p.scope}` : p.action),
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getUserPermissions: ToolDefinition = {
+  name: 'get_user_permissions',
+  description: 'Get the effective permissions of a user, as a map of action to the scopes it applies to. Use to debug why a user cannot see or edit a resource',
+  inputSchema: GetUserPermissionsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const result = await client.searchUserPermissions({
+        userId: params.userId,
+        action: params.action,
+        actionPrefix: params.actionPrefix,
+        scope: params.scope,
+      });
+      
+      const permissions = result[String(params.userId)] || {};
+      
+      return createToolResult({
+        userId: params.userId,
+        actionCount: Object.keys(permissions).length,
+        permissions,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getResourcePermissions: ToolDefinition = {
+  name: 'get_resource_permissions',
+  description: 'List who has access to a dashboard, folder, or datasource, including permissions inherited from parent folders',
+  inputSchema: GetResourcePermissionsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const permissions = await client.getResourcePermissions(params.resource, params.uid);
+      
+      const formatted = permissions.map(p => ({
+        permission: p.permission,
+        user: p.userLogin || undefined,
+        team: p.team || undefined,
+        role: p.builtInRole || undefined,
+        inherited: p.isInherited,
+        managed: p.isManaged,
+        actions: p.actions,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const whoami: ToolDefinition = {
+  name: 'whoami',
+  description: 'Report the identity the server is authenticated as: user or service account, current org, and org role',
+  inputSchema: WhoamiSchema,
+  handler: async (_params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const user = await client.getCurrentUser();
+      const orgs = await client.getCurrentUserOrgs();
+      const currentOrg = orgs.find(org => org.orgId === user.orgId);
+      
+      return createToolResult({
+        id: user.id,
+        uid: user.uid,
+        login: user.login,
+        name: user.name,
+        email: user.email || undefined,
+        isServiceAccount: user.isServiceAccount === true,
+        isGrafanaAdmin: user.isGrafanaAdmin,
+        org: {
+          id: user.orgId,
+          name: currentOrg?.name,
+          role: currentOrg?.role,
+        },
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const canI: ToolDefinition = {
+  name: 'can_i',
+  description: 'Check whether the current identity is permitted to perform an action (e.g. dashboards:write in folder F) before attempting it. Permissions granted on a folder count for the dashboards and folders nested in it',
+  inputSchema: CanISchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const permissions = await client.getCurrentUserPermissions();
+      
+      const scope = params.scope || (params.folderUid ? `folders:uid:${params.folderUid}` : undefined);
+      const grantedScopes = permissions[params.action];
+      
+      let allowed = false;
+      let matchedScope: string | undefined;
+      let checkedScopes: string[] | undefined;
+      if (grantedScopes) {
+        if (!scope) {
+          allowed = true;
+        } else {
+          // Permissions on a folder apply to the dashboards and folders nested in it
+          const candidates = await resolveScopes(client, scope);
+          checkedScopes = candidates;
+          matchedScope = grantedScopes.find(granted =>
+            candidates.some(candidate => scopeCovers(granted, candidate))
+          );
+          allowed = matchedScope !== undefined;
+        }
+      }
+      
+      return createToolResult({
+        action: params.action,
+        scope,
+        allowed,
+        matchedScope,
+        checkedScopes: checkedScopes && checkedScopes.length > 1 ? checkedScopes : undefined,
+        reason: allowed
+          ? undefined
+          : grantedScopes
+            ? `Action is granted only on: ${grantedScopes.join(', ')}`
+            : 'Action is not granted on any scope',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getPreferences: ToolDefinition = {
+  name: 'get_preferences',
+  description: 'Get the home dashboard, theme, timezone, and week start preferences of the org, a team, or the authenticated user',
+  inputSchema: GetPreferencesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const preferences = await client.getPreferences(preferencesPath(params.scope, params.teamId));
+      
+      return createToolResult({
+        scope: params.scope,
+        teamId: params.teamId,
+        homeDashboardUid: preferences.homeDashboardUID || undefined,
+        theme: preferences.theme || undefined,
+        timezone: preferences.timezone || undefined,
+        weekStart: preferences.weekStart || undefined,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const updatePreferences: ToolDefinition = {
+  name: 'update_preferences',
+  description: 'Update the home dashboard, theme, timezone, or week start preferences of the org, a team, or the authenticated user. Only the provided fields are changed',
+  inputSchema: UpdatePreferencesSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const path = preferencesPath(params.scope, params.teamId);
+      const existing = await client.getPreferences(path);
+      
+      await client.updatePreferences(path, {
+        homeDashboardUID: params.homeDashboardUid ?? existing.homeDashboardUID,
+        theme: params.theme ?? existing.theme,
+        timezone: params.timezone ?? existing.timezone,
+        weekStart: params.weekStart ?? existing.weekStart,
+      });
+      
+      return createToolResult({
+        scope: params.scope,
+        teamId: params.teamId,
+        message: 'Preferences updated successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getLdapStatus: ToolDefinition = {
+  name: 'get_ldap_status',
+  description: 'Check whether the configured LDAP servers are reachable, and optionally how a user is mapped from LDAP (roles, teams, attributes)',
+  inputSchema: GetLdapStatusSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const servers = await client.getLdapStatus();
+      
+      let user: any;
+      if (params.username) {
+        const ldapUser = await client.getLdapUser(params.username);
+        user = {
+          name: ldapUser.name,
+          surname: ldapUser.surname,
+          email: ldapUser.email,
+          login: ldapUser.login,
+          isGrafanaAdmin: ldapUser.isGrafanaAdmin,
+          isDisabled: ldapUser.isDisabled,
+          roles: ldapUser.roles,
+          teams: ldapUser.teams,
+        };
+      }
+      
+      return createToolResult({
+        servers: (servers || []).map(server => ({
+          host: server.host,
+          port: server.port,
+          available: server.available,
+          error: server.error || undefined,
+        })),
+        user,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const syncLdapUser: ToolDefinition = {
+  name: 'sync_ldap_user',
+  description: 'Sync a Grafana user from LDAP now, updating their roles and team memberships',
+  inputSchema: SyncLdapUserSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const result = await client.syncLdapUser(params.userId);
+      
+      return createToolResult({
+        userId: params.userId,
+        message: result?.message || 'User synchronized successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getSsoSettings: ToolDefinition = {
+  name: 'get_sso_settings',
+  description: 'Get SSO (OAuth, SAML, LDAP) provider settings with secrets redacted, to troubleshoot login problems',
+  inputSchema: GetSsoSettingsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      let providers = await client.listSsoSettings();
+      
+      if (params.provider) {
+        providers = providers.filter(p => p.provider === params.provider);
+      }
+      
+      const formatted = providers.map(p => ({
+        provider: p.provider,
+        source: p.source,
+        enabled: p.settings?.enabled,
+        settings: redactSecrets(p.settings || {}),
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getServerStats: ToolDefinition = {
+  name: 'get_server_stats',
+  description: 'Get instance-wide usage statistics: orgs, users, active users, dashboards, datasources, alert rules, and more. Requires a server admin identity',
+  inputSchema: GetServerStatsSchema,
+  handler: async (_params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const stats = await client.getAdminStats();
+      return createToolResult(stats);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+// Helper function to count the search results of a type, page by page
+async function countSearchResults(client: GrafanaClient, type: string): Promise<number> {
+  let count = 0;
+  for (let page = 1; ; page++) {
+    const batch = await client.search({ type, limit: SEARCH_PAGE_SIZE, page });
+    count += batch.length;
+    if (batch.length < SEARCH_PAGE_SIZE) return count;
+  }
+}
+
+export const getOrgUsage: ToolDefinition = {
+  name: 'get_org_usage',
+  description: 'Count the dashboards, folders, datasources (by type), users, and teams in the current organization',
+  inputSchema: GetOrgUsageSchema,
+  handler: async (_params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const org = await client.getCurrentOrg();
+      const dashboards = await countSearchResults(client, 'dash-db');
+      const folders = await countSearchResults(client, 'dash-folder');
+      const datasources = await client.listDatasources();
+      const users = await client.listUsers();
+      const teams = await client.listTeams();
+      
+      const datasourcesByType: Record<string, number> = {};
+      for (const ds of datasources) {
+        datasourcesByType[ds.type] = (datasourcesByType[ds.type] || 0) + 1;
+      }
+      
+      const usersByRole: Record<string, number> = {};
+      for (const user of users) {
+        const role = user.role || 'None';
+        usersByRole[role] = (usersByRole[role] || 0) + 1;
+      }
+      
+      return createToolResult({
+        org: { id: org.id, name: org.name },
+        dashboards,
+        folders,
+        datasources: datasources.length,
+        datasourcesByType,
+        users: users.length,
+        usersByRole,
+        teams: teams.length,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
 export function registerAdminTools(server: any) {
   server.registerTool(listTeams);
   server.registerTool(listUsersByOrg);
+  server.registerTool(listOrgs);
+  server.registerTool(listServiceAccounts);
+  server.registerTool(createServiceAccount);
+  server.registerTool(listServiceAccountTokens);
+  server.registerTool(createServiceAccountToken);
+  server.registerTool(revokeServiceAccountToken);
+  server.registerTool(listRbacRoles);
+  server.registerTool(getUserPermissions);
+  server.registerTool(getResourcePermissions);
+  server.registerTool(whoami);
+  server.registerTool(canI);
+  server.registerTool(getPreferences);
+  server.registerTool(updatePreferences);
+  server.registerTool(getLdapStatus);
+  server.registerTool(syncLdapUser);
+  server.registerTool(getSsoSettings);
+  server.registerTool(getServerStats);
+  server.registerTool(getOrgUsage);
 }
\ No newline at end of file
@@ -1,6 +1,16 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
 import { GrafanaClient } from '../clients/grafana-client';
+import { RulerClient } from '../clients/ruler-client';
+import { redactSecrets } from '../utils/redact';
+import { projectFields } from '../utils/fields';
+import { formatTime, parseTime, parseTimeRange, toUnixSeconds } from '../utils/time';
+import { renderTables, resultTables } from '../utils/table';
+import { mapConcurrent } from '../utils/concurrency';
+import { normalizeQuery, queryMatchers, queryMetrics } from '../utils/promql';
+import { dashboardPanels, panelExpressions } from '../utils/dashboard';
+import { alertEvents } from '../server/alert-webhook';
+import { checkFolderWrite } from '../server/folder-policy';
 
 // Schema definitions
 const ListAlertRulesSchema = z.object({
@@ -13,10 +23,12 @@ const ListAlertRulesSchema = z.object({
   })).optional().describe('Label matchers to filter alert rules'),
   limit: z.number().optional().describe('Maximum number of results to return'),
   page: z.number().optional().describe('Page number to return'),
+  fields: z.array(z.string()).optional().describe('Only return these fields, as dotted paths (e.g. ["uid", "title"]); default: all fields'),
 });
 
 const GetAlertRuleByUidSchema = z.object({
   uid: z.string().describe('The uid of the alert rule'),
+  fields: z.array(z.string()).optional().describe('Only return these fields, as dotted paths (e.g. ["uid", "title"]); default: all fields'),
 });
 
 const ListContactPointsSchema = z.object({
@@ -24,6 +36,326 @@ const ListContactPointsSchema = z.object({
   limit: z.number().optional().describe('Maximum number of results to return'),
 });
 
+const AlertmanagerMatcherSchema = z.object({
+  name: z.string().describe('The name of the label to match against'),
+  value: z.string().describe('The value to match against'),
+  type: z.enum(['=', '!=', '=~', '!~']).describe('The match operator'),
+});
+
+const ListActiveAlertsSchema = z.object({
+  filters: z.array(AlertmanagerMatcherSchema).optional().describe('Label matchers to filter alerts'),
+  receiver: z.string().optional().describe('Regex matching the receiver name'),
+  silenced: z.boolean().optional().describe('Whether to include silenced alerts (default: false)'),
+  inhibited: z.boolean().optional().describe('Whether to include inhibited alerts (default: false)'),
+  limit: z.number().optional().describe('Maximum number of results to return'),
+});
+
+const ListAlertGroupsSchema = z.object({
+  filters: z.array(AlertmanagerMatcherSchema).optional().describe('Label matchers to filter alerts'),
+  receiver: z.string().optional().describe('Regex matching the receiver name'),
+  silenced: z.boolean().optional().describe('Whether to include silenced alerts (default: false)'),
+  inhibited: z.boolean().optional().describe('Whether to include inhibited alerts (default: false)'),
+});
+
+const GetAlertStateHistorySchema = z.object({
+  ruleUid: z.string().optional().describe('The uid of the alert rule to retrieve history for'),
+  labels: z.record(z.string()).optional().describe('Only include state transitions of alert instances with these labels'),
+  startRfc3339: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-6h"; default: 24 hours ago)'),
+  endRfc3339: z.string().optional().describe('The end time (default: now)'),
+  limit: z.number().optional().describe('Maximum number of state transitions to return (default: 100)'),
+});
+
+const PreviewAlertRuleSchema = z.object({
+  data: z.array(z.object({
+    refId: z.string().describe('The reference ID of the query or expression (e.g. "A")'),
+    datasourceUid: z.string().describe('The UID of the datasource, or "__expr__" for server-side expressions'),
+    model: z.record(z.any()).describe('The query or expression model, as in the alert rule definition'),
+    relativeTimeRange: z.object({
+      from: z.number().describe('Seconds before evaluation time where the query range starts'),
+      to: z.number().describe('Seconds before evaluation time where the query range ends'),
+    }).optional().describe('The relative time range of the query (default: last 10 minutes)'),
+  })).describe('The queries and expressions of the alert rule'),
+  condition: z.string().describe('The refId of the query or expression used as the alert condition'),
+  startRfc3339: z.string().optional().describe('Start of the backtesting range (RFC3339, unix epoch, or relative like "now-6h"). When omitted, the rule is evaluated once at the current time'),
+  endRfc3339: z.string().optional().describe('End of the backtesting range (default: now)'),
+  intervalSeconds: z.number().optional().describe('Evaluation interval used when backtesting (default: 60)'),
+  for: z.string().optional().describe('Pending period used when backtesting (e.g. "5m")'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or compact CSV or markdown tables of the result frames (default: json)'),
+});
+
+const TestContactPointSchema = z.object({
+  name: z.string().describe('The name of the contact point to send a test notification to'),
+  labels: z.record(z.string()).optional().describe('Labels of the test alert, e.g. to verify notification templates'),
+  annotations: z.record(z.string()).optional().describe('Annotations of the test alert (e.g. summary, description)'),
+});
+
+const TimeIntervalSchema = z.object({
+  times: z.array(z.object({
+    start_time: z.string().describe('Start time in HH:MM format (e.g. "22:00")'),
+    end_time: z.string().describe('End time in HH:MM format (e.g. "23:59")'),
+  })).optional().describe('Time ranges within a day'),
+  weekdays: z.array(z.string()).optional().describe('Days of the week or ranges (e.g. "monday:friday", "saturday")'),
+  days_of_month: z.array(z.string()).optional().describe('Days of the month or ranges; negative values count from the end (e.g. "1:5", "-1")'),
+  months: z.array(z.string()).optional().describe('Months by name or number, or ranges (e.g. "january:march", "12")'),
+  years: z.array(z.string()).optional().describe('Years or ranges (e.g. "2025", "2025:2026")'),
+  location: z.string().optional().describe('IANA time zone for the interval (e.g. "Europe/Berlin")'),
+});
+
+const ListMuteTimingsSchema = z.object({
+  name: z.string().optional().describe('Filter mute timings by name'),
+});
+
+const CreateMuteTimingSchema = z.object({
+  name: z.string().describe('The name of the mute timing'),
+  time_intervals: z.array(TimeIntervalSchema).describe('The time intervals during which notifications are muted'),
+});
+
+const UpdateMuteTimingSchema = z.object({
+  name: z.string().describe('The name of the mute timing to update'),
+  time_intervals: z.array(TimeIntervalSchema).describe('The new time intervals of the mute timing'),
+});
+
+const DeleteMuteTimingSchema = z.object({
+  name: z.string().describe('The name of the mute timing to delete'),
+});
+
+const ExportAlertingConfigurationSchema = z.object({
+  resources: z.array(z.enum(['alert_rules', 'contact_points', 'notification_policies', 'mute_timings']))
+    .optional()
+    .describe('The alerting resources to export (default: all)'),
+  format: z.enum(['yaml', 'json', 'hcl']).optional().describe('The export format; hcl produces Terraform resources (default: yaml)'),
+  ruleUid: z.string().optional().describe('Only export the alert rule with this uid'),
+  folderUid: z.string().optional().describe('Only export alert rules in this folder'),
+});
+
+const ListRulerRulesSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Mimir, Cortex, or Loki datasource'),
+  namespace: z.string().optional().describe('Only list rule groups in this namespace'),
+  type: z.enum(['recording', 'alerting']).optional().describe('Only list rules of this type'),
+});
+
+const GetRulerRuleGroupSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Mimir, Cortex, or Loki datasource'),
+  namespace: z.string().describe('The namespace of the rule group'),
+  group: z.string().describe('The name of the rule group'),
+});
+
+const SetRulerRuleGroupSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Mimir, Cortex, or Loki datasource'),
+  namespace: z.string().describe('The namespace of the rule group'),
+  group: z.string().describe('The name of the rule group'),
+  interval: z.string().optional().describe('The evaluation interval of the group (e.g. "1m")'),
+  rules: z.array(z.object({
+    record: z.string().optional().describe('The name of the recorded series (recording rules)'),
+    alert: z.string().optional().describe('The name of the alert (alerting rules)'),
+    expr: z.string().describe('The PromQL or LogQL expression to evaluate'),
+    for: z.string().optional().describe('Pending period of an alerting rule (e.g. "5m")'),
+    labels: z.record(z.string()).optional().describe('Labels to add to the recorded series or alert'),
+    annotations: z.record(z.string()).optional().describe('Annotations of an alerting rule'),
+  })).describe('The complete list of rules in the group; replaces any existing rules'),
+});
+
+const DeleteRulerRuleGroupSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Mimir, Cortex, or Loki datasource'),
+  namespace: z.string().describe('The namespace of the rule group'),
+  group: z.string().describe('The name of the rule group to delete'),
+});
+
+const FindAlertDashboardsSchema = z.object({
+  uid: z.string().describe('The UID of the alert rule'),
+  folderUids: z.array(z.string()).optional().describe('Only search dashboards in these folders'),
+  tags: z.array(z.string()).optional().describe('Only search dashboards with all of these tags'),
+  maxDashboards: z.number().optional().describe('Maximum dashboards to search (default: 200, max: 1000)'),
+  limit: z.number().optional().describe('Maximum panels to return (default: 20)'),
+});
+
+const ListRecentAlertEventsSchema = z.object({
+  status: z.enum(['firing', 'resolved']).optional().describe('Only return alerts with this status'),
+  alertname: z.string().optional().describe('Only return alerts of this alert rule'),
+  labels: z.record(z.string()).optional().describe('Only return alerts with all of these label values, e.g. {"severity": "critical"}'),
+  since: z.string().optional().describe('Only return alerts received after this time (RFC3339, unix epoch, or relative like "now-15m")'),
+  limit: z.number().optional().describe('Maximum alerts to return, newest first (default: 50)'),
+});
+
+// Helper to flatten the state history data frame into transitions
+function parseStateHistoryFrame(frame: any, timezone?: string): any[] {
+  const fields = frame?.schema?.fields || [];
+  const values = frame?.data?.values || [];
+  const timeIndex = fields.findIndex((f: any) => f.type === 'time');
+  const lineIndex = fields.findIndex((f: any) => f.name === 'Line' || f.name === 'line');
+  if (timeIndex === -1 || lineIndex === -1) {
+    return [];
+  }
+
+  const transitions = [];
+  const times = values[timeIndex] || [];
+  const order = times
+    .map((_: number, i: number) => i)
+    .sort((a: number, b: number) => times[a] - times[b]);
+  for (const i of order) {
+    let line = values[lineIndex][i];
+    if (typeof line === 'string') {
+      try {
+        line = JSON.parse(line);
+      } catch {
+        line = { current: line };
+      }
+    }
+    transitions.push({
+      time: formatTime(times[i], timezone),
+      ruleUID: line.ruleUID,
+      ruleTitle: line.ruleTitle,
+      previous: line.previous,
+      current: line.current,
+      labels: line.labels || {},
+      values: line.values,
+    });
+  }
+
+  return transitions;
+}
+
+// Helper to derive firing periods per alert instance from state transitions
+function summarizeFiringPeriods(transitions: any[]) {
+  const open = new Map<string, string>();
+  const periods: any[] = [];
+
+  for (const t of transitions) {
+    const key = JSON.stringify(t.labels);
+    const firing = typeof t.current === 'string' && t.current.startsWith('Alerting');
+    if (firing && !open.has(key)) {
+      open.set(key, t.time);
+    } else if (!firing && open.has(key)) {
+      const start = open.get(key)!;
+      open.delete(key);
+      periods.push({
+        labels: t.labels,
+        start,
+        end: t.time,
+        durationSeconds: (Date.parse(t.time) - Date.parse(start)) / 1000,
+      });
+    }
+  }
+
+  for (const [key, start] of open) {
+    periods.push({ labels: JSON.parse(key), start, end: null, durationSeconds: null });
+  }
+
+  periods.sort((a, b) => Date.parse(b.start) - Date.parse(a.start));
+  return {
+    firingPeriods: periods,
+    lastFiredAt: periods[0]?.start || null,
+    currentlyFiring: open.size > 0,
+  };
+}
+
+// Helper to convert preview query input into the alert query format
+function toAlertQueries(data: any[]) {
+  return data.map((q: any) => ({
+    refId: q.refId,
+    datasourceUid: q.datasourceUid,
+    queryType: q.model.queryType || '',
+    relativeTimeRange: q.relativeTimeRange || { from: 600, to: 0 },
+    model: { ...q.model, refId: q.refId },
+  }));
+}
+
+const WEEKDAYS = ['sunday', 'monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday'];
+const MONTHS = [
+  'january', 'february', 'march', 'april', 'may', 'june',
+  'july', 'august', 'september', 'october', 'november', 'december',
+];
+
+// Helper to validate "a" or "a:b" range expressions
+function validateRanges(
+  field: string,
+  ranges: string[] | undefined,
+  isValid: (value: string) => boolean
+): string[] {
+  const errors: string[] = [];
+  for (const range of ranges || []) {
+    const parts = range.toLowerCase().split(':');
+    if (parts.length > 2 || !parts.every(isValid)) {
+      errors.push(`${field}: invalid value "${range}"`);
+    }
+  }
+  return errors;
+}
+
+// Helper to validate mute timing interval syntax before sending it to Grafana
+function validateTimeIntervals(intervals: any[]): string[] {
+  const errors: string[] = [];
+  const clock = /^([01]\d|2[0-3]):[0-5]\d$|^24:00$/;
+  const isInt = (min: number, max: number) => (v: string) =>
+    /^-?\d+$/.test(v) && Math.abs(parseInt(v)) >= min && Math.abs(parseInt(v)) <= max;
+
+  intervals.forEach((interval, i) => {
+    const prefix = `time_intervals[${i}]`;
+    for (const t of interval.times || []) {
+      if (!clock.test(t.start_time) || !clock.test(t.end_time)) {
+        errors.push(`${prefix}.times: invalid time range "${t.start_time}-${t.end_time}", expected HH:MM`);
+      } else if (t.start_time >= t.end_time) {
+        errors.push(`${prefix}.times: start_time ${t.start_time} must be before end_time ${t.end_time}`);
+      }
+    }
+    errors.push(
+      ...validateRanges(`${prefix}.weekdays`, interval.weekdays, v => WEEKDAYS.includes(v)),
+      ...validateRanges(`${prefix}.days_of_month`, interval.days_of_month, isInt(1, 31)),
+      ...validateRanges(
+        `${prefix}.months`,
+        interval.months,
+        v => MONTHS.includes(v) || isInt(1, 12)(v)
+      ),
+      ...validateRanges(`${prefix}.years`, interval.years, v => /^\d{4}$/.test(v))
+    );
+    if (interval.location) {
+      try {
+        new Intl.DateTimeFormat('en-US', { timeZone: interval.location });
+      } catch {
+        errors.push(`${prefix}.location: unknown time zone "${interval.location}"`);
+      }
+    }
+  });
+
+  return errors;
+}
+
+// Provisioning export endpoints per alerting resource
+const EXPORT_PATHS: Record<string, string> = {
+  alert_rules: 'alert-rules/export',
+  contact_points: 'contact-points/export',
+  notification_policies: 'policies/export',
+  mute_timings: 'mute-timings/export',
+};
+
+// Helper to build Alertmanager query params from label matchers
+function buildAlertmanagerParams(params: any): Record<string, any> {
+  const query: Record<string, any> = {
+    active: true,
+    silenced: params.silenced ?? false,
+    inhibited: params.inhibited ?? false,
+  };
+  if (params.filters && params.filters.length > 0) {
+    query.filter = params.filters.map((f: any) => `${f.name}${f.type}"${f.value}"`);
+  }
+  if (params.receiver) query.receiver = params.receiver;
+  return query;
+}
+
+// Helper to format an Alertmanager alert
+function formatAlertmanagerAlert(alert: any) {
+  return {
+    fingerprint: alert.fingerprint,
+    alertname: alert.labels?.alertname,
+    state: alert.status?.state,
+    startsAt: alert.startsAt,
+    labels: alert.labels || {},
+    summary: alert.annotations?.summary,
+    receivers: alert.receivers?.map((r: any) => r.name) || [],
+    generatorURL: alert.generatorURL,
+  };
+}
+
 // Tool definitions
 export const listAlertRules: ToolDefinition = {
   name: 'list_alert_rules',
@@ -54,7 +386,7 @@ export const listAlertRules: ToolDefinition = {
         folder: rule.folderUID,
       }));
       
-      return createToolResult(formatted);
+      return createToolResult(projectFields(formatted, params.fields));
     } catch (error: any) {
       return createErrorResult(error.message);
     }
@@ -69,7 +401,7 @@ export const getAlertRuleByUid: ToolDefinition = {
     try {
       const client = new GrafanaClient(context.config.grafanaConfig);
       const rule = await client.getAlertRuleByUid(params.uid);
-      return createToolResult(rule);
+      return createToolResult(projectFields(rule, params.fields));
     } catch (error: any) {
       return createErrorResult(error.message);
     }
@@ -100,7 +432,7 @@ export const listContactPoints: ToolDefinition = {
         uid: cp.uid,
         name: cp.name,
         type: cp.type,
-        settings: cp.settings,
+        settings: redactSecrets(cp.settings),
       }));
       
       return createToolResult(formatted);
@@ -110,8 +442,573 @@ export const listContactPoints: ToolDefinition = {
   },
 };
 
+export const listActiveAlerts: ToolDefinition = {
+  name: 'list_active_alerts',
+  description: 'Lists alerts currently firing in the Grafana-managed Alertmanager, optionally filtered by label matchers',
+  inputSchema: ListActiveAlertsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      let alerts = await client.listAlertmanagerAlerts(buildAlertmanagerParams(params));
+      
+      // Apply limit
+      if (params.limit) {
+        alerts = alerts.slice(0, params.limit);
+      }
+      
+      return createToolResult(alerts.map(formatAlertmanagerAlert));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listAlertGroups: ToolDefinition = {
+  name: 'list_alert_groups',
+  description: 'Lists alert groups from the Grafana-managed Alertmanager, grouped per notification policy, optionally filtered by label matchers',
+  inputSchema: ListAlertGroupsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const groups = await client.listAlertmanagerAlertGroups(buildAlertmanagerParams(params));
+      
+      // Format the response
+      const formatted = groups.map((group: any) => ({
+        receiver: group.receiver?.name,
+        groupLabels: group.labels || {},
+        alertCount: group.alerts?.length || 0,
+        alerts: (group.alerts || []).map(formatAlertmanagerAlert),
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getAlertStateHistory: ToolDefinition = {
+  name: 'get_alert_state_history',
+  description: 'Retrieves the state history of Grafana alert rules within a time range, including when alert instances fired and for how long',
+  inputSchema: GetAlertStateHistorySchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      
+      const range = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-24h',
+      });
+      const query: Record<string, any> = {
+        from: toUnixSeconds(range.start),
+        to: toUnixSeconds(range.end),
+        limit: params.limit || 100,
+      };
+      if (params.ruleUid) query.ruleUID = params.ruleUid;
+      if (params.labels) {
+        for (const [name, value] of Object.entries(params.labels)) {
+          query[`labels_${name}`] = value;
+        }
+      }
+      
+      const frame = await client.getAlertStateHistory(query);
+      const transitions = parseStateHistoryFrame(frame, context.timezone);
+      
+      return createToolResult({
+        ...summarizeFiringPeriods(transitions),
+        transitions,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const previewAlertRule: ToolDefinition = {
+  name: 'preview_alert_rule',
+  description: 'Evaluates an alert rule\'s queries and condition without saving the rule. Evaluates once at the current time, or backtests over a time range when a start time is given, so a rule can be validated before it is created',
+  inputSchema: PreviewAlertRuleSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const data = toAlertQueries(params.data);
+      
+      if (!data.some((q: any) => q.refId === params.condition)) {
+        return createErrorResult(`condition "${params.condition}" does not match any refId in data`);
+      }
+      
+      if (!params.startRfc3339) {
+        const result = await client.evaluateAlertQueries({
+          data,
+          condition: params.condition,
+          now: new Date().toISOString(),
+        });
+        if (params.format && params.format !== 'json') {
+          return createToolResult(renderTables(resultTables(result, context.timezone), params.format));
+        }
+        return createToolResult({ mode: 'instant', condition: params.condition, result });
+      }
+      
+      const range = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+      });
+      const result = await client.backtestAlertRule({
+        from: range.start.toISOString(),
+        to: range.end.toISOString(),
+        interval: `${params.intervalSeconds || 60}s`,
+        condition: params.condition,
+        data,
+        for: params.for || '0s',
+        title: 'mcp-preview',
+        no_data_state: 'NoData',
+      });
+      
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables(resultTables(result, context.timezone), params.format));
+      }
+      return createToolResult({ mode: 'backtest', condition: params.condition, result });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const testContactPoint: ToolDefinition = {
+  name: 'test_contact_point',
+  description: 'Sends a test notification to every integration of a Grafana contact point and reports the delivery status of each, to verify notification routing end-to-end',
+  inputSchema: TestContactPointSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      
+      const integrations = (await client.listContactPoints()).filter(
+        (cp: any) => cp.name === params.name
+      );
+      if (integrations.length === 0) {
+        return createErrorResult(`Contact point "${params.name}" not found`);
+      }
+      
+      // Existing integration UIDs let Grafana reuse the stored secure settings
+      const result = await client.testContactPoint({
+        receivers: [{
+          name: params.name,
+          grafana_managed_receiver_configs: integrations.map((cp: any) => ({
+            uid: cp.uid,
+            name: cp.name,
+            type: cp.type,
+            settings: cp.settings,
+            disableResolveMessage: cp.disableResolveMessage,
+          })),
+        }],
+        alert: {
+          labels: { alertname: 'TestAlert', instance: 'mcp-grafana', ...params.labels },
+          annotations: { summary: 'Test notification sent via MCP', ...params.annotations },
+        },
+      });
+      
+      const receiver = result.receivers?.[0] || {};
+      return createToolResult({
+        contactPoint: params.name,
+        notifiedAt: result.notified_at,
+        integrations: (receiver.grafana_managed_receiver_configs || []).map((c: any) => ({
+          uid: c.uid,
+          name: c.name,
+          status: c.status,
+          error: c.error,
+        })),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listMuteTimings: ToolDefinition = {
+  name: 'list_mute_timings',
+  description: 'Lists Grafana alerting mute timings and the time intervals during which they mute notifications',
+  inputSchema: ListMuteTimingsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      let muteTimings = await client.listMuteTimings();
+      
+      if (params.name) {
+        muteTimings = muteTimings.filter((mt: any) => mt.name === params.name);
+      }
+      
+      return createToolResult(muteTimings);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const createMuteTiming: ToolDefinition = {
+  name: 'create_mute_timing',
+  description: 'Creates a Grafana alerting mute timing, e.g. to schedule a maintenance window. Interval syntax is validated before the request is sent',
+  inputSchema: CreateMuteTimingSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const errors = validateTimeIntervals(params.time_intervals);
+      if (errors.length > 0) {
+        return createErrorResult(`Invalid mute timing: ${errors.join('; ')}`);
+      }
+      
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const result = await client.createMuteTiming({
+        name: params.name,
+        time_intervals: params.time_intervals,
+      });
+      
+      return createToolResult(result);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const updateMuteTiming: ToolDefinition = {
+  name: 'update_mute_timing',
+  description: 'Replaces the time intervals of an existing Grafana alerting mute timing. Interval syntax is validated before the request is sent',
+  inputSchema: UpdateMuteTimingSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const errors = validateTimeIntervals(params.time_intervals);
+      if (errors.length > 0) {
+        return createErrorResult(`Invalid mute timing: ${errors.join('; ')}`);
+      }
+      
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const result = await client.updateMuteTiming(params.name, {
+        name: params.name,
+        time_intervals: params.time_intervals,
+      });
+      
+      return createToolResult(result);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const deleteMuteTiming: ToolDefinition = {
+  name: 'delete_mute_timing',
+  description: 'Deletes a Grafana alerting mute timing. Fails if the mute timing is still referenced by a notification policy',
+  inputSchema: DeleteMuteTimingSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      await client.deleteMuteTiming(params.name);
+      
+      return createToolResult({
+        success: true,
+        message: `Mute timing "${params.name}" deleted`,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const exportAlertingConfiguration: ToolDefinition = {
+  name: 'export_alerting_configuration',
+  description: 'Exports Grafana alert rules, contact points, notification policies, and mute timings in file provisioning format (YAML or JSON) or as Terraform HCL, for GitOps workflows. Secrets in contact points are redacted',
+  inputSchema: ExportAlertingConfigurationSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const format = params.format || 'yaml';
+      const resources: string[] = params.resources || Object.keys(EXPORT_PATHS);
+      
+      const exports: Record<string, string> = {};
+      for (const resource of resources) {
+        let path = EXPORT_PATHS[resource];
+        const query: Record<string, any> = { format };
+        
+        if (resource === 'alert_rules') {
+          if (params.ruleUid) {
+            path = `alert-rules/${encodeURIComponent(params.ruleUid)}/export`;
+          } else if (params.folderUid) {
+            query.folderUid = params.folderUid;
+          }
+        }
+        
+        exports[resource] = await client.exportAlertingResource(path, query);
+      }
+      
+      return createToolResult({ format, exports });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listRulerRules: ToolDefinition = {
+  name: 'list_ruler_rules',
+  description: 'Lists datasource-managed recording and alerting rules from the ruler API of a Mimir, Cortex, or Loki datasource. These are distinct from Grafana-managed alert rules',
+  inputSchema: ListRulerRulesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new RulerClient(context.config.grafanaConfig, params.datasourceUid);
+      const namespaces = await client.listRules(params.namespace);
+      
+      // Flatten namespaces into a list of rule groups
+      const formatted = [];
+      for (const [namespace, groups] of Object.entries(namespaces)) {
+        for (const group of groups) {
+          const rules = group.rules.filter(rule => {
+            if (params.type === 'recording') return !!rule.record;
+            if (params.type === 'alerting') return !!rule.alert;
+            return true;
+          });
+          if (rules.length === 0 && params.type) continue;
+          formatted.push({
+            namespace,
+            group: group.name,
+            interval: group.interval,
+            rules: rules.map(rule => ({
+              type: rule.record ? 'recording' : 'alerting',
+              name: rule.record || rule.alert,
+              expr: rule.expr,
+              for: rule.for,
+              labels: rule.labels || {},
+            })),
+          });
+        }
+      }
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getRulerRuleGroup: ToolDefinition = {
+  name: 'get_ruler_rule_group',
+  description: 'Retrieves a single rule group from the ruler API of a Mimir, Cortex, or Loki datasource',
+  inputSchema: GetRulerRuleGroupSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new RulerClient(context.config.grafanaConfig, params.datasourceUid);
+      const group = await client.getRuleGroup(params.namespace, params.group);
+      return createToolResult(group);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+// Ruler "datasource" of Grafana-managed alert rules
+const GRAFANA_RULER_UID = 'grafana';
+
+// Helper to apply the folder restriction of write tools to the Grafana-managed ruler, whose
+// namespaces are folder UIDs
+function checkRulerNamespaceWrite(context: ToolContext, datasourceUid: string, namespace: string) {
+  if (datasourceUid === GRAFANA_RULER_UID) {
+    checkFolderWrite(context.config.grafanaConfig, namespace, 'alert rules');
+  }
+}
+
+export const setRulerRuleGroup: ToolDefinition = {
+  name: 'set_ruler_rule_group',
+  description: 'Creates or replaces a rule group of recording and alerting rules in the ruler API of a Mimir, Cortex, or Loki datasource',
+  inputSchema: SetRulerRuleGroupSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const invalid = params.rules.filter((rule: any) => !!rule.record === !!rule.alert);
+      if (invalid.length > 0) {
+        return createErrorResult('Each rule must set exactly one of record or alert');
+      }
+      
+      checkRulerNamespaceWrite(context, params.datasourceUid, params.namespace);
+      const client = new RulerClient(context.config.grafanaConfig, params.datasourceUid);
+      await client.setRuleGroup(params.namespace, {
+        name: params.group,
+        interval: params.interval,
+        rules: params.rules,
+      });
+      
+      return createToolResult({
+        success: true,
+        message: `Rule group "${params.group}" saved in namespace "${params.namespace}"`,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const deleteRulerRuleGroup: ToolDefinition = {
+  name: 'delete_ruler_rule_group',
+  description: 'Deletes a rule group from the ruler API of a Mimir, Cortex, or Loki datasource',
+  inputSchema: DeleteRulerRuleGroupSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      checkRulerNamespaceWrite(context, params.datasourceUid, params.namespace);
+      const client = new RulerClient(context.config.grafanaConfig, params.datasourceUid);
+      await client.deleteRuleGroup(params.namespace, params.group);
+      
+      return createToolResult({
+        success: true,
+        message: `Rule group "${params.group}" deleted from namespace "${params.namespace}"`,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const findAlertDashboards: ToolDefinition = {
+  name: 'find_alert_dashboards',
+  description: 'Finds the dashboard panels related to a Grafana alert rule, so responders can jump from a firing alert to its visualizations. Returns the panel linked in the rule\'s annotations, then panels ranked by whether they run the same query, read the same metrics, or select the same labels as the rule. At most maxDashboards dashboards are searched',
+  inputSchema: FindAlertDashboardsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const rule = await client.getAlertRuleByUid(params.uid);
+
+      // Server-side expressions (math, reduce, threshold) query no datasource
+      const ruleExprs: string[] = (rule.data || [])
+        .filter((query: any) => query.datasourceUid !== '__expr__' && query.model?.expr)
+        .map((query: any) => query.model.expr);
+      const ruleQueries = new Set(ruleExprs.map(normalizeQuery));
+      const ruleMetrics = new Set(ruleExprs.flatMap(queryMetrics));
+      // Static rule labels count like matchers; templated ones vary per alert instance
+      const ruleMatchers = new Set([
+        ...ruleExprs.flatMap(queryMatchers),
+        ...Object.entries(rule.labels || {})
+          .filter(([, value]) => !value.includes('{{'))
+          .map(([name, value]) => `${name}="${value}"`),
+      ]);
+
+      const dashboards = await client.search({
+        type: 'dash-db',
+        folderUIDs: params.folderUids,
+        tag: params.tags,
+        limit: Math.min(params.maxDashboards || 200, 1000),
+      });
+      const fetched = await mapConcurrent(dashboards, 10, async (hit: any) => {
+        try {
+          return { hit, dashboard: await client.getDashboardByUid(hit.uid) };
+        } catch {
+          // Dashboards that cannot be read are left out
+          return undefined;
+        }
+      });
+
+      const baseUrl = context.config.grafanaConfig.url.replace(/\/$/, '');
+      const matches: any[] = [];
+      for (const entry of fetched) {
+        if (!entry) continue;
+        for (const panel of dashboardPanels(entry.dashboard)) {
+          const exprs = panelExpressions(panel);
+          if (exprs.length === 0) continue;
+
+          const sameQuery = exprs.some(expr => ruleQueries.has(normalizeQuery(expr)));
+          const metrics = [...new Set(exprs.flatMap(queryMetrics))]
+            .filter(name => ruleMetrics.has(name));
+          const labels = [...new Set(exprs.flatMap(queryMatchers))]
+            .filter(matcher => ruleMatchers.has(matcher));
+          if (!sameQuery && metrics.length === 0 && labels.length === 0) continue;
+
+          matches.push({
+            dashboardUid: entry.hit.uid,
+            dashboardTitle: entry.hit.title,
+            panelId: panel.id,
+            panelTitle: panel.title,
+            url: `${baseUrl}/d/${entry.hit.uid}?viewPanel=${panel.id}`,
+            sameQuery,
+            sharedMetrics: metrics,
+            sharedLabels: labels,
+            score: (sameQuery ? 100 : 0) + metrics.length * 10 + labels.length,
+          });
+        }
+      }
+      matches.sort((a, b) => b.score - a.score);
+
+      const linkedUid = rule.annotations?.__dashboardUid__;
+      const linkedPanel = rule.annotations?.__panelId__;
+      return createToolResult({
+        rule: { uid: rule.uid, title: rule.title, queries: ruleExprs },
+        linked: linkedUid
+          ? {
+            dashboardUid: linkedUid,
+            panelId: linkedPanel ? Number(linkedPanel) : undefined,
+            url: `${baseUrl}/d/${linkedUid}${linkedPanel ? `?viewPanel=${linkedPanel}` : ''}`,
+          }
+          : undefined,
+        dashboardsSearched: fetched.filter(entry => entry).length,
+        panels: matches.slice(0, params.limit || 20),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listRecentAlertEvents: ToolDefinition = {
+  name: 'list_recent_alert_events',
+  description: 'Lists alerts recently received from Grafana\'s webhook contact point, newest first. Requires the alert webhook receiver of this server (--alert-webhook-port) and a webhook contact point pointing at it; alerts are also announced to connected clients as they arrive',
+  inputSchema: ListRecentAlertEventsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      if (!context.config.alertWebhook) {
+        return createErrorResult(
+          'The alert webhook receiver is not enabled; start the server with --alert-webhook-port'
+        );
+      }
+      const since = params.since
+        ? parseTime(params.since, { timezone: context.timezone }).getTime()
+        : 0;
+
+      const events = alertEvents
+        .list()
+        .filter(event =>
+          event.receivedAt >= since &&
+          (!params.status || event.status === params.status) &&
+          (!params.alertname || event.alertname === params.alertname) &&
+          Object.entries(params.labels || {}).every(([name, value]) => event.labels[name] === value)
+        )
+        .reverse();
+
+      return createToolResult({
+        total: events.length,
+        events: events.slice(0, params.limit || 50).map(event => ({
+          ...event,
+          receivedAt: formatTime(event.receivedAt, context.timezone),
+        })),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
 export function registerAlertingTools(server: any) {
   server.registerTool(listAlertRules);
   server.registerTool(getAlertRuleByUid);
   server.registerTool(listContactPoints);
+  server.registerTool(listActiveAlerts);
+  server.registerTool(listAlertGroups);
+  server.registerTool(getAlertStateHistory);
+  server.registerTool(previewAlertRule);
+  server.registerTool(testContactPoint);
+  server.registerTool(listMuteTimings);
+  server.registerTool(createMuteTiming);
+  server.registerTool(updateMuteTiming);
+  server.registerTool(deleteMuteTiming);
+  server.registerTool(exportAlertingConfiguration);
+  server.registerTool(listRulerRules);
+  server.registerTool(getRulerRuleGroup);
+  server.registerTool(setRulerRuleGroup);
+  server.registerTool(deleteRulerRuleGroup);
+  server.registerTool(findAlertDashboards);
+  server.registerTool(listRecentAlertEvents);
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
-import axios from 'axios';
+import { createPluginClient } from '../clients/base-client';
+import { formatTime, parseTimeRange } from '../utils/time';
 
 // Schema definitions
 const GetAssertionsSchema = z.object({
@@ -9,34 +10,19 @@ const GetAssertionsSchema = z.object({
   env: z.string().optional().describe('The environment of the entity'),
   site: z.string().optional().describe('The site of the entity'),
   namespace: z.string().optional().describe('The namespace of the entity'),
-  startTime: z.string().describe('The start time in RFC3339 format'),
-  endTime: z.string().describe('The end time in RFC3339 format'),
+  startTime: z.string().describe('The start time (RFC3339, unix epoch, or relative like "now-1h")'),
+  endTime: z.string().optional().describe('The end time (default: now)'),
 });
 
 // Helper function to create Asserts client
 function createAssertsClient(config: any) {
-  const headers: any = {
-    'User-Agent': 'mcp-grafana/1.0.0',
-    'Content-Type': 'application/json',
-  };
-  
-  if (config.serviceAccountToken) {
-    headers['Authorization'] = `Bearer ${config.serviceAccountToken}`;
-  } else if (config.apiKey) {
-    headers['Authorization'] = `Bearer ${config.apiKey}`;
-  }
-  
   // Asserts uses a different base URL pattern
   const baseUrl = config.url.replace(/\/$/, '');
   const assertsUrl = baseUrl.includes('grafana.net')
     ? baseUrl.replace('grafana.net', 'asserts.grafana.net')
     : `${baseUrl}/api/plugins/grafana-asserts-app/resources`;
   
-  return axios.create({
-    baseURL: assertsUrl,
-    headers,
-    timeout: 30000,
-  });
+  return createPluginClient(config, assertsUrl);
 }
 
 // Tool definitions
@@ -47,13 +33,16 @@ export const getAssertions: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = createAssertsClient(context.config.grafanaConfig);
+      const range = parseTimeRange(params.startTime, params.endTime, {
+        timezone: context.timezone,
+      });
       
       // Build the query parameters
       const queryParams: any = {
         entity_type: params.entityType,
         entity_name: params.entityName,
-        start_time: params.startTime,
-        end_time: params.endTime,
+        start_time: range.start.toISOString(),
+        end_time: range.end.toISOString(),
       };
       
       if (params.env) queryParams.env = params.env;
@@ -74,8 +63,8 @@ export const getAssertions: ToolDefinition = {
           namespace: params.namespace,
         },
         timeRange: {
-          start: params.startTime,
-          end: params.endTime,
+          start: formatTime(range.start, context.timezone),
+          end: formatTime(range.end, context.timezone),
         },
         assertions: assertions.map((assertion: any) => ({
           id: assertion.id,
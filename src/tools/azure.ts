@@ -0,0 +1,326 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+import { createPluginClient } from '../clients/base-client';
+import { parseTimeRange } from '../utils/time';
+import { renderTables, resultTables } from '../utils/table';
+import { createLogScrubber } from '../utils/scrub';
+
+// Schema definitions
+const ListAzureSubscriptionsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Azure Monitor datasource'),
+});
+
+const ListAzureWorkspacesSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Azure Monitor datasource'),
+  subscriptionId: z.string().optional().describe('The subscription to list Log Analytics workspaces of (default: the datasource\'s default subscription)'),
+});
+
+const ListAzureResourcesSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Azure Monitor datasource'),
+  subscriptionId: z.string().optional().describe('The subscription (default: the datasource\'s default subscription)'),
+  resourceGroup: z.string().optional().describe('Only list resources in this resource group'),
+  resourceType: z.string().optional().describe('Only list resources of this type, e.g. "Microsoft.Compute/virtualMachines"'),
+});
+
+const ListAzureMetricDefinitionsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Azure Monitor datasource'),
+  resourceUri: z.string().describe('The resource ID, e.g. "/subscriptions/.../resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm1"'),
+  metricNamespace: z.string().optional().describe('The metric namespace (default: the resource type)'),
+});
+
+const QueryAzureLogAnalyticsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Azure Monitor datasource'),
+  query: z.string().describe('The KQL query, e.g. "AppRequests | where Success == false | summarize count() by Name | top 10 by count_"'),
+  resources: z.array(z.string()).describe('The Log Analytics workspace or resource IDs to query'),
+  startRfc3339: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time (default: now)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+const QueryAzureMetricsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Azure Monitor datasource'),
+  resourceUri: z.string().describe('The resource ID, e.g. "/subscriptions/.../resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm1"'),
+  metricName: z.string().describe('The metric, e.g. "Percentage CPU"'),
+  metricNamespace: z.string().optional().describe('The metric namespace (default: the resource type)'),
+  aggregation: z.enum(['Average', 'Total', 'Minimum', 'Maximum', 'Count']).optional().describe('The aggregation (default: Average)'),
+  timeGrain: z.string().optional().describe('The ISO 8601 interval of the datapoints, e.g. "PT5M" (default: chosen from the time range)'),
+  dimensions: z.record(z.array(z.string())).optional().describe('Dimension values to filter and split by, e.g. {"ApiName": ["GetBlob"]}; an empty list splits by every value'),
+  startRfc3339: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time (default: now)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+const RESOURCE_URI = /^\/subscriptions\/([^/]+)\/resourceGroups\/([^/]+)\/providers\/([^/]+\/[^/]+)\/([^/]+)/i;
+
+const RESOURCE_URI_HINT =
+  'resourceUri must look like /subscriptions/<id>/resourceGroups/<group>/providers/<namespace>/<type>/<name>';
+
+// Helper function to create a client for the Azure Resource Manager API proxied by an Azure
+// Monitor datasource
+function createAzureClient(config: any, datasourceUid: string) {
+  return createPluginClient(
+    config,
+    `${config.url}/api/datasources/uid/${datasourceUid}/resources/azuremonitor`
+  );
+}
+
+// Helper to resolve the subscription of a request: the given one, else the datasource default
+async function resolveSubscription(
+  context: ToolContext,
+  datasourceUid: string,
+  subscriptionId?: string
+): Promise<string> {
+  if (subscriptionId) return subscriptionId;
+  const client = new GrafanaClient(context.config.grafanaConfig);
+  const datasource = await client.getDatasourceByUid(datasourceUid);
+  const fallback = datasource.jsonData?.subscriptionId;
+  if (!fallback) {
+    throw new Error('The datasource has no default subscription; pass subscriptionId');
+  }
+  return fallback;
+}
+
+// Helper to run a query through Grafana's query API, failing on the error of the query
+async function runQuery(client: GrafanaClient, body: any): Promise<any> {
+  const result = await client.queryDatasources(body);
+  const error = result?.results?.A?.error;
+  if (error) {
+    throw new Error(`Azure Monitor query failed: ${error}`);
+  }
+  return result;
+}
+
+// Helper to read the error message of an Azure Resource Manager response
+function armError(error: any): string {
+  return error.response?.data?.error?.message || error.response?.data?.message || error.message;
+}
+
+// Tool definitions
+export const listAzureSubscriptions: ToolDefinition = {
+  name: 'list_azure_subscriptions',
+  description: 'Lists the Azure subscriptions an Azure Monitor datasource can access',
+  inputSchema: ListAzureSubscriptionsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createAzureClient(context.config.grafanaConfig, params.datasourceUid);
+      const response = await client.get('/subscriptions', {
+        params: { 'api-version': '2019-03-01' },
+      });
+      return createToolResult((response.data.value || []).map((subscription: any) => ({
+        subscriptionId: subscription.subscriptionId,
+        name: subscription.displayName,
+        state: subscription.state,
+      })));
+    } catch (error: any) {
+      return createErrorResult(armError(error));
+    }
+  },
+};
+
+export const listAzureWorkspaces: ToolDefinition = {
+  name: 'list_azure_workspaces',
+  description: 'Lists the Log Analytics workspaces of an Azure subscription, whose IDs are the resources of Log Analytics queries',
+  inputSchema: ListAzureWorkspacesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const subscription = await resolveSubscription(
+        context,
+        params.datasourceUid,
+        params.subscriptionId
+      );
+      const client = createAzureClient(context.config.grafanaConfig, params.datasourceUid);
+      const scope = `/subscriptions/${encodeURIComponent(subscription)}`;
+      const response = await client.get(
+        `${scope}/providers/Microsoft.OperationalInsights/workspaces`,
+        { params: { 'api-version': '2017-04-26-preview' } }
+      );
+      return createToolResult((response.data.value || []).map((workspace: any) => ({
+        id: workspace.id,
+        name: workspace.name,
+        location: workspace.location,
+        customerId: workspace.properties?.customerId,
+      })));
+    } catch (error: any) {
+      return createErrorResult(armError(error));
+    }
+  },
+};
+
+export const listAzureResources: ToolDefinition = {
+  name: 'list_azure_resources',
+  description: 'Lists the resources of an Azure subscription, optionally in one resource group or of one type, to find the resource IDs of metric and log queries',
+  inputSchema: ListAzureResourcesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const subscription = await resolveSubscription(
+        context,
+        params.datasourceUid,
+        params.subscriptionId
+      );
+      const client = createAzureClient(context.config.grafanaConfig, params.datasourceUid);
+      let scope = `/subscriptions/${encodeURIComponent(subscription)}`;
+      if (params.resourceGroup) {
+        scope += `/resourceGroups/${encodeURIComponent(params.resourceGroup)}`;
+      }
+      const response = await client.get(`${scope}/resources`, {
+        params: {
+          'api-version': '2018-05-01',
+          $filter: params.resourceType
+            ? `resourceType eq '${params.resourceType.replace(/'/g, "''")}'`
+            : undefined,
+        },
+      });
+      return createToolResult((response.data.value || []).map((resource: any) => ({
+        id: resource.id,
+        name: resource.name,
+        type: resource.type,
+        location: resource.location,
+      })));
+    } catch (error: any) {
+      return createErrorResult(armError(error));
+    }
+  },
+};
+
+export const listAzureMetricDefinitions: ToolDefinition = {
+  name: 'list_azure_metric_definitions',
+  description: 'Lists the metrics of an Azure resource with their units, aggregations, and dimensions',
+  inputSchema: ListAzureMetricDefinitionsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      // The resource ID becomes part of the request path
+      if (!RESOURCE_URI.test(params.resourceUri) || params.resourceUri.includes('..')) {
+        return createErrorResult(RESOURCE_URI_HINT);
+      }
+      const client = createAzureClient(context.config.grafanaConfig, params.datasourceUid);
+      const response = await client.get(
+        `${params.resourceUri.replace(/\/$/, '')}/providers/microsoft.insights/metricdefinitions`,
+        {
+          params: { 'api-version': '2018-01-01', metricnamespace: params.metricNamespace },
+        }
+      );
+      return createToolResult((response.data.value || []).map((definition: any) => ({
+        name: definition.name?.value,
+        unit: definition.unit,
+        primaryAggregation: definition.primaryAggregationType,
+        aggregations: definition.supportedAggregationTypes,
+        dimensions: (definition.dimensions || []).map((dimension: any) => dimension.value),
+      })));
+    } catch (error: any) {
+      return createErrorResult(armError(error));
+    }
+  },
+};
+
+export const queryAzureLogAnalytics: ToolDefinition = {
+  name: 'query_azure_log_analytics',
+  description: 'Runs a KQL query against Log Analytics workspaces or Azure resources through an Azure Monitor datasource',
+  inputSchema: QueryAzureLogAnalyticsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+
+      const result = await runQuery(client, {
+        queries: [{
+          refId: 'A',
+          datasource: { uid: params.datasourceUid, type: 'grafana-azure-monitor-datasource' },
+          queryType: 'Azure Log Analytics',
+          azureLogAnalytics: {
+            query: params.query,
+            resources: params.resources,
+            resultFormat: 'table',
+            // Limit the query to the time range
+            dashboardTime: true,
+          },
+        }],
+        from: timeRange.start.getTime().toString(),
+        to: timeRange.end.getTime().toString(),
+      });
+
+      // Redact personal data and secrets when configured
+      const scrub = createLogScrubber(context.config.grafanaConfig.logScrubbing);
+      const tables = resultTables(result, context.timezone).map(table =>
+        scrub
+          ? {
+            ...table,
+            rows: table.rows.map(row =>
+              row.map(value => (typeof value === 'string' ? scrub(value) : value))
+            ),
+          }
+          : table
+      );
+
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables(tables, params.format));
+      }
+      return createToolResult(tables.map(table => ({ columns: table.columns, rows: table.rows })));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const queryAzureMetrics: ToolDefinition = {
+  name: 'query_azure_metrics',
+  description: 'Queries an Azure Monitor metric of a resource through an Azure Monitor datasource, returning its datapoints over a time range',
+  inputSchema: QueryAzureMetricsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const match = params.resourceUri.match(RESOURCE_URI);
+      if (!match) {
+        return createErrorResult(RESOURCE_URI_HINT);
+      }
+      const [, subscription, resourceGroup, resourceType, resourceName] = match;
+      const metricNamespace = params.metricNamespace || resourceType;
+
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+
+      const result = await runQuery(client, {
+        queries: [{
+          refId: 'A',
+          datasource: { uid: params.datasourceUid, type: 'grafana-azure-monitor-datasource' },
+          queryType: 'Azure Monitor',
+          subscription,
+          azureMonitor: {
+            resources: [{ subscription, resourceGroup, metricNamespace, resourceName }],
+            metricNamespace,
+            metricName: params.metricName,
+            aggregation: params.aggregation || 'Average',
+            timeGrain: params.timeGrain || 'auto',
+            dimensionFilters: Object.entries(params.dimensions || {}).map(
+              ([dimension, filters]) => ({ dimension, operator: 'eq', filters })
+            ),
+          },
+        }],
+        from: timeRange.start.getTime().toString(),
+        to: timeRange.end.getTime().toString(),
+      });
+
+      const tables = resultTables(result, context.timezone);
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables(tables, params.format));
+      }
+      return createToolResult(tables.map(table => ({ columns: table.columns, rows: table.rows })));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerAzureTools(server: any) {
+  server.registerTool(listAzureSubscriptions);
+  server.registerTool(listAzureWorkspaces);
+  server.registerTool(listAzureResources);
+  server.registerTool(listAzureMetricDefinitions);
+  server.registerTool(queryAzureLogAnalytics);
+  server.registerTool(queryAzureMetrics);
+}
@@ -0,0 +1,215 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { CloudClient } from '../clients/cloud-client';
+import { PrometheusClient } from '../clients/prometheus-client';
+import { checkDatasourceAccess } from '../server/datasource-policy';
+
+// Schema definitions
+const ListCloudStacksSchema = z.object({
+  orgSlug: z.string().optional().describe('The slug of the Grafana Cloud organization'),
+});
+
+const ListCloudAccessPoliciesSchema = z.object({
+  name: z.string().optional().describe('Filter access policies by name'),
+});
+
+const CreateCloudAccessPolicySchema = z.object({
+  name: z.string().describe('The name of the access policy (lowercase, no spaces)'),
+  displayName: z.string().optional().describe('The display name of the access policy'),
+  scopes: z.array(z.string()).describe('The scopes granted, e.g. "metrics:read", "logs:write"'),
+  realms: z.array(z.object({
+    type: z.enum(['org', 'stack']).describe('The type of realm'),
+    identifier: z.string().describe('The org ID or stack ID'),
+  })).describe('The orgs or stacks the policy applies to'),
+});
+
+const ListCloudTokensSchema = z.object({
+  accessPolicyId: z.string().optional().describe('Only list tokens of this access policy'),
+});
+
+const CreateCloudTokenSchema = z.object({
+  accessPolicyId: z.string().describe('The ID of the access policy the token belongs to'),
+  name: z.string().describe('The name of the token (lowercase, no spaces)'),
+  displayName: z.string().optional().describe('The display name of the token'),
+  expiresAt: z.string().optional().describe('Expiry of the token in RFC3339 format'),
+});
+
+const GetCloudUsageSchema = z.object({
+  datasourceUid: z.string().optional().describe('The UID of the Grafana Cloud usage datasource (default: grafanacloud-usage)'),
+});
+
+// Usage and billing series of the Grafana Cloud usage datasource. Their names differ between
+// products and change over time, so they are discovered rather than listed here.
+const CLOUD_USAGE_METRIC_MATCHER = '{__name__=~"grafanacloud_.*(usage|billable|active_series|overage).*"}';
+
+// Usage series reported at most, to keep the result readable
+const MAX_CLOUD_USAGE_METRICS = 50;
+
+// Tool definitions
+export const listCloudStacks: ToolDefinition = {
+  name: 'list_cloud_stacks',
+  description: 'List Grafana Cloud stacks with their URLs, region, and status',
+  inputSchema: ListCloudStacksSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new CloudClient(context.config.grafanaConfig);
+      const stacks = await client.listStacks(params.orgSlug);
+      
+      const formatted = stacks.map((stack: any) => ({
+        id: stack.id,
+        slug: stack.slug,
+        name: stack.name,
+        url: stack.url,
+        orgSlug: stack.orgSlug,
+        region: stack.regionSlug,
+        status: stack.status,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listCloudAccessPolicies: ToolDefinition = {
+  name: 'list_cloud_access_policies',
+  description: 'List Grafana Cloud access policies in the configured region, with their scopes and realms',
+  inputSchema: ListCloudAccessPoliciesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new CloudClient(context.config.grafanaConfig);
+      const policies = await client.listAccessPolicies(params.name);
+      
+      const formatted = policies.map(policy => ({
+        id: policy.id,
+        name: policy.name,
+        displayName: policy.displayName,
+        scopes: policy.scopes,
+        realms: policy.realms,
+        status: policy.status,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const createCloudAccessPolicy: ToolDefinition = {
+  name: 'create_cloud_access_policy',
+  description: 'Create a Grafana Cloud access policy granting scopes on orgs or stacks',
+  inputSchema: CreateCloudAccessPolicySchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new CloudClient(context.config.grafanaConfig);
+      const policy = await client.createAccessPolicy({
+        name: params.name,
+        displayName: params.displayName || params.name,
+        scopes: params.scopes,
+        realms: params.realms.map((realm: any) => ({ ...realm, labelPolicies: [] })),
+      });
+      
+      return createToolResult({
+        id: policy.id,
+        name: policy.name,
+        scopes: policy.scopes,
+        message: 'Access policy created successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listCloudTokens: ToolDefinition = {
+  name: 'list_cloud_tokens',
+  description: 'List Grafana Cloud access policy tokens (metadata only, never secrets)',
+  inputSchema: ListCloudTokensSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new CloudClient(context.config.grafanaConfig);
+      const tokens = await client.listTokens(params.accessPolicyId);
+      
+      const formatted = tokens.map(token => ({
+        id: token.id,
+        accessPolicyId: token.accessPolicyId,
+        name: token.name,
+        displayName: token.displayName,
+        expiresAt: token.expiresAt,
+        lastUsedAt: token.lastUsedAt,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const createCloudToken: ToolDefinition = {
+  name: 'create_cloud_token',
+  description: 'Create a token for a Grafana Cloud access policy. The token secret is only returned once',
+  inputSchema: CreateCloudTokenSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new CloudClient(context.config.grafanaConfig);
+      const token = await client.createToken({
+        accessPolicyId: params.accessPolicyId,
+        name: params.name,
+        displayName: params.displayName || params.name,
+        expiresAt: params.expiresAt,
+      });
+      
+      return createToolResult({
+        id: token.id,
+        name: token.name,
+        expiresAt: token.expiresAt,
+        key: token.key,
+        message: 'Token created. Store the key now; it cannot be retrieved again.',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getCloudUsage: ToolDefinition = {
+  name: 'get_cloud_usage',
+  description: 'Get current Grafana Cloud usage and billing values (billable series, usage, overage) from the grafanacloud_* series of the Grafana Cloud usage datasource, where available. Each series is summed across its labels',
+  inputSchema: GetCloudUsageSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const datasourceUid = params.datasourceUid || 'grafanacloud-usage';
+      await checkDatasourceAccess(context.config.grafanaConfig, [datasourceUid]);
+      const prometheus = new PrometheusClient(context.config.grafanaConfig, datasourceUid);
+      
+      const names = await prometheus.getLabelValues('__name__', [CLOUD_USAGE_METRIC_MATCHER]);
+      const metrics = names.sort().slice(0, MAX_CLOUD_USAGE_METRICS);
+      
+      const usage: Record<string, number | null> = {};
+      for (const name of metrics) {
+        const result = await prometheus.query(`sum(${name})`);
+        usage[name] = result.length > 0 && result[0].value ? parseFloat(result[0].value[1]) : null;
+      }
+      if (names.length > metrics.length) {
+        return createToolResult({ usage, truncated: true, totalMetrics: names.length });
+      }
+      return createToolResult(usage);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerCloudTools(server: any) {
+  server.registerTool(listCloudStacks);
+  server.registerTool(listCloudAccessPolicies);
+  server.registerTool(createCloudAccessPolicy);
+  server.registerTool(listCloudTokens);
+  server.registerTool(createCloudToken);
+  server.registerTool(getCloudUsage);
+}
@@ -0,0 +1,206 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+import { createPluginClient } from '../clients/base-client';
+import { parseTimeRange } from '../utils/time';
+import { renderTables, resultTables } from '../utils/table';
+
+// Schema definitions
+const ListGcmMetricDescriptorsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Google Cloud Monitoring datasource'),
+  projectName: z.string().optional().describe('The Google Cloud project (default: the datasource\'s default project)'),
+  service: z.string().optional().describe('Only list metrics of this service, e.g. "compute.googleapis.com"'),
+  query: z.string().optional().describe('Only list metrics whose type or name contains this text, e.g. "cpu"'),
+  limit: z.number().optional().describe('Maximum metrics to return (default: 100)'),
+});
+
+const QueryGcmTimeSeriesSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Google Cloud Monitoring datasource'),
+  metricType: z.string().describe('The metric type, e.g. "compute.googleapis.com/instance/cpu/utilization"'),
+  projectName: z.string().optional().describe('The Google Cloud project (default: the datasource\'s default project)'),
+  filters: z.record(z.string()).optional().describe('Label values to filter on, e.g. {"resource.label.zone": "us-central1-a", "metric.label.instance_name": "web-1"}'),
+  aligner: z.string().optional().describe('The per-series aligner, e.g. ALIGN_MEAN, ALIGN_RATE, ALIGN_DELTA, ALIGN_PERCENTILE_99 (default: ALIGN_MEAN)'),
+  reducer: z.string().optional().describe('The cross-series reducer, e.g. REDUCE_SUM or REDUCE_MEAN (default: REDUCE_NONE)'),
+  groupBy: z.array(z.string()).optional().describe('Labels to group by when reducing, e.g. ["resource.label.zone"]'),
+  alignmentPeriodSeconds: z.number().optional().describe('The alignment period in seconds (default: chosen from the time range)'),
+  startRfc3339: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time (default: now)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+const QueryGcmMqlSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Google Cloud Monitoring datasource'),
+  query: z.string().describe('The MQL query, e.g. "fetch gce_instance | metric \'compute.googleapis.com/instance/cpu/utilization\' | group_by 5m, mean(val())"'),
+  projectName: z.string().optional().describe('The Google Cloud project (default: the datasource\'s default project)'),
+  startRfc3339: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time (default: now)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+const DATASOURCE_TYPE = 'stackdriver';
+
+// Helper function to create a client for the resource API of a Google Cloud Monitoring
+// datasource
+function createGcmClient(config: any, datasourceUid: string) {
+  return createPluginClient(config, `${config.url}/api/datasources/uid/${datasourceUid}/resources`);
+}
+
+// Helper to resolve the project of a request: the given one, else the datasource default
+async function resolveProject(
+  context: ToolContext,
+  datasourceUid: string,
+  projectName?: string
+): Promise<string> {
+  if (projectName) return projectName;
+  const client = new GrafanaClient(context.config.grafanaConfig);
+  const datasource = await client.getDatasourceByUid(datasourceUid);
+  const fallback = datasource.jsonData?.defaultProject;
+  if (!fallback) {
+    throw new Error('The datasource has no default project; pass projectName');
+  }
+  return fallback;
+}
+
+// Helper to run a query through Grafana's query API, failing on the error of the query
+async function runQuery(client: GrafanaClient, body: any): Promise<any> {
+  const result = await client.queryDatasources(body);
+  const error = result?.results?.A?.error;
+  if (error) {
+    throw new Error(`Google Cloud Monitoring query failed: ${error}`);
+  }
+  return result;
+}
+
+// Tool definitions
+export const listGcmMetricDescriptors: ToolDefinition = {
+  name: 'list_gcm_metric_descriptors',
+  description: 'Lists the metric descriptors of a Google Cloud project through a Google Cloud Monitoring datasource: metric types with their kind, value type, unit, and labels',
+  inputSchema: ListGcmMetricDescriptorsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const project = await resolveProject(context, params.datasourceUid, params.projectName);
+      const client = createGcmClient(context.config.grafanaConfig, params.datasourceUid);
+      const response = await client.get(
+        `/metricDescriptors/v3/projects/${encodeURIComponent(project)}/metricDescriptors`,
+        {
+          params: {
+            filter: params.service
+              ? `metric.type = starts_with("${params.service.replace(/"/g, '')}/")`
+              : undefined,
+          },
+        }
+      );
+
+      const search = params.query?.toLowerCase();
+      const descriptors = (Array.isArray(response.data) ? response.data : [])
+        .filter((descriptor: any) =>
+          !search ||
+          String(descriptor.type).toLowerCase().includes(search) ||
+          String(descriptor.displayName || '').toLowerCase().includes(search)
+        )
+        .slice(0, params.limit || 100)
+        .map((descriptor: any) => ({
+          type: descriptor.type,
+          displayName: descriptor.displayName,
+          metricKind: descriptor.metricKind,
+          valueType: descriptor.valueType,
+          unit: descriptor.unit,
+          labels: (descriptor.labels || []).map((label: any) => label.key),
+        }));
+      return createToolResult(descriptors);
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.error || error.message);
+    }
+  },
+};
+
+export const queryGcmTimeSeries: ToolDefinition = {
+  name: 'query_gcm_time_series',
+  description: 'Queries a Google Cloud Monitoring metric by type and label filters, aligned per series and optionally reduced across series, returning its datapoints over a time range',
+  inputSchema: QueryGcmTimeSeriesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const project = await resolveProject(context, params.datasourceUid, params.projectName);
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+
+      // Filters are a flat list of key, operator, value triples joined by AND
+      const filters = ['metric.type', '=', params.metricType];
+      for (const [key, value] of Object.entries(params.filters || {})) {
+        filters.push('AND', key, '=', value as string);
+      }
+
+      const result = await runQuery(client, {
+        queries: [{
+          refId: 'A',
+          datasource: { uid: params.datasourceUid, type: DATASOURCE_TYPE },
+          queryType: 'timeSeriesList',
+          timeSeriesList: {
+            projectName: project,
+            filters,
+            perSeriesAligner: params.aligner || 'ALIGN_MEAN',
+            crossSeriesReducer: params.reducer || 'REDUCE_NONE',
+            groupBys: params.groupBy || [],
+            alignmentPeriod: params.alignmentPeriodSeconds
+              ? `+${params.alignmentPeriodSeconds}s`
+              : 'cloud-monitoring-auto',
+          },
+        }],
+        from: timeRange.start.getTime().toString(),
+        to: timeRange.end.getTime().toString(),
+      });
+
+      const tables = resultTables(result, context.timezone);
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables(tables, params.format));
+      }
+      return createToolResult(tables.map(table => ({ columns: table.columns, rows: table.rows })));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const queryGcmMql: ToolDefinition = {
+  name: 'query_gcm_mql',
+  description: 'Runs a Monitoring Query Language (MQL) query through a Google Cloud Monitoring datasource, for joins, ratios, and other computations beyond a single metric',
+  inputSchema: QueryGcmMqlSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const project = await resolveProject(context, params.datasourceUid, params.projectName);
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+
+      const result = await runQuery(client, {
+        queries: [{
+          refId: 'A',
+          datasource: { uid: params.datasourceUid, type: DATASOURCE_TYPE },
+          queryType: 'timeSeriesQuery',
+          timeSeriesQuery: { projectName: project, query: params.query },
+        }],
+        from: timeRange.start.getTime().toString(),
+        to: timeRange.end.getTime().toString(),
+      });
+
+      const tables = resultTables(result, context.timezone);
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables(tables, params.format));
+      }
+      return createToolResult(tables.map(table => ({ columns: table.columns, rows: table.rows })));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerCloudMonitoringTools(server: any) {
+  server.registerTool(listGcmMetricDescriptors);
+  server.registerTool(queryGcmTimeSeries);
+  server.registerTool(queryGcmMql);
+}
@@ -0,0 +1,298 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+import { createPluginClient } from '../clients/base-client';
+import { parseTimeRange } from '../utils/time';
+import { Table, renderTables, resultTables } from '../utils/table';
+import { createLogScrubber } from '../utils/scrub';
+
+// Schema definitions
+const ListCloudWatchNamespacesSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the CloudWatch datasource'),
+  region: z.string().optional().describe('The AWS region (default: the datasource\'s default region)'),
+});
+
+const ListCloudWatchMetricsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the CloudWatch datasource'),
+  namespace: z.string().describe('The namespace, e.g. "AWS/EC2"'),
+  region: z.string().optional().describe('The AWS region (default: the datasource\'s default region)'),
+});
+
+const ListCloudWatchDimensionsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the CloudWatch datasource'),
+  namespace: z.string().describe('The namespace, e.g. "AWS/EC2"'),
+  metricName: z.string().optional().describe('Only list dimensions of this metric'),
+  dimensionKey: z.string().optional().describe('List the values of this dimension instead of the dimension keys'),
+  region: z.string().optional().describe('The AWS region (default: the datasource\'s default region)'),
+});
+
+const QueryCloudWatchMetricsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the CloudWatch datasource'),
+  namespace: z.string().describe('The namespace, e.g. "AWS/EC2"'),
+  metricName: z.string().describe('The metric, e.g. "CPUUtilization"'),
+  dimensions: z.record(z.union([z.string(), z.array(z.string())])).optional().describe('Dimension values to filter on, e.g. {"InstanceId": "i-0abc"}; "*" matches any value'),
+  statistic: z.string().optional().describe('The statistic: Average, Sum, Minimum, Maximum, SampleCount, or a percentile like p99 (default: Average)'),
+  periodSeconds: z.number().optional().describe('The period of the datapoints in seconds (default: chosen from the time range)'),
+  matchExact: z.boolean().optional().describe('Only match metrics with exactly these dimensions (default: true)'),
+  region: z.string().optional().describe('The AWS region (default: the datasource\'s default region)'),
+  startRfc3339: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time (default: now)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+const QueryCloudWatchLogsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the CloudWatch datasource'),
+  query: z.string().describe('The Logs Insights query, e.g. "fields @timestamp, @message | filter @message like /ERROR/ | sort @timestamp desc | limit 20"'),
+  logGroups: z.array(z.string()).describe('The log group names or ARNs to query'),
+  region: z.string().optional().describe('The AWS region (default: the datasource\'s default region)'),
+  startRfc3339: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time (default: now)'),
+  timeoutSeconds: z.number().optional().describe('How long to wait for the query to complete (default: 30, max: 120)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+// Logs Insights query states after which no more results arrive
+const TERMINAL_LOGS_STATES = ['Complete', 'Failed', 'Cancelled', 'Timeout', 'Unknown'];
+
+// Helper function to create a client for the resource API of a CloudWatch datasource
+function createCloudWatchClient(config: any, datasourceUid: string) {
+  return createPluginClient(config, `${config.url}/api/datasources/uid/${datasourceUid}/resources`);
+}
+
+// Helper to read the values of a resource response; Grafana 10+ wraps each in { value }
+function resourceValues(data: any[]): any[] {
+  return (data || []).map(item =>
+    item && typeof item === 'object' && 'value' in item ? item.value : item?.text ?? item
+  );
+}
+
+// Helper to run queries through Grafana's query API, failing on the error of a query
+async function runQuery(client: GrafanaClient, body: any): Promise<any> {
+  const result = await client.queryDatasources(body);
+  const error = result?.results?.A?.error;
+  if (error) {
+    throw new Error(`CloudWatch query failed: ${error}`);
+  }
+  return result;
+}
+
+// Helper to drop the internal fields Grafana adds to log frames
+function withoutInternalFields(table: Table): Table {
+  const keep = table.columns.map(column => !column.includes('grafana_internal'));
+  return {
+    ...table,
+    columns: table.columns.filter((_, i) => keep[i]),
+    rows: table.rows.map(row => row.filter((_, i) => keep[i])),
+  };
+}
+
+// Tool definitions
+export const listCloudWatchNamespaces: ToolDefinition = {
+  name: 'list_cloudwatch_namespaces',
+  description: 'Lists the CloudWatch metric namespaces available to a CloudWatch datasource, including custom namespaces configured on it',
+  inputSchema: ListCloudWatchNamespacesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createCloudWatchClient(context.config.grafanaConfig, params.datasourceUid);
+      const response = await client.get('/namespaces', {
+        params: { region: params.region || 'default' },
+      });
+      return createToolResult(resourceValues(response.data));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const listCloudWatchMetrics: ToolDefinition = {
+  name: 'list_cloudwatch_metrics',
+  description: 'Lists the metrics of a CloudWatch namespace',
+  inputSchema: ListCloudWatchMetricsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createCloudWatchClient(context.config.grafanaConfig, params.datasourceUid);
+      const response = await client.get('/metrics', {
+        params: { namespace: params.namespace, region: params.region || 'default' },
+      });
+      const metrics = resourceValues(response.data).map(metric =>
+        typeof metric === 'object' ? metric.name : metric
+      );
+      return createToolResult(metrics);
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const listCloudWatchDimensions: ToolDefinition = {
+  name: 'list_cloudwatch_dimensions',
+  description: 'Lists the dimension keys of a CloudWatch namespace or metric, or the values of one dimension when dimensionKey is given',
+  inputSchema: ListCloudWatchDimensionsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createCloudWatchClient(context.config.grafanaConfig, params.datasourceUid);
+      const query = {
+        namespace: params.namespace,
+        metricName: params.metricName,
+        region: params.region || 'default',
+      };
+      const response = params.dimensionKey
+        ? await client.get('/dimension-values', {
+          params: { ...query, dimensionKey: params.dimensionKey },
+        })
+        : await client.get('/dimension-keys', { params: query });
+      return createToolResult(resourceValues(response.data));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const queryCloudWatchMetrics: ToolDefinition = {
+  name: 'query_cloudwatch_metrics',
+  description: 'Queries a CloudWatch metric through a CloudWatch datasource, returning its datapoints per matching dimension set over a time range',
+  inputSchema: QueryCloudWatchMetricsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+      const dimensions = Object.fromEntries(
+        Object.entries(params.dimensions || {}).map(([key, value]) => [
+          key,
+          Array.isArray(value) ? value : [value],
+        ])
+      );
+
+      const result = await runQuery(client, {
+        queries: [{
+          refId: 'A',
+          datasource: { uid: params.datasourceUid, type: 'cloudwatch' },
+          queryMode: 'Metrics',
+          // Metric search in the builder editor
+          metricQueryType: 0,
+          metricEditorMode: 0,
+          region: params.region || 'default',
+          namespace: params.namespace,
+          metricName: params.metricName,
+          dimensions,
+          statistic: params.statistic || 'Average',
+          period: params.periodSeconds ? String(params.periodSeconds) : '',
+          matchExact: params.matchExact ?? true,
+          id: '',
+          expression: '',
+        }],
+        from: timeRange.start.getTime().toString(),
+        to: timeRange.end.getTime().toString(),
+      });
+
+      const tables = resultTables(result, context.timezone);
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables(tables, params.format));
+      }
+      return createToolResult(tables.map(table => ({ columns: table.columns, rows: table.rows })));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const queryCloudWatchLogs: ToolDefinition = {
+  name: 'query_cloudwatch_logs',
+  description: 'Runs a CloudWatch Logs Insights query through a CloudWatch datasource and waits for it to complete. Logs Insights queries run asynchronously; if one does not complete within the timeout it is stopped and the results so far are returned',
+  inputSchema: QueryCloudWatchLogsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+      const region = params.region || 'default';
+      const logQuery = (subtype: string, fields: Record<string, any>) => ({
+        queries: [{
+          refId: 'A',
+          datasource: { uid: params.datasourceUid, type: 'cloudwatch' },
+          queryMode: 'Logs',
+          type: 'logAction',
+          subtype,
+          region,
+          ...fields,
+        }],
+        from: timeRange.start.getTime().toString(),
+        to: timeRange.end.getTime().toString(),
+      });
+
+      // Log groups are given by name, or by ARN for cross-account observability
+      const arns = params.logGroups.filter((group: string) => group.startsWith('arn:'));
+      const started = await runQuery(client, logQuery('StartQuery', {
+        expression: params.query,
+        logGroupNames: params.logGroups.filter((group: string) => !group.startsWith('arn:')),
+        logGroups: arns.map((arn: string) => ({ arn })),
+      }));
+      const frame = started.results.A.frames?.[0];
+      const queryIdField = (frame?.schema?.fields || [])
+        .findIndex((field: any) => field.name === 'queryId');
+      const queryId = frame?.data?.values?.[queryIdField]?.[0];
+      if (!queryId) {
+        return createErrorResult('CloudWatch did not return a Logs Insights query ID');
+      }
+
+      const deadline = Date.now() + Math.min(params.timeoutSeconds || 30, 120) * 1000;
+      let result: any;
+      let status = 'Unknown';
+      for (let attempt = 0; ; attempt++) {
+        result = await runQuery(client, logQuery('GetQueryResults', { queryId }));
+        status = result.results.A.frames?.[0]?.schema?.meta?.custom?.Status || 'Unknown';
+        await context.sendProgress(attempt + 1, undefined, `Logs Insights query ${status}`);
+        if (TERMINAL_LOGS_STATES.includes(status)) break;
+        if (Date.now() >= deadline || context.signal?.aborted) {
+          // Stop the query so it does not keep scanning (and billing) in the background
+          await runQuery(client, logQuery('StopQuery', { queryId })).catch(() => undefined);
+          break;
+        }
+        // Poll quickly at first, then back off
+        await new Promise(resolve => setTimeout(resolve, Math.min(500 * 2 ** attempt, 5000)));
+      }
+
+      if (status === 'Failed') {
+        return createErrorResult(`Logs Insights query ${queryId} failed`);
+      }
+
+      // Redact personal data and secrets when configured
+      const scrub = createLogScrubber(context.config.grafanaConfig.logScrubbing);
+      const tables = resultTables(result, context.timezone).map(table => {
+        const visible = withoutInternalFields(table);
+        return scrub
+          ? {
+            ...visible,
+            rows: visible.rows.map(row =>
+              row.map(value => (typeof value === 'string' ? scrub(value) : value))
+            ),
+          }
+          : visible;
+      });
+
+      if (params.format && params.format !== 'json') {
+        return createToolResult(`Status: ${status}\n\n${renderTables(tables, params.format)}`);
+      }
+      return createToolResult({
+        queryId,
+        status,
+        tables: tables.map(table => ({ columns: table.columns, rows: table.rows })),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerCloudWatchTools(server: any) {
+  server.registerTool(listCloudWatchNamespaces);
+  server.registerTool(listCloudWatchMetrics);
+  server.registerTool(listCloudWatchDimensions);
+  server.registerTool(queryCloudWatchMetrics);
+  server.registerTool(queryCloudWatchLogs);
+}
@@ -1,11 +1,24 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
 import { GrafanaClient } from '../clients/grafana-client';
+import { projectFields } from '../utils/fields';
+import { checkDatasourceAccess } from '../server/datasource-policy';
+import { TimeRange, formatTime, parseTimeRange } from '../utils/time';
+import { formatLabels, renderTables, resultTables } from '../utils/table';
+import { createLogScrubber, scrubFrames } from '../utils/scrub';
+import {
+  checkDashboardWrite,
+  checkFolderWrite,
+  checkOverwriteWrite,
+  getOverwrittenDashboards,
+  isFolderWritable,
+} from '../server/folder-policy';
 import * as jsonpath from 'jsonpath';
 
 // Schema definitions
 const GetDashboardByUidSchema = z.object({
   uid: z.string().describe('The UID of the dashboard'),
+  fields: z.array(z.string()).optional().describe('Only return these fields, as dotted paths (e.g. ["title", "panels.title"]); default: all fields'),
 });
 
 const GetDashboardSummarySchema = z.object({
@@ -34,6 +47,460 @@ const UpdateDashboardSchema = z.object({
   overwrite: z.boolean().optional().describe('Overwrite the dashboard if it exists'),
 });
 
+const ListPublicDashboardsSchema = z.object({});
+
+const GetPublicDashboardSchema = z.object({
+  dashboardUid: z.string().describe('The UID of the dashboard'),
+});
+
+const EnablePublicDashboardSchema = z.object({
+  dashboardUid: z.string().describe('The UID of the dashboard to share publicly'),
+  timeSelectionEnabled: z.boolean().optional().describe('Allow viewers to change the time range (default: false)'),
+  annotationsEnabled: z.boolean().optional().describe('Show annotations to viewers (default: false)'),
+});
+
+const DisablePublicDashboardSchema = z.object({
+  dashboardUid: z.string().describe('The UID of the dashboard to stop sharing'),
+});
+
+const CreateDashboardSnapshotSchema = z.object({
+  uid: z.string().describe('The UID of the dashboard to snapshot'),
+  name: z.string().optional().describe('The name of the snapshot (default: dashboard title and time)'),
+  from: z.string().optional().describe('Start of the captured time range, e.g. "2024-01-01T10:00:00Z" (default: dashboard time range)'),
+  to: z.string().optional().describe('End of the captured time range (default: dashboard time range)'),
+  expiresIn: z.number().optional().describe('Seconds until the snapshot is deleted; 0 keeps it forever (default: 0)'),
+});
+
+const ListDashboardSnapshotsSchema = z.object({
+  query: z.string().optional().describe('Filter snapshots by name'),
+  limit: z.number().optional().describe('Maximum number of snapshots to return (default: 100)'),
+});
+
+const DeleteDashboardSnapshotSchema = z.object({
+  key: z.string().describe('The key of the snapshot to delete'),
+});
+
+const ImportCatalogDashboardSchema = z.object({
+  gnetId: z.number().describe('The grafana.com dashboard ID, e.g. 1860 for Node Exporter Full'),
+  revision: z.number().optional().describe('The dashboard revision (default: latest)'),
+  datasources: z.record(z.string()).optional().describe('Map of dashboard datasource input name (e.g. "DS_PROMETHEUS") to datasource UID. Unmapped inputs use the default, or first, datasource of the required type'),
+  folderUid: z.string().optional().describe('The UID of the folder to import into'),
+  overwrite: z.boolean().optional().describe('Overwrite an existing dashboard with the same UID (default: false)'),
+});
+
+const ExportDashboardSchema = z.object({
+  uid: z.string().describe('The UID of the dashboard to export'),
+  format: z.enum(['json', 'terraform', 'grafonnet']).optional().describe('Output format: provisioning JSON, a Terraform grafana_dashboard resource, or a Grafonnet skeleton (default: json)'),
+  externalizeDatasources: z.boolean().optional().describe('Replace datasource UIDs with ${DS_*} inputs so the dashboard can be imported elsewhere (default: true, except for terraform, which cannot resolve inputs)'),
+});
+
+const BulkUpdateDashboardsSchema = z.object({
+  query: z.string().optional().describe('Only include dashboards whose title matches this search'),
+  tags: z.array(z.string()).optional().describe('Only include dashboards with all of these tags'),
+  folderUids: z.array(z.string()).optional().describe('Only include dashboards in these folders'),
+  addTags: z.array(z.string()).optional().describe('Tags to add to each dashboard'),
+  removeTags: z.array(z.string()).optional().describe('Tags to remove from each dashboard'),
+  moveToFolderUid: z.string().optional().describe('Move each dashboard to this folder'),
+  replaceDatasourceUid: z.object({
+    from: z.string().describe('The datasource UID to replace'),
+    to: z.string().describe('The datasource UID to use instead'),
+  }).optional().describe('Replace references to one datasource with another'),
+  dryRun: z.boolean().optional().describe('Only report what would change without saving (default: true)'),
+  limit: z.number().optional().describe('Maximum number of dashboards to change (default: 100)'),
+});
+
+const QueryPanelDataSchema = z.object({
+  uid: z.string().describe('The UID of the dashboard'),
+  panelId: z.number().describe('The ID of the panel'),
+  startTime: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-1h"; default: the dashboard\'s time range)'),
+  endTime: z.string().optional().describe('The end time (default: the dashboard\'s time range)'),
+  variables: z.record(z.union([z.string(), z.array(z.string())])).optional().describe('Values of dashboard variables overriding their current values, e.g. {"service": "checkout"} or {"pod": ["a", "b"]}'),
+  maxDataPoints: z.number().optional().describe('Maximum data points per series (default: 500)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or compact CSV or markdown tables of the result frames (default: json)'),
+});
+
+const PanelComparisonSideSchema = z.object({
+  startTime: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-1h"; default: the dashboard\'s time range)'),
+  endTime: z.string().optional().describe('The end time (default: the dashboard\'s time range)'),
+  version: z.number().optional().describe('A saved version of the dashboard (default: the current one). Only the current version can be rendered; other versions are compared by their data'),
+});
+
+const ComparePanelRendersSchema = z.object({
+  uid: z.string().describe('The UID of the dashboard'),
+  panelId: z.number().describe('The ID of the panel'),
+  before: PanelComparisonSideSchema.describe('The time range or dashboard version to compare from, e.g. {"startTime": "now-7d-1h", "endTime": "now-7d"}'),
+  after: PanelComparisonSideSchema.describe('The time range or dashboard version to compare with, e.g. {"startTime": "now-1h"}'),
+  variables: z.record(z.union([z.string(), z.array(z.string())])).optional().describe('Values of dashboard variables overriding their current values, e.g. {"service": "checkout"}'),
+  render: z.boolean().optional().describe('Render both sides as images (default: true); false only compares the data'),
+  width: z.number().optional().describe('Image width in pixels (default: 1000)'),
+  height: z.number().optional().describe('Image height in pixels (default: 500)'),
+  maxDataPoints: z.number().optional().describe('Maximum data points per series (default: 500)'),
+});
+
+const ListStarredDashboardsSchema = z.object({});
+
+const StarDashboardSchema = z.object({
+  uid: z.string().describe('The UID of the dashboard'),
+  starred: z.boolean().optional().describe('Whether to star (true) or unstar (false) the dashboard (default: true)'),
+});
+
+// Helper function to replace datasource UID references, returning the number replaced
+function replaceDatasourceRefs(node: any, from: string, to: string): number {
+  if (Array.isArray(node)) {
+    return node.reduce((count, child) => count + replaceDatasourceRefs(child, from, to), 0);
+  }
+  if (!node || typeof node !== 'object') return 0;
+  
+  let count = 0;
+  if (node.datasource && typeof node.datasource === 'object' && node.datasource.uid === from) {
+    node.datasource.uid = to;
+    count++;
+  }
+  for (const [key, value] of Object.entries(node)) {
+    if (key !== 'datasource') {
+      count += replaceDatasourceRefs(value, from, to);
+    }
+  }
+  return count;
+}
+
+// Panel types with a Grafonnet builder
+const GRAFONNET_PANELS: Record<string, string> = {
+  timeseries: 'timeSeries',
+  stat: 'stat',
+  gauge: 'gauge',
+  bargauge: 'barGauge',
+  barchart: 'barChart',
+  table: 'table',
+  text: 'text',
+  logs: 'logs',
+  heatmap: 'heatmap',
+  piechart: 'pieChart',
+  histogram: 'histogram',
+  row: 'row',
+};
+
+// Helper function to convert a dashboard to provisioning-ready form
+function toProvisioningDashboard(dashboard: any, externalize: boolean) {
+  const exported = JSON.parse(JSON.stringify(dashboard));
+  delete exported.id;
+  delete exported.version;
+  delete exported.iteration;
+  
+  if (!externalize) return exported;
+  
+  const inputs = new Map<string, any>();
+  const visit = (node: any) => {
+    if (Array.isArray(node)) {
+      node.forEach(visit);
+      return;
+    }
+    if (!node || typeof node !== 'object') return;
+    
+    const ds = node.datasource;
+    if (ds && typeof ds === 'object' && ds.uid && ds.type && !String(ds.uid).startsWith('$')) {
+      const name = `DS_${String(ds.type).toUpperCase().replace(/[^A-Z0-9]/g, '_')}`;
+      const key = `${ds.type}/${ds.uid}`;
+      if (!inputs.has(key)) {
+        const suffix = Array.from(inputs.values()).filter(i => i.pluginId === ds.type).length;
+        inputs.set(key, {
+          name: suffix > 0 ? `${name}_${suffix}` : name,
+          label: ds.type,
+          type: 'datasource',
+          pluginId: ds.type,
+        });
+      }
+      node.datasource = { ...ds, uid: `\${${inputs.get(key).name}}` };
+    }
+    Object.values(node).forEach(visit);
+  };
+  visit(exported.panels);
+  visit(exported.templating);
+  visit(exported.annotations);
+  
+  return { __inputs: Array.from(inputs.values()), ...exported };
+}
+
+// Helper function to render a Terraform resource for a dashboard
+function toTerraform(dashboard: any, folderUid?: string): string {
+  const name = String(dashboard.uid || dashboard.title).toLowerCase().replace(/[^a-z0-9_]/g, '_');
+  const lines = [
+    `resource "grafana_dashboard" "${name}" {`,
+    folderUid ? `  folder      = "${folderUid}"` : undefined,
+    `  config_json = file("\${path.module}/dashboards/${dashboard.uid}.json")`,
+    '}',
+  ];
+  return lines.filter(line => line !== undefined).join('\n');
+}
+
+// Helper function to render a Grafonnet skeleton for a dashboard
+function toGrafonnet(dashboard: any): string {
+  const panels = (dashboard.panels || []).map((panel: any) => {
+    const builder = GRAFONNET_PANELS[panel.type];
+    if (!builder) {
+      return `    // Unsupported panel type "${panel.type}": ${JSON.stringify(panel.title || '')}`;
+    }
+    
+    const parts = [`g.panel.${builder}.new(${JSON.stringify(panel.title || '')})`];
+    if (panel.gridPos) {
+      parts.push(`g.panel.${builder}.gridPos.withH(${panel.gridPos.h}) + g.panel.${builder}.gridPos.withW(${panel.gridPos.w})`);
+    }
+    const targets = (panel.targets || [])
+      .filter((t: any) => t.expr)
+      .map((t: any) => `g.query.prometheus.new(${JSON.stringify(t.datasource?.uid || panel.datasource?.uid || '')}, ${JSON.stringify(t.expr)})`);
+    if (targets.length > 0 && builder !== 'row' && builder !== 'text') {
+      parts.push(`g.panel.${builder}.queryOptions.withTargets([\n      ${targets.join(',\n      ')},\n    ])`);
+    }
+    return `    ${parts.join('\n    + ')},`;
+  });
+  
+  return [
+    "local g = import 'github.com/grafana/grafonnet/gen/grafonnet-latest/main.libsonnet';",
+    '',
+    `g.dashboard.new(${JSON.stringify(dashboard.title || '')})`,
+    `+ g.dashboard.withUid(${JSON.stringify(dashboard.uid || '')})`,
+    `+ g.dashboard.withTags(${JSON.stringify(dashboard.tags || [])})`,
+    '+ g.dashboard.withPanels([',
+    ...panels,
+    '])',
+  ].join('\n');
+}
+
+// Helper function to resolve the values of dashboard variables: their current values, overridden
+// by the given ones, with "All" expanded to the custom all value or every option
+function variableValues(
+  dashboard: any,
+  overrides: Record<string, string | string[]> = {}
+): Record<string, string[]> {
+  const values: Record<string, string[]> = {};
+  for (const variable of dashboard.templating?.list || []) {
+    const current = overrides[variable.name] ?? variable.current?.value;
+    if (current === undefined || current === null) continue;
+    let list = (Array.isArray(current) ? current : [current]).map(String);
+    if (list.includes('$__all')) {
+      list = variable.allValue
+        ? [variable.allValue]
+        : (variable.options || [])
+          .map((option: any) => String(option.value))
+          .filter((value: string) => value !== '$__all');
+    }
+    values[variable.name] = list;
+  }
+  for (const [name, value] of Object.entries(overrides)) {
+    values[name] ??= Array.isArray(value) ? value : [value];
+  }
+  return values;
+}
+
+// Helper function to format a variable's values the way Grafana does for a ${name:format}
+// reference; multiple values default to a regex alternation as used in PromQL and LogQL
+function formatVariable(values: string[], format?: string): string {
+  const escapeRegex = (value: string) => value.replace(/[\\^$*+?.()|[\]{}]/g, '\\$&');
+  switch (format) {
+    case 'csv':
+    case 'raw':
+      return values.join(',');
+    case 'pipe':
+      return values.join('|');
+    case 'glob':
+      return values.length > 1 ? `{${values.join(',')}}` : values[0];
+    case 'regex':
+      return values.length > 1 ? `(${values.map(escapeRegex).join('|')})` : escapeRegex(values[0]);
+    default:
+      return values.length > 1 ? `(${values.map(escapeRegex).join('|')})` : values[0];
+  }
+}
+
+// Helper function to interpolate dashboard variables ($name, ${name}, ${name:format}, and
+// [[name]]) in every string of a query. Grafana's global variables such as $__interval are
+// left for the datasource to resolve.
+function interpolateVariables(node: any, values: Record<string, string[]>): any {
+  if (typeof node === 'string') {
+    return node.replace(
+      /\$\{(\w+)(?::(\w+))?\}|\[\[(\w+)\]\]|\$(\w+)/g,
+      (match, braced, format, bracketed, plain) => {
+        const name = braced || bracketed || plain;
+        const value = values[name];
+        return value && value.length > 0 ? formatVariable(value, format) : match;
+      }
+    );
+  }
+  if (Array.isArray(node)) return node.map(item => interpolateVariables(item, values));
+  if (node && typeof node === 'object') {
+    return Object.fromEntries(
+      Object.entries(node).map(([key, value]) => [key, interpolateVariables(value, values)])
+    );
+  }
+  return node;
+}
+
+// Helper function to find a panel of a dashboard, including the panels of collapsed rows
+function findPanel(dashboard: any, panelId: number): any {
+  const panels = (dashboard.panels || []).flatMap((panel: any) =>
+    panel.type === 'row' ? [panel, ...(panel.panels || [])] : [panel]
+  );
+  return panels.find((p: any) => p.id === panelId);
+}
+
+// Helper function to run the queries of a panel against its datasources, with dashboard
+// variables interpolated
+async function runPanelQueries(
+  context: ToolContext,
+  client: GrafanaClient,
+  dashboard: any,
+  panel: any,
+  options: {
+    variables?: Record<string, string | string[]>;
+    range: TimeRange;
+    maxDataPoints: number;
+  }
+): Promise<{ queries: any[]; result: any }> {
+  const targets = (panel.targets || []).filter((target: any) => !target.hide);
+  if (targets.length === 0) {
+    throw new Error(`Panel ${panel.id} has no queries`);
+  }
+
+  const { range, maxDataPoints } = options;
+  const values = variableValues(dashboard, options.variables);
+  const intervalMs = Math.max(
+    Math.ceil((range.end.getTime() - range.start.getTime()) / maxDataPoints),
+    1000
+  );
+
+  // Targets use the panel's datasource unless they set their own (mixed panels); legacy
+  // dashboards refer to datasources by name, and no datasource means the default one
+  let defaultDatasource: Promise<any> | undefined;
+  const resolveDatasource = async (ref: any) => {
+    const interpolated = interpolateVariables(ref, values);
+    if (interpolated && typeof interpolated === 'object' && interpolated.uid) {
+      return { uid: interpolated.uid, type: interpolated.type };
+    }
+    if (typeof interpolated === 'string' && interpolated !== '') {
+      const datasource = await client.getDatasourceByName(interpolated);
+      return { uid: datasource.uid, type: datasource.type };
+    }
+    defaultDatasource ??= client.listDatasources().then(list => list.find(ds => ds.isDefault));
+    const datasource = await defaultDatasource;
+    if (!datasource) {
+      throw new Error('The panel has no datasource and there is no default datasource');
+    }
+    return { uid: datasource.uid, type: datasource.type };
+  };
+
+  const queries = await Promise.all(targets.map(async (target: any) => {
+    const datasource = await resolveDatasource(target.datasource || panel.datasource);
+    return {
+      ...interpolateVariables(target, values),
+      datasource,
+      maxDataPoints,
+      intervalMs,
+    };
+  }));
+
+  // Server-side expressions query no datasource of their own
+  const uids = [...new Set(queries.map(query => query.datasource.uid))]
+    .filter(uid => uid !== '__expr__');
+  await checkDatasourceAccess(context.config.grafanaConfig, uids);
+
+  const result = await client.queryDatasources({
+    queries,
+    from: range.start.getTime().toString(),
+    to: range.end.getTime().toString(),
+  });
+  // Log panels return lines like the log tools do, so they are redacted the same way
+  const scrub = createLogScrubber(context.config.grafanaConfig.logScrubbing);
+  return { queries, result: scrub ? scrubFrames(result, scrub) : result };
+}
+
+// Helper function to summarize the numeric fields of query result frames, keyed by refId, field
+// name, and labels
+function seriesStats(result: any): Map<string, Record<string, number>> {
+  const stats = new Map<string, Record<string, number>>();
+  for (const [refId, query] of Object.entries<any>(result?.results || {})) {
+    for (const frame of query.frames || []) {
+      const fields: any[] = frame.schema?.fields || [];
+      fields.forEach((field, i) => {
+        if (field.type !== 'number') return;
+        const values = (frame.data?.values?.[i] || [])
+          .filter((value: any) => typeof value === 'number' && isFinite(value));
+        if (values.length === 0) return;
+        const name = field.config?.displayNameFromDS || field.name || 'Value';
+        stats.set(`${refId}: ${name}${formatLabels(field.labels)}`, {
+          mean: values.reduce((sum: number, value: number) => sum + value, 0) / values.length,
+          min: Math.min(...values),
+          max: Math.max(...values),
+          last: values[values.length - 1],
+          count: values.length,
+        });
+      });
+    }
+  }
+  return stats;
+}
+
+// Helper function to pair the series of two results and compute the change of each statistic
+function seriesDeltas(before: any, after: any) {
+  const beforeStats = seriesStats(before);
+  const afterStats = seriesStats(after);
+  const keys = [...new Set([...beforeStats.keys(), ...afterStats.keys()])];
+  return keys.map(series => {
+    const from = beforeStats.get(series);
+    const to = afterStats.get(series);
+    if (!from || !to) {
+      return { series, before: from, after: to, note: `Only ${from ? 'before' : 'after'}` };
+    }
+    const delta = Object.fromEntries(
+      ['mean', 'min', 'max', 'last'].map(stat => [stat, to[stat] - from[stat]])
+    );
+    const meanChangePercent = from.mean !== 0
+      ? Math.round(((to.mean - from.mean) / Math.abs(from.mean)) * 10000) / 100
+      : undefined;
+    return { series, before: from, after: to, delta, meanChangePercent };
+  });
+}
+
+// Helper function to build the renderer parameters of dashboard variable overrides
+function renderVariables(variables: Record<string, string | string[]> = {}) {
+  return Object.fromEntries(
+    Object.entries(variables).map(([name, value]) => [`var-${name}`, value])
+  );
+}
+
+// Helper function to convert the frames of a query result (schema plus columnar values) to the
+// frames panels of a snapshot render from, with the values inside each field
+function snapshotFrames(result: any): any[] {
+  return Object.entries<any>(result?.results || {}).flatMap(([refId, query]) =>
+    (query.frames || []).map((frame: any) => ({
+      name: frame.schema?.name,
+      refId: frame.schema?.refId || refId,
+      meta: frame.schema?.meta,
+      fields: (frame.schema?.fields || []).map((field: any, i: number) => ({
+        name: field.name,
+        type: field.type,
+        config: field.config || {},
+        labels: field.labels,
+        values: frame.data?.values?.[i] || [],
+      })),
+    }))
+  );
+}
+
+// Helper function to build the public URL of a shared dashboard
+function publicDashboardUrl(baseUrl: string, accessToken?: string): string | undefined {
+  return accessToken ? `${baseUrl.replace(/\/$/, '')}/public-dashboards/${accessToken}` : undefined;
+}
+
+// Helper function to find an existing public dashboard configuration
+async function findPublicDashboard(client: GrafanaClient, dashboardUid: string): Promise<any> {
+  try {
+    const publicDashboard = await client.getPublicDashboard(dashboardUid);
+    return publicDashboard?.uid ? publicDashboard : undefined;
+  } catch (error: any) {
+    if (error.message.includes('(404)')) return undefined;
+    throw error;
+  }
+}
+
 // Tool definitions
 export const getDashboardByUid: ToolDefinition = {
   name: 'get_dashboard_by_uid',
@@ -43,7 +510,7 @@ export const getDashboardByUid: ToolDefinition = {
     try {
       const client = new GrafanaClient(context.config.grafanaConfig);
       const dashboard = await client.getDashboardByUid(params.uid);
-      return createToolResult(dashboard);
+      return createToolResult(projectFields(dashboard, params.fields));
     } catch (error: any) {
       return createErrorResult(error.message);
     }
@@ -134,6 +601,7 @@ export const updateDashboard: ToolDefinition = {
   name: 'update_dashboard',
   description: 'Create or update a dashboard using either full JSON or efficient patch operations',
   inputSchema: UpdateDashboardSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true },
   handler: async (params, context: ToolContext) => {
     try {
       const client = new GrafanaClient(context.config.grafanaConfig);
@@ -179,7 +647,18 @@ export const updateDashboard: ToolDefinition = {
         return createErrorResult('Either dashboard or uid+operations must be provided');
       }
       
-      const result = await client.updateDashboard(dashboard, params.message);
+      // Grafana saves over the dashboard matching the body's UID or ID, so the folders of both
+      // must be writable; existing dashboards stay in their folder unless a new one is given
+      const config = context.config.grafanaConfig;
+      const uid = dashboard.uid || params.uid;
+      const overwritten = await getOverwrittenDashboards(client, { uid, id: dashboard.id });
+      for (const existing of overwritten) {
+        checkFolderWrite(config, existing.folderUid, `dashboard "${existing.uid}"`);
+      }
+      const folderUid = params.folderUid ?? overwritten[0]?.folderUid;
+      checkFolderWrite(config, folderUid, `dashboard "${uid || dashboard.title}"`);
+      
+      const result = await client.updateDashboard(dashboard, params.message, folderUid);
       return createToolResult(result);
     } catch (error: any) {
       return createErrorResult(error.message);
@@ -187,10 +666,644 @@ export const updateDashboard: ToolDefinition = {
   },
 };
 
+export const listPublicDashboards: ToolDefinition = {
+  name: 'list_public_dashboards',
+  description: 'List dashboards that are shared publicly, with their public URLs and whether sharing is enabled',
+  inputSchema: ListPublicDashboardsSchema,
+  handler: async (_params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const result = await client.listPublicDashboards();
+      
+      const formatted = (result.publicDashboards || []).map((pd: any) => ({
+        uid: pd.uid,
+        dashboardUid: pd.dashboardUid,
+        title: pd.title,
+        isEnabled: pd.isEnabled,
+        url: publicDashboardUrl(context.config.grafanaConfig.url, pd.accessToken),
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getPublicDashboard: ToolDefinition = {
+  name: 'get_public_dashboard',
+  description: 'Get the public sharing configuration and public URL of a dashboard',
+  inputSchema: GetPublicDashboardSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const publicDashboard = await findPublicDashboard(client, params.dashboardUid);
+      
+      if (!publicDashboard) {
+        return createToolResult({
+          dashboardUid: params.dashboardUid,
+          isEnabled: false,
+          message: 'Dashboard is not shared publicly',
+        });
+      }
+      
+      return createToolResult({
+        uid: publicDashboard.uid,
+        dashboardUid: publicDashboard.dashboardUid,
+        isEnabled: publicDashboard.isEnabled,
+        timeSelectionEnabled: publicDashboard.timeSelectionEnabled,
+        annotationsEnabled: publicDashboard.annotationsEnabled,
+        share: publicDashboard.share,
+        url: publicDashboardUrl(context.config.grafanaConfig.url, publicDashboard.accessToken),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const enablePublicDashboard: ToolDefinition = {
+  name: 'enable_public_dashboard',
+  description: 'Share a dashboard publicly. DESTRUCTIVE: anyone with the URL can view its data without logging in',
+  inputSchema: EnablePublicDashboardSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      await checkDashboardWrite(client, context.config.grafanaConfig, params.dashboardUid);
+      const existing = await findPublicDashboard(client, params.dashboardUid);
+      
+      const settings = {
+        isEnabled: true,
+        share: 'public',
+        timeSelectionEnabled: params.timeSelectionEnabled ?? false,
+        annotationsEnabled: params.annotationsEnabled ?? false,
+      };
+      
+      const publicDashboard = existing
+        ? await client.updatePublicDashboard(params.dashboardUid, existing.uid, settings)
+        : await client.createPublicDashboard(params.dashboardUid, settings);
+      
+      return createToolResult({
+        uid: publicDashboard.uid,
+        dashboardUid: params.dashboardUid,
+        url: publicDashboardUrl(context.config.grafanaConfig.url, publicDashboard.accessToken),
+        message: 'Public sharing enabled',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const disablePublicDashboard: ToolDefinition = {
+  name: 'disable_public_dashboard',
+  description: 'Stop sharing a dashboard publicly. The public URL is paused and can be re-enabled later',
+  inputSchema: DisablePublicDashboardSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      await checkDashboardWrite(client, context.config.grafanaConfig, params.dashboardUid);
+      const existing = await findPublicDashboard(client, params.dashboardUid);
+      
+      if (!existing) {
+        return createToolResult({
+          dashboardUid: params.dashboardUid,
+          message: 'Dashboard is not shared publicly',
+        });
+      }
+      
+      await client.updatePublicDashboard(params.dashboardUid, existing.uid, { isEnabled: false });
+      
+      return createToolResult({
+        uid: existing.uid,
+        dashboardUid: params.dashboardUid,
+        message: 'Public sharing disabled',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const createDashboardSnapshot: ToolDefinition = {
+  name: 'create_dashboard_snapshot',
+  description: 'Create a snapshot of a dashboard pinned to a time range, to capture point-in-time evidence during an incident. The queries of every panel are run and their data is stored in the snapshot; panels whose queries fail are captured empty and reported',
+  inputSchema: CreateDashboardSnapshotSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const dashboard = await client.getDashboardByUid(params.uid);
+      
+      // Snapshots are static, so the range is made absolute
+      const range = parseTimeRange(params.from, params.to, {
+        timezone: context.timezone,
+        defaultStart: dashboard.time?.from || 'now-6h',
+        defaultEnd: dashboard.time?.to || 'now',
+      });
+      const time = { from: range.start.toISOString(), to: range.end.toISOString() };
+      
+      // Panels keep their data instead of their queries, the way Grafana's share dialog saves
+      // them; the panels of collapsed rows are captured too
+      const model = structuredClone(dashboard);
+      const panels = (model.panels || []).flatMap((panel: any) =>
+        panel.type === 'row' ? [panel, ...(panel.panels || [])] : [panel]
+      );
+      const failed: { id: number; title?: string; error: string }[] = [];
+      for (const panel of panels) {
+        if (!panel.targets?.length) continue;
+        try {
+          const { result } = await runPanelQueries(context, client, dashboard, panel, {
+            range,
+            maxDataPoints: panel.maxDataPoints || 500,
+          });
+          panel.snapshotData = snapshotFrames(result);
+        } catch (error: any) {
+          panel.snapshotData = [];
+          failed.push({ id: panel.id, title: panel.title, error: error.message });
+        }
+        panel.targets = [];
+        panel.links = [];
+        panel.datasource = null;
+      }
+      
+      const snapshot = await client.createSnapshot({
+        dashboard: { ...model, id: null, time },
+        name: params.name || `${dashboard.title} (${new Date().toISOString()})`,
+        expires: params.expiresIn || 0,
+      });
+      
+      return createToolResult({
+        key: snapshot.key,
+        url: snapshot.url,
+        deleteKey: snapshot.deleteKey,
+        time,
+        expiresIn: params.expiresIn || 0,
+        capturedPanels: panels.filter((panel: any) => panel.snapshotData).length - failed.length,
+        failedPanels: failed.length > 0 ? failed : undefined,
+        message: 'Snapshot created successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listDashboardSnapshots: ToolDefinition = {
+  name: 'list_dashboard_snapshots',
+  description: 'List dashboard snapshots with their keys, creation time, and expiry',
+  inputSchema: ListDashboardSnapshotsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const snapshots = await client.listSnapshots(params.query, params.limit || 100);
+      
+      const baseUrl = context.config.grafanaConfig.url.replace(/\/$/, '');
+      const formatted = snapshots.map(snapshot => ({
+        key: snapshot.key,
+        name: snapshot.name,
+        url: snapshot.external ? snapshot.externalUrl : `${baseUrl}/dashboard/snapshot/${snapshot.key}`,
+        created: snapshot.created,
+        expires: snapshot.expires,
+        external: snapshot.external,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const deleteDashboardSnapshot: ToolDefinition = {
+  name: 'delete_dashboard_snapshot',
+  description: 'Delete a dashboard snapshot by its key',
+  inputSchema: DeleteDashboardSnapshotSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      await client.deleteSnapshot(params.key);
+      
+      return createToolResult({
+        key: params.key,
+        message: 'Snapshot deleted successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const importCatalogDashboard: ToolDefinition = {
+  name: 'import_catalog_dashboard',
+  description: 'Import a community dashboard from the grafana.com catalog by ID, mapping its datasource inputs to local datasources',
+  inputSchema: ImportCatalogDashboardSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const dashboard = await client.getCatalogDashboard(params.gnetId, params.revision);
+      if (!dashboard) {
+        return createErrorResult(`Dashboard ${params.gnetId} not found on grafana.com`);
+      }
+      
+      const datasources = await client.listDatasources();
+      const inputs = [];
+      for (const input of dashboard.__inputs || []) {
+        if (input.type === 'datasource') {
+          let uid = params.datasources?.[input.name];
+          if (!uid) {
+            const candidates = datasources.filter(ds => ds.type === input.pluginId);
+            uid = (candidates.find(ds => ds.isDefault) || candidates[0])?.uid;
+          }
+          if (!uid) {
+            return createErrorResult(
+              `No ${input.pluginId} datasource found for input ${input.name}; map it with the datasources parameter`
+            );
+          }
+          inputs.push({ name: input.name, type: 'datasource', pluginId: input.pluginId, value: uid });
+        } else if (input.type === 'constant') {
+          inputs.push({ name: input.name, type: 'constant', value: input.value });
+        }
+      }
+      
+      // An overwriting import replaces the dashboard of the same UID wherever it is
+      checkFolderWrite(
+        context.config.grafanaConfig,
+        params.folderUid,
+        `dashboard "${dashboard.title}"`
+      );
+      await checkOverwriteWrite(client, context.config.grafanaConfig, { uid: dashboard.uid });
+      
+      const result = await client.importDashboard({
+        dashboard,
+        inputs,
+        folderUid: params.folderUid,
+        overwrite: params.overwrite || false,
+      });
+      
+      return createToolResult({
+        uid: result.uid,
+        title: result.title,
+        url: result.importedUrl,
+        revision: result.revision || params.revision,
+        inputs: inputs.map(input => ({ name: input.name, value: input.value })),
+        message: 'Dashboard imported successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const exportDashboard: ToolDefinition = {
+  name: 'export_dashboard',
+  description: 'Export a dashboard as code: provisioning-ready JSON with IDs stripped and datasources externalized into inputs, optionally with a Terraform grafana_dashboard resource or a Grafonnet skeleton',
+  inputSchema: ExportDashboardSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const { dashboard, meta } = await client.getDashboardWithMeta(params.uid);
+      
+      // Terraform applies the file as it is and cannot resolve ${DS_*} inputs
+      const externalize = params.externalizeDatasources ?? params.format !== 'terraform';
+      const exported = toProvisioningDashboard(dashboard, externalize);
+      
+      switch (params.format || 'json') {
+        case 'terraform':
+          return createToolResult({
+            terraform: toTerraform(dashboard, meta?.folderUid),
+            file: `dashboards/${dashboard.uid}.json`,
+            dashboard: exported,
+          });
+        case 'grafonnet':
+          return createToolResult(toGrafonnet(dashboard));
+        default:
+          return createToolResult(exported);
+      }
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const bulkUpdateDashboards: ToolDefinition = {
+  name: 'bulk_update_dashboards',
+  description: 'Retag, move, or repoint datasource references across all dashboards matching a search. Runs as a dry run by default; review the planned changes, then call again with dryRun false',
+  inputSchema: BulkUpdateDashboardsSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      if (!params.addTags && !params.removeTags && !params.moveToFolderUid && !params.replaceDatasourceUid) {
+        return createErrorResult('At least one of addTags, removeTags, moveToFolderUid, or replaceDatasourceUid must be provided');
+      }
+      
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const dryRun = params.dryRun ?? true;
+      const limit = params.limit || 100;
+      
+      const matches = await client.search({
+        type: 'dash-db',
+        query: params.query,
+        tag: params.tags,
+        folderUIDs: params.folderUids,
+        limit: 5000,
+      });
+      
+      const changes = [];
+      const skipped = [];
+      for (const [index, match] of matches.slice(0, limit).entries()) {
+        await context.sendProgress(index, Math.min(matches.length, limit), `Processing ${match.title}`);
+        
+        const { dashboard, meta } = await client.getDashboardWithMeta(match.uid);
+        const change: any = { uid: dashboard.uid, title: dashboard.title };
+        
+        const config = context.config.grafanaConfig;
+        if (
+          !isFolderWritable(config, meta?.folderUid) ||
+          (params.moveToFolderUid && !isFolderWritable(config, params.moveToFolderUid))
+        ) {
+          skipped.push({ ...change, reason: 'folder is not writable' });
+          continue;
+        }
+        
+        if (params.addTags || params.removeTags) {
+          const before: string[] = dashboard.tags || [];
+          const after = Array.from(new Set([...before, ...(params.addTags || [])]))
+            .filter(tag => !(params.removeTags || []).includes(tag));
+          if (after.join(',') !== before.join(',')) {
+            change.tags = { from: before, to: after };
+            dashboard.tags = after;
+          }
+        }
+        
+        let folderUid = meta?.folderUid;
+        if (params.moveToFolderUid && params.moveToFolderUid !== folderUid) {
+          change.folder = { from: folderUid || 'general', to: params.moveToFolderUid };
+          folderUid = params.moveToFolderUid;
+        }
+        
+        if (params.replaceDatasourceUid) {
+          const replaced = replaceDatasourceRefs(
+            dashboard,
+            params.replaceDatasourceUid.from,
+            params.replaceDatasourceUid.to
+          );
+          if (replaced > 0) {
+            change.datasourceReferencesReplaced = replaced;
+          }
+        }
+        
+        if (!change.tags && !change.folder && !change.datasourceReferencesReplaced) {
+          continue;
+        }
+        
+        if (!dryRun) {
+          await client.updateDashboard(dashboard, 'Bulk update via MCP', folderUid);
+        }
+        changes.push(change);
+      }
+      
+      return createToolResult({
+        dryRun,
+        matched: matches.length,
+        changed: changes.length,
+        truncated: matches.length > limit,
+        changes,
+        skipped: skipped.length > 0 ? skipped : undefined,
+        message: dryRun
+          ? 'Dry run: no dashboards were saved. Call again with dryRun false to apply these changes.'
+          : `Updated ${changes.length} dashboards`,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listStarredDashboards: ToolDefinition = {
+  name: 'list_starred_dashboards',
+  description: 'List the dashboards starred by the authenticated user ("my dashboards")',
+  inputSchema: ListStarredDashboardsSchema,
+  handler: async (_params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const dashboards = await client.search({ type: 'dash-db', starred: true, limit: 5000 });
+      
+      const formatted = dashboards.map(d => ({
+        uid: d.uid,
+        title: d.title,
+        folder: d.folderTitle,
+        tags: d.tags,
+        url: d.url,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const starDashboard: ToolDefinition = {
+  name: 'star_dashboard',
+  description: 'Star or unstar a dashboard for the authenticated user',
+  inputSchema: StarDashboardSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const starred = params.starred ?? true;
+      
+      try {
+        if (starred) {
+          await client.starDashboard(params.uid);
+        } else {
+          await client.unstarDashboard(params.uid);
+        }
+      } catch (error: any) {
+        // Starring an already starred dashboard (or the reverse) is not an error here; a
+        // missing dashboard (404) is
+        if (!error.message.includes('(400)')) {
+          throw error;
+        }
+      }
+      
+      return createToolResult({
+        uid: params.uid,
+        starred,
+        message: starred ? 'Dashboard starred' : 'Dashboard unstarred',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const queryPanelData: ToolDefinition = {
+  name: 'query_panel_data',
+  description: 'Runs the queries of a dashboard panel against its datasources and returns the data, with dashboard variables interpolated from their current values or the given overrides. Use it to read what a panel shows without reconstructing its queries. Defaults to the dashboard\'s time range',
+  inputSchema: QueryPanelDataSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const dashboard = await client.getDashboardByUid(params.uid);
+      const panel = findPanel(dashboard, params.panelId);
+      if (!panel) {
+        return createErrorResult(`Panel ${params.panelId} not found in dashboard ${params.uid}`);
+      }
+
+      const range = parseTimeRange(params.startTime, params.endTime, {
+        timezone: context.timezone,
+        defaultStart: dashboard.time?.from || 'now-6h',
+        defaultEnd: dashboard.time?.to || 'now',
+      });
+      const { queries, result } = await runPanelQueries(context, client, dashboard, panel, {
+        variables: params.variables,
+        range,
+        maxDataPoints: params.maxDataPoints || 500,
+      });
+
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables(resultTables(result, context.timezone), params.format));
+      }
+      return createToolResult({
+        dashboard: { uid: params.uid, title: dashboard.title },
+        panel: { id: panel.id, title: panel.title, type: panel.type },
+        range: { from: range.start.toISOString(), to: range.end.toISOString() },
+        queries: queries.map(query => ({
+          refId: query.refId,
+          datasource: query.datasource,
+          query: query.expr || query.query || query.rawSql || undefined,
+        })),
+        results: result.results,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const comparePanelRenders: ToolDefinition = {
+  name: 'compare_panel_renders',
+  description: 'Compares a panel at two time ranges or two dashboard versions, for before/after questions such as "did the deploy change latency?". Returns the panel rendered as an image for each side (requires the Grafana image renderer) and the change of each series\' mean, min, max, and last value between the sides. Old dashboard versions are compared by their queries\' data only, since Grafana renders saved dashboards',
+  inputSchema: ComparePanelRendersSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const current = await client.getDashboardByUid(params.uid);
+      const sides = { before: params.before, after: params.after };
+      const maxDataPoints = params.maxDataPoints || 500;
+
+      const compared = await Promise.all(Object.entries(sides).map(async ([name, side]) => {
+        const version = side.version !== undefined && side.version !== current.version
+          ? side.version
+          : undefined;
+        const dashboard = version !== undefined
+          ? (await client.getDashboardVersion(params.uid, version)).data
+          : current;
+        const panel = findPanel(dashboard, params.panelId);
+        if (!panel) {
+          throw new Error(
+            `Panel ${params.panelId} not found in ${name} dashboard` +
+              (version !== undefined ? ` version ${version}` : '')
+          );
+        }
+        const range = parseTimeRange(side.startTime, side.endTime, {
+          timezone: context.timezone,
+          defaultStart: dashboard.time?.from || 'now-6h',
+          defaultEnd: dashboard.time?.to || 'now',
+        });
+        const { result } = await runPanelQueries(context, client, dashboard, panel, {
+          variables: params.variables,
+          range,
+          maxDataPoints,
+        });
+        return { name, version, panel, range, result };
+      }));
+
+      // Rendering runs after the queries so that the datasource policy has been checked
+      const images = await Promise.all(compared.map(async (side): Promise<{
+        image?: Buffer;
+        renderError?: string;
+      }> => {
+        if (params.render === false) return {};
+        if (side.version !== undefined) {
+          return {
+            renderError: `Only the current version can be rendered, not version ${side.version}`,
+          };
+        }
+        try {
+          const image = await client.renderPanel(params.uid, {
+            panelId: params.panelId,
+            from: side.range.start.getTime(),
+            to: side.range.end.getTime(),
+            width: params.width || 1000,
+            height: params.height || 500,
+            tz: context.timezone,
+            ...renderVariables(params.variables),
+          });
+          return { image };
+        } catch (error: any) {
+          return { renderError: error.message };
+        }
+      }));
+
+      const summary = createToolResult({
+        dashboard: { uid: params.uid, title: current.title },
+        panel: {
+          id: params.panelId,
+          title: compared[1].panel.title,
+          type: compared[1].panel.type,
+        },
+        sides: compared.map((side, i) => ({
+          name: side.name,
+          version: side.version ?? current.version,
+          from: formatTime(side.range.start, context.timezone),
+          to: formatTime(side.range.end, context.timezone),
+          rendered: images[i].image !== undefined,
+          renderError: images[i].renderError,
+        })),
+        series: seriesDeltas(compared[0].result, compared[1].result),
+      });
+      compared.forEach((side, i) => {
+        const image = images[i].image;
+        if (!image) return;
+        const from = formatTime(side.range.start, context.timezone);
+        const to = formatTime(side.range.end, context.timezone);
+        summary.content.push(
+          { type: 'text', text: `${side.name}: ${from} to ${to}` },
+          { type: 'image', data: image.toString('base64'), mimeType: 'image/png' }
+        );
+      });
+      return summary;
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
 export function registerDashboardTools(server: any) {
   server.registerTool(getDashboardByUid);
   server.registerTool(getDashboardSummary);
   server.registerTool(getDashboardProperty);
   server.registerTool(getDashboardPanelQueries);
   server.registerTool(updateDashboard);
+  server.registerTool(listPublicDashboards);
+  server.registerTool(getPublicDashboard);
+  server.registerTool(enablePublicDashboard);
+  server.registerTool(disablePublicDashboard);
+  server.registerTool(createDashboardSnapshot);
+  server.registerTool(listDashboardSnapshots);
+  server.registerTool(deleteDashboardSnapshot);
+  server.registerTool(importCatalogDashboard);
+  server.registerTool(exportDashboard);
+  server.registerTool(bulkUpdateDashboards);
+  server.registerTool(listStarredDashboards);
+  server.registerTool(starDashboard);
+  server.registerTool(queryPanelData);
+  server.registerTool(comparePanelRenders);
 }
\ No newline at end of file
@@ -1,6 +1,8 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
 import { GrafanaClient } from '../clients/grafana-client';
+import { redactSecrets } from '../utils/redact';
+import { isDatasourceAllowed } from '../server/datasource-policy';
 
 const ListDatasourcesSchema = z.object({
   type: z.string().optional().describe('The type of datasources to search for (e.g., "prometheus", "loki")'),
@@ -21,7 +23,9 @@ export const listDatasources: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = new GrafanaClient(context.config.grafanaConfig);
-      const datasources = await client.listDatasources(params.type);
+      const policy = context.config.grafanaConfig.datasourcePolicy;
+      const datasources = (await client.listDatasources(params.type))
+        .filter(ds => isDatasourceAllowed(policy, ds));
       
       // Format for readability
       const formatted = datasources.map(ds => ({
@@ -47,7 +51,12 @@ export const getDatasourceByUid: ToolDefinition = {
     try {
       const client = new GrafanaClient(context.config.grafanaConfig);
       const datasource = await client.getDatasourceByUid(params.uid);
-      return createToolResult(datasource);
+      if (!isDatasourceAllowed(context.config.grafanaConfig.datasourcePolicy, datasource)) {
+        return createErrorResult(
+          `Datasource "${params.uid}" is not allowed by the datasource policy of this server`
+        );
+      }
+      return createToolResult(redactSecrets(datasource));
     } catch (error: any) {
       return createErrorResult(error.message);
     }
@@ -62,7 +71,12 @@ export const getDatasourceByName: ToolDefinition = {
     try {
       const client = new GrafanaClient(context.config.grafanaConfig);
       const datasource = await client.getDatasourceByName(params.name);
-      return createToolResult(datasource);
+      if (!isDatasourceAllowed(context.config.grafanaConfig.datasourcePolicy, datasource)) {
+        return createErrorResult(
+          `Datasource "${params.name}" is not allowed by the datasource policy of this server`
+        );
+      }
+      return createToolResult(redactSecrets(datasource));
     } catch (error: any) {
       return createErrorResult(error.message);
     }
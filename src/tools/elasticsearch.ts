@@ -0,0 +1,247 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { ElasticsearchClient } from '../clients/elasticsearch-client';
+import { GrafanaClient } from '../clients/grafana-client';
+import { formatTime, parseTimeRange } from '../utils/time';
+import { recordsToTable, renderTables } from '../utils/table';
+import { createLogScrubber } from '../utils/scrub';
+
+// Schema definitions
+const QueryElasticsearchSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Elasticsearch datasource to query'),
+  query: z.string().optional().describe('The Lucene query, e.g. \'level:error AND service:"checkout"\' (default: all documents)'),
+  index: z.string().optional().describe('The index or index pattern to search, e.g. "logs-*" (default: the datasource\'s index)'),
+  timeField: z.string().optional().describe('The timestamp field used for the time range and sorting (default: the datasource\'s time field)'),
+  startRfc3339: z.string().optional().describe('The start time of the query (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time of the query (default: now)'),
+  size: z.number().optional().describe('Maximum number of documents to return (default: 10, max: 100)'),
+  sort: z.enum(['asc', 'desc']).optional().describe('Sort order by time (default: desc, newest first)'),
+  fields: z.array(z.string()).optional().describe('Only return these document fields, e.g. ["message", "service.name"] (default: all fields)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+const QueryElasticsearchEsqlSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Elasticsearch datasource to query'),
+  query: z.string().describe('The ES|QL query, e.g. "FROM logs-* | WHERE level == \\"error\\" | STATS count = COUNT(*) BY service | SORT count DESC | LIMIT 10"'),
+  startRfc3339: z.string().optional().describe('Only include documents from this time on (RFC3339, unix epoch, or relative like "now-1h"); default: no time filter'),
+  endRfc3339: z.string().optional().describe('Only include documents up to this time (default: now)'),
+  timeField: z.string().optional().describe('The timestamp field of the time filter (default: the datasource\'s time field)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+const ListElasticsearchFieldsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Elasticsearch datasource'),
+  index: z.string().optional().describe('The index or index pattern (default: the datasource\'s index)'),
+  type: z.string().optional().describe('Only list fields of this type, e.g. "keyword" or "date"'),
+});
+
+// Helper to read the index and time field configured on an Elasticsearch datasource
+async function datasourceSettings(
+  context: ToolContext,
+  uid: string
+): Promise<{ index?: string; timeField: string }> {
+  const client = new GrafanaClient(context.config.grafanaConfig);
+  const datasource = await client.getDatasourceByUid(uid);
+  if (!String(datasource.type).includes('elasticsearch')) {
+    throw new Error(`Datasource ${uid} is a ${datasource.type} datasource, not Elasticsearch`);
+  }
+  let index: string | undefined = datasource.jsonData?.index || datasource.database || undefined;
+  // Time-based index patterns such as [logs-]YYYY.MM.DD are searched as logs-*
+  if (index && datasource.jsonData?.interval) {
+    index = `${(index.match(/\[([^\]]*)\]/) || [])[1] || ''}*`;
+  }
+  return { index, timeField: datasource.jsonData?.timeField || '@timestamp' };
+}
+
+// Helper to redact every string of a document when log scrubbing is configured
+function scrubDocument(value: any, scrub: (text: string) => string): any {
+  if (typeof value === 'string') return scrub(value);
+  if (Array.isArray(value)) return value.map(item => scrubDocument(item, scrub));
+  if (value && typeof value === 'object') {
+    return Object.fromEntries(
+      Object.entries(value).map(([key, item]) => [key, scrubDocument(item, scrub)])
+    );
+  }
+  return value;
+}
+
+// Helper to flatten nested document fields to dotted names for tables
+function flattenDocument(value: any, prefix = '', out: Record<string, unknown> = {}) {
+  for (const [key, item] of Object.entries(value || {})) {
+    const name = prefix ? `${prefix}.${key}` : key;
+    if (item && typeof item === 'object' && !Array.isArray(item)) {
+      flattenDocument(item, name, out);
+    } else {
+      out[name] = item;
+    }
+  }
+  return out;
+}
+
+// Helper to list the fields of index mappings with their types, as dotted names
+function mappingFields(mapping: Record<string, any>): Record<string, string> {
+  const fields: Record<string, string> = {};
+  const visit = (properties: Record<string, any>, prefix: string) => {
+    for (const [name, field] of Object.entries(properties || {})) {
+      const path = prefix ? `${prefix}.${name}` : name;
+      if (field.type) fields[path] = field.type;
+      if (field.properties) visit(field.properties, path);
+      for (const [subName, subField] of Object.entries<any>(field.fields || {})) {
+        fields[`${path}.${subName}`] = subField.type;
+      }
+    }
+  };
+  for (const index of Object.values<any>(mapping)) {
+    visit(index.mappings?.properties, '');
+  }
+  return fields;
+}
+
+// Tool definitions
+export const queryElasticsearch: ToolDefinition = {
+  name: 'query_elasticsearch',
+  description: 'Searches an Elasticsearch datasource with a Lucene query string within a time range, returning the matching documents newest first. Use it for logs and events kept in Elasticsearch rather than Loki',
+  inputSchema: QueryElasticsearchSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const settings = await datasourceSettings(context, params.datasourceUid);
+      const index = params.index || settings.index;
+      if (!index) {
+        return createErrorResult('The datasource has no default index; pass index');
+      }
+      const timeField = params.timeField || settings.timeField;
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+
+      const client = new ElasticsearchClient(context.config.grafanaConfig, params.datasourceUid);
+      const result = await client.search(index, {
+        size: Math.min(params.size || 10, 100),
+        sort: [{ [timeField]: { order: params.sort || 'desc' } }],
+        _source: params.fields,
+        query: {
+          bool: {
+            filter: [
+              {
+                range: {
+                  [timeField]: {
+                    gte: timeRange.start.toISOString(),
+                    lte: timeRange.end.toISOString(),
+                    format: 'strict_date_optional_time',
+                  },
+                },
+              },
+              { query_string: { query: params.query || '*', analyze_wildcard: true } },
+            ],
+          },
+        },
+      });
+
+      // Redact personal data and secrets when configured
+      const scrub = createLogScrubber(context.config.grafanaConfig.logScrubbing);
+      const documents = result.hits.map(hit => {
+        const time = hit.sort?.[0];
+        return {
+          timestamp: typeof time === 'number' ? formatTime(time, context.timezone) : time,
+          index: hit._index,
+          id: hit._id,
+          source: scrub ? scrubDocument(hit._source, scrub) : hit._source,
+        };
+      });
+
+      if (params.format && params.format !== 'json') {
+        const records = documents.map(document => ({
+          timestamp: document.timestamp,
+          ...flattenDocument(document.source),
+        }));
+        return createToolResult(renderTables([recordsToTable(records)], params.format));
+      }
+      return createToolResult({ index, total: result.total, documents });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const queryElasticsearchEsql: ToolDefinition = {
+  name: 'query_elasticsearch_esql',
+  description: 'Runs an ES|QL query against an Elasticsearch datasource (Elasticsearch 8.11+), for aggregations and transformations beyond a Lucene search. Optionally restricts the documents to a time range',
+  inputSchema: QueryElasticsearchEsqlSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      let filter: any;
+      if (params.startRfc3339) {
+        const settings = await datasourceSettings(context, params.datasourceUid);
+        const timeField = params.timeField || settings.timeField;
+        const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+          timezone: context.timezone,
+        });
+        filter = {
+          range: {
+            [timeField]: {
+              gte: timeRange.start.toISOString(),
+              lte: timeRange.end.toISOString(),
+              format: 'strict_date_optional_time',
+            },
+          },
+        };
+      }
+
+      const client = new ElasticsearchClient(context.config.grafanaConfig, params.datasourceUid);
+      const result = await client.esql(params.query, filter);
+
+      const scrub = createLogScrubber(context.config.grafanaConfig.logScrubbing);
+      const records = (result.values || []).map(row =>
+        Object.fromEntries(
+          result.columns.map((column, i) => [
+            column.name,
+            scrub ? scrubDocument(row[i], scrub) : row[i],
+          ])
+        )
+      );
+
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables([recordsToTable(records)], params.format));
+      }
+      return createToolResult({ columns: result.columns, rows: records });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listElasticsearchFields: ToolDefinition = {
+  name: 'list_elasticsearch_fields',
+  description: 'Lists the fields of an Elasticsearch index with their types, to find the fields to query, filter, and aggregate on',
+  inputSchema: ListElasticsearchFieldsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const settings = await datasourceSettings(context, params.datasourceUid);
+      const index = params.index || settings.index;
+      if (!index) {
+        return createErrorResult('The datasource has no default index; pass index');
+      }
+
+      const client = new ElasticsearchClient(context.config.grafanaConfig, params.datasourceUid);
+      const fields = mappingFields(await client.getMapping(index));
+      const filtered = Object.entries(fields)
+        .filter(([, type]) => !params.type || type === params.type)
+        .sort(([a], [b]) => a.localeCompare(b));
+
+      return createToolResult({
+        index,
+        timeField: settings.timeField,
+        fields: Object.fromEntries(filtered),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerElasticsearchTools(server: any) {
+  server.registerTool(queryElasticsearch);
+  server.registerTool(queryElasticsearchEsql);
+  server.registerTool(listElasticsearchFields);
+}
@@ -0,0 +1,176 @@
+import { z } from 'zod';
+import axios from 'axios';
+import { instrumentClient } from '../clients/base-client';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+
+// Schema definitions
+const ListFleetCollectorsSchema = z.object({
+  matchers: z.array(z.string()).optional().describe('Attribute matchers to filter collectors, e.g. "env=prod" or "os=~linux.*"'),
+  inactiveOnly: z.boolean().optional().describe('Only include collectors that have been marked inactive'),
+});
+
+const GetFleetCollectorSchema = z.object({
+  id: z.string().describe('The ID of the collector'),
+});
+
+const ListFleetPipelinesSchema = z.object({
+  enabledOnly: z.boolean().optional().describe('Only include enabled pipelines'),
+  includeContents: z.boolean().optional().describe('Whether to include the Alloy configuration of each pipeline (default: false)'),
+});
+
+// Helper function to create Fleet Management client
+function createFleetClient(config: any) {
+  if (!config.fleetManagementUrl) {
+    throw new Error('FLEET_MANAGEMENT_URL is required for Fleet Management tools');
+  }
+  
+  const token = config.fleetManagementToken || config.cloudApiKey;
+  if (!config.fleetManagementUser || !token) {
+    throw new Error('FLEET_MANAGEMENT_USER and FLEET_MANAGEMENT_TOKEN are required for Fleet Management tools');
+  }
+  
+  return instrumentClient(axios.create({
+    baseURL: config.fleetManagementUrl.replace(/\/$/, ''),
+    headers: {
+      'User-Agent': 'mcp-grafana/1.0.0',
+      'Content-Type': 'application/json',
+    },
+    auth: {
+      username: config.fleetManagementUser,
+      password: token,
+    },
+    timeout: 30000,
+  }));
+}
+
+// Helper function to check an attribute matcher ("key=value", "key!=value", "key=~regex", "key!~regex")
+function matches(attributes: Record<string, string>, matcher: string): boolean {
+  const parsed = matcher.match(/^\s*([^=!~\s]+)\s*(=~|!~|!=|=)\s*"?(.*?)"?\s*$/);
+  if (!parsed) return false;
+  
+  const [, key, op, value] = parsed;
+  const actual = attributes[key] ?? '';
+  switch (op) {
+    case '=': return actual === value;
+    case '!=': return actual !== value;
+    case '=~': return new RegExp(`^(?:${value})$`).test(actual);
+    case '!~': return !new RegExp(`^(?:${value})$`).test(actual);
+    default: return false;
+  }
+}
+
+// Helper function to merge a collector's remote and local attributes
+function collectorAttributes(collector: any): Record<string, string> {
+  return { ...collector.local_attributes, ...collector.remote_attributes };
+}
+
+// Helper function to format a collector
+function formatCollector(collector: any) {
+  return {
+    id: collector.id,
+    name: collector.name,
+    enabled: collector.enabled,
+    active: !collector.marked_inactive_at,
+    markedInactiveAt: collector.marked_inactive_at,
+    createdAt: collector.created_at,
+    updatedAt: collector.updated_at,
+    attributes: collectorAttributes(collector),
+  };
+}
+
+// Tool definitions
+export const listFleetCollectors: ToolDefinition = {
+  name: 'list_fleet_collectors',
+  description: 'List Alloy collectors registered with Grafana Fleet Management, with their attributes and health',
+  inputSchema: ListFleetCollectorsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createFleetClient(context.config.grafanaConfig);
+      const response = await client.post('/collector.v1.CollectorService/ListCollectors', {});
+      
+      let collectors = response.data.collectors || [];
+      if (params.matchers) {
+        collectors = collectors.filter((c: any) =>
+          params.matchers.every((m: string) => matches(collectorAttributes(c), m))
+        );
+      }
+      if (params.inactiveOnly) {
+        collectors = collectors.filter((c: any) => !!c.marked_inactive_at);
+      }
+      
+      return createToolResult(collectors.map(formatCollector));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const getFleetCollector: ToolDefinition = {
+  name: 'get_fleet_collector',
+  description: 'Get a Fleet Management collector, its health, and the pipelines assigned to it through attribute matchers',
+  inputSchema: GetFleetCollectorSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createFleetClient(context.config.grafanaConfig);
+      
+      const collectorResponse = await client.post('/collector.v1.CollectorService/GetCollector', {
+        id: params.id,
+      });
+      const collector = collectorResponse.data;
+      const attributes = collectorAttributes(collector);
+      
+      const pipelinesResponse = await client.post('/pipeline.v1.PipelineService/ListPipelines', {});
+      const assigned = (pipelinesResponse.data.pipelines || [])
+        .filter((p: any) => (p.matchers || []).every((m: string) => matches(attributes, m)))
+        .map((p: any) => ({
+          id: p.id,
+          name: p.name,
+          enabled: p.enabled,
+          matchers: p.matchers || [],
+        }));
+      
+      return createToolResult({
+        ...formatCollector(collector),
+        assignedPipelines: assigned,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const listFleetPipelines: ToolDefinition = {
+  name: 'list_fleet_pipelines',
+  description: 'List Fleet Management configuration pipelines and the attribute matchers that assign them to collectors',
+  inputSchema: ListFleetPipelinesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createFleetClient(context.config.grafanaConfig);
+      const response = await client.post('/pipeline.v1.PipelineService/ListPipelines', {});
+      
+      let pipelines = response.data.pipelines || [];
+      if (params.enabledOnly) {
+        pipelines = pipelines.filter((p: any) => p.enabled);
+      }
+      
+      const formatted = pipelines.map((p: any) => ({
+        id: p.id,
+        name: p.name,
+        enabled: p.enabled,
+        matchers: p.matchers || [],
+        updatedAt: p.updated_at,
+        contents: params.includeContents ? p.contents : undefined,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export function registerFleetTools(server: any) {
+  server.registerTool(listFleetCollectors);
+  server.registerTool(getFleetCollector);
+  server.registerTool(listFleetPipelines);
+}
@@ -0,0 +1,140 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+import { createPluginClient } from '../clients/base-client';
+import { parseTimeRange } from '../utils/time';
+import { DEFAULT_MAX_DATA_POINTS } from '../utils/downsample';
+import { renderTables, resultTables } from '../utils/table';
+
+// Schema definitions
+const QueryGraphiteSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Graphite datasource'),
+  targets: z.array(z.string()).min(1).max(26).describe('Graphite target expressions, e.g. ["aliasByNode(sumSeries(servers.*.cpu.user), 1)"]'),
+  startRfc3339: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time (default: now)'),
+  maxDataPoints: z.number().optional().describe(`Maximum datapoints per series; Graphite consolidates beyond this (default: ${DEFAULT_MAX_DATA_POINTS})`),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+const FindGraphiteMetricsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Graphite datasource'),
+  query: z.string().optional().describe('The metric path pattern, e.g. "servers.*" or "servers.web-1.cpu.*" (default: "*", the top level)'),
+});
+
+const ListGraphiteFunctionsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Graphite datasource'),
+  search: z.string().optional().describe('Only list functions whose name, group, or description contains this text'),
+});
+
+// Helper function to create a client for the HTTP API of a Graphite datasource
+function createGraphiteClient(config: any, datasourceUid: string) {
+  return createPluginClient(config, `${config.url}/api/datasources/proxy/uid/${datasourceUid}`);
+}
+
+// Tool definitions
+export const queryGraphite: ToolDefinition = {
+  name: 'query_graphite',
+  description: 'Renders Graphite target expressions through a Graphite datasource, returning the datapoints of each resulting series over a time range',
+  inputSchema: QueryGraphiteSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+
+      // Targets are lettered like panel queries, so they can refer to each other as #A
+      const result = await client.queryDatasources({
+        queries: params.targets.map((target: string, i: number) => ({
+          refId: String.fromCharCode(65 + i),
+          datasource: { uid: params.datasourceUid, type: 'graphite' },
+          target,
+          maxDataPoints: params.maxDataPoints || DEFAULT_MAX_DATA_POINTS,
+        })),
+        from: timeRange.start.getTime().toString(),
+        to: timeRange.end.getTime().toString(),
+      });
+
+      const errors = Object.entries(result?.results || {})
+        .filter(([, query]: [string, any]) => query.error)
+        .map(([refId, query]: [string, any]) => `${refId}: ${query.error}`);
+      if (errors.length > 0) {
+        return createErrorResult(`Graphite query failed: ${errors.join('; ')}`);
+      }
+
+      const tables = resultTables(result, context.timezone);
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables(tables, params.format));
+      }
+      return createToolResult(tables.map(table => ({
+        refId: table.name,
+        target: params.targets[(table.name || 'A').charCodeAt(0) - 65],
+        columns: table.columns,
+        rows: table.rows,
+      })));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const findGraphiteMetrics: ToolDefinition = {
+  name: 'find_graphite_metrics',
+  description: 'Browses the Graphite metric tree: lists the nodes matching a path pattern and whether each is a leaf metric or a branch to explore further',
+  inputSchema: FindGraphiteMetricsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createGraphiteClient(context.config.grafanaConfig, params.datasourceUid);
+      const response = await client.get('/metrics/find', {
+        params: { query: params.query || '*' },
+      });
+      return createToolResult((response.data || []).map((node: any) => ({
+        path: node.id,
+        name: node.text,
+        leaf: node.leaf === 1 || node.leaf === true,
+      })));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const listGraphiteFunctions: ToolDefinition = {
+  name: 'list_graphite_functions',
+  description: 'Lists the functions a Graphite server supports for target expressions, with their groups, descriptions, and parameters (Graphite 1.1+)',
+  inputSchema: ListGraphiteFunctionsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createGraphiteClient(context.config.grafanaConfig, params.datasourceUid);
+      const response = await client.get('/functions');
+
+      const search = params.search?.toLowerCase();
+      const functions = Object.values<any>(response.data || {})
+        .filter(fn =>
+          !search ||
+          [fn.name, fn.group, fn.description]
+            .some(text => String(text || '').toLowerCase().includes(search))
+        )
+        .map(fn => ({
+          name: fn.name,
+          group: fn.group,
+          // Descriptions are reStructuredText; the first paragraph summarizes the function
+          description: String(fn.description || '').split('\n\n')[0].trim(),
+          params: (fn.params || []).map((param: any) =>
+            `${param.name}${param.required ? '' : '?'}: ${param.type}`
+          ),
+        }))
+        .sort((a, b) => a.name.localeCompare(b.name));
+      return createToolResult(functions);
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export function registerGraphiteTools(server: any) {
+  server.registerTool(queryGraphite);
+  server.registerTool(findGraphiteMetrics);
+  server.registerTool(listGraphiteFunctions);
+}
@@ -0,0 +1,90 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+import { formatTime } from '../utils/time';
+
+// Schema definitions
+const SearchQueryHistorySchema = z.object({
+  searchString: z.string().optional().describe('Text to search for in queries and comments, e.g. a service name'),
+  datasourceUids: z.array(z.string()).optional().describe('Only include queries against these datasources'),
+  onlyStarred: z.boolean().optional().describe('Only include starred queries'),
+  hours: z.number().optional().describe('Only include queries run in the last N hours (default: 168)'),
+  limit: z.number().optional().describe('Maximum number of entries to return (default: 50)'),
+});
+
+const StarQuerySchema = z.object({
+  uid: z.string().describe('The UID of the query history entry'),
+  starred: z.boolean().optional().describe('Whether to star (true) or unstar (false) the entry (default: true)'),
+});
+
+// Tool definitions
+export const searchQueryHistory: ToolDefinition = {
+  name: 'search_query_history',
+  description: 'Search Grafana Explore query history, e.g. to surface queries recently run for a service. History is per user, so results reflect the identity the server is authenticated as',
+  inputSchema: SearchQueryHistorySchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const now = Math.floor(Date.now() / 1000);
+      
+      const result = await client.searchQueryHistory({
+        searchString: params.searchString,
+        datasourceUid: params.datasourceUids,
+        onlyStarred: params.onlyStarred,
+        sort: 'time-desc',
+        from: now - (params.hours || 168) * 3600,
+        to: now,
+        limit: params.limit || 50,
+      });
+      
+      const formatted = (result.queryHistory || []).map((entry: any) => ({
+        uid: entry.uid,
+        datasourceUid: entry.datasourceUid,
+        createdBy: entry.createdBy,
+        createdAt: formatTime(entry.createdAt * 1000, context.timezone),
+        starred: entry.starred,
+        comment: entry.comment || undefined,
+        queries: entry.queries,
+      }));
+      
+      return createToolResult({
+        totalCount: result.totalCount,
+        entries: formatted,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const starQuery: ToolDefinition = {
+  name: 'star_query_history',
+  description: 'Star or unstar a query history entry so it is kept and easy to find again',
+  inputSchema: StarQuerySchema,
+  annotations: { readOnlyHint: false, destructiveHint: false, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const starred = params.starred ?? true;
+      
+      if (starred) {
+        await client.starQuery(params.uid);
+      } else {
+        await client.unstarQuery(params.uid);
+      }
+      
+      return createToolResult({
+        uid: params.uid,
+        starred,
+        message: starred ? 'Query starred' : 'Query unstarred',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerHistoryTools(server: any) {
+  server.registerTool(searchQueryHistory);
+  server.registerTool(starQuery);
+}
@@ -1,16 +1,20 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
-import axios from 'axios';
+import { createPluginClient } from '../clients/base-client';
+import { GrafanaClient } from '../clients/grafana-client';
+import { projectFields } from '../utils/fields';
 
 // Schema definitions
 const ListIncidentsSchema = z.object({
   status: z.enum(['active', 'resolved']).optional().describe('The status of incidents to include'),
   drill: z.boolean().optional().describe('Whether to include drill incidents'),
   limit: z.number().optional().describe('Maximum number of incidents to return'),
+  fields: z.array(z.string()).optional().describe('Only return these fields, as dotted paths (e.g. ["uid", "title"]); default: all fields'),
 });
 
 const GetIncidentSchema = z.object({
   id: z.string().describe('The ID of the incident to retrieve'),
+  fields: z.array(z.string()).optional().describe('Only return these fields, as dotted paths (e.g. ["uid", "title"]); default: all fields'),
 });
 
 const CreateIncidentSchema = z.object({
@@ -35,23 +39,77 @@ const AddActivityToIncidentSchema = z.object({
   eventTime: z.string().optional().describe('The time that the activity occurred'),
 });
 
+const GetIncidentTimelineSchema = z.object({
+  incidentId: z.string().describe('The ID of the incident'),
+  activityKinds: z.array(z.string()).optional().describe('Only include these activity kinds (e.g. "userNote", "statusChanged", "severityChanged")'),
+  limit: z.number().optional().describe('Maximum number of activity items to return (default: 100)'),
+});
+
+const GenerateIncidentRetrospectiveSchema = z.object({
+  incidentId: z.string().describe('The ID of the incident'),
+});
+
+const CreateIncidentFromAlertSchema = z.object({
+  fingerprint: z.string().optional().describe('The fingerprint of the firing alert instance'),
+  ruleUid: z.string().optional().describe('The UID of the alert rule (used when fingerprint is not set)'),
+  labels: z.record(z.string()).optional().describe('Labels identifying the alert instance of the rule'),
+  severity: z.string().optional().describe('The incident severity (default: derived from the alert\'s severity label, else "minor")'),
+  roomPrefix: z.string().optional().describe('The prefix of the room to create the incident in (default: "incident")'),
+  isDrill: z.boolean().optional().describe('Whether the incident is a drill'),
+});
+
+const AssignIncidentRoleSchema = z.object({
+  incidentId: z.string().describe('The ID of the incident'),
+  userId: z.string().describe('The Grafana Incident user ID to assign the role to'),
+  role: z.enum(['commander', 'investigator', 'observer']).describe('The role to assign'),
+  unassign: z.boolean().optional().describe('Remove the role from the user instead of assigning it'),
+});
+
+const UpdateIncidentSchema = z.object({
+  incidentId: z.string().describe('The ID of the incident'),
+  status: z.enum(['active', 'resolved']).optional().describe('The new status of the incident'),
+  severity: z.string().optional().describe('The new severity of the incident'),
+  title: z.string().optional().describe('The new title of the incident'),
+});
+
+const AddIncidentTaskSchema = z.object({
+  incidentId: z.string().describe('The ID of the incident'),
+  text: z.string().describe('The description of the task'),
+  assigneeUserId: z.string().optional().describe('The Grafana Incident user ID to assign the task to'),
+});
+
 // Helper function to create incident client
 function createIncidentClient(config: any) {
-  const headers: any = {
-    'User-Agent': 'mcp-grafana/1.0.0',
-  };
+  return createPluginClient(config, `${config.url}/api/plugins/grafana-incident-app/resources/api/v1`);
+}
+
+// Helper function to fetch the activity timeline of an incident in chronological order
+async function queryIncidentActivity(client: any, incidentId: string, limit: number): Promise<any[]> {
+  const items: any[] = [];
+  let cursor: any;
   
-  if (config.serviceAccountToken) {
-    headers['Authorization'] = `Bearer ${config.serviceAccountToken}`;
-  } else if (config.apiKey) {
-    headers['Authorization'] = `Bearer ${config.apiKey}`;
-  }
+  do {
+    const response = await client.post('/ActivityService.QueryActivity', {
+      query: { incidentID: incidentId, limit: Math.min(limit - items.length, 100), orderDirection: 'ASC' },
+      cursor,
+    });
+    items.push(...(response.data.activityItems || []));
+    cursor = response.data.cursor?.hasMore ? response.data.cursor : undefined;
+  } while (cursor && items.length < limit);
   
-  return axios.create({
-    baseURL: `${config.url}/api/plugins/grafana-incident-app/resources/api/v1`,
-    headers,
-    timeout: 30000,
-  });
+  return items;
+}
+
+// Helper function to format an activity item
+function formatActivity(item: any) {
+  return {
+    activityItemID: item.activityItemID,
+    kind: item.activityKind,
+    eventTime: item.eventTime,
+    user: item.user?.name,
+    body: item.body,
+    fieldValues: item.fieldValues,
+  };
 }
 
 // Tool definitions
@@ -83,7 +141,7 @@ export const listIncidents: ToolDefinition = {
         labels: incident.labels,
       }));
       
-      return createToolResult(formatted);
+      return createToolResult(projectFields(formatted, params.fields));
     } catch (error: any) {
       return createErrorResult(error.response?.data?.message || error.message);
     }
@@ -101,7 +159,7 @@ export const getIncident: ToolDefinition = {
         params: { incidentID: params.id },
       });
       
-      return createToolResult(response.data.incident);
+      return createToolResult(projectFields(response.data.incident, params.fields));
     } catch (error: any) {
       return createErrorResult(error.response?.data?.message || error.message);
     }
@@ -112,6 +170,7 @@ export const createIncident: ToolDefinition = {
   name: 'create_incident',
   description: 'Create a new Grafana incident. Requires title, severity, and room prefix',
   inputSchema: CreateIncidentSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
   handler: async (params, context: ToolContext) => {
     try {
       const client = createIncidentClient(context.config.grafanaConfig);
@@ -157,6 +216,7 @@ export const addActivityToIncident: ToolDefinition = {
   name: 'add_activity_to_incident',
   description: 'Add a note (userNote activity) to an existing incident\'s timeline',
   inputSchema: AddActivityToIncidentSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
   handler: async (params, context: ToolContext) => {
     try {
       const client = createIncidentClient(context.config.grafanaConfig);
@@ -184,9 +244,311 @@ export const addActivityToIncident: ToolDefinition = {
   },
 };
 
+export const getIncidentTimeline: ToolDefinition = {
+  name: 'get_incident_timeline',
+  description: 'Get the full activity timeline of an incident in chronological order, including notes, status and severity changes, and role assignments',
+  inputSchema: GetIncidentTimelineSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createIncidentClient(context.config.grafanaConfig);
+      
+      let activity = await queryIncidentActivity(client, params.incidentId, params.limit || 100);
+      if (params.activityKinds) {
+        activity = activity.filter((item: any) => params.activityKinds.includes(item.activityKind));
+      }
+      
+      return createToolResult({
+        incidentID: params.incidentId,
+        activity: activity.map(formatActivity),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const generateIncidentRetrospective: ToolDefinition = {
+  name: 'generate_incident_retrospective',
+  description: 'Generate a structured post-incident review skeleton (summary, impact, timeline, contributing factors, action items) from an incident and its activity timeline',
+  inputSchema: GenerateIncidentRetrospectiveSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createIncidentClient(context.config.grafanaConfig);
+      
+      const incidentResponse = await client.post('/IncidentService.GetIncident', {
+        incidentID: params.incidentId,
+      });
+      const incident = incidentResponse.data.incident;
+      const activity = await queryIncidentActivity(client, params.incidentId, 500);
+      
+      const start = incident.incidentStart || incident.createdTime;
+      const end = incident.incidentEnd || (incident.status === 'resolved' ? incident.modifiedTime : null);
+      const durationMinutes = start && end
+        ? Math.round((Date.parse(end) - Date.parse(start)) / 60000)
+        : null;
+      
+      const timeline = activity
+        .filter((item: any) => item.activityKind !== 'incidentUpdated')
+        .map((item: any) => ({
+          time: item.eventTime,
+          kind: item.activityKind,
+          description: item.body,
+          user: item.user?.name,
+        }));
+      
+      return createToolResult({
+        title: `Post-incident review: ${incident.title}`,
+        summary: {
+          incidentID: incident.incidentID,
+          title: incident.title,
+          severity: incident.severity,
+          status: incident.status,
+          isDrill: incident.isDrill,
+          labels: (incident.labels || []).map((l: any) => l.label),
+          summary: incident.summary || null,
+        },
+        impact: {
+          start,
+          end,
+          durationMinutes,
+          severity: incident.severity,
+          description: null,
+        },
+        roles: (incident.incidentMembership?.assignments || []).map((a: any) => ({
+          role: a.role?.name,
+          user: a.user?.name,
+        })),
+        timeline,
+        keyNotes: timeline.filter((t: any) => t.kind === 'userNote').map((t: any) => t.description),
+        contributingFactors: [],
+        whatWentWell: [],
+        whatWentWrong: [],
+        actionItems: (incident.taskList?.tasks || []).map((t: any) => ({
+          text: t.text,
+          status: t.status,
+          assignee: t.assignedUser?.name,
+        })),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+// Alert severity label values mapped to incident severities
+const ALERT_SEVERITY_MAP: Record<string, string> = {
+  critical: 'critical',
+  page: 'critical',
+  high: 'major',
+  major: 'major',
+  error: 'major',
+  warning: 'minor',
+  minor: 'minor',
+  info: 'minor',
+};
+
+export const createIncidentFromAlert: ToolDefinition = {
+  name: 'create_incident_from_alert',
+  description: 'Declare a Grafana incident from a firing alert instance. The incident is pre-populated with the alert\'s title, severity, labels, and a link to the alert',
+  inputSchema: CreateIncidentFromAlertSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      if (!params.fingerprint && !params.ruleUid) {
+        return createErrorResult('Either fingerprint or ruleUid must be provided');
+      }
+      
+      const grafanaClient = new GrafanaClient(context.config.grafanaConfig);
+      const filter = Object.entries(params.labels || {}).map(([name, value]) => `${name}="${value}"`);
+      if (params.ruleUid) filter.push(`__alert_rule_uid__="${params.ruleUid}"`);
+      
+      const alerts = await grafanaClient.listAlertmanagerAlerts({
+        active: true,
+        silenced: true,
+        inhibited: true,
+        filter,
+      });
+      const alert = params.fingerprint
+        ? alerts.find((a: any) => a.fingerprint === params.fingerprint)
+        : alerts[0];
+      if (!alert) {
+        return createErrorResult('No matching firing alert found');
+      }
+      
+      const alertLabels: Record<string, string> = alert.labels || {};
+      const alertname = alertLabels.alertname || 'Alert';
+      const severity = params.severity
+        || ALERT_SEVERITY_MAP[(alertLabels.severity || '').toLowerCase()]
+        || 'minor';
+      const ruleUid = params.ruleUid || alertLabels.__alert_rule_uid__;
+      const alertUrl = ruleUid
+        ? `${context.config.grafanaConfig.url}/alerting/grafana/${ruleUid}/view`
+        : alert.generatorURL;
+      
+      const labels = Object.entries(alertLabels)
+        .filter(([key]) => !key.startsWith('__') && key !== 'alertname')
+        .map(([key, value]) => ({ key, label: `${key}:${value}` }));
+      
+      const client = createIncidentClient(context.config.grafanaConfig);
+      const response = await client.post('/IncidentService.CreateIncident', {
+        incident: {
+          title: alert.annotations?.summary ? `${alertname}: ${alert.annotations.summary}` : alertname,
+          severity,
+          roomPrefix: params.roomPrefix || 'incident',
+          isDrill: params.isDrill || false,
+          status: 'active',
+          labels,
+        },
+        attachments: alertUrl
+          ? [{
+              attachmentID: 'alert-1',
+              url: alertUrl,
+              useToSummarize: true,
+              caption: `Firing alert ${alertname} since ${alert.startsAt}`,
+            }]
+          : [],
+      });
+      
+      return createToolResult({
+        incidentID: response.data.incident.incidentID,
+        title: response.data.incident.title,
+        severity: response.data.incident.severity,
+        status: response.data.incident.status,
+        alertFingerprint: alert.fingerprint,
+        alertUrl,
+        message: 'Incident created from alert successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+// Built-in incident role IDs
+const INCIDENT_ROLE_IDS: Record<string, number> = {
+  commander: 1,
+  investigator: 2,
+  observer: 3,
+};
+
+export const assignIncidentRole: ToolDefinition = {
+  name: 'assign_incident_role',
+  description: 'Assign a role (commander, investigator, observer) on an incident to a user, or remove it',
+  inputSchema: AssignIncidentRoleSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createIncidentClient(context.config.grafanaConfig);
+      
+      const endpoint = params.unassign ? '/IncidentService.UnassignRole' : '/IncidentService.AssignRole';
+      const response = await client.post(endpoint, {
+        incidentID: params.incidentId,
+        userID: params.userId,
+        roleID: INCIDENT_ROLE_IDS[params.role],
+      });
+      
+      return createToolResult({
+        success: true,
+        message: params.unassign
+          ? `Role ${params.role} removed from user ${params.userId}`
+          : `Role ${params.role} assigned to user ${params.userId}`,
+        assignments: response.data.incidentMembership?.assignments?.map((a: any) => ({
+          role: a.role?.name,
+          user: a.user?.name,
+        })),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const updateIncident: ToolDefinition = {
+  name: 'update_incident',
+  description: 'Update the status, severity, or title of an existing incident',
+  inputSchema: UpdateIncidentSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      if (!params.status && !params.severity && !params.title) {
+        return createErrorResult('At least one of status, severity, or title must be provided');
+      }
+      
+      const client = createIncidentClient(context.config.grafanaConfig);
+      
+      let incident: any;
+      if (params.title) {
+        const response = await client.post('/IncidentService.UpdateTitle', {
+          incidentID: params.incidentId,
+          title: params.title,
+        });
+        incident = response.data.incident;
+      }
+      if (params.severity) {
+        const response = await client.post('/IncidentService.UpdateSeverity', {
+          incidentID: params.incidentId,
+          severity: params.severity,
+        });
+        incident = response.data.incident;
+      }
+      if (params.status) {
+        const response = await client.post('/IncidentService.UpdateStatus', {
+          incidentID: params.incidentId,
+          status: params.status,
+        });
+        incident = response.data.incident;
+      }
+      
+      return createToolResult({
+        incidentID: incident.incidentID,
+        title: incident.title,
+        status: incident.status,
+        severity: incident.severity,
+        message: 'Incident updated successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const addIncidentTask: ToolDefinition = {
+  name: 'add_incident_task',
+  description: 'Add a task to an incident\'s task list, optionally assigned to a user',
+  inputSchema: AddIncidentTaskSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createIncidentClient(context.config.grafanaConfig);
+      
+      const taskData: any = {
+        incidentID: params.incidentId,
+        text: params.text,
+      };
+      if (params.assigneeUserId) taskData.assignToUserId = params.assigneeUserId;
+      
+      const response = await client.post('/TaskService.AddTask', taskData);
+      
+      return createToolResult({
+        success: true,
+        message: 'Task added to incident',
+        task: response.data.task,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
 export function registerIncidentTools(server: any) {
   server.registerTool(listIncidents);
   server.registerTool(getIncident);
   server.registerTool(createIncident);
   server.registerTool(addActivityToIncident);
+  server.registerTool(getIncidentTimeline);
+  server.registerTool(generateIncidentRetrospective);
+  server.registerTool(createIncidentFromAlert);
+  server.registerTool(assignIncidentRole);
+  server.registerTool(updateIncident);
+  server.registerTool(addIncidentTask);
 }
\ No newline at end of file
@@ -0,0 +1,88 @@
+import { MCPServer } from '../server/mcp-server';
+import { registerSearchTools } from './search';
+import { registerDashboardTools } from './dashboard';
+import { registerDatasourceTools } from './datasource';
+import { registerPrometheusTools } from './prometheus';
+import { registerLokiTools } from './loki';
+import { registerIncidentTools } from './incident';
+import { registerAlertingTools } from './alerting';
+import { registerOncallTools } from './oncall';
+import { registerAdminTools } from './admin';
+import { registerSiftTools } from './sift';
+import { registerPyroscopeTools } from './pyroscope';
+import { registerNavigationTools } from './navigation';
+import { registerAssertsTools } from './asserts';
+import { registerSloTools } from './slo';
+import { registerSyntheticsTools } from './synthetics';
+import { registerK6Tools } from './k6';
+import { registerKubernetesTools } from './kubernetes';
+import { registerMlTools } from './ml';
+import { registerCloudTools } from './cloud';
+import { registerFleetTools } from './fleet';
+import { registerReportingTools } from './reporting';
+import { registerHistoryTools } from './history';
+import { registerInstanceTools } from './instance';
+import { registerQueryTools } from './query';
+import { registerInvestigationTools } from './investigate';
+import { registerElasticsearchTools } from './elasticsearch';
+import { registerSqlTools } from './sql';
+import { registerCloudWatchTools } from './cloudwatch';
+import { registerAzureTools } from './azure';
+import { registerCloudMonitoringTools } from './cloudmonitoring';
+import { registerGraphiteTools } from './graphite';
+import { registerInfluxdbTools } from './influxdb';
+import { registerTestDataTools } from './testdata';
+import { registerLiveTools } from './live';
+import { registerSchedulerTools } from './scheduler';
+
+// Registration function of each built-in tool category
+export const TOOL_REGISTRARS: Record<string, (server: MCPServer) => void> = {
+  search: registerSearchTools,
+  dashboard: registerDashboardTools,
+  datasource: registerDatasourceTools,
+  prometheus: registerPrometheusTools,
+  loki: registerLokiTools,
+  incident: registerIncidentTools,
+  alerting: registerAlertingTools,
+  oncall: registerOncallTools,
+  admin: registerAdminTools,
+  sift: registerSiftTools,
+  pyroscope: registerPyroscopeTools,
+  navigation: registerNavigationTools,
+  asserts: registerAssertsTools,
+  slo: registerSloTools,
+  synthetics: registerSyntheticsTools,
+  k6: registerK6Tools,
+  kubernetes: registerKubernetesTools,
+  ml: registerMlTools,
+  cloud: registerCloudTools,
+  fleet: registerFleetTools,
+  reporting: registerReportingTools,
+  history: registerHistoryTools,
+  instance: registerInstanceTools,
+  query: registerQueryTools,
+  investigation: registerInvestigationTools,
+  elasticsearch: registerElasticsearchTools,
+  sql: registerSqlTools,
+  cloudwatch: registerCloudWatchTools,
+  azure: registerAzureTools,
+  cloudmonitoring: registerCloudMonitoringTools,
+  graphite: registerGraphiteTools,
+  influxdb: registerInfluxdbTools,
+  testdata: registerTestDataTools,
+  live: registerLiveTools,
+  scheduler: registerSchedulerTools,
+};
+
+// Registers the built-in tools of the given categories (default: all) on a server.
+// Embedders can add their own tools alongside them with server.registerTool.
+export function registerTools(server: MCPServer, ...categories: string[]) {
+  const selected = categories.length > 0 ? categories : Object.keys(TOOL_REGISTRARS);
+  for (const category of selected) {
+    const register = TOOL_REGISTRARS[category];
+    if (!register) {
+      throw new Error(`Unknown tool category "${category}"`);
+    }
+    register(server);
+  }
+}
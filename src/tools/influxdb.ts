@@ -0,0 +1,82 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+import { parseTimeRange } from '../utils/time';
+import { DEFAULT_MAX_DATA_POINTS } from '../utils/downsample';
+import { renderTables, resultTables } from '../utils/table';
+
+// Schema definitions
+const QueryInfluxdbSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the InfluxDB datasource'),
+  query: z.string().describe('An InfluxQL query (e.g. \'SELECT mean("usage_user") FROM "cpu" WHERE $timeFilter GROUP BY time($__interval), "host"\' or "SHOW MEASUREMENTS") or a Flux query (e.g. \'from(bucket: "telegraf") |> range(start: v.timeRangeStart, stop: v.timeRangeStop) |> filter(fn: (r) => r._measurement == "cpu")\'), matching the query language of the datasource'),
+  startRfc3339: z.string().optional().describe('The start of $timeFilter or v.timeRangeStart (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end of the time range (default: now)'),
+  maxDataPoints: z.number().optional().describe(`Maximum datapoints per series, used for $__interval (default: ${DEFAULT_MAX_DATA_POINTS})`),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+// Tool definitions
+export const queryInfluxdb: ToolDefinition = {
+  name: 'query_influxdb',
+  description: 'Runs an InfluxQL or Flux query through an InfluxDB datasource, depending on the query language the datasource is configured with. Grafana macros such as $timeFilter and $__interval (InfluxQL) and v.timeRangeStart (Flux) are filled in from the time range. Use SHOW MEASUREMENTS, SHOW TAG KEYS, or the Flux schema functions to discover the data',
+  inputSchema: QueryInfluxdbSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const datasource = await client.getDatasourceByUid(params.datasourceUid);
+      if (datasource.type !== 'influxdb') {
+        return createErrorResult(
+          `Datasource ${params.datasourceUid} is a ${datasource.type} datasource, not InfluxDB`
+        );
+      }
+      const language = datasource.jsonData?.version || 'InfluxQL';
+
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+      const maxDataPoints = params.maxDataPoints || DEFAULT_MAX_DATA_POINTS;
+      const intervalMs = Math.max(
+        Math.ceil((timeRange.end.getTime() - timeRange.start.getTime()) / maxDataPoints),
+        1000
+      );
+
+      const query: any = {
+        refId: 'A',
+        datasource: { uid: datasource.uid, type: 'influxdb' },
+        query: params.query,
+        maxDataPoints,
+        intervalMs,
+      };
+      if (language === 'InfluxQL') {
+        // Raw InfluxQL rather than the query builder model
+        Object.assign(query, { rawQuery: true, resultFormat: 'time_series' });
+      }
+
+      const result = await client.queryDatasources({
+        queries: [query],
+        from: timeRange.start.getTime().toString(),
+        to: timeRange.end.getTime().toString(),
+      });
+      const error = result?.results?.A?.error;
+      if (error) {
+        return createErrorResult(`${language} query failed: ${error}`);
+      }
+
+      const tables = resultTables(result, context.timezone);
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables(tables, params.format));
+      }
+      return createToolResult({
+        language,
+        tables: tables.map(table => ({ columns: table.columns, rows: table.rows })),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerInfluxdbTools(server: any) {
+  server.registerTool(queryInfluxdb);
+}
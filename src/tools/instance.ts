@@ -0,0 +1,56 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+
+// Schema definitions
+const GetGrafanaInfoSchema = z.object({
+  includePlugins: z.boolean().optional().describe('Whether to include installed plugins (default: true)'),
+  includeCorePlugins: z.boolean().optional().describe('Whether to include plugins bundled with Grafana (default: false)'),
+});
+
+// Tool definitions
+export const getGrafanaInfo: ToolDefinition = {
+  name: 'get_grafana_info',
+  description: 'Report the Grafana version, edition, enabled feature toggles, and installed plugins, to check compatibility before using other tools',
+  inputSchema: GetGrafanaInfoSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const health = await client.getHealth();
+      const settings = await client.getFrontendSettings();
+      
+      const featureToggles = Object.entries(settings.featureToggles || {})
+        .filter(([, enabled]) => enabled === true)
+        .map(([name]) => name)
+        .sort();
+      
+      let plugins: any[] | undefined;
+      if (params.includePlugins ?? true) {
+        const installed = await client.listPlugins(params.includeCorePlugins ? {} : { core: 0 });
+        plugins = installed.map(plugin => ({
+          id: plugin.id,
+          name: plugin.name,
+          type: plugin.type,
+          version: plugin.info?.version,
+          enabled: plugin.enabled,
+        }));
+      }
+      
+      return createToolResult({
+        version: settings.buildInfo?.version || health.version,
+        commit: settings.buildInfo?.commit || health.commit,
+        edition: settings.buildInfo?.edition,
+        environment: settings.buildInfo?.env,
+        database: health.database,
+        featureToggles,
+        plugins,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerInstanceTools(server: any) {
+  server.registerTool(getGrafanaInfo);
+}
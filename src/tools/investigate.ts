@@ -0,0 +1,582 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient, Datasource } from '../clients/grafana-client';
+import { PrometheusClient, PrometheusQueryResult } from '../clients/prometheus-client';
+import { LokiClient } from '../clients/loki-client';
+import { createPluginClient } from '../clients/base-client';
+import { checkDatasourceAccess, isDatasourceAllowed } from '../server/datasource-policy';
+import {
+  TimeRange,
+  formatTime,
+  parseTime,
+  parseTimeRange,
+  toUnixSeconds,
+} from '../utils/time';
+import { widenStep } from '../utils/downsample';
+import { createLogScrubber } from '../utils/scrub';
+import { mapConcurrent } from '../utils/concurrency';
+import { escapeLabelValue, labelSelector } from '../utils/promql';
+import { PhraseEvent, resolvePhraseEvent, resolvePhraseRange } from '../utils/time-phrases';
+
+// Samples per series of the key metrics; only their summary is returned
+const METRIC_POINTS = 60;
+
+// Key metrics of an entity; $selector is replaced with its label matchers
+const DEFAULT_METRICS: Record<string, string> = {
+  up: 'up{$selector}',
+  cpu_cores: 'sum by (pod) (rate(container_cpu_usage_seconds_total{$selector}[5m]))',
+  memory_bytes: 'sum by (pod) (container_memory_working_set_bytes{$selector})',
+  restarts: 'sum by (pod) (increase(kube_pod_container_status_restarts_total{$selector}[1h]))',
+};
+
+const LABEL_NAME_PATTERN = /^[a-zA-Z_][a-zA-Z0-9_]*$/;
+
+// Line filter for error logs
+const ERROR_LOG_FILTER = '|~ "(?i)(error|exception|fatal|panic)"';
+
+// Trace attributes of common entity labels; other labels are matched as attributes of the
+// same name
+const TRACE_ATTRIBUTES: Record<string, string> = {
+  service: 'resource.service.name',
+  service_name: 'resource.service.name',
+  job: 'resource.service.name',
+  namespace: 'resource.k8s.namespace.name',
+  pod: 'resource.k8s.pod.name',
+  container: 'resource.k8s.container.name',
+  cluster: 'resource.k8s.cluster.name',
+};
+
+const InvestigateEntitySchema = z.object({
+  labels: z.record(z.string()).describe('Labels identifying the entity in metrics and logs, e.g. {"namespace": "shop", "service": "checkout"} or {"pod": "checkout-7d9f"}'),
+  startTime: z.string().optional().describe('The start of the window (RFC3339, unix epoch, or relative like "now-1h"; default: now-1h)'),
+  endTime: z.string().optional().describe('The end of the window (default: now)'),
+  prometheusUid: z.string().optional().describe('The Prometheus datasource for metrics (default: the default or first Prometheus datasource)'),
+  lokiUid: z.string().optional().describe('The Loki datasource for logs (default: the default or first Loki datasource)'),
+  tempoUid: z.string().optional().describe('The Tempo datasource for traces (default: the default or first Tempo datasource)'),
+  metrics: z.record(z.string()).optional().describe('PromQL queries by name replacing the default key metrics (up, CPU, memory, restarts); $selector is replaced with the label matchers'),
+  errorLogLimit: z.number().optional().describe('Maximum error log lines (default: 20, max: 100)'),
+  minTraceDuration: z.string().regex(/^\d+(\.\d+)?(ns|us|ms|s|m|h)$/, 'Use a duration such as 500ms or 2s').optional().describe('Minimum duration of slow traces, e.g. "500ms" or "2s" (default: 500ms)'),
+  traceLimit: z.number().optional().describe('Maximum slow traces (default: 10)'),
+});
+
+// Picks the datasource of a type to use: the requested one, else the default or first one the
+// datasource policy allows
+async function pickDatasource(
+  context: ToolContext,
+  datasources: () => Promise<Datasource[]>,
+  type: string,
+  uid?: string
+): Promise<string | undefined> {
+  if (uid) {
+    await checkDatasourceAccess(context.config.grafanaConfig, [uid]);
+    return uid;
+  }
+  const policy = context.config.grafanaConfig.datasourcePolicy;
+  const candidates = (await datasources()).filter(
+    datasource => datasource.type === type && isDatasourceAllowed(policy, datasource)
+  );
+  return (candidates.find(datasource => datasource.isDefault) || candidates[0])?.uid;
+}
+
+// Summarizes a series as its last, minimum, maximum, and average value
+function summarizeSeries(series: PrometheusQueryResult) {
+  const values = (series.values || [])
+    .map(([, value]) => parseFloat(value))
+    .filter(value => Number.isFinite(value));
+  if (values.length === 0) {
+    return { labels: series.metric, samples: 0 };
+  }
+  const round = (value: number) => Math.round(value * 1000) / 1000;
+  return {
+    labels: series.metric,
+    last: round(values[values.length - 1]),
+    min: round(Math.min(...values)),
+    max: round(Math.max(...values)),
+    avg: round(values.reduce((sum, value) => sum + value, 0) / values.length),
+    samples: values.length,
+  };
+}
+
+async function gatherMetrics(
+  context: ToolContext,
+  uid: string,
+  selector: string,
+  queries: Record<string, string>,
+  range: TimeRange
+) {
+  const client = new PrometheusClient(context.config.grafanaConfig, uid);
+  const start = toUnixSeconds(range.start);
+  const end = toUnixSeconds(range.end);
+  const stepSeconds = widenStep(start, end, 60, METRIC_POINTS);
+
+  const entries = Object.entries(queries);
+  const results = await mapConcurrent(entries, 5, async ([name, template]) => {
+    const expr = template.replace(/\$selector/g, selector);
+    try {
+      const series = await client.queryRange(
+        expr,
+        start.toString(),
+        end.toString(),
+        `${stepSeconds}s`
+      );
+      return [name, { expr, series: series.map(summarizeSeries) }];
+    } catch (error: any) {
+      return [name, { expr, error: error.message }];
+    }
+  });
+  return { datasourceUid: uid, stepSeconds, queries: Object.fromEntries(results) };
+}
+
+async function gatherErrorLogs(
+  context: ToolContext,
+  uid: string,
+  selector: string,
+  range: TimeRange,
+  limit: number
+) {
+  const client = new LokiClient(context.config.grafanaConfig, uid);
+  const query = `{${selector}} ${ERROR_LOG_FILTER}`;
+  const logs = await client.queryLogs(
+    query,
+    range.start.toISOString(),
+    range.end.toISOString(),
+    limit,
+    'backward'
+  );
+  // Redact personal data and secrets when configured; Loki timestamps are unix nanoseconds
+  const scrub = createLogScrubber(context.config.grafanaConfig.logScrubbing);
+  return {
+    datasourceUid: uid,
+    query,
+    lines: logs.map(entry => ({
+      timestamp: formatTime(parseInt(entry.timestamp.slice(0, -6), 10), context.timezone),
+      labels: scrub
+        ? Object.fromEntries(
+          Object.entries(entry.labels).map(([name, value]) => [name, scrub(value)])
+        )
+        : entry.labels,
+      line: scrub && entry.line !== undefined ? scrub(entry.line) : entry.line,
+    })),
+  };
+}
+
+async function gatherSlowTraces(
+  context: ToolContext,
+  uid: string,
+  labels: Record<string, string>,
+  range: TimeRange,
+  minDuration: string,
+  limit: number
+) {
+  const conditions = Object.entries(labels).map(
+    ([name, value]) => `${TRACE_ATTRIBUTES[name] || `.${name}`}="${escapeLabelValue(value)}"`
+  );
+  const query = `{ ${[...conditions, `duration > ${minDuration}`].join(' && ')} }`;
+  const config = context.config.grafanaConfig;
+  const client = createPluginClient(config, `${config.url}/api/datasources/proxy/uid/${uid}`);
+  const response = await client.get('/api/search', {
+    params: {
+      q: query,
+      start: toUnixSeconds(range.start),
+      end: toUnixSeconds(range.end),
+      limit,
+    },
+  });
+  const traces = (response.data?.traces || []).map((trace: any) => ({
+    traceId: trace.traceID,
+    rootService: trace.rootServiceName,
+    rootSpan: trace.rootTraceName,
+    start: formatTime(Math.floor(parseInt(trace.startTimeUnixNano, 10) / 1e6), context.timezone),
+    durationMs: trace.durationMs,
+  }));
+  traces.sort((a: any, b: any) => (b.durationMs || 0) - (a.durationMs || 0));
+  return { datasourceUid: uid, query, traces };
+}
+
+export const investigateEntity: ToolDefinition = {
+  name: 'investigate_entity',
+  description: 'Investigate a service, namespace, or pod in one call: gathers its key metrics from Prometheus (summarized per series), recent error logs from Loki, and slow traces from Tempo in parallel. Use it as the first step of an investigation, then drill down with the individual query tools. Signals without a datasource or that fail are reported as such.',
+  inputSchema: InvestigateEntitySchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const labels: Record<string, string> = params.labels;
+      if (Object.keys(labels).length === 0) {
+        return createErrorResult('At least one label is required to identify the entity');
+      }
+      const invalid = Object.keys(labels).filter(name => !LABEL_NAME_PATTERN.test(name));
+      if (invalid.length > 0) {
+        return createErrorResult(`Invalid label names: ${invalid.join(', ')}`);
+      }
+      const range = parseTimeRange(params.startTime, params.endTime, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+      const selector = labelSelector(labels);
+
+      // The datasources are listed at most once, and only when one is not given
+      let listing: Promise<Datasource[]> | undefined;
+      const datasources = () => {
+        listing ??= new GrafanaClient(context.config.grafanaConfig).listDatasources();
+        return listing;
+      };
+
+      // Each signal is gathered on its own, so one failing does not hide the others
+      const gather = async (
+        type: string,
+        requestedUid: string | undefined,
+        fn: (uid: string) => Promise<object>
+      ) => {
+        try {
+          const uid = await pickDatasource(context, datasources, type, requestedUid);
+          if (!uid) {
+            return { skipped: `No ${type} datasource available` };
+          }
+          return await fn(uid);
+        } catch (error: any) {
+          return { error: error.message };
+        }
+      };
+
+      const [metrics, errorLogs, slowTraces] = await Promise.all([
+        gather('prometheus', params.prometheusUid, uid =>
+          gatherMetrics(context, uid, selector, params.metrics || DEFAULT_METRICS, range)
+        ),
+        gather('loki', params.lokiUid, uid =>
+          gatherErrorLogs(context, uid, selector, range, Math.min(params.errorLogLimit || 20, 100))
+        ),
+        gather('tempo', params.tempoUid, uid =>
+          gatherSlowTraces(
+            context,
+            uid,
+            labels,
+            range,
+            params.minTraceDuration || '500ms',
+            params.traceLimit || 10
+          )
+        ),
+      ]);
+
+      return createToolResult({
+        entity: labels,
+        window: {
+          start: formatTime(range.start, context.timezone),
+          end: formatTime(range.end, context.timezone),
+        },
+        metrics,
+        errorLogs,
+        slowTraces,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+const WhatChangedSchema = z.object({
+  startTime: z.string().optional().describe('The start of the window (RFC3339, unix epoch, or relative like "now-1h"; default: now-1h). Ignored when around is given'),
+  endTime: z.string().optional().describe('The end of the window (default: now). Ignored when around is given'),
+  around: z.string().optional().describe('A point in time to look around instead of a start and end, e.g. "2024-05-01T14:05:00Z" or "now-2h"'),
+  window: z.string().regex(/^\d+[smhd]$/, 'Use a duration such as 15m or 1h').optional().describe('How far before and after around to look, e.g. "15m" (default: 30m)'),
+  folderUids: z.array(z.string()).optional().describe('Only check dashboards in these folders'),
+  tags: z.array(z.string()).optional().describe('Only check dashboards with all of these tags'),
+  maxDashboards: z.number().optional().describe('Maximum dashboards whose versions are checked (default: 100, max: 500)'),
+  limit: z.number().optional().describe('Maximum changes per source (default: 100)'),
+});
+
+// Versions fetched per dashboard; older ones are unlikely to fall in an incident window
+const DASHBOARD_VERSIONS = 20;
+
+interface Change {
+  time: string;
+  source: string;
+  summary: string;
+  [key: string]: any;
+}
+
+function inRange(time: string | number | undefined, range: TimeRange): boolean {
+  if (time === undefined) return false;
+  const ms = new Date(time).getTime();
+  return ms >= range.start.getTime() && ms <= range.end.getTime();
+}
+
+async function dashboardChanges(
+  context: ToolContext,
+  client: GrafanaClient,
+  params: any,
+  range: TimeRange,
+  limit: number
+): Promise<Change[]> {
+  const maxDashboards = Math.min(params.maxDashboards || 100, 500);
+  const dashboards = await client.search({
+    type: 'dash-db',
+    folderUIDs: params.folderUids,
+    tag: params.tags,
+    limit: maxDashboards,
+  });
+  // Search results carry no modification time, so the versions of each dashboard are checked
+  const versions = await mapConcurrent(dashboards, 10, async (dashboard: any) => {
+    const saved = await client.getDashboardVersions(dashboard.uid, DASHBOARD_VERSIONS);
+    return saved
+      .filter(version => inRange(version.created, range))
+      .map(version => ({
+        time: formatTime(new Date(version.created), context.timezone),
+        source: 'dashboard',
+        summary: `Dashboard "${dashboard.title}" saved as version ${version.version}`,
+        dashboardUid: dashboard.uid,
+        version: version.version,
+        createdBy: version.createdBy,
+        message: version.message || undefined,
+      }));
+  });
+  return versions.flat().slice(0, limit);
+}
+
+async function alertRuleChanges(
+  context: ToolContext,
+  client: GrafanaClient,
+  range: TimeRange,
+  limit: number
+): Promise<Change[]> {
+  const rules = await client.listAlertRules();
+  return rules
+    .filter(rule => inRange(rule.updated, range))
+    .slice(0, limit)
+    .map(rule => ({
+      time: formatTime(new Date(rule.updated), context.timezone),
+      source: 'alert_rule',
+      summary: `Alert rule "${rule.title}" updated`,
+      ruleUid: rule.uid,
+      folderUid: rule.folderUID,
+      ruleGroup: rule.ruleGroup,
+      provenance: rule.provenance || undefined,
+    }));
+}
+
+async function annotationChanges(
+  context: ToolContext,
+  client: GrafanaClient,
+  range: TimeRange,
+  limit: number
+): Promise<Change[]> {
+  const annotations = await client.listAnnotations({
+    from: range.start.getTime(),
+    to: range.end.getTime(),
+    type: 'annotation',
+    limit,
+  });
+  return annotations.map(annotation => ({
+    time: formatTime(annotation.time, context.timezone),
+    source: 'annotation',
+    summary: annotation.text || '(no text)',
+    tags: annotation.tags?.length ? annotation.tags : undefined,
+    dashboardUid: annotation.dashboardUID || undefined,
+    user: annotation.login || annotation.email || undefined,
+  }));
+}
+
+async function datasourceChanges(
+  context: ToolContext,
+  client: GrafanaClient,
+  range: TimeRange,
+  limit: number
+): Promise<Change[]> {
+  // Grafana only reports modification times of datasources in some versions
+  const policy = context.config.grafanaConfig.datasourcePolicy;
+  const datasources = await client.listDatasources();
+  return datasources
+    .filter(datasource => isDatasourceAllowed(policy, datasource))
+    .filter(datasource => inRange(datasource.updated, range))
+    .slice(0, limit)
+    .map(datasource => ({
+      time: formatTime(new Date(datasource.updated), context.timezone),
+      source: 'datasource',
+      summary: `Datasource "${datasource.name}" (${datasource.type}) updated`,
+      datasourceUid: datasource.uid,
+    }));
+}
+
+export const whatChanged: ToolDefinition = {
+  name: 'what_changed',
+  description: 'List what changed in Grafana within a time window, answering "what changed around 14:05?" during an incident: saved dashboard versions, alert rule updates, annotations (such as deploy markers), and datasource updates, merged into one timeline. Give either a start and end time or a point in time with a window around it. Sources that fail are reported as such.',
+  inputSchema: WhatChangedSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      let range: TimeRange;
+      if (params.around) {
+        const center = parseTime(params.around, { timezone: context.timezone });
+        const window = params.window || '30m';
+        range = {
+          start: parseTime(`now-${window}`, { now: center }),
+          end: parseTime(`now+${window}`, { now: center }),
+        };
+      } else {
+        range = parseTimeRange(params.startTime, params.endTime, {
+          timezone: context.timezone,
+          defaultStart: 'now-1h',
+        });
+      }
+      const limit = params.limit || 100;
+      const client = new GrafanaClient(context.config.grafanaConfig);
+
+      // Each source is queried on its own, so one failing does not hide the others
+      const sources: Record<string, () => Promise<Change[]>> = {
+        dashboards: () => dashboardChanges(context, client, params, range, limit),
+        alertRules: () => alertRuleChanges(context, client, range, limit),
+        annotations: () => annotationChanges(context, client, range, limit),
+        datasources: () => datasourceChanges(context, client, range, limit),
+      };
+      const errors: Record<string, string> = {};
+      const results = await Promise.all(
+        Object.entries(sources).map(async ([name, fn]) => {
+          try {
+            return await fn();
+          } catch (error: any) {
+            errors[name] = error.message;
+            return [];
+          }
+        })
+      );
+
+      const changes = results.flat();
+      changes.sort((a, b) => new Date(a.time).getTime() - new Date(b.time).getTime());
+      return createToolResult({
+        window: {
+          start: formatTime(range.start, context.timezone),
+          end: formatTime(range.end, context.timezone),
+        },
+        count: changes.length,
+        changes,
+        errors: Object.keys(errors).length > 0 ? errors : undefined,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+const ResolveTimeRangeSchema = z.object({
+  phrase: z.string().describe('The time phrase to resolve, e.g. "during last night\'s deploy", "since the upgrade", "yesterday afternoon", "the past 2 hours", or "around 14:05"'),
+  window: z.string().regex(/^\d+[smhd]$/, 'Use a duration such as 15m or 1h').optional().describe('How far a range around an event reaches, e.g. after its end or before its start (default: 30m)'),
+  lookback: z.string().regex(/^\d+[smhdw]$/, 'Use a duration such as 1d or 2w').optional().describe('How far back to look for an event when the phrase names no day or period (default: 7d)'),
+  tags: z.array(z.string()).optional().describe('Only consider annotations with all of these tags as the event, e.g. ["deploy", "checkout"]'),
+});
+
+// Candidate events returned besides the chosen one
+const EVENT_CANDIDATES = 10;
+
+// Helper to find the annotations of an event within a range, latest first
+async function findEventAnnotations(
+  client: GrafanaClient,
+  event: PhraseEvent,
+  range: TimeRange,
+  tags?: string[]
+): Promise<any[]> {
+  const annotations = await client.listAnnotations({
+    from: range.start.getTime(),
+    to: range.end.getTime(),
+    type: 'annotation',
+    tags,
+    limit: 500,
+  });
+  return annotations
+    .filter(annotation =>
+      event.pattern.test(annotation.text || '') ||
+      (annotation.tags || []).some((tag: string) => event.pattern.test(tag))
+    )
+    .sort((a, b) => b.time - a.time);
+}
+
+// Helper to derive the range a phrase means from the event it refers to
+function eventRange(event: PhraseEvent, annotation: any, window: string, now: Date): TimeRange {
+  const start = new Date(annotation.time);
+  const end = new Date(Math.max(annotation.timeEnd || 0, annotation.time));
+  const before = (time: Date) => parseTime(`now-${window}`, { now: time });
+  const after = (time: Date) => {
+    const shifted = parseTime(`now+${window}`, { now: time });
+    return shifted > now ? now : shifted;
+  };
+  switch (event.relation) {
+    case 'since':
+      return { start, end: now };
+    case 'after':
+      return { start: end, end: after(end) };
+    case 'before':
+      return { start: before(start), end: start };
+    case 'around':
+      return { start: before(start), end: after(end) };
+    default:
+      // The effects of an event outlast it, so the range runs on for the window
+      return { start, end: after(end) };
+  }
+}
+
+export const resolveTimeRange: ToolDefinition = {
+  name: 'resolve_time_range',
+  description: 'Resolve a natural-language time phrase into concrete from/to timestamps for other query tools, e.g. "during last night\'s deploy", "since the upgrade", "yesterday afternoon", or "around 14:05". Phrases referring to deploys, releases, upgrades, migrations, incidents, restarts, or maintenance are resolved with the matching Grafana annotations (such as deploy markers), choosing the latest one within the named period. Day parts follow the configured time zone: morning 06-12, afternoon 12-18, evening 18-24, night 18-06.',
+  inputSchema: ResolveTimeRangeSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const now = new Date();
+      const window = params.window || '30m';
+      const period = resolvePhraseRange(params.phrase, { now, timezone: context.timezone });
+      const event = resolvePhraseEvent(params.phrase);
+      if (!period && !event) {
+        return createErrorResult(
+          `Could not interpret "${params.phrase}"; use a phrase like "the past 2 hours", ` +
+            '"yesterday afternoon", or "during the last deploy", or give times directly'
+        );
+      }
+
+      let range: TimeRange = period as TimeRange;
+      let interpretation = period?.interpretation;
+      let matched: any;
+      let candidates: any[] | undefined;
+      if (event) {
+        const searched = period ||
+          parseTimeRange(`now-${params.lookback || '7d'}`, 'now', { now });
+        const client = new GrafanaClient(context.config.grafanaConfig);
+        const annotations = await findEventAnnotations(client, event, searched, params.tags);
+        if (annotations.length === 0) {
+          return createErrorResult(
+            `No annotation of a ${event.keyword} found between ` +
+              `${formatTime(searched.start, context.timezone)} and ` +
+              `${formatTime(searched.end, context.timezone)}; widen the lookback or name the period`
+          );
+        }
+        matched = annotations[0];
+        range = eventRange(event, matched, window, now);
+        interpretation = `${event.relation} the ${event.keyword} annotated at ` +
+          `${formatTime(matched.time, context.timezone)}` +
+          (period ? ` (the latest within ${period.interpretation})` : ' (the latest one)');
+        candidates = annotations.slice(0, EVENT_CANDIDATES).map(annotation => ({
+          id: annotation.id,
+          time: formatTime(annotation.time, context.timezone),
+          timeEnd: annotation.timeEnd && annotation.timeEnd > annotation.time
+            ? formatTime(annotation.timeEnd, context.timezone)
+            : undefined,
+          text: annotation.text,
+          tags: annotation.tags?.length ? annotation.tags : undefined,
+        }));
+      }
+
+      return createToolResult({
+        phrase: params.phrase,
+        from: formatTime(range.start, context.timezone),
+        to: formatTime(range.end, context.timezone),
+        fromMs: range.start.getTime(),
+        toMs: range.end.getTime(),
+        interpretation,
+        event: candidates?.[0],
+        candidates: candidates && candidates.length > 1 ? candidates : undefined,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerInvestigationTools(server: any) {
+  server.registerTool(investigateEntity);
+  server.registerTool(whatChanged);
+  server.registerTool(resolveTimeRange);
+}
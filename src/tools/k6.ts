@@ -0,0 +1,136 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { createPluginClient } from '../clients/base-client';
+
+// Schema definitions
+const ListK6TestsSchema = z.object({
+  projectId: z.number().optional().describe('Only include tests of this project'),
+  name: z.string().optional().describe('Only include tests whose name contains this string'),
+});
+
+const ListK6TestRunsSchema = z.object({
+  testId: z.number().describe('The ID of the load test'),
+  limit: z.number().optional().describe('Maximum number of runs to return, most recent first (default: 10)'),
+});
+
+const GetK6TestRunSchema = z.object({
+  runId: z.number().describe('The ID of the test run'),
+});
+
+const StartK6TestSchema = z.object({
+  testId: z.number().describe('The ID of the saved load test to run'),
+});
+
+// Helper function to create k6 Cloud client through the k6 app plugin
+function createK6Client(config: any) {
+  return createPluginClient(config, `${config.url}/api/plugins/k6-app/resources/cloud/v5`);
+}
+
+// Helper function to format a test run summary
+function formatTestRun(run: any) {
+  return {
+    id: run.id,
+    testId: run.test_id,
+    status: run.status,
+    result: run.result,
+    resultDetails: run.result_details,
+    createdAt: run.created,
+    endedAt: run.ended,
+    startedBy: run.started_by,
+    note: run.note,
+    estimatedDurationSeconds: run.estimated_duration,
+    distribution: run.distribution,
+  };
+}
+
+// Tool definitions
+export const listK6Tests: ToolDefinition = {
+  name: 'list_k6_tests',
+  description: 'List saved k6 Cloud load tests, optionally filtered by project or name',
+  inputSchema: ListK6TestsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createK6Client(context.config.grafanaConfig);
+      const endpoint = params.projectId ? `/projects/${params.projectId}/load_tests` : '/load_tests';
+      const response = await client.get(endpoint);
+      
+      let tests = response.data.value || [];
+      if (params.name) {
+        tests = tests.filter((t: any) => t.name.includes(params.name));
+      }
+      
+      const formatted = tests.map((test: any) => ({
+        id: test.id,
+        name: test.name,
+        projectId: test.project_id,
+        createdAt: test.created,
+        updatedAt: test.updated,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.error?.message || error.message);
+    }
+  },
+};
+
+export const listK6TestRuns: ToolDefinition = {
+  name: 'list_k6_test_runs',
+  description: 'List the runs of a k6 Cloud load test, most recent first, with their status and pass/fail result',
+  inputSchema: ListK6TestRunsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createK6Client(context.config.grafanaConfig);
+      const response = await client.get(`/load_tests/${params.testId}/test_runs`, {
+        params: { $top: params.limit || 10, $orderby: 'created desc' },
+      });
+      
+      return createToolResult((response.data.value || []).map(formatTestRun));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.error?.message || error.message);
+    }
+  },
+};
+
+export const getK6TestRun: ToolDefinition = {
+  name: 'get_k6_test_run',
+  description: 'Get the summary of a k6 Cloud test run: its status and overall result (passed, failed, or error). A run fails when any of its thresholds fails; the outcome of each threshold is not included',
+  inputSchema: GetK6TestRunSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createK6Client(context.config.grafanaConfig);
+      const response = await client.get(`/test_runs/${params.runId}`);
+      
+      return createToolResult(formatTestRun(response.data));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.error?.message || error.message);
+    }
+  },
+};
+
+export const startK6Test: ToolDefinition = {
+  name: 'start_k6_test',
+  description: 'Trigger a run of a saved k6 Cloud load test. This generates real load against the test\'s targets',
+  inputSchema: StartK6TestSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false, openWorldHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createK6Client(context.config.grafanaConfig);
+      const response = await client.post(`/load_tests/${params.testId}/start`);
+      
+      return createToolResult({
+        ...formatTestRun(response.data),
+        message: 'Test run started. Use get_k6_test_run to follow its progress.',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.error?.message || error.message);
+    }
+  },
+};
+
+export function registerK6Tools(server: any) {
+  server.registerTool(listK6Tests);
+  server.registerTool(listK6TestRuns);
+  server.registerTool(getK6TestRun);
+  server.registerTool(startK6Test);
+}
@@ -0,0 +1,280 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { PrometheusClient, PrometheusQueryResult } from '../clients/prometheus-client';
+import { escapeLabelValue } from '../utils/promql';
+
+// Schema definitions
+const ListK8sClustersSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Prometheus datasource receiving Kubernetes Monitoring metrics'),
+});
+
+const ListK8sNamespacesSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Prometheus datasource receiving Kubernetes Monitoring metrics'),
+  cluster: z.string().optional().describe('The cluster to list namespaces for'),
+});
+
+const ListK8sWorkloadsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Prometheus datasource receiving Kubernetes Monitoring metrics'),
+  cluster: z.string().optional().describe('The cluster of the workloads'),
+  namespace: z.string().describe('The namespace to list workloads for'),
+});
+
+const GetK8sWorkloadHealthSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Prometheus datasource receiving Kubernetes Monitoring metrics'),
+  cluster: z.string().optional().describe('The cluster of the workload'),
+  namespace: z.string().describe('The namespace of the workload'),
+  kind: z.enum(['deployment', 'statefulset', 'daemonset']).describe('The kind of workload'),
+  name: z.string().describe('The name of the workload'),
+});
+
+const GetK8sNamespaceSummarySchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Prometheus datasource receiving Kubernetes Monitoring metrics'),
+  cluster: z.string().optional().describe('The cluster of the namespace'),
+  namespace: z.string().describe('The namespace to summarize'),
+});
+
+// Replica metrics per workload kind from kube-state-metrics
+const WORKLOAD_REPLICA_METRICS: Record<string, { desired: string; ready: string }> = {
+  deployment: {
+    desired: 'kube_deployment_spec_replicas',
+    ready: 'kube_deployment_status_replicas_available',
+  },
+  statefulset: {
+    desired: 'kube_statefulset_replicas',
+    ready: 'kube_statefulset_status_replicas_ready',
+  },
+  daemonset: {
+    desired: 'kube_daemonset_status_desired_number_scheduled',
+    ready: 'kube_daemonset_status_number_ready',
+  },
+};
+
+// Pod name suffixes each kind of workload adds to its name: the ReplicaSet and pod hashes of
+// deployments, the ordinals of statefulsets, and the pod hashes of daemonsets
+const POD_NAME_SUFFIXES: Record<string, string> = {
+  deployment: '-[a-z0-9]+-[a-z0-9]+',
+  statefulset: '-[0-9]+',
+  daemonset: '-[a-z0-9]+',
+};
+
+// Helper to build a label selector scoped to a cluster and namespace
+function scope(cluster?: string, namespace?: string, extra: string[] = []): string {
+  const matchers = [...extra];
+  if (cluster) matchers.unshift(`cluster="${escapeLabelValue(cluster)}"`);
+  if (namespace) matchers.unshift(`namespace="${escapeLabelValue(namespace)}"`);
+  return `{${matchers.join(',')}}`;
+}
+
+// Helper to match the pods of a workload, and not those of workloads sharing its name as prefix
+function podMatcher(kind: string, name: string): string {
+  const pattern = name.replace(/[\\^$*+?.()|[\]{}]/g, '\\$&') + POD_NAME_SUFFIXES[kind];
+  return `pod=~"${escapeLabelValue(pattern)}"`;
+}
+
+// Helper to read a single scalar value from an instant query result
+function scalar(result: PrometheusQueryResult[]): number | null {
+  return result.length > 0 && result[0].value ? parseFloat(result[0].value[1]) : null;
+}
+
+// Helper to index instant query values by a label
+function valuesBy(result: PrometheusQueryResult[], label: string): Map<string, number> {
+  const values = new Map<string, number>();
+  for (const r of result) {
+    if (r.value) values.set(r.metric[label], parseFloat(r.value[1]));
+  }
+  return values;
+}
+
+// Tool definitions
+export const listK8sClusters: ToolDefinition = {
+  name: 'list_k8s_clusters',
+  description: 'List Kubernetes clusters reporting to Grafana Kubernetes Monitoring, with node and pod counts',
+  inputSchema: ListK8sClustersSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new PrometheusClient(context.config.grafanaConfig, params.datasourceUid);
+      
+      const nodes = await client.query('count by (cluster) (kube_node_info)');
+      const pods = await client.query('count by (cluster) (kube_pod_info)');
+      const podCounts = valuesBy(pods, 'cluster');
+      
+      const formatted = nodes.map(r => ({
+        cluster: r.metric.cluster,
+        nodes: r.value ? parseInt(r.value[1]) : 0,
+        pods: podCounts.get(r.metric.cluster) ?? 0,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listK8sNamespaces: ToolDefinition = {
+  name: 'list_k8s_namespaces',
+  description: 'List Kubernetes namespaces of a cluster with their pod counts',
+  inputSchema: ListK8sNamespacesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new PrometheusClient(context.config.grafanaConfig, params.datasourceUid);
+      
+      const result = await client.query(
+        `count by (namespace) (kube_pod_info${scope(params.cluster)})`
+      );
+      
+      const formatted = result
+        .map(r => ({
+          namespace: r.metric.namespace,
+          pods: r.value ? parseInt(r.value[1]) : 0,
+        }))
+        .sort((a, b) => a.namespace.localeCompare(b.namespace));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listK8sWorkloads: ToolDefinition = {
+  name: 'list_k8s_workloads',
+  description: 'List Kubernetes workloads (deployments, statefulsets, daemonsets) in a namespace with desired and ready replica counts',
+  inputSchema: ListK8sWorkloadsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new PrometheusClient(context.config.grafanaConfig, params.datasourceUid);
+      const selector = scope(params.cluster, params.namespace);
+      
+      const workloads = [];
+      for (const [kind, metrics] of Object.entries(WORKLOAD_REPLICA_METRICS)) {
+        const desired = await client.query(`${metrics.desired}${selector}`);
+        const ready = await client.query(`${metrics.ready}${selector}`);
+        // kube-state-metrics labels each series with the workload kind
+        const readyByName = valuesBy(ready, kind);
+        
+        for (const r of desired) {
+          const name = r.metric[kind];
+          workloads.push({
+            kind,
+            name,
+            desiredReplicas: r.value ? parseInt(r.value[1]) : null,
+            readyReplicas: readyByName.get(name) ?? null,
+          });
+        }
+      }
+      
+      return createToolResult(workloads);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getK8sWorkloadHealth: ToolDefinition = {
+  name: 'get_k8s_workload_health',
+  description: 'Get a health and resource summary of a Kubernetes workload: replica status, recent restarts, CPU and memory usage versus requests and limits',
+  inputSchema: GetK8sWorkloadHealthSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new PrometheusClient(context.config.grafanaConfig, params.datasourceUid);
+      const metrics = WORKLOAD_REPLICA_METRICS[params.kind];
+      const workloadSelector = scope(params.cluster, params.namespace, [
+        `${params.kind}="${escapeLabelValue(params.name)}"`,
+      ]);
+      const pods = podMatcher(params.kind, params.name);
+      const podSelector = scope(params.cluster, params.namespace, [pods]);
+      const containerSelector = scope(params.cluster, params.namespace, [pods, 'container!=""']);
+      
+      const desired = scalar(await client.query(`${metrics.desired}${workloadSelector}`));
+      const ready = scalar(await client.query(`${metrics.ready}${workloadSelector}`));
+      const restarts = scalar(await client.query(
+        `sum(increase(kube_pod_container_status_restarts_total${podSelector}[1h]))`
+      ));
+      const notReadyPods = await client.query(
+        `kube_pod_status_ready${scope(params.cluster, params.namespace, [pods, 'condition="false"'])} == 1`
+      );
+      const waiting = await client.query(
+        `kube_pod_container_status_waiting_reason${podSelector} == 1`
+      );
+      
+      const cpuUsage = scalar(await client.query(
+        `sum(rate(container_cpu_usage_seconds_total${containerSelector}[5m]))`
+      ));
+      const memoryUsage = scalar(await client.query(
+        `sum(container_memory_working_set_bytes${containerSelector})`
+      ));
+      const resource = async (kind: string, res: string) => scalar(await client.query(
+        `sum(kube_pod_container_resource_${kind}${scope(params.cluster, params.namespace, [pods, `resource="${res}"`])})`
+      ));
+      
+      return createToolResult({
+        workload: { kind: params.kind, name: params.name, namespace: params.namespace, cluster: params.cluster },
+        replicas: { desired, ready, healthy: desired !== null && ready !== null && ready >= desired },
+        restartsLastHour: restarts,
+        notReadyPods: notReadyPods.map(r => r.metric.pod),
+        waitingContainers: waiting.map(r => ({
+          pod: r.metric.pod,
+          container: r.metric.container,
+          reason: r.metric.reason,
+        })),
+        cpu: {
+          usageCores: cpuUsage,
+          requestsCores: await resource('requests', 'cpu'),
+          limitsCores: await resource('limits', 'cpu'),
+        },
+        memory: {
+          usageBytes: memoryUsage,
+          requestsBytes: await resource('requests', 'memory'),
+          limitsBytes: await resource('limits', 'memory'),
+        },
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const getK8sNamespaceSummary: ToolDefinition = {
+  name: 'get_k8s_namespace_summary',
+  description: 'Get a health and resource summary of a Kubernetes namespace: pods by phase, restarts, and total CPU and memory usage',
+  inputSchema: GetK8sNamespaceSummarySchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new PrometheusClient(context.config.grafanaConfig, params.datasourceUid);
+      const selector = scope(params.cluster, params.namespace);
+      const containerSelector = scope(params.cluster, params.namespace, ['container!=""']);
+      
+      const phases = await client.query(`sum by (phase) (kube_pod_status_phase${selector})`);
+      const restarts = await client.query(
+        `topk(5, sum by (pod) (increase(kube_pod_container_status_restarts_total${selector}[1h])) > 0)`
+      );
+      
+      return createToolResult({
+        namespace: params.namespace,
+        cluster: params.cluster,
+        podsByPhase: Object.fromEntries(valuesBy(phases, 'phase')),
+        topRestartingPods: restarts.map(r => ({
+          pod: r.metric.pod,
+          restartsLastHour: r.value ? parseFloat(r.value[1]) : null,
+        })),
+        cpuUsageCores: scalar(await client.query(
+          `sum(rate(container_cpu_usage_seconds_total${containerSelector}[5m]))`
+        )),
+        memoryUsageBytes: scalar(await client.query(
+          `sum(container_memory_working_set_bytes${containerSelector})`
+        )),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerKubernetesTools(server: any) {
+  server.registerTool(listK8sClusters);
+  server.registerTool(listK8sNamespaces);
+  server.registerTool(listK8sWorkloads);
+  server.registerTool(getK8sWorkloadHealth);
+  server.registerTool(getK8sNamespaceSummary);
+}
@@ -0,0 +1,86 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaLiveClient, LiveEvent } from '../clients/live-client';
+import { checkDatasourceAccess } from '../server/datasource-policy';
+import { formatTime } from '../utils/time';
+
+// Schema definitions
+const SubscribeGrafanaLiveSchema = z.object({
+  channels: z.array(z.string()).min(1).max(10).describe('Grafana Live channels to follow, e.g. grafana/dashboard/uid/<dashboard UID> for dashboard saves and edits, ds/<datasource UID>/<path> for a streaming datasource, or stream/<stream>/<path> for data pushed to Grafana'),
+  durationSeconds: z.number().optional().describe('How long to follow the channels (default: 30, max: 300)'),
+  maxEvents: z.number().optional().describe('Stop after this many events (default: 200, max: 1000)'),
+});
+
+// Helper to check the datasource policy for ds/<uid>/... channels
+async function checkChannelAccess(context: ToolContext, channels: string[]) {
+  const uids = channels
+    .filter(channel => channel.startsWith('ds/'))
+    .map(channel => channel.split('/')[1]);
+  await checkDatasourceAccess(context.config.grafanaConfig, uids);
+}
+
+// Tool definitions
+export const subscribeGrafanaLive: ToolDefinition = {
+  name: 'subscribe_grafana_live',
+  description: 'Follows Grafana Live channels for a bounded time, such as the change events of a dashboard or the data of a streaming datasource. Events are streamed to the client as logging notifications while the tool runs, and all events are returned when it ends: after the duration, at the event limit, or when the call is cancelled',
+  inputSchema: SubscribeGrafanaLiveSchema,
+  handler: async (params, context: ToolContext) => {
+    const client = new GrafanaLiveClient(context.config.grafanaConfig);
+    try {
+      await checkChannelAccess(context, params.channels);
+      const durationMs = Math.min(params.durationSeconds || 30, 300) * 1000;
+      const maxEvents = Math.min(params.maxEvents || 200, 1000);
+      const startedAt = Date.now();
+      const events: any[] = [];
+      let stopped = 'duration';
+
+      await client.connect();
+      const done = new Promise<void>((resolve, reject) => {
+        const finish = (reason: string) => {
+          clearTimeout(timer);
+          stopped = reason;
+          resolve();
+        };
+        const timer = setTimeout(() => finish('duration'), durationMs);
+        context.signal?.addEventListener('abort', () => finish('cancelled'), { once: true });
+
+        client.onevent = (event: LiveEvent) => {
+          if (events.length >= maxEvents) return;
+          const formatted = {
+            received: formatTime(Date.now(), context.timezone),
+            ...event,
+          };
+          events.push(formatted);
+          context.sendLog(formatted).catch(() => undefined);
+          context
+            .sendProgress(Date.now() - startedAt, durationMs, `${events.length} events so far`)
+            .catch(() => undefined);
+          if (events.length >= maxEvents) finish('max_events');
+        };
+        client.onclose = error => {
+          clearTimeout(timer);
+          if (error) reject(error);
+        };
+      });
+
+      // Subscription errors, such as unknown channels or missing permissions, end the call
+      await Promise.all([done, ...params.channels.map(channel => client.subscribe(channel))]);
+
+      return createToolResult({
+        channels: params.channels,
+        followedSeconds: Math.round((Date.now() - startedAt) / 1000),
+        stopped,
+        count: events.length,
+        events,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    } finally {
+      client.close();
+    }
+  },
+};
+
+export function registerLiveTools(server: any) {
+  server.registerTool(subscribeGrafanaLive);
+}
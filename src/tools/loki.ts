@@ -1,50 +1,56 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
 import { LokiClient } from '../clients/loki-client';
-
-// Helper function to get default time range (last hour)
-function getDefaultTimeRange(): { start: string; end: string } {
-  const now = Math.floor(Date.now() / 1000);
-  const oneHourAgo = now - 3600;
-  return {
-    start: oneHourAgo.toString(),
-    end: now.toString(),
-  };
-}
-
-interface TimeRange {
-  start: string;
-  end: string;
-}
+import { formatTime, parseTimeRange, toUnixSeconds } from '../utils/time';
+import {
+  DEFAULT_MAX_DATA_POINTS,
+  describeResolution,
+  downsampleSeries,
+  widenStep,
+} from '../utils/downsample';
+import { formatLabels, recordsToTable, renderTables, seriesToTable } from '../utils/table';
+import { createLogScrubber } from '../utils/scrub';
 
 // Schema definitions
 const ListLokiLabelNamesSchema = z.object({
   datasourceUid: z.string().describe('The UID of the datasource to query'),
-  startRfc3339: z.string().optional().describe('The start time of the query in RFC3339 format'),
-  endRfc3339: z.string().optional().describe('The end time of the query in RFC3339 format'),
+  startRfc3339: z.string().optional().describe('The start time of the query (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time of the query (default: now)'),
 });
 
 const ListLokiLabelValuesSchema = z.object({
   datasourceUid: z.string().describe('The UID of the datasource to query'),
   labelName: z.string().describe('The name of the label to retrieve values for'),
-  startRfc3339: z.string().optional().describe('The start time of the query in RFC3339 format'),
-  endRfc3339: z.string().optional().describe('The end time of the query in RFC3339 format'),
+  startRfc3339: z.string().optional().describe('The start time of the query (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time of the query (default: now)'),
 });
 
 const QueryLokiLogsSchema = z.object({
   datasourceUid: z.string().describe('The UID of the datasource to query'),
   logql: z.string().describe('The LogQL query to execute against Loki'),
-  startRfc3339: z.string().optional().describe('The start time of the query in RFC3339 format'),
-  endRfc3339: z.string().optional().describe('The end time of the query in RFC3339 format'),
+  startRfc3339: z.string().optional().describe('The start time of the query (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time of the query (default: now)'),
   limit: z.number().optional().describe('Maximum number of log lines to return (default: 10, max: 100)'),
   direction: z.enum(['forward', 'backward']).optional().describe('Direction of the query'),
+  stepSeconds: z.number().optional().describe('Step size in seconds for metric queries (default: 60)'),
+  maxDataPoints: z.number().optional().describe(`Maximum samples per series for metric queries (default: ${DEFAULT_MAX_DATA_POINTS})`),
+  downsample: z.enum(['step', 'lttb', 'none']).optional().describe('How metric queries stay within maxDataPoints: widen the step, keep the most shape-preserving samples (LTTB), or return everything (default: step)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
 });
 
 const QueryLokiStatsSchema = z.object({
   datasourceUid: z.string().describe('The UID of the datasource to query'),
   logql: z.string().describe('The LogQL matcher expression to execute'),
-  startRfc3339: z.string().optional().describe('The start time of the query in RFC3339 format'),
-  endRfc3339: z.string().optional().describe('The end time of the query in RFC3339 format'),
+  startRfc3339: z.string().optional().describe('The start time of the query (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time of the query (default: now)'),
+});
+
+const TailLokiLogsSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the datasource to query'),
+  logql: z.string().describe('The LogQL log query to follow, e.g. \'{app="checkout"} |= "error"\''),
+  durationSeconds: z.number().optional().describe('How long to follow the logs (default: 30, max: 300)'),
+  intervalSeconds: z.number().optional().describe('Seconds between checks for new lines (default: 2)'),
+  maxLines: z.number().optional().describe('Stop after this many lines (default: 500, max: 2000)'),
 });
 
 const FindErrorPatternLogsSchema = z.object({
@@ -62,13 +68,14 @@ export const listLokiLabelNames: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = new LokiClient(context.config.grafanaConfig, params.datasourceUid);
-      const timeRange: TimeRange = params.startRfc3339 || params.endRfc3339 
-        ? { start: '', end: '' } 
-        : getDefaultTimeRange();
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
       
       const labels = await client.getLabelNames(
-        params.startRfc3339 || timeRange.start,
-        params.endRfc3339 || timeRange.end
+        timeRange.start.toISOString(),
+        timeRange.end.toISOString()
       );
       
       return createToolResult(labels);
@@ -85,14 +92,15 @@ export const listLokiLabelValues: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = new LokiClient(context.config.grafanaConfig, params.datasourceUid);
-      const timeRange: TimeRange = params.startRfc3339 || params.endRfc3339 
-        ? { start: '', end: '' } 
-        : getDefaultTimeRange();
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
       
       const values = await client.getLabelValues(
         params.labelName,
-        params.startRfc3339 || timeRange.start,
-        params.endRfc3339 || timeRange.end
+        timeRange.start.toISOString(),
+        timeRange.end.toISOString()
       );
       
       return createToolResult(values);
@@ -109,19 +117,78 @@ export const queryLokiLogs: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = new LokiClient(context.config.grafanaConfig, params.datasourceUid);
-      const timeRange: TimeRange = params.startRfc3339 || params.endRfc3339 
-        ? { start: '', end: '' } 
-        : getDefaultTimeRange();
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+      
+      // Metric queries (e.g. rate({app="x"}[5m])) return series rather than log lines
+      if (!params.logql.trim().startsWith('{')) {
+        const start = toUnixSeconds(timeRange.start);
+        const end = toUnixSeconds(timeRange.end);
+        const method = params.downsample || 'step';
+        const maxDataPoints = params.maxDataPoints || DEFAULT_MAX_DATA_POINTS;
+        const stepSeconds = method === 'step'
+          ? widenStep(start, end, params.stepSeconds || 60, maxDataPoints)
+          : params.stepSeconds || 60;
+        
+        const result = await client.queryMetrics(
+          params.logql,
+          start.toString(),
+          end.toString(),
+          `${stepSeconds}s`
+        );
+        
+        const downsampled = downsampleSeries(result, method, stepSeconds, maxDataPoints);
+        if (params.format && params.format !== 'json') {
+          const table = renderTables(
+            [seriesToTable(downsampled.series, context.timezone)],
+            params.format
+          );
+          return createToolResult(`${describeResolution(downsampled.resolution)}\n\n${table}`);
+        }
+        return createToolResult({
+          resolution: downsampled.resolution,
+          series: downsampled.series.map(series => ({
+            metric: series.metric,
+            values: series.values.map(([time, value]) => [
+              formatTime(time * 1000, context.timezone),
+              value,
+            ]),
+          })),
+        });
+      }
       
       const logs = await client.queryLogs(
         params.logql,
-        params.startRfc3339 || timeRange.start,
-        params.endRfc3339 || timeRange.end,
+        timeRange.start.toISOString(),
+        timeRange.end.toISOString(),
         Math.min(params.limit || 10, 100),
         params.direction || 'backward'
       );
       
-      return createToolResult(logs);
+      // Redact personal data and secrets when configured; Loki timestamps are unix nanoseconds
+      const scrub = createLogScrubber(context.config.grafanaConfig.logScrubbing);
+      const formatted = logs.map(entry => ({
+        ...entry,
+        timestamp: formatTime(parseInt(entry.timestamp.slice(0, -6), 10), context.timezone),
+        labels: scrub
+          ? Object.fromEntries(
+            Object.entries(entry.labels).map(([name, value]) => [name, scrub(value)])
+          )
+          : entry.labels,
+        line: scrub && entry.line !== undefined ? scrub(entry.line) : entry.line,
+      }));
+      
+      if (params.format && params.format !== 'json') {
+        const records = formatted.map(entry => ({
+          timestamp: entry.timestamp,
+          labels: formatLabels(entry.labels),
+          line: entry.line,
+        }));
+        return createToolResult(renderTables([recordsToTable(records)], params.format));
+      }
+      return createToolResult(formatted);
     } catch (error: any) {
       return createErrorResult(error.message);
     }
@@ -135,14 +202,15 @@ export const queryLokiStats: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = new LokiClient(context.config.grafanaConfig, params.datasourceUid);
-      const timeRange: TimeRange = params.startRfc3339 || params.endRfc3339 
-        ? { start: '', end: '' } 
-        : getDefaultTimeRange();
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
       
       const stats = await client.queryStats(
         params.logql,
-        params.startRfc3339 || timeRange.start,
-        params.endRfc3339 || timeRange.end
+        timeRange.start.toISOString(),
+        timeRange.end.toISOString()
       );
       
       return createToolResult(stats);
@@ -171,10 +239,88 @@ export const findErrorPatternLogs: ToolDefinition = {
   },
 };
 
+export const tailLokiLogs: ToolDefinition = {
+  name: 'tail_loki_logs',
+  description: 'Follows a LogQL log query for a bounded time, e.g. to watch the logs during a redeploy. New lines are streamed to the client as logging notifications while the tool runs, and all lines are returned when it ends: after the duration, at the line limit, or when the call is cancelled',
+  inputSchema: TailLokiLogsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      if (!params.logql.trim().startsWith('{')) {
+        return createErrorResult('Only log queries can be tailed; metric queries return series');
+      }
+      const client = new LokiClient(context.config.grafanaConfig, params.datasourceUid);
+      const durationMs = Math.min(params.durationSeconds || 30, 300) * 1000;
+      const intervalMs = Math.max(params.intervalSeconds || 2, 1) * 1000;
+      const maxLines = Math.min(params.maxLines || 500, 2000);
+      const scrub = createLogScrubber(context.config.grafanaConfig.logScrubbing);
+
+      // Loki timestamps are unix nanoseconds; each check starts just after the last line seen
+      const startedAt = Date.now();
+      let since = BigInt(startedAt) * 1000000n;
+      const lines: any[] = [];
+      let stopped = 'duration';
+
+      for (;;) {
+        const batch = await client.queryLogs(
+          params.logql,
+          (since + 1n).toString(),
+          (BigInt(Date.now()) * 1000000n).toString(),
+          Math.min(maxLines - lines.length, 1000),
+          'forward'
+        );
+        batch.sort((a, b) => (BigInt(a.timestamp) < BigInt(b.timestamp) ? -1 : 1));
+
+        if (batch.length > 0) {
+          since = BigInt(batch[batch.length - 1].timestamp);
+          const formatted = batch.map(entry => ({
+            timestamp: formatTime(parseInt(entry.timestamp.slice(0, -6), 10), context.timezone),
+            labels: scrub
+              ? Object.fromEntries(
+                Object.entries(entry.labels).map(([name, value]) => [name, scrub(value)])
+              )
+              : entry.labels,
+            line: scrub && entry.line !== undefined ? scrub(entry.line) : entry.line,
+          }));
+          lines.push(...formatted);
+          await context.sendLog({ query: params.logql, lines: formatted });
+        }
+
+        const elapsed = Date.now() - startedAt;
+        await context.sendProgress(
+          Math.min(elapsed, durationMs),
+          durationMs,
+          `${lines.length} lines so far`
+        );
+        if (lines.length >= maxLines) {
+          stopped = 'max_lines';
+          break;
+        }
+        if (context.signal?.aborted) {
+          stopped = 'cancelled';
+          break;
+        }
+        if (elapsed + intervalMs > durationMs) break;
+        await new Promise(resolve => setTimeout(resolve, intervalMs));
+      }
+
+      return createToolResult({
+        query: params.logql,
+        followedSeconds: Math.round((Date.now() - startedAt) / 1000),
+        stopped,
+        count: lines.length,
+        lines,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
 export function registerLokiTools(server: any) {
   server.registerTool(listLokiLabelNames);
   server.registerTool(listLokiLabelValues);
   server.registerTool(queryLokiLogs);
   server.registerTool(queryLokiStats);
   server.registerTool(findErrorPatternLogs);
+  server.registerTool(tailLokiLogs);
 }
\ No newline at end of file
@@ -0,0 +1,202 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { createPluginClient } from '../clients/base-client';
+import { parseTime, parseTimeRange } from '../utils/time';
+
+// Schema definitions
+const ListMlJobsSchema = z.object({
+  name: z.string().optional().describe('Only include jobs whose name or metric contains this string'),
+});
+
+const GetMlForecastSchema = z.object({
+  jobId: z.string().describe('The ID of the forecast job'),
+  startRfc3339: z.string().optional().describe('Start of the forecast range (RFC3339, unix epoch, or relative like "now+1h"; default: now)'),
+  endRfc3339: z.string().optional().describe('End of the forecast range (default: 24 hours after the start)'),
+  intervalSeconds: z.number().optional().describe('Resolution of the forecast in seconds (default: 300)'),
+});
+
+const DetectOutliersSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Prometheus or Loki datasource to query'),
+  datasourceType: z.enum(['prometheus', 'loki']).optional().describe('The type of the datasource (default: prometheus)'),
+  expr: z.string().describe('A query returning one series per member of the group to compare, e.g. CPU usage per pod'),
+  startRfc3339: z.string().optional().describe('Start of the range (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('End of the range (default: now)'),
+  intervalSeconds: z.number().optional().describe('Resolution of the query in seconds (default: 60)'),
+  algorithm: z.enum(['dbscan', 'mad']).optional().describe('The outlier detection algorithm (default: dbscan)'),
+  sensitivity: z.number().min(0).max(1).optional().describe('Detection sensitivity between 0 and 1 (default: 0.5)'),
+});
+
+// Responses of the ML API wrap their data with a status, and an error when it failed
+interface MlResponse<T> {
+  status: 'success' | 'error';
+  data: T;
+  error?: string;
+}
+
+interface MlJob {
+  id: string;
+  name: string;
+  metric: string;
+  description?: string;
+  datasourceUid: string;
+  datasourceType: string;
+  queryParams: { expr?: string; [key: string]: unknown };
+  interval: number;
+  status?: string;
+}
+
+interface MlOutlierSeries {
+  labels: Record<string, string>;
+  isOutlier: boolean;
+  outlierIntervals?: { start: string; end: string }[];
+}
+
+interface MlOutlierResult {
+  results: MlOutlierSeries[];
+}
+
+// Helper function to create ML client
+function createMlClient(config: any) {
+  return createPluginClient(config, `${config.url}/api/plugins/grafana-ml-app/resources/ml/v1`, 60000);
+}
+
+// Helper function to unwrap an ML API response, failing on errors and unexpected shapes rather
+// than returning empty results
+function unwrapMlResponse<T>(
+  body: MlResponse<T> | undefined,
+  what: string,
+  isValid: (data: any) => boolean
+): T {
+  if (body?.status === 'error') {
+    throw new Error(`ML API error: ${body.error || 'unknown error'}`);
+  }
+  if (body?.status !== 'success' || !isValid(body.data)) {
+    throw new Error(`Unexpected ML API response for ${what}`);
+  }
+  return body.data;
+}
+
+// Tool definitions
+export const listMlJobs: ToolDefinition = {
+  name: 'list_ml_jobs',
+  description: 'List Grafana Machine Learning forecast jobs and the metrics they model',
+  inputSchema: ListMlJobsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createMlClient(context.config.grafanaConfig);
+      const response = await client.get('/jobs');
+      
+      let jobs = unwrapMlResponse<MlJob[]>(response.data, 'jobs', Array.isArray);
+      if (params.name) {
+        jobs = jobs.filter(job =>
+          job.name?.includes(params.name) || job.metric?.includes(params.name)
+        );
+      }
+      
+      const formatted = jobs.map(job => ({
+        id: job.id,
+        name: job.name,
+        metric: job.metric,
+        description: job.description,
+        datasourceUid: job.datasourceUid,
+        datasourceType: job.datasourceType,
+        query: job.queryParams?.expr,
+        interval: job.interval,
+        status: job.status,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const getMlForecast: ToolDefinition = {
+  name: 'get_ml_forecast',
+  description: 'Get the forecast of a Grafana Machine Learning job for a time range, including predicted values and upper/lower bounds',
+  inputSchema: GetMlForecastSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createMlClient(context.config.grafanaConfig);
+      
+      const timezone = context.timezone;
+      const start = parseTime(params.startRfc3339 || 'now', { timezone });
+      const end = params.endRfc3339
+        ? parseTime(params.endRfc3339, { timezone, roundUp: true })
+        : new Date(start.getTime() + 24 * 60 * 60 * 1000);
+      
+      const response = await client.post(`/jobs/${params.jobId}/forecast`, {
+        start: start.toISOString(),
+        end: end.toISOString(),
+        interval: params.intervalSeconds || 300,
+      });
+      
+      return createToolResult({
+        jobId: params.jobId,
+        start: start.toISOString(),
+        end: end.toISOString(),
+        forecast: unwrapMlResponse<unknown>(
+          response.data,
+          'forecast',
+          data => data !== undefined && data !== null
+        ),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const detectOutliers: ToolDefinition = {
+  name: 'detect_outliers',
+  description: 'Run Grafana Machine Learning outlier detection over a query and time range, returning which series behave differently from the rest of their group and when',
+  inputSchema: DetectOutliersSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createMlClient(context.config.grafanaConfig);
+      
+      const { start, end } = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+      
+      const response = await client.post('/outliers/preview', {
+        datasourceUid: params.datasourceUid,
+        datasourceType: params.datasourceType || 'prometheus',
+        queryParams: { expr: params.expr },
+        algorithm: {
+          name: params.algorithm || 'dbscan',
+          sensitivity: params.sensitivity ?? 0.5,
+        },
+        start: start.toISOString(),
+        end: end.toISOString(),
+        interval: params.intervalSeconds || 60,
+      });
+      
+      const { results } = unwrapMlResponse<MlOutlierResult>(
+        response.data,
+        'outlier detection',
+        data => Array.isArray(data?.results)
+      );
+      const outliers = results.filter(series => series.isOutlier);
+      
+      return createToolResult({
+        seriesCount: results.length,
+        outlierCount: outliers.length,
+        outliers: outliers.map(series => ({
+          labels: series.labels,
+          outlierIntervals: series.outlierIntervals,
+        })),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export function registerMlTools(server: any) {
+  server.registerTool(listMlJobs);
+  server.registerTool(getMlForecast);
+  server.registerTool(detectOutliers);
+}
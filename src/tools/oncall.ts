@@ -1,6 +1,7 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
-import axios from 'axios';
+import { createPluginClient } from '../clients/base-client';
+import { TimeRange, formatTime, parseTimeRange } from '../utils/time';
 
 // Schema definitions
 const ListOncallSchedulesSchema = z.object({
@@ -27,23 +28,99 @@ const GetOncallShiftSchema = z.object({
   shiftId: z.string().describe('The ID of the shift to get details for'),
 });
 
+const ListOncallEscalationChainsSchema = z.object({
+  escalationChainId: z.string().optional().describe('The ID of a specific escalation chain to retrieve'),
+  includeSteps: z.boolean().optional().describe('Whether to include the escalation steps of each chain (default: true)'),
+});
+
+const GetOncallIntegrationRoutingSchema = z.object({
+  integrationId: z.string().optional().describe('The ID of the integration'),
+  integrationName: z.string().optional().describe('The name of the integration (used when integrationId is not set)'),
+});
+
+const ListOncallAlertGroupsSchema = z.object({
+  state: z.enum(['new', 'acknowledged', 'silenced', 'resolved']).optional().describe('Filter alert groups by state'),
+  integrationId: z.string().optional().describe('Filter alert groups by integration ID'),
+  teamId: z.string().optional().describe('Filter alert groups by team ID'),
+  startedAt: z.string().optional().describe('Filter by start time range in "{start}_{end}" ISO 8601 format'),
+  page: z.number().optional().describe('The page number to return'),
+});
+
+const OncallAlertGroupActionSchema = z.object({
+  alertGroupId: z.string().describe('The ID of the alert group'),
+});
+
+const SilenceOncallAlertGroupSchema = z.object({
+  alertGroupId: z.string().describe('The ID of the alert group'),
+  delaySeconds: z.number().describe('How long to silence the alert group in seconds, or -1 to silence forever'),
+});
+
+const CreateOncallOverrideSchema = z.object({
+  scheduleId: z.string().describe('The ID of the schedule to add the override to'),
+  userIds: z.array(z.string()).describe('The IDs of the users who will be on-call during the override'),
+  start: z.string().describe('Start of the override (RFC3339, unix epoch, or relative like "now+1h")'),
+  end: z.string().describe('End of the override (RFC3339, unix epoch, or relative like "now+9h")'),
+  timezone: z.string().optional().describe('Time zone of the override, also used for times without an offset (default: the session time zone, else UTC)'),
+});
+
+const ListOncallShiftSwapsSchema = z.object({
+  scheduleId: z.string().optional().describe('Filter shift swap requests by schedule ID'),
+  beneficiaryId: z.string().optional().describe('Filter by the user ID requesting the swap'),
+  openOnly: z.boolean().optional().describe('Only return swap requests that have not been taken yet'),
+  page: z.number().optional().describe('The page number to return'),
+});
+
+const CreateOncallShiftSwapSchema = z.object({
+  scheduleId: z.string().describe('The ID of the schedule'),
+  beneficiaryId: z.string().describe('The ID of the user whose shifts need to be covered'),
+  start: z.string().describe('Start of the swap period (RFC3339, unix epoch, or relative like "now+1d/d")'),
+  end: z.string().describe('End of the swap period (RFC3339, unix epoch, or relative like "now+2d/d")'),
+  description: z.string().optional().describe('A note for potential takers'),
+});
+
+const TakeOncallShiftSwapSchema = z.object({
+  shiftSwapId: z.string().describe('The ID of the shift swap request'),
+  benefactorId: z.string().describe('The ID of the user taking over the shifts'),
+});
+
+const GetOncallSummarySchema = z.object({
+  teamId: z.string().optional().describe('Only include schedules of this team'),
+});
+
 // Helper function to create OnCall client
 function createOncallClient(config: any) {
-  const headers: any = {
-    'User-Agent': 'mcp-grafana/1.0.0',
-  };
+  return createPluginClient(config, `${config.url}/api/plugins/grafana-oncall-app/resources/api/v1`);
+}
+
+// Helper function to fetch all pages of a paginated OnCall list endpoint
+async function listAllPages(client: any, endpoint: string, params: any = {}): Promise<any[]> {
+  const results: any[] = [];
+  let page = 1;
   
-  if (config.serviceAccountToken) {
-    headers['Authorization'] = `Bearer ${config.serviceAccountToken}`;
-  } else if (config.apiKey) {
-    headers['Authorization'] = `Bearer ${config.apiKey}`;
+  for (;;) {
+    const response = await client.get(endpoint, { params: { ...params, page } });
+    results.push(...(response.data.results || []));
+    if (!response.data.next) break;
+    page++;
   }
   
-  return axios.create({
-    baseURL: `${config.url}/api/plugins/grafana-oncall-app/resources/api/v1`,
-    headers,
-    timeout: 30000,
-  });
+  return results;
+}
+
+// Helper function to format an escalation policy step
+function formatEscalationStep(step: any) {
+  return {
+    id: step.id,
+    position: step.position,
+    type: step.type,
+    duration: step.duration,
+    important: step.important,
+    personsToNotify: step.persons_to_notify,
+    personsToNotifyNextEachTime: step.persons_to_notify_next_each_time,
+    notifyOnCallFromSchedule: step.notify_on_call_from_schedule,
+    groupToNotify: step.group_to_notify,
+    actionToTrigger: step.action_to_trigger,
+  };
 }
 
 // Tool definitions
@@ -217,10 +294,439 @@ export const getOncallShift: ToolDefinition = {
   },
 };
 
+export const listOncallEscalationChains: ToolDefinition = {
+  name: 'list_oncall_escalation_chains',
+  description: 'List Grafana OnCall escalation chains and their ordered escalation steps, for auditing paging paths',
+  inputSchema: ListOncallEscalationChainsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createOncallClient(context.config.grafanaConfig);
+      
+      const chains = params.escalationChainId
+        ? [(await client.get(`/escalation_chains/${params.escalationChainId}`)).data]
+        : await listAllPages(client, '/escalation_chains');
+      
+      const formatted = [];
+      for (const chain of chains) {
+        const entry: any = {
+          id: chain.id,
+          name: chain.name,
+          teamId: chain.team_id,
+        };
+        
+        if (params.includeSteps !== false) {
+          const steps = await listAllPages(client, '/escalation_policies', {
+            escalation_chain_id: chain.id,
+          });
+          entry.steps = steps
+            .sort((a: any, b: any) => a.position - b.position)
+            .map(formatEscalationStep);
+        }
+        
+        formatted.push(entry);
+      }
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.detail || error.message);
+    }
+  },
+};
+
+export const getOncallIntegrationRouting: ToolDefinition = {
+  name: 'get_oncall_integration_routing',
+  description: 'Resolve which escalation chains a Grafana OnCall integration routes alerts to, including each route\'s filter and the chain\'s escalation steps',
+  inputSchema: GetOncallIntegrationRoutingSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      if (!params.integrationId && !params.integrationName) {
+        return createErrorResult('Either integrationId or integrationName must be provided');
+      }
+      
+      const client = createOncallClient(context.config.grafanaConfig);
+      
+      let integration: any;
+      if (params.integrationId) {
+        integration = (await client.get(`/integrations/${params.integrationId}`)).data;
+      } else {
+        const integrations = await listAllPages(client, '/integrations');
+        integration = integrations.find((i: any) => i.name === params.integrationName);
+        if (!integration) {
+          return createErrorResult(`Integration "${params.integrationName}" not found`);
+        }
+      }
+      
+      const routes = await listAllPages(client, '/routes', { integration_id: integration.id });
+      
+      const resolved = [];
+      for (const route of routes.sort((a: any, b: any) => a.position - b.position)) {
+        let escalationChain = null;
+        if (route.escalation_chain_id) {
+          const chain = (await client.get(`/escalation_chains/${route.escalation_chain_id}`)).data;
+          const steps = await listAllPages(client, '/escalation_policies', {
+            escalation_chain_id: chain.id,
+          });
+          escalationChain = {
+            id: chain.id,
+            name: chain.name,
+            steps: steps
+              .sort((a: any, b: any) => a.position - b.position)
+              .map(formatEscalationStep),
+          };
+        }
+        
+        resolved.push({
+          routeId: route.id,
+          position: route.position,
+          isDefault: route.is_the_last_route,
+          routingType: route.routing_type,
+          routingRegex: route.routing_regex,
+          escalationChain,
+        });
+      }
+      
+      return createToolResult({
+        integrationId: integration.id,
+        integrationName: integration.name,
+        integrationType: integration.type,
+        routes: resolved,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.detail || error.message);
+    }
+  },
+};
+
+// Helper function to format an alert group
+function formatAlertGroup(group: any) {
+  return {
+    id: group.id,
+    title: group.title,
+    state: group.state,
+    integrationId: group.integration_id,
+    routeId: group.route_id,
+    teamId: group.team_id,
+    alertsCount: group.alerts_count,
+    createdAt: group.created_at,
+    acknowledgedAt: group.acknowledged_at,
+    resolvedAt: group.resolved_at,
+    silencedAt: group.silenced_at,
+    labels: group.labels,
+    permalink: group.permalinks?.web,
+  };
+}
+
+export const listOncallAlertGroups: ToolDefinition = {
+  name: 'list_oncall_alert_groups',
+  description: 'List Grafana OnCall alert groups (pages), optionally filtering by state, integration, team, or start time',
+  inputSchema: ListOncallAlertGroupsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createOncallClient(context.config.grafanaConfig);
+      
+      const queryParams: any = {};
+      if (params.state) queryParams.state = params.state;
+      if (params.integrationId) queryParams.integration_id = params.integrationId;
+      if (params.teamId) queryParams.team_id = params.teamId;
+      if (params.startedAt) queryParams.started_at = params.startedAt;
+      if (params.page) queryParams.page = params.page;
+      
+      const response = await client.get('/alert_groups', { params: queryParams });
+      
+      const groups = response.data.results || [];
+      
+      return createToolResult(groups.map(formatAlertGroup));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.detail || error.message);
+    }
+  },
+};
+
+// Helper function to define a state-changing alert group action tool
+function alertGroupActionTool(
+  name: string,
+  action: string,
+  description: string
+): ToolDefinition {
+  return {
+    name,
+    description,
+    inputSchema: OncallAlertGroupActionSchema,
+    annotations: { readOnlyHint: false, destructiveHint: false, idempotentHint: true },
+    handler: async (params, context: ToolContext) => {
+      try {
+        const client = createOncallClient(context.config.grafanaConfig);
+        
+        await client.post(`/alert_groups/${params.alertGroupId}/${action}`);
+        const response = await client.get(`/alert_groups/${params.alertGroupId}`);
+        
+        return createToolResult(formatAlertGroup(response.data));
+      } catch (error: any) {
+        return createErrorResult(error.response?.data?.detail || error.message);
+      }
+    },
+  };
+}
+
+export const acknowledgeOncallAlertGroup = alertGroupActionTool(
+  'acknowledge_oncall_alert_group',
+  'acknowledge',
+  'Acknowledge a Grafana OnCall alert group, stopping its escalation'
+);
+
+export const resolveOncallAlertGroup = alertGroupActionTool(
+  'resolve_oncall_alert_group',
+  'resolve',
+  'Resolve a Grafana OnCall alert group'
+);
+
+export const silenceOncallAlertGroup: ToolDefinition = {
+  name: 'silence_oncall_alert_group',
+  description: 'Silence a Grafana OnCall alert group for a given duration, pausing escalation and notifications',
+  inputSchema: SilenceOncallAlertGroupSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createOncallClient(context.config.grafanaConfig);
+      
+      await client.post(`/alert_groups/${params.alertGroupId}/silence`, {
+        delay: params.delaySeconds,
+      });
+      const response = await client.get(`/alert_groups/${params.alertGroupId}`);
+      
+      return createToolResult(formatAlertGroup(response.data));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.detail || error.message);
+    }
+  },
+};
+
+// Helper function to parse the time window of an override or swap, which must not be empty
+function parseOncallWindow(start: string, end: string, timezone?: string): TimeRange {
+  const range = parseTimeRange(start, end, { timezone });
+  if (range.end <= range.start) {
+    throw new Error('end must be after start');
+  }
+  return range;
+}
+
+// Helper function to format a shift swap request
+function formatShiftSwap(swap: any) {
+  return {
+    id: swap.id,
+    scheduleId: swap.schedule,
+    status: swap.status,
+    swapStart: swap.swap_start,
+    swapEnd: swap.swap_end,
+    beneficiary: swap.beneficiary,
+    benefactor: swap.benefactor,
+    description: swap.description,
+    shifts: swap.shifts,
+  };
+}
+
+export const createOncallOverride: ToolDefinition = {
+  name: 'create_oncall_override',
+  description: 'Create an override on a Grafana OnCall schedule so the given users are on-call for a specific time window',
+  inputSchema: CreateOncallOverrideSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const timezone = params.timezone || context.timezone || 'UTC';
+      const range = parseOncallWindow(params.start, params.end, timezone);
+      const client = createOncallClient(context.config.grafanaConfig);
+      
+      // OnCall reads the start as wall-clock time in the override's time zone
+      const start = formatTime(range.start, timezone).slice(0, 19);
+      const response = await client.post('/on_call_shifts', {
+        name: `Override ${start}`,
+        type: 'override',
+        schedule: params.scheduleId,
+        start,
+        rotation_start: start,
+        duration: Math.round((range.end.getTime() - range.start.getTime()) / 1000),
+        time_zone: timezone,
+        users: params.userIds,
+      });
+      const shift = response.data;
+      
+      return createToolResult({
+        id: shift.id,
+        scheduleId: params.scheduleId,
+        start: shift.start,
+        duration: shift.duration,
+        users: shift.users,
+        message: 'Override created successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.detail || error.message);
+    }
+  },
+};
+
+export const listOncallShiftSwaps: ToolDefinition = {
+  name: 'list_oncall_shift_swaps',
+  description: 'List Grafana OnCall shift swap requests, optionally filtering by schedule, requesting user, or open requests',
+  inputSchema: ListOncallShiftSwapsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createOncallClient(context.config.grafanaConfig);
+      
+      const queryParams: any = {};
+      if (params.scheduleId) queryParams.schedule_id = params.scheduleId;
+      if (params.beneficiaryId) queryParams.beneficiary = params.beneficiaryId;
+      if (params.openOnly) queryParams.open_only = true;
+      if (params.page) queryParams.page = params.page;
+      
+      const response = await client.get('/shift_swaps', { params: queryParams });
+      
+      const swaps = response.data.results || [];
+      
+      return createToolResult(swaps.map(formatShiftSwap));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.detail || error.message);
+    }
+  },
+};
+
+export const createOncallShiftSwap: ToolDefinition = {
+  name: 'create_oncall_shift_swap',
+  description: 'Create a Grafana OnCall shift swap request asking teammates to cover the beneficiary\'s shifts in a time window',
+  inputSchema: CreateOncallShiftSwapSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const range = parseOncallWindow(params.start, params.end, context.timezone);
+      const client = createOncallClient(context.config.grafanaConfig);
+      
+      const swapData: any = {
+        schedule: params.scheduleId,
+        beneficiary: params.beneficiaryId,
+        swap_start: range.start.toISOString(),
+        swap_end: range.end.toISOString(),
+      };
+      if (params.description) swapData.description = params.description;
+      
+      const response = await client.post('/shift_swaps', swapData);
+      
+      return createToolResult(formatShiftSwap(response.data));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.detail || error.message);
+    }
+  },
+};
+
+export const takeOncallShiftSwap: ToolDefinition = {
+  name: 'take_oncall_shift_swap',
+  description: 'Take an open Grafana OnCall shift swap request on behalf of the given user',
+  inputSchema: TakeOncallShiftSwapSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createOncallClient(context.config.grafanaConfig);
+      
+      const response = await client.post(`/shift_swaps/${params.shiftSwapId}/take`, {
+        benefactor: params.benefactorId,
+      });
+      
+      return createToolResult(formatShiftSwap(response.data));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.detail || error.message);
+    }
+  },
+};
+
+export const getOncallSummary: ToolDefinition = {
+  name: 'get_oncall_summary',
+  description: 'Summarize who is currently on-call across all Grafana OnCall schedules and teams, grouped by schedule and by user. Useful at incident kickoff',
+  inputSchema: GetOncallSummarySchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createOncallClient(context.config.grafanaConfig);
+      
+      const schedules = await listAllPages(
+        client,
+        '/schedules',
+        params.teamId ? { team_id: params.teamId } : {}
+      );
+      const teams = await listAllPages(client, '/teams');
+      const teamNames = new Map<string, string>();
+      teams.forEach((t: any) => teamNames.set(t.id, t.name));
+      
+      // on_call_now holds user IDs; resolve each user once
+      const userCache = new Map<string, any>();
+      const resolveUser = async (entry: any) => {
+        if (typeof entry !== 'string') return entry.user || entry;
+        if (!userCache.has(entry)) {
+          const response = await client.get(`/users/${entry}`);
+          userCache.set(entry, response.data);
+        }
+        return userCache.get(entry);
+      };
+      
+      const bySchedule = [];
+      const byUser = new Map<string, any>();
+      
+      for (const schedule of schedules) {
+        const users = [];
+        for (const entry of schedule.on_call_now || []) {
+          const user = await resolveUser(entry);
+          users.push({ id: user.id, username: user.username, email: user.email });
+          
+          if (!byUser.has(user.id)) {
+            byUser.set(user.id, {
+              id: user.id,
+              username: user.username,
+              email: user.email,
+              name: user.name,
+              schedules: [],
+            });
+          }
+          byUser.get(user.id).schedules.push({
+            id: schedule.id,
+            name: schedule.name,
+            team: teamNames.get(schedule.team_id) || null,
+          });
+        }
+        
+        bySchedule.push({
+          scheduleId: schedule.id,
+          scheduleName: schedule.name,
+          team: teamNames.get(schedule.team_id) || null,
+          onCallNow: users,
+        });
+      }
+      
+      return createToolResult({
+        generatedAt: new Date().toISOString(),
+        scheduleCount: schedules.length,
+        schedulesWithoutOnCall: bySchedule
+          .filter(s => s.onCallNow.length === 0)
+          .map(s => s.scheduleName),
+        onCallUsers: Array.from(byUser.values()),
+        schedules: bySchedule,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.detail || error.message);
+    }
+  },
+};
+
 export function registerOncallTools(server: any) {
   server.registerTool(listOncallSchedules);
   server.registerTool(listOncallTeams);
   server.registerTool(listOncallUsers);
   server.registerTool(getCurrentOncallUsers);
   server.registerTool(getOncallShift);
+  server.registerTool(listOncallEscalationChains);
+  server.registerTool(getOncallIntegrationRouting);
+  server.registerTool(listOncallAlertGroups);
+  server.registerTool(acknowledgeOncallAlertGroup);
+  server.registerTool(resolveOncallAlertGroup);
+  server.registerTool(silenceOncallAlertGroup);
+  server.registerTool(createOncallOverride);
+  server.registerTool(listOncallShiftSwaps);
+  server.registerTool(createOncallShiftSwap);
+  server.registerTool(takeOncallShiftSwap);
+  server.registerTool(getOncallSummary);
 }
\ No newline at end of file
@@ -1,14 +1,37 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
-import { PrometheusClient } from '../clients/prometheus-client';
+import { PrometheusClient, PrometheusQueryResult } from '../clients/prometheus-client';
+import { formatTime, parseTime, parseTimeRange, toUnixSeconds } from '../utils/time';
+import {
+  DEFAULT_MAX_DATA_POINTS,
+  describeResolution,
+  downsampleSeries,
+  widenStep,
+} from '../utils/downsample';
+import { Table, formatLabels, recordsToTable, renderTables, seriesToTable } from '../utils/table';
+import { GrafanaClient } from '../clients/grafana-client';
+import { mapConcurrent } from '../utils/concurrency';
+import {
+  containsQuery,
+  labelSelector,
+  normalizeQuery,
+  queryMetrics,
+  queryShape,
+  stripOuterAggregation,
+} from '../utils/promql';
+import { dashboardPanels } from '../utils/dashboard';
 
 const QueryPrometheusSchema = z.object({
   datasourceUid: z.string().describe('The UID of the datasource to query'),
   expr: z.string().describe('The PromQL expression to query'),
   queryType: z.enum(['range', 'instant']).describe('The type of query to use'),
-  startTime: z.string().describe('The start time (RFC3339 or relative like "now-1h")'),
-  endTime: z.string().optional().describe('The end time for range queries'),
+  startTime: z.string().describe('The start time (RFC3339, unix epoch, or relative like "now-1h")'),
+  endTime: z.string().optional().describe('The end time for range queries (default: now)'),
   stepSeconds: z.number().optional().describe('The time series step size in seconds for range queries'),
+  maxDataPoints: z.number().optional().describe(`Maximum samples per series for range queries (default: ${DEFAULT_MAX_DATA_POINTS})`),
+  downsample: z.enum(['step', 'lttb', 'none']).optional().describe('How to stay within maxDataPoints: widen the query step, query at the requested step and keep the most shape-preserving samples (LTTB), or return everything (default: step)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+  baselineOffset: z.string().regex(/^\d+[mhdw]$/, 'Use a duration such as 1d or 1w').optional().describe('Also return the same query shifted back by this offset, e.g. "1d" or "1w", with its timestamps aligned to the current data and a per-series comparison of averages'),
 });
 
 const ListPrometheusMetricNamesSchema = z.object({
@@ -18,6 +41,14 @@ const ListPrometheusMetricNamesSchema = z.object({
   page: z.number().optional().describe('The page number to return'),
 });
 
+const CheckRecordingRuleDriftSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Prometheus or Mimir datasource whose rules are checked'),
+  folderUids: z.array(z.string()).optional().describe('Only check dashboards in these folders'),
+  tags: z.array(z.string()).optional().describe('Only check dashboards with all of these tags'),
+  maxDashboards: z.number().optional().describe('Maximum dashboards to check (default: 200, max: 1000)'),
+  limit: z.number().optional().describe('Maximum findings to return (default: 100)'),
+});
+
 const ListPrometheusLabelNamesSchema = z.object({
   datasourceUid: z.string().describe('The UID of the datasource to query'),
   matches: z.array(z.object({
@@ -27,8 +58,8 @@ const ListPrometheusLabelNamesSchema = z.object({
       type: z.enum(['=', '!=', '=~', '!~']).describe('The match operator'),
     })),
   })).optional().describe('Label matchers to filter the results'),
-  startRfc3339: z.string().optional().describe('The start time of the time range'),
-  endRfc3339: z.string().optional().describe('The end time of the time range'),
+  startRfc3339: z.string().optional().describe('The start time of the time range (RFC3339, unix epoch, or relative like "now-1h")'),
+  endRfc3339: z.string().optional().describe('The end time of the time range (default: now)'),
   limit: z.number().optional().describe('Maximum number of results'),
 });
 
@@ -42,8 +73,8 @@ const ListPrometheusLabelValuesSchema = z.object({
       type: z.enum(['=', '!=', '=~', '!~']).describe('The match operator'),
     })),
   })).optional().describe('Selectors to filter the results'),
-  startRfc3339: z.string().optional().describe('The start time of the query'),
-  endRfc3339: z.string().optional().describe('The end time of the query'),
+  startRfc3339: z.string().optional().describe('The start time of the query (RFC3339, unix epoch, or relative like "now-1h")'),
+  endRfc3339: z.string().optional().describe('The end time of the query (default: now)'),
   limit: z.number().optional().describe('Maximum number of results'),
 });
 
@@ -54,30 +85,162 @@ const ListPrometheusMetricMetadataSchema = z.object({
   limitPerMetric: z.number().optional().describe('The maximum number of metrics to return per metric'),
 });
 
-// Helper function to convert relative time to Unix timestamp
-function parseTime(time: string): string {
-  if (time === 'now') {
-    return Math.floor(Date.now() / 1000).toString();
-  }
-  
-  const relativeMatch = time.match(/^now-(\d+)([smhd])$/);
-  if (relativeMatch) {
-    const value = parseInt(relativeMatch[1]);
-    const unit = relativeMatch[2];
-    let seconds = 0;
-    
-    switch (unit) {
-      case 's': seconds = value; break;
-      case 'm': seconds = value * 60; break;
-      case 'h': seconds = value * 3600; break;
-      case 'd': seconds = value * 86400; break;
-    }
-    
-    return Math.floor((Date.now() / 1000) - seconds).toString();
-  }
-  
-  // Assume it's already a Unix timestamp or RFC3339
-  return time;
+const GetServiceRedSummarySchema = z.object({
+  datasourceUid: z.string().describe('The UID of the Prometheus datasource'),
+  labels: z.record(z.string()).describe('Labels identifying the service, e.g. {"job": "checkout"}, {"service_name": "checkout"}, or {"service": "checkout"} for span metrics'),
+  window: z.string().regex(/^\d+[smhd]$/, 'Use a duration such as 5m or 1h').optional().describe('Rate window, e.g. "5m" or "1h" (default: 5m)'),
+  time: z.string().optional().describe('The evaluation time (RFC3339, unix epoch, or relative like "now-1h"; default: now)'),
+});
+
+// Request metric conventions, in order of preference. requests counts requests (or is the _count
+// of the duration histogram), errors selects failed requests, and histogram is the request
+// duration histogram without its _bucket suffix.
+interface RedConvention {
+  name: string;
+  requests: string;
+  errors: string;
+  // Label the error matcher needs, to tell conventions sharing a request metric apart
+  errorLabel: string;
+  histogram: string;
+  // Seconds per unit of the histogram
+  unit?: number;
+  // Matchers restricting the metrics to requests served by the service
+  extra?: string;
+}
+
+const GRPC_SERVER_ERRORS =
+  'Unknown|DeadlineExceeded|Unimplemented|Internal|Unavailable|DataLoss|ResourceExhausted';
+
+const RED_CONVENTIONS: RedConvention[] = [
+  {
+    name: 'opentelemetry',
+    requests: 'http_server_request_duration_seconds_count',
+    errors: 'http_response_status_code=~"5.."',
+    errorLabel: 'http_response_status_code',
+    histogram: 'http_server_request_duration_seconds',
+  },
+  {
+    name: 'opentelemetry (before semantic conventions 1.20)',
+    requests: 'http_server_duration_milliseconds_count',
+    errors: 'http_status_code=~"5.."',
+    errorLabel: 'http_status_code',
+    histogram: 'http_server_duration_milliseconds',
+    unit: 0.001,
+  },
+  {
+    name: 'micrometer',
+    requests: 'http_server_requests_seconds_count',
+    errors: 'status=~"5.."',
+    errorLabel: 'status',
+    histogram: 'http_server_requests_seconds',
+  },
+  {
+    name: 'prometheus client',
+    requests: 'http_requests_total',
+    errors: 'code=~"5.."',
+    errorLabel: 'code',
+    histogram: 'http_request_duration_seconds',
+  },
+  {
+    name: 'prometheus client',
+    requests: 'http_requests_total',
+    errors: 'status=~"5.."',
+    errorLabel: 'status',
+    histogram: 'http_request_duration_seconds',
+  },
+  {
+    name: 'grpc',
+    requests: 'grpc_server_handled_total',
+    errors: `grpc_code=~"${GRPC_SERVER_ERRORS}"`,
+    errorLabel: 'grpc_code',
+    histogram: 'grpc_server_handling_seconds',
+  },
+  {
+    name: 'tempo span metrics',
+    requests: 'traces_spanmetrics_calls_total',
+    errors: 'status_code="STATUS_CODE_ERROR"',
+    errorLabel: 'status_code',
+    histogram: 'traces_spanmetrics_latency',
+    extra: 'span_kind=~"SPAN_KIND_SERVER|SPAN_KIND_CONSUMER"',
+  },
+  {
+    name: 'tempo span metrics',
+    requests: 'traces_span_metrics_calls_total',
+    errors: 'status_code="STATUS_CODE_ERROR"',
+    errorLabel: 'status_code',
+    histogram: 'traces_span_metrics_duration_seconds',
+    extra: 'span_kind=~"SPAN_KIND_SERVER|SPAN_KIND_CONSUMER"',
+  },
+];
+
+// Error ratios from which a service counts as degraded or unhealthy
+const DEGRADED_ERROR_RATIO = 0.01;
+const UNHEALTHY_ERROR_RATIO = 0.05;
+
+// Helper to render sample timestamps as RFC3339 in the requested time zone
+export function formatSampleTimes(result: PrometheusQueryResult[], timezone?: string) {
+  return result.map(series => ({
+    metric: series.metric,
+    value: series.value
+      ? [formatTime(series.value[0] * 1000, timezone), series.value[1]]
+      : undefined,
+    values: series.values?.map(([time, value]) => [formatTime(time * 1000, timezone), value]),
+  }));
+}
+
+// Helper to move the samples of series forward in time, aligning baseline data with current data
+function shiftSeries(series: PrometheusQueryResult[], seconds: number): PrometheusQueryResult[] {
+  return series.map(item => ({
+    metric: item.metric,
+    value: item.value && [item.value[0] + seconds, item.value[1]],
+    values: item.values?.map(([time, value]) => [time + seconds, value] as [number, string]),
+  }));
+}
+
+// Helper to average the samples of a series; undefined without numeric samples
+function seriesAverage(series: PrometheusQueryResult | undefined): number | undefined {
+  const samples = series?.values || (series?.value ? [series.value] : []);
+  const values = samples.map(([, value]) => parseFloat(value)).filter(Number.isFinite);
+  return values.length > 0
+    ? values.reduce((sum, value) => sum + value, 0) / values.length
+    : undefined;
+}
+
+// Helper to compare each series with the baseline series of the same labels by their averages
+function compareWithBaseline(current: PrometheusQueryResult[], baseline: PrometheusQueryResult[]) {
+  const key = (metric: Record<string, string>) => JSON.stringify(Object.entries(metric).sort());
+  const baselineByKey = new Map(baseline.map(series => [key(series.metric), series]));
+  const round = (value: number | undefined) =>
+    value === undefined ? undefined : Math.round(value * 1000) / 1000;
+  return current.map(series => {
+    const now = seriesAverage(series);
+    const before = seriesAverage(baselineByKey.get(key(series.metric)));
+    return {
+      metric: series.metric,
+      current: round(now),
+      baseline: round(before),
+      changePercent:
+        now !== undefined && before ? round(((now - before) / Math.abs(before)) * 100) : undefined,
+    };
+  });
+}
+
+// Helper to render current and baseline data as tables, followed by their comparison
+function baselineTables(
+  current: PrometheusQueryResult[],
+  baseline: PrometheusQueryResult[],
+  offset: string,
+  timezone?: string
+): Table[] {
+  const comparison = compareWithBaseline(current, baseline).map(row => ({
+    ...row,
+    metric: formatLabels(row.metric),
+  }));
+  return [
+    { ...seriesToTable(current, timezone), name: 'Current' },
+    { ...seriesToTable(baseline, timezone), name: `Baseline (${offset} earlier)` },
+    { ...recordsToTable(comparison), name: 'Comparison of averages' },
+  ];
 }
 
 // Helper to build Prometheus selector from filters
@@ -99,23 +262,95 @@ function buildSelector(filters: any[]): string {
 
 export const queryPrometheus: ToolDefinition = {
   name: 'query_prometheus',
-  description: 'Query Prometheus using a PromQL expression. Supports both instant and range queries.',
+  description: 'Query Prometheus using a PromQL expression. Supports both instant and range queries. Set baselineOffset to also get the same window a day or week earlier for comparison.',
   inputSchema: QueryPrometheusSchema,
   handler: async (params, context: ToolContext) => {
     try {
       const client = new PrometheusClient(context.config.grafanaConfig, params.datasourceUid);
       
+      const timezone = context.timezone;
+      // The baseline is the same query over a window shifted back by the offset
+      const now = new Date();
+      const offsetSeconds = params.baselineOffset
+        ? toUnixSeconds(now) - toUnixSeconds(parseTime(`now-${params.baselineOffset}`, { now }))
+        : 0;
+      const withBaseline = async (run: (shift: number) => Promise<PrometheusQueryResult[]>) => {
+        const [current, baseline] = await Promise.all([
+          run(0),
+          offsetSeconds ? run(offsetSeconds) : Promise.resolve(undefined),
+        ]);
+        return { current, baseline: baseline && shiftSeries(baseline, offsetSeconds) };
+      };
+      
       let result;
+      let baseline;
       if (params.queryType === 'instant') {
-        result = await client.query(params.expr, parseTime(params.startTime));
+        const time = toUnixSeconds(parseTime(params.startTime, { timezone }));
+        ({ current: result, baseline } = await withBaseline(shift =>
+          client.query(params.expr, (time - shift).toString())
+        ));
       } else {
-        const start = parseTime(params.startTime);
-        const end = params.endTime ? parseTime(params.endTime) : 'now';
-        const step = params.stepSeconds ? `${params.stepSeconds}s` : '60s';
-        result = await client.queryRange(params.expr, start, end, step);
+        const range = parseTimeRange(params.startTime, params.endTime, { timezone });
+        const start = toUnixSeconds(range.start);
+        const end = toUnixSeconds(range.end);
+        const method = params.downsample || 'step';
+        const maxDataPoints = params.maxDataPoints || DEFAULT_MAX_DATA_POINTS;
+        const stepSeconds = method === 'step'
+          ? widenStep(start, end, params.stepSeconds || 60, maxDataPoints)
+          : params.stepSeconds || 60;
+        
+        ({ current: result, baseline } = await withBaseline(shift =>
+          client.queryRange(
+            params.expr,
+            (start - shift).toString(),
+            (end - shift).toString(),
+            `${stepSeconds}s`
+          )
+        ));
+        
+        const downsampled = downsampleSeries(result, method, stepSeconds, maxDataPoints);
+        const downsampledBaseline =
+          baseline && downsampleSeries(baseline, method, stepSeconds, maxDataPoints).series;
+        if (params.format && params.format !== 'json') {
+          const tables = downsampledBaseline
+            ? baselineTables(
+              downsampled.series,
+              downsampledBaseline,
+              params.baselineOffset,
+              timezone
+            )
+            : [seriesToTable(downsampled.series, timezone)];
+          const table = renderTables(tables, params.format);
+          return createToolResult(`${describeResolution(downsampled.resolution)}\n\n${table}`);
+        }
+        return createToolResult({
+          resolution: downsampled.resolution,
+          series: formatSampleTimes(downsampled.series, timezone),
+          baseline: downsampledBaseline && {
+            offset: params.baselineOffset,
+            series: formatSampleTimes(downsampledBaseline, timezone),
+            comparison: compareWithBaseline(result, baseline!),
+          },
+        });
       }
       
-      return createToolResult(result);
+      if (params.format && params.format !== 'json') {
+        const tables = baseline
+          ? baselineTables(result, baseline, params.baselineOffset, timezone)
+          : [seriesToTable(result, timezone)];
+        return createToolResult(renderTables(tables, params.format));
+      }
+      if (baseline) {
+        return createToolResult({
+          result: formatSampleTimes(result, timezone),
+          baseline: {
+            offset: params.baselineOffset,
+            result: formatSampleTimes(baseline, timezone),
+            comparison: compareWithBaseline(result, baseline),
+          },
+        });
+      }
+      return createToolResult(formatSampleTimes(result, timezone));
     } catch (error: any) {
       return createErrorResult(error.message);
     }
@@ -172,10 +407,15 @@ export const listPrometheusLabelNames: ToolDefinition = {
       const client = new PrometheusClient(context.config.grafanaConfig, params.datasourceUid);
       
       const match = params.matches?.map((m: any) => buildSelector(m.filters)) || [];
+      const range = params.startRfc3339
+        ? parseTimeRange(params.startRfc3339, params.endRfc3339, {
+          timezone: context.timezone,
+        })
+        : undefined;
       const labels = await client.getLabelNames(
         match.length > 0 ? match : undefined,
-        params.startRfc3339,
-        params.endRfc3339
+        range?.start.toISOString(),
+        range?.end.toISOString()
       );
       
       const limited = params.limit ? labels.slice(0, params.limit) : labels;
@@ -196,11 +436,16 @@ export const listPrometheusLabelValues: ToolDefinition = {
       const client = new PrometheusClient(context.config.grafanaConfig, params.datasourceUid);
       
       const match = params.matches?.map((m: any) => buildSelector(m.filters)) || [];
+      const range = params.startRfc3339
+        ? parseTimeRange(params.startRfc3339, params.endRfc3339, {
+          timezone: context.timezone,
+        })
+        : undefined;
       const values = await client.getLabelValues(
         params.labelName,
         match.length > 0 ? match : undefined,
-        params.startRfc3339,
-        params.endRfc3339
+        range?.start.toISOString(),
+        range?.end.toISOString()
       );
       
       const limited = params.limit ? values.slice(0, params.limit) : values;
@@ -229,10 +474,280 @@ export const listPrometheusMetricMetadata: ToolDefinition = {
   },
 };
 
+// A dashboard panel or alerting rule whose queries are checked against the recording rules
+interface RuleConsumer {
+  source: 'dashboard' | 'alert_rule';
+  where: Record<string, any>;
+  exprs: string[];
+}
+
+// Helper to check whether a query's datasource may be the given one; panels using a datasource
+// variable or the default datasource are assumed to
+function usesDatasource(ref: any, uid: string): boolean {
+  const refUid = typeof ref === 'object' && ref ? ref.uid : ref;
+  return !refUid || refUid === uid || String(refUid).startsWith('$');
+}
+
+export const checkRecordingRuleDrift: ToolDefinition = {
+  name: 'check_recording_rule_drift',
+  description: 'Compares the recording rules of a Prometheus or Mimir datasource with the dashboard queries and alerting rules that use them. Flags queries referencing recorded metrics (level:metric:operation names) that no recording rule produces, queries that inline a recording rule\'s expression instead of using its metric, and queries that compute a recording rule\'s expression, apart from its outer aggregation, over a different range or label matchers (drift)',
+  inputSchema: CheckRecordingRuleDriftSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const prometheus = new PrometheusClient(context.config.grafanaConfig, params.datasourceUid);
+      const grafana = new GrafanaClient(context.config.grafanaConfig);
+
+      const groups = await prometheus.getRules();
+      const rules = groups.flatMap((group: any) =>
+        (group.rules || []).map((rule: any) => ({ ...rule, group: group.name }))
+      );
+      const recording = rules
+        .filter((rule: any) => rule.type === 'recording')
+        .map((rule: any) => {
+          const normalized = normalizeQuery(rule.query);
+          const inner = stripOuterAggregation(normalized);
+          return {
+            name: rule.name,
+            group: rule.group,
+            expr: rule.query,
+            normalized,
+            inner,
+            innerShape: queryShape(inner),
+          };
+        });
+      const recorded = new Set(recording.map((rule: any) => rule.name));
+      const ruleRef = (rule: any) => ({ rule: rule.name, ruleExpr: rule.expr });
+
+      const consumers: RuleConsumer[] = rules
+        .filter((rule: any) => rule.type === 'alerting')
+        .map((rule: any) => ({
+          source: 'alert_rule',
+          where: { alertRule: rule.name, group: rule.group },
+          exprs: [rule.query],
+        }));
+
+      const dashboards = await grafana.search({
+        type: 'dash-db',
+        folderUIDs: params.folderUids,
+        tag: params.tags,
+        limit: Math.min(params.maxDashboards || 200, 1000),
+      });
+      const fetched = await mapConcurrent(dashboards, 10, async (hit: any) => {
+        try {
+          return { hit, dashboard: await grafana.getDashboardByUid(hit.uid) };
+        } catch {
+          // Dashboards that cannot be read are left out
+          return undefined;
+        }
+      });
+      for (const entry of fetched) {
+        if (!entry) continue;
+        for (const panel of dashboardPanels(entry.dashboard)) {
+          const exprs = (panel.targets || [])
+            .filter((target: any) => !target.hide && typeof target.expr === 'string')
+            .filter((target: any) =>
+              usesDatasource(target.datasource || panel.datasource, params.datasourceUid)
+            )
+            .map((target: any) => target.expr);
+          if (exprs.length === 0) continue;
+          consumers.push({
+            source: 'dashboard',
+            where: {
+              dashboardUid: entry.hit.uid,
+              dashboardTitle: entry.hit.title,
+              panelId: panel.id,
+              panelTitle: panel.title,
+            },
+            exprs,
+          });
+        }
+      }
+
+      const findings: any[] = [];
+      for (const consumer of consumers) {
+        for (const expr of consumer.exprs) {
+          const metrics = queryMetrics(expr);
+          const base = { source: consumer.source, ...consumer.where, expr };
+
+          // Recorded metrics are named level:metric:operations by convention
+          for (const metric of metrics) {
+            if (metric.includes(':') && !recorded.has(metric)) {
+              findings.push({ kind: 'missing_recording_rule', metric, ...base });
+            }
+          }
+
+          const normalized = normalizeQuery(expr);
+          const shape = queryShape(normalized);
+          for (const rule of recording) {
+            // Rules over a bare metric are renames, which are not worth flagging when inlined
+            if (metrics.includes(rule.name) || !rule.inner.includes('(')) continue;
+            if (containsQuery(normalized, rule.normalized)) {
+              findings.push({ kind: 'inlined_rule', ...ruleRef(rule), ...base });
+            } else if (
+              containsQuery(shape, rule.innerShape) &&
+              !containsQuery(normalized, rule.inner)
+            ) {
+              // The rule's expression, apart from its outer aggregation, with other ranges or
+              // label matchers
+              findings.push({ kind: 'drift', ...ruleRef(rule), ...base });
+            }
+          }
+        }
+      }
+
+      const limit = params.limit || 100;
+      const counts: Record<string, number> = {};
+      for (const finding of findings) {
+        counts[finding.kind] = (counts[finding.kind] || 0) + 1;
+      }
+      return createToolResult({
+        recordingRules: recording.length,
+        alertingRules: rules.length - recording.length,
+        dashboardsChecked: fetched.filter(entry => entry).length,
+        counts,
+        findings: findings.slice(0, limit),
+        truncated: findings.length > limit,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+// Helper to pick the first request metric convention the service's metrics follow
+async function detectRedConvention(
+  client: PrometheusClient,
+  selector: string,
+  start: string,
+  end: string
+): Promise<{ convention: RedConvention; hasHistogram: boolean } | undefined> {
+  const names = new Set(await client.getLabelValues('__name__', [`{${selector}}`], start, end));
+  const labelsByMetric: Map<string, string[]> = new Map();
+  for (const convention of RED_CONVENTIONS) {
+    if (!names.has(convention.requests)) continue;
+    if (!labelsByMetric.has(convention.requests)) {
+      labelsByMetric.set(
+        convention.requests,
+        await client.getLabelNames([`${convention.requests}{${selector}}`], start, end)
+      );
+    }
+    if (labelsByMetric.get(convention.requests)!.includes(convention.errorLabel)) {
+      return { convention, hasHistogram: names.has(`${convention.histogram}_bucket`) };
+    }
+  }
+  return undefined;
+}
+
+// Helper to evaluate a query returning a single value; undefined without data
+async function scalarValue(
+  client: PrometheusClient,
+  expr: string,
+  time: string
+): Promise<number | undefined> {
+  const result = await client.query(expr, time);
+  const value = parseFloat(result[0]?.value?.[1] ?? 'NaN');
+  return Number.isFinite(value) ? value : undefined;
+}
+
+export const getServiceRedSummary: ToolDefinition = {
+  name: 'get_service_red_summary',
+  description: `Summarizes the health of a service from its request metrics: request rate, error rate and ratio, and p50/p90/p99 latency (RED), plus the share of its scrape targets that are up. The metrics are found from common conventions: OpenTelemetry HTTP server metrics, Micrometer, Prometheus client http_requests_total, gRPC server metrics, and Tempo span metrics. The status is no_traffic, healthy, degraded (error ratio from ${DEGRADED_ERROR_RATIO * 100}%), or unhealthy (from ${UNHEALTHY_ERROR_RATIO * 100}% or no target up)`,
+  inputSchema: GetServiceRedSummarySchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new PrometheusClient(context.config.grafanaConfig, params.datasourceUid);
+      const labels: Record<string, string> = params.labels;
+      if (Object.keys(labels).length === 0) {
+        return createErrorResult('At least one label is required to identify the service');
+      }
+      const selector = labelSelector(labels);
+      const window = params.window || '5m';
+      const time = parseTime(params.time || 'now', { timezone: context.timezone });
+      const at = toUnixSeconds(time).toString();
+      const lookback = (toUnixSeconds(time) - 3600).toString();
+
+      const detected = await detectRedConvention(client, selector, lookback, at);
+      const upExpr = `sum(up{${selector}})`;
+      const totalExpr = `count(up{${selector}})`;
+      const [up, targets] = await Promise.all([
+        scalarValue(client, upExpr, at),
+        scalarValue(client, totalExpr, at),
+      ]);
+      const availability = targets ? { up: up ?? 0, total: targets } : undefined;
+      if (!detected) {
+        return createToolResult({
+          selector: `{${selector}}`,
+          time: formatTime(time, context.timezone),
+          availability,
+          error: `No request metrics of a known convention match {${selector}} in the last hour`,
+          conventions: [...new Set(RED_CONVENTIONS.map(convention => convention.requests))],
+        });
+      }
+
+      const { convention, hasHistogram } = detected;
+      const base = [selector, convention.extra].filter(matcher => matcher).join(',');
+      const queries: Record<string, string> = {
+        requests: `sum(rate(${convention.requests}{${base}}[${window}]))`,
+        errors: `sum(rate(${convention.requests}{${base},${convention.errors}}[${window}]))`,
+      };
+      if (hasHistogram) {
+        for (const quantile of [0.5, 0.9, 0.99]) {
+          queries[`p${quantile * 100}`] =
+            `histogram_quantile(${quantile}, sum by (le) ` +
+            `(rate(${convention.histogram}_bucket{${base}}[${window}])))`;
+        }
+      }
+      const entries = Object.entries(queries);
+      const values = Object.fromEntries(
+        await Promise.all(
+          entries.map(async ([name, expr]) => [name, await scalarValue(client, expr, at)])
+        )
+      );
+
+      const round = (value: number | undefined, digits = 3) =>
+        value === undefined ? undefined : Math.round(value * 10 ** digits) / 10 ** digits;
+      const requests = values.requests ?? 0;
+      const errors = values.errors ?? 0;
+      const errorRatio = requests > 0 ? errors / requests : undefined;
+      const seconds = (value: number | undefined) =>
+        round(value === undefined ? undefined : value * (convention.unit ?? 1), 4);
+
+      let status = 'healthy';
+      if (requests === 0) {
+        status = 'no_traffic';
+      } else if ((errorRatio ?? 0) >= UNHEALTHY_ERROR_RATIO || (availability && !availability.up)) {
+        status = 'unhealthy';
+      } else if ((errorRatio ?? 0) >= DEGRADED_ERROR_RATIO) {
+        status = 'degraded';
+      }
+
+      return createToolResult({
+        selector: `{${selector}}`,
+        time: formatTime(time, context.timezone),
+        window,
+        convention: convention.name,
+        status,
+        requestsPerSecond: round(requests),
+        errorsPerSecond: round(errors),
+        errorRatio: round(errorRatio, 4),
+        latencySeconds: hasHistogram
+          ? { p50: seconds(values.p50), p90: seconds(values.p90), p99: seconds(values.p99) }
+          : undefined,
+        availability,
+        queries: { ...queries, up: upExpr },
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
 export function registerPrometheusTools(server: any) {
   server.registerTool(queryPrometheus);
   server.registerTool(listPrometheusMetricNames);
   server.registerTool(listPrometheusLabelNames);
   server.registerTool(listPrometheusLabelValues);
   server.registerTool(listPrometheusMetricMetadata);
+  server.registerTool(checkRecordingRuleDrift);
+  server.registerTool(getServiceRedSummary);
 }
\ No newline at end of file
@@ -1,65 +1,42 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
-import axios from 'axios';
+import { createPluginClient } from '../clients/base-client';
+import { parseTimeRange } from '../utils/time';
 
 // Schema definitions
 const ListPyroscopeLabelNamesSchema = z.object({
   data_source_uid: z.string().describe('The UID of the datasource to query'),
-  start_rfc_3339: z.string().optional().describe('Start time in RFC3339 format'),
-  end_rfc_3339: z.string().optional().describe('End time in RFC3339 format'),
+  start_rfc_3339: z.string().optional().describe('Start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  end_rfc_3339: z.string().optional().describe('End time (default: now)'),
   matchers: z.string().optional().describe('Prometheus-style matchers'),
 });
 
 const ListPyroscopeLabelValuesSchema = z.object({
   data_source_uid: z.string().describe('The UID of the datasource to query'),
   name: z.string().describe('A label name'),
-  start_rfc_3339: z.string().optional().describe('Start time in RFC3339 format'),
-  end_rfc_3339: z.string().optional().describe('End time in RFC3339 format'),
+  start_rfc_3339: z.string().optional().describe('Start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  end_rfc_3339: z.string().optional().describe('End time (default: now)'),
   matchers: z.string().optional().describe('Prometheus-style matchers'),
 });
 
 const ListPyroscopeProfileTypesSchema = z.object({
   data_source_uid: z.string().describe('The UID of the datasource to query'),
-  start_rfc_3339: z.string().optional().describe('Start time in RFC3339 format'),
-  end_rfc_3339: z.string().optional().describe('End time in RFC3339 format'),
+  start_rfc_3339: z.string().optional().describe('Start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  end_rfc_3339: z.string().optional().describe('End time (default: now)'),
 });
 
 const FetchPyroscopeProfileSchema = z.object({
   data_source_uid: z.string().describe('The UID of the datasource to query'),
   profile_type: z.string().describe('Profile type to fetch'),
-  start_rfc_3339: z.string().optional().describe('Start time in RFC3339 format'),
-  end_rfc_3339: z.string().optional().describe('End time in RFC3339 format'),
+  start_rfc_3339: z.string().optional().describe('Start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  end_rfc_3339: z.string().optional().describe('End time (default: now)'),
   matchers: z.string().optional().describe('Prometheus-style matchers'),
   max_node_depth: z.number().optional().describe('Maximum depth of nodes in the profile'),
 });
 
 // Helper function to create Pyroscope client
 function createPyroscopeClient(config: any, datasourceUid: string) {
-  const headers: any = {
-    'User-Agent': 'mcp-grafana/1.0.0',
-  };
-  
-  if (config.serviceAccountToken) {
-    headers['Authorization'] = `Bearer ${config.serviceAccountToken}`;
-  } else if (config.apiKey) {
-    headers['Authorization'] = `Bearer ${config.apiKey}`;
-  }
-  
-  return axios.create({
-    baseURL: `${config.url}/api/datasources/proxy/uid/${datasourceUid}`,
-    headers,
-    timeout: 30000,
-  });
-}
-
-// Helper function to get default time range (last hour)
-function getDefaultTimeRange(): { start: string; end: string } {
-  const now = new Date();
-  const oneHourAgo = new Date(now.getTime() - 60 * 60 * 1000);
-  return {
-    start: oneHourAgo.toISOString(),
-    end: now.toISOString(),
-  };
+  return createPluginClient(config, `${config.url}/api/datasources/proxy/uid/${datasourceUid}`);
 }
 
 // Tool definitions
@@ -70,13 +47,14 @@ export const listPyroscopeLabelNames: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = createPyroscopeClient(context.config.grafanaConfig, params.data_source_uid);
-      const timeRange = params.start_rfc_3339 || params.end_rfc_3339 
-        ? { start: '', end: '' } 
-        : getDefaultTimeRange();
+      const timeRange = parseTimeRange(params.start_rfc_3339, params.end_rfc_3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
       
       const queryParams: any = {
-        start: params.start_rfc_3339 || timeRange.start,
-        end: params.end_rfc_3339 || timeRange.end,
+        start: timeRange.start.toISOString(),
+        end: timeRange.end.toISOString(),
       };
       
       if (params.matchers) {
@@ -102,14 +80,15 @@ export const listPyroscopeLabelValues: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = createPyroscopeClient(context.config.grafanaConfig, params.data_source_uid);
-      const timeRange = params.start_rfc_3339 || params.end_rfc_3339 
-        ? { start: '', end: '' } 
-        : getDefaultTimeRange();
+      const timeRange = parseTimeRange(params.start_rfc_3339, params.end_rfc_3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
       
       const queryParams: any = {
         label: params.name,
-        start: params.start_rfc_3339 || timeRange.start,
-        end: params.end_rfc_3339 || timeRange.end,
+        start: timeRange.start.toISOString(),
+        end: timeRange.end.toISOString(),
       };
       
       if (params.matchers) {
@@ -132,13 +111,14 @@ export const listPyroscopeProfileTypes: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = createPyroscopeClient(context.config.grafanaConfig, params.data_source_uid);
-      const timeRange = params.start_rfc_3339 || params.end_rfc_3339 
-        ? { start: '', end: '' } 
-        : getDefaultTimeRange();
+      const timeRange = parseTimeRange(params.start_rfc_3339, params.end_rfc_3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
       
       const queryParams: any = {
-        start: params.start_rfc_3339 || timeRange.start,
-        end: params.end_rfc_3339 || timeRange.end,
+        start: timeRange.start.toISOString(),
+        end: timeRange.end.toISOString(),
       };
       
       const response = await client.get('/pyroscope/api/v1/profile-types', { params: queryParams });
@@ -157,14 +137,15 @@ export const fetchPyroscopeProfile: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = createPyroscopeClient(context.config.grafanaConfig, params.data_source_uid);
-      const timeRange = params.start_rfc_3339 || params.end_rfc_3339 
-        ? { start: '', end: '' } 
-        : getDefaultTimeRange();
+      const timeRange = parseTimeRange(params.start_rfc_3339, params.end_rfc_3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
       
       const queryParams: any = {
         query: params.profile_type,
-        from: params.start_rfc_3339 || timeRange.start,
-        until: params.end_rfc_3339 || timeRange.end,
+        from: timeRange.start.toISOString(),
+        until: timeRange.end.toISOString(),
         format: 'dot', // Return in DOT format
       };
       
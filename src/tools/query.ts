@@ -0,0 +1,204 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+import { PrometheusClient } from '../clients/prometheus-client';
+import { LokiClient } from '../clients/loki-client';
+import { formatTime, parseTime, parseTimeRange, toUnixSeconds } from '../utils/time';
+import { DEFAULT_MAX_DATA_POINTS, downsampleSeries, widenStep } from '../utils/downsample';
+import { createLogScrubber } from '../utils/scrub';
+import { mapConcurrent } from '../utils/concurrency';
+import { formatSampleTimes } from './prometheus';
+
+// Queries per run_queries call, and how many of them run at the same time by default
+const MAX_QUERIES = 20;
+const DEFAULT_CONCURRENCY = 5;
+const MAX_CONCURRENCY = 10;
+
+const QuerySpecSchema = z.object({
+  key: z.string().describe('Key of the result in the response, e.g. the service the query is about'),
+  datasourceUid: z.string().describe('The UID of the Prometheus or Loki datasource to query'),
+  expr: z.string().describe('The PromQL or LogQL expression, matching the datasource type'),
+  queryType: z.enum(['range', 'instant']).optional().describe('Range or instant query; LogQL log queries are always range queries (default: range)'),
+  startTime: z.string().optional().describe('The start time, or the time of instant queries (default: the shared startTime)'),
+  endTime: z.string().optional().describe('The end time of range queries (default: the shared endTime)'),
+  stepSeconds: z.number().optional().describe('The step of range queries in seconds, widened to stay within the maximum data points (default: 60)'),
+  limit: z.number().optional().describe('Maximum log lines of LogQL log queries (default: 10, max: 100)'),
+});
+
+const RunQueriesSchema = z.object({
+  queries: z.array(QuerySpecSchema).min(1).max(MAX_QUERIES).describe(`Queries to run, up to ${MAX_QUERIES}, possibly against different datasources`),
+  startTime: z.string().optional().describe('The start time for queries without their own (RFC3339, unix epoch, or relative like "now-1h"; default: now-1h)'),
+  endTime: z.string().optional().describe('The end time for queries without their own (default: now)'),
+  concurrency: z.number().int().min(1).max(MAX_CONCURRENCY).optional().describe(`How many queries run at the same time (default: ${DEFAULT_CONCURRENCY})`),
+});
+
+type QuerySpec = z.infer<typeof QuerySpecSchema>;
+
+interface QueryDefaults {
+  startTime?: string;
+  endTime?: string;
+}
+
+// Time range of a range query in unix seconds, with the step widened to stay within the
+// maximum data points
+function queryWindow(spec: QuerySpec, defaults: QueryDefaults, timezone?: string) {
+  const range = parseTimeRange(
+    spec.startTime || defaults.startTime,
+    spec.endTime || defaults.endTime,
+    { timezone, defaultStart: 'now-1h' }
+  );
+  const start = toUnixSeconds(range.start);
+  const end = toUnixSeconds(range.end);
+  const stepSeconds = widenStep(start, end, spec.stepSeconds || 60, DEFAULT_MAX_DATA_POINTS);
+  return { range, start, end, stepSeconds };
+}
+
+// Downsamples the series of a range query and formats their sample times
+function formatSeries(result: any[], stepSeconds: number, timezone?: string) {
+  const downsampled = downsampleSeries(result, 'step', stepSeconds, DEFAULT_MAX_DATA_POINTS);
+  return {
+    resolution: downsampled.resolution,
+    series: formatSampleTimes(downsampled.series, timezone),
+  };
+}
+
+async function runPrometheusQuery(
+  spec: QuerySpec,
+  defaults: QueryDefaults,
+  context: ToolContext
+): Promise<any> {
+  const client = new PrometheusClient(context.config.grafanaConfig, spec.datasourceUid);
+  const timezone = context.timezone;
+
+  if (spec.queryType === 'instant') {
+    const time = parseTime(spec.startTime || defaults.endTime || 'now', { timezone });
+    const result = await client.query(spec.expr, toUnixSeconds(time).toString());
+    return formatSampleTimes(result, timezone);
+  }
+
+  const { start, end, stepSeconds } = queryWindow(spec, defaults, timezone);
+  const result = await client.queryRange(
+    spec.expr,
+    start.toString(),
+    end.toString(),
+    `${stepSeconds}s`
+  );
+  return formatSeries(result, stepSeconds, timezone);
+}
+
+async function runLokiQuery(
+  spec: QuerySpec,
+  defaults: QueryDefaults,
+  context: ToolContext
+): Promise<any> {
+  const client = new LokiClient(context.config.grafanaConfig, spec.datasourceUid);
+  const timezone = context.timezone;
+  const { range, start, end, stepSeconds } = queryWindow(spec, defaults, timezone);
+
+  // Metric queries (e.g. rate({app="x"}[5m])) return series rather than log lines
+  if (!spec.expr.trim().startsWith('{')) {
+    const result = await client.queryMetrics(
+      spec.expr,
+      start.toString(),
+      end.toString(),
+      `${stepSeconds}s`
+    );
+    return formatSeries(result, stepSeconds, timezone);
+  }
+
+  const logs = await client.queryLogs(
+    spec.expr,
+    range.start.toISOString(),
+    range.end.toISOString(),
+    Math.min(spec.limit || 10, 100),
+    'backward'
+  );
+  // Redact personal data and secrets when configured; Loki timestamps are unix nanoseconds
+  const scrub = createLogScrubber(context.config.grafanaConfig.logScrubbing);
+  return logs.map(entry => ({
+    timestamp: formatTime(parseInt(entry.timestamp.slice(0, -6), 10), timezone),
+    labels: scrub
+      ? Object.fromEntries(
+        Object.entries(entry.labels).map(([name, value]) => [name, scrub(value)])
+      )
+      : entry.labels,
+    line: scrub && entry.line !== undefined ? scrub(entry.line) : entry.line,
+  }));
+}
+
+// Query runners by datasource type
+const QUERY_RUNNERS: Record<
+  string,
+  (spec: QuerySpec, defaults: QueryDefaults, context: ToolContext) => Promise<any>
+> = {
+  prometheus: runPrometheusQuery,
+  loki: runLokiQuery,
+};
+
+export const runQueries: ToolDefinition = {
+  name: 'run_queries',
+  description: 'Run several PromQL and LogQL queries at once, possibly against different datasources, and get their results keyed by name. Use it to compare services or signals in one call instead of many. Failed queries report their error without failing the others.',
+  inputSchema: RunQueriesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const queries: QuerySpec[] = params.queries;
+      const duplicates = queries
+        .map(query => query.key)
+        .filter((key, i, keys) => keys.indexOf(key) !== i);
+      if (duplicates.length > 0) {
+        return createErrorResult(
+          `Query keys must be unique, repeated: ${[...new Set(duplicates)].join(', ')}`
+        );
+      }
+
+      // Each datasource is looked up once, also when several queries use it
+      const grafana = new GrafanaClient(context.config.grafanaConfig);
+      const types: Map<string, Promise<string>> = new Map();
+      const datasourceType = (uid: string) => {
+        if (!types.has(uid)) {
+          types.set(uid, grafana.getDatasourceByUid(uid).then(datasource => datasource.type));
+        }
+        return types.get(uid)!;
+      };
+
+      const defaults = { startTime: params.startTime, endTime: params.endTime };
+      let done = 0;
+      const outcomes = await mapConcurrent(
+        queries,
+        params.concurrency || DEFAULT_CONCURRENCY,
+        async query => {
+          let outcome: Record<string, any>;
+          try {
+            if (context.signal?.aborted) {
+              throw new Error('Cancelled');
+            }
+            const type = await datasourceType(query.datasourceUid);
+            const run = QUERY_RUNNERS[type];
+            if (!run) {
+              throw new Error(
+                `Datasource type "${type}" is not supported; supported types: ` +
+                  Object.keys(QUERY_RUNNERS).join(', ')
+              );
+            }
+            const result = await run(query, defaults, context);
+            outcome = { datasourceUid: query.datasourceUid, type, result };
+          } catch (error: any) {
+            outcome = { datasourceUid: query.datasourceUid, error: error.message };
+          }
+          await context.sendProgress(++done, queries.length, `Ran query "${query.key}"`);
+          return outcome;
+        }
+      );
+
+      const results = Object.fromEntries(queries.map((query, i) => [query.key, outcomes[i]]));
+      const failed = outcomes.filter(outcome => outcome.error).length;
+      return createToolResult({ succeeded: queries.length - failed, failed, results });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerQueryTools(server: any) {
+  server.registerTool(runQueries);
+}
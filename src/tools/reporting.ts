@@ -0,0 +1,191 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+
+// Schema definitions
+const ListReportsSchema = z.object({
+  dashboardUid: z.string().optional().describe('Only list reports that include this dashboard'),
+});
+
+const ReportScheduleSchema = z.object({
+  frequency: z.enum(['once', 'hourly', 'daily', 'weekly', 'monthly', 'custom']).describe('How often the report is sent'),
+  startDate: z.string().optional().describe('When the schedule starts, in RFC3339 format (default: now)'),
+  endDate: z.string().optional().describe('When the schedule ends, in RFC3339 format'),
+  intervalFrequency: z.enum(['hours', 'days', 'weeks', 'months']).optional().describe('Unit of the interval for custom schedules'),
+  intervalAmount: z.number().optional().describe('Number of units between sends for custom schedules'),
+  workdaysOnly: z.boolean().optional().describe('Only send on workdays (daily schedules)'),
+  timeZone: z.string().optional().describe('Time zone of the schedule (default: UTC)'),
+});
+
+const ReportFieldsSchema = {
+  name: z.string().describe('The name of the report'),
+  dashboardUid: z.string().describe('The UID of the dashboard to report on'),
+  recipients: z.array(z.string()).describe('Email addresses to send the report to'),
+  message: z.string().optional().describe('Message included in the report email'),
+  replyTo: z.string().optional().describe('Reply-to email address'),
+  timeFrom: z.string().optional().describe('Start of the dashboard time range (default: dashboard default)'),
+  timeTo: z.string().optional().describe('End of the dashboard time range (default: dashboard default)'),
+  variables: z.record(z.array(z.string())).optional().describe('Dashboard template variable values'),
+  formats: z.array(z.enum(['pdf', 'csv', 'image'])).optional().describe('Attachment formats (default: pdf)'),
+  orientation: z.enum(['portrait', 'landscape']).optional().describe('PDF orientation (default: landscape)'),
+  layout: z.enum(['simple', 'grid']).optional().describe('PDF layout (default: grid)'),
+  schedule: ReportScheduleSchema.describe('When to send the report'),
+};
+
+const CreateReportSchema = z.object(ReportFieldsSchema);
+
+const UpdateReportSchema = z.object(ReportFieldsSchema).partial().extend({
+  id: z.number().describe('The ID of the report to update'),
+});
+
+const SendReportSchema = z.object({
+  id: z.number().describe('The ID of the report to send'),
+  emails: z.array(z.string()).optional().describe('Send to these addresses instead of the report recipients'),
+});
+
+// Helper function to build a report payload from tool parameters
+function buildReport(params: any, existing: any = {}) {
+  const existingDashboard = existing.dashboards?.[0] || {};
+  const schedule = params.schedule || existing.schedule || {};
+  
+  return {
+    ...existing,
+    name: params.name ?? existing.name,
+    recipients: params.recipients ? params.recipients.join(',') : existing.recipients,
+    replyTo: params.replyTo ?? existing.replyTo,
+    message: params.message ?? existing.message,
+    formats: params.formats ?? existing.formats ?? ['pdf'],
+    options: {
+      ...existing.options,
+      orientation: params.orientation ?? existing.options?.orientation ?? 'landscape',
+      layout: params.layout ?? existing.options?.layout ?? 'grid',
+    },
+    schedule: {
+      ...schedule,
+      startDate: schedule.startDate || new Date().toISOString(),
+      timeZone: schedule.timeZone || 'UTC',
+    },
+    dashboards: [
+      {
+        ...existingDashboard,
+        dashboard: params.dashboardUid ? { uid: params.dashboardUid } : existingDashboard.dashboard,
+        timeRange: params.timeFrom || params.timeTo
+          ? { from: params.timeFrom, to: params.timeTo }
+          : existingDashboard.timeRange,
+        reportVariables: params.variables ?? existingDashboard.reportVariables,
+      },
+    ],
+  };
+}
+
+// Helper function to format a report
+function formatReport(report: any) {
+  return {
+    id: report.id,
+    name: report.name,
+    dashboards: (report.dashboards || []).map((d: any) => d.dashboard?.uid),
+    recipients: report.recipients ? String(report.recipients).split(',') : [],
+    formats: report.formats,
+    frequency: report.schedule?.frequency,
+    nextSend: report.schedule?.nextDate,
+    timeZone: report.schedule?.timeZone,
+    state: report.state,
+  };
+}
+
+// Tool definitions
+export const listReports: ToolDefinition = {
+  name: 'list_reports',
+  description: 'List report schedules (Grafana Enterprise/Cloud) with their dashboards, recipients, and frequency',
+  inputSchema: ListReportsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      let reports = await client.listReports();
+      
+      if (params.dashboardUid) {
+        reports = reports.filter(report =>
+          (report.dashboards || []).some((d: any) => d.dashboard?.uid === params.dashboardUid)
+        );
+      }
+      
+      return createToolResult(reports.map(formatReport));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const createReport: ToolDefinition = {
+  name: 'create_report',
+  description: 'Create a scheduled PDF/CSV/image report for a dashboard (Grafana Enterprise/Cloud)',
+  inputSchema: CreateReportSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const result = await client.createReport(buildReport(params));
+      
+      return createToolResult({
+        id: result.id,
+        message: 'Report created successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const updateReport: ToolDefinition = {
+  name: 'update_report',
+  description: 'Update a report schedule. Only the provided fields are changed (Grafana Enterprise/Cloud)',
+  inputSchema: UpdateReportSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const existing = await client.getReport(params.id);
+      await client.updateReport(params.id, buildReport(params, existing));
+      
+      return createToolResult({
+        id: params.id,
+        message: 'Report updated successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const sendReport: ToolDefinition = {
+  name: 'send_report',
+  description: 'Send a report immediately, to its recipients or to the given addresses (Grafana Enterprise/Cloud)',
+  inputSchema: SendReportSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      await client.sendReport({
+        id: String(params.id),
+        emails: params.emails?.join(','),
+        useEmailsFromReport: !params.emails,
+      });
+      
+      return createToolResult({
+        id: params.id,
+        message: params.emails
+          ? `Report sent to ${params.emails.join(', ')}`
+          : 'Report sent to its recipients',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerReportingTools(server: any) {
+  server.registerTool(listReports);
+  server.registerTool(createReport);
+  server.registerTool(updateReport);
+  server.registerTool(sendReport);
+}
@@ -0,0 +1,183 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+import { PrometheusClient } from '../clients/prometheus-client';
+import { CheckOutcome, ScheduledCheck, checkScheduler } from '../server/scheduler';
+import { formatTime } from '../utils/time';
+
+// Schema definitions
+const ScheduleCheckSchema = z.object({
+  name: z.string().optional().describe('A short description of the check, included in its notifications'),
+  kind: z.enum(['prometheus', 'alerts']).describe('prometheus: compare a PromQL instant query with a threshold; alerts: watch for firing Grafana alerts'),
+  datasourceUid: z.string().optional().describe('The UID of the Prometheus datasource (prometheus checks)'),
+  expr: z.string().optional().describe('The PromQL expression to evaluate (prometheus checks)'),
+  operator: z.enum(['>', '>=', '<', '<=', '==', '!=']).optional().describe('How series values are compared with the threshold (default: >)'),
+  threshold: z.number().optional().describe('The threshold a series value crosses to breach the check (prometheus checks)'),
+  ruleUid: z.string().optional().describe('Only watch alerts of this alert rule (alerts checks)'),
+  matchers: z.array(z.string()).optional().describe('Label matchers alerts must match, e.g. [\'service="checkout"\'] (alerts checks)'),
+  intervalSeconds: z.number().optional().describe('Seconds between runs (default: 60, min: 10)'),
+  durationMinutes: z.number().optional().describe('Minutes until the check expires (default: 60, max: 1440)'),
+});
+
+const ListScheduledChecksSchema = z.object({});
+
+const CancelScheduledCheckSchema = z.object({
+  id: z.string().describe('The ID of the scheduled check'),
+});
+
+// Helper to compare a value with a threshold
+function crosses(value: number, operator: string, threshold: number): boolean {
+  switch (operator) {
+    case '>=':
+      return value >= threshold;
+    case '<':
+      return value < threshold;
+    case '<=':
+      return value <= threshold;
+    case '==':
+      return value === threshold;
+    case '!=':
+      return value !== threshold;
+    default:
+      return value > threshold;
+  }
+}
+
+// Helper to build the run function of a check from its parameters
+function checkRunner(params: any, context: ToolContext): () => Promise<CheckOutcome> {
+  const config = context.config.grafanaConfig;
+  if (params.kind === 'prometheus') {
+    if (!params.datasourceUid || !params.expr || params.threshold === undefined) {
+      throw new Error('Prometheus checks require datasourceUid, expr, and threshold');
+    }
+    const operator = params.operator || '>';
+    return async () => {
+      const series = await new PrometheusClient(config, params.datasourceUid).query(params.expr);
+      const breaching = series
+        .map(item => ({ labels: item.metric, value: parseFloat(item.value?.[1] ?? 'NaN') }))
+        .filter(item => !isNaN(item.value) && crosses(item.value, operator, params.threshold));
+      return {
+        breached: breaching.length > 0,
+        details: { series: series.length, breaching: breaching.slice(0, 20) },
+      };
+    };
+  }
+
+  const filter = [...(params.matchers || [])];
+  if (params.ruleUid) {
+    filter.push(`__alert_rule_uid__="${params.ruleUid}"`);
+  }
+  return async () => {
+    const alerts = await new GrafanaClient(config).listAlertmanagerAlerts({
+      filter,
+      active: true,
+      silenced: false,
+      inhibited: false,
+    });
+    return {
+      breached: alerts.length > 0,
+      details: {
+        firing: alerts.length,
+        alerts: alerts.slice(0, 20).map((alert: any) => ({
+          labels: alert.labels,
+          startsAt: alert.startsAt,
+          summary: alert.annotations?.summary,
+        })),
+      },
+    };
+  };
+}
+
+// Helper to format a scheduled check for clients
+function formatCheck(check: ScheduledCheck, timezone?: string) {
+  return {
+    id: check.id,
+    description: check.description,
+    intervalSeconds: check.intervalMs / 1000,
+    expiresAt: formatTime(check.expiresAt, timezone),
+    runs: check.runs,
+    lastRunAt: check.lastRunAt && formatTime(check.lastRunAt, timezone),
+    breached: check.breached,
+    lastOutcome: check.lastOutcome?.details,
+    lastError: check.lastError,
+  };
+}
+
+// Tool definitions
+export const scheduleCheck: ToolDefinition = {
+  name: 'schedule_check',
+  description: 'Schedules a check that runs periodically in the background, e.g. to monitor a deploy: a Prometheus query compared with a threshold, or firing Grafana alerts. The client is sent a logging notification whenever the check becomes breached or recovers. Checks run until they expire, are cancelled, or the session ends',
+  inputSchema: ScheduleCheckSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      if (context.config.stateless) {
+        return createErrorResult(
+          'Scheduled checks need a session and are unavailable in stateless mode'
+        );
+      }
+      const run = checkRunner(params, context);
+      const description = params.name ||
+        (params.kind === 'prometheus'
+          ? `${params.expr} ${params.operator || '>'} ${params.threshold}`
+          : `firing alerts${params.ruleUid ? ` of rule ${params.ruleUid}` : ''}`);
+      const session = context.session;
+
+      const check = await checkScheduler.schedule(session.id, {
+        description,
+        intervalMs: Math.max(params.intervalSeconds || 60, 10) * 1000,
+        durationMs: Math.min(params.durationMinutes || 60, 1440) * 60 * 1000,
+        run,
+        onChange: (check, outcome, error) => {
+          const data = error
+            ? { check: check.id, description, error: error.message }
+            : { check: check.id, description, ...outcome };
+          const level = error || outcome?.breached ? 'warning' : 'info';
+          session.notify(data, level, 'schedule_check').catch(error => {
+            context.logger.debug({ error: error.message }, 'Failed to notify check outcome');
+          });
+        },
+      });
+      session.onClose(() => checkScheduler.cancelSession(session.id));
+
+      return createToolResult(formatCheck(check, context.timezone));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const listScheduledChecks: ToolDefinition = {
+  name: 'list_scheduled_checks',
+  description: 'Lists the checks scheduled in this session with their latest outcome',
+  inputSchema: ListScheduledChecksSchema,
+  handler: async (_params, context: ToolContext) => {
+    try {
+      const checks = checkScheduler.list(context.session.id);
+      return createToolResult(checks.map(check => formatCheck(check, context.timezone)));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const cancelScheduledCheck: ToolDefinition = {
+  name: 'cancel_scheduled_check',
+  description: 'Cancels a check scheduled in this session',
+  inputSchema: CancelScheduledCheckSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      if (!checkScheduler.cancel(context.session.id, params.id)) {
+        return createErrorResult(`No scheduled check with ID "${params.id}" in this session`);
+      }
+      return createToolResult({ cancelled: params.id });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerSchedulerTools(server: any) {
+  server.registerTool(scheduleCheck);
+  server.registerTool(listScheduledChecks);
+  server.registerTool(cancelScheduledCheck);
+}
@@ -1,11 +1,82 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
 import { GrafanaClient } from '../clients/grafana-client';
+import { projectFields } from '../utils/fields';
+import { isDatasourceAllowed } from '../server/datasource-policy';
+import { IndexedQuery, dashboardIndexes } from '../server/dashboard-index';
 
 const SearchDashboardsSchema = z.object({
   query: z.string().describe('The query to search for'),
+  fields: z.array(z.string()).optional().describe('Only return these fields, as dotted paths (e.g. ["uid", "title"]); default: all fields'),
 });
 
+const SearchSchema = z.object({
+  query: z.string().describe('The text to search for in titles and names'),
+  types: z.array(z.enum(['dashboard', 'folder', 'alert_rule', 'datasource'])).optional().describe('Only return these result types (default: all)'),
+  limit: z.number().optional().describe('Maximum number of results per type (default: 20)'),
+});
+
+const SearchPanelQueriesSchema = z.object({
+  query: z.string().describe('The text to find in panel queries, e.g. a metric name like http_requests_total or a table name'),
+  regex: z.boolean().optional().describe('Treat query as a case-insensitive regular expression instead of text, up to 200 characters and without repeated groups containing quantifiers, e.g. (a+)+ (default: false)'),
+  datasourceUid: z.string().optional().describe('Only match queries of this datasource'),
+  limit: z.number().optional().describe('Maximum number of dashboards (default: 20)'),
+  refresh: z.boolean().optional().describe('Rebuild the index of dashboards first, e.g. after dashboards were just changed (default: false; the index is rebuilt when older than 10 minutes)'),
+});
+
+// Longer query texts are shortened to this many characters around the first match
+const QUERY_SNIPPET_SIZE = 300;
+
+// Regular expressions run over every indexed query, so their size is limited
+const MAX_PATTERN_LENGTH = 200;
+
+// Helper to refuse regular expressions that can backtrack catastrophically and block the
+// process: repeated groups that contain a quantifier or an alternation, e.g. (a+)+ or (a|ab)*,
+// and backreferences
+function checkPatternSafety(pattern: string): void {
+  if (pattern.length > MAX_PATTERN_LENGTH) {
+    throw new Error(`Regular expressions are limited to ${MAX_PATTERN_LENGTH} characters`);
+  }
+  // Whether each open group contains a quantifier or alternation so far
+  const groups: boolean[] = [];
+  const markGroups = () => groups.fill(true);
+  for (let i = 0; i < pattern.length; i++) {
+    const char = pattern[i];
+    if (char === '\\') {
+      if (/[1-9k]/.test(pattern[i + 1] || '')) {
+        throw new Error('Backreferences are not supported in regular expressions');
+      }
+      i++;
+    } else if (char === '[') {
+      // Skips the character class, whose characters are not operators
+      for (i++; i < pattern.length && pattern[i] !== ']'; i++) {
+        if (pattern[i] === '\\') i++;
+      }
+    } else if (char === '(') {
+      groups.push(false);
+    } else if (char === ')') {
+      const risky = groups.pop();
+      if (risky && /^[*+{]/.test(pattern.slice(i + 1))) {
+        throw new Error(
+          'Repeated groups containing quantifiers or alternatives, such as (a+)+, are not ' +
+            'supported in regular expressions'
+        );
+      }
+    } else if (char === '*' || char === '+' || char === '{' || char === '|') {
+      markGroups();
+    }
+  }
+}
+
+// Helper to shorten a query text around a match
+function querySnippet(text: string, index: number, length: number): string {
+  if (text.length <= QUERY_SNIPPET_SIZE) return text;
+  const centered = Math.floor(index - (QUERY_SNIPPET_SIZE - length) / 2);
+  const start = Math.max(0, Math.min(centered, text.length - QUERY_SNIPPET_SIZE));
+  const end = start + QUERY_SNIPPET_SIZE;
+  return `${start > 0 ? '...' : ''}${text.slice(start, end)}${end < text.length ? '...' : ''}`;
+}
+
 export const searchDashboards: ToolDefinition = {
   name: 'search_dashboards',
   description: 'Search for Grafana dashboards by a query string. Returns a list of matching dashboards with details like title, UID, folder, tags, and URL.',
@@ -25,7 +96,145 @@ export const searchDashboards: ToolDefinition = {
         type: dashboard.type,
       }));
       
-      return createToolResult(formatted);
+      return createToolResult(projectFields(formatted, params.fields));
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const search: ToolDefinition = {
+  name: 'search',
+  description: 'Search across dashboards, folders, alert rules, and datasources by name. Each result has a type and the identifier to pass to follow-up tools (e.g. get_dashboard_by_uid, get_alert_rule_by_uid, get_datasource_by_uid)',
+  inputSchema: SearchSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const types = new Set(params.types || ['dashboard', 'folder', 'alert_rule', 'datasource']);
+      const limit = params.limit || 20;
+      const needle = params.query.toLowerCase();
+      const results: any[] = [];
+      
+      if (types.has('dashboard') || types.has('folder')) {
+        const searchTypes = [];
+        if (types.has('dashboard')) searchTypes.push('dash-db');
+        if (types.has('folder')) searchTypes.push('dash-folder');
+        
+        const hits = await client.search({
+          query: params.query,
+          type: searchTypes.length === 1 ? searchTypes[0] : undefined,
+          limit: limit * 2,
+        });
+        const counts: Record<string, number> = {};
+        for (const hit of hits) {
+          const type = hit.type === 'dash-folder' ? 'folder' : 'dashboard';
+          if (!types.has(type) || (counts[type] || 0) >= limit) continue;
+          counts[type] = (counts[type] || 0) + 1;
+          results.push({
+            type,
+            uid: hit.uid,
+            title: hit.title,
+            folder: hit.folderTitle,
+            tags: hit.tags?.length ? hit.tags : undefined,
+            url: hit.url,
+          });
+        }
+      }
+      
+      if (types.has('alert_rule')) {
+        const rules = await client.listAlertRules();
+        results.push(...rules
+          .filter(rule => rule.title.toLowerCase().includes(needle))
+          .slice(0, limit)
+          .map(rule => ({
+            type: 'alert_rule',
+            uid: rule.uid,
+            title: rule.title,
+            folderUid: rule.folderUID,
+            ruleGroup: rule.ruleGroup,
+          })));
+      }
+      
+      if (types.has('datasource')) {
+        const policy = context.config.grafanaConfig.datasourcePolicy;
+        const datasources = await client.listDatasources();
+        results.push(...datasources
+          .filter(ds => isDatasourceAllowed(policy, ds))
+          .filter(ds => ds.name.toLowerCase().includes(needle) || ds.type.toLowerCase().includes(needle))
+          .slice(0, limit)
+          .map(ds => ({
+            type: 'datasource',
+            uid: ds.uid,
+            title: ds.name,
+            datasourceType: ds.type,
+            isDefault: ds.isDefault || undefined,
+          })));
+      }
+      
+      return createToolResult(results);
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export const searchPanelQueries: ToolDefinition = {
+  name: 'search_panel_queries',
+  description: 'Search dashboards by the contents of their panel queries, answering "which dashboards query http_requests_total?". Matches PromQL, LogQL, SQL, and other query texts of every panel, and returns each matching dashboard with its matching panels and queries. Uses an index of all dashboards, built on first use and rebuilt every 10 minutes',
+  inputSchema: SearchPanelQueriesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      let pattern: RegExp;
+      try {
+        if (params.regex) checkPatternSafety(params.query);
+        pattern = params.regex
+          ? new RegExp(params.query, 'i')
+          : new RegExp(params.query.replace(/[\\^$*+?.()|[\]{}]/g, '\\$&'), 'i');
+      } catch (error: any) {
+        return createErrorResult(`Invalid regular expression: ${error.message}`);
+      }
+      const limit = params.limit || 20;
+
+      const index = await dashboardIndexes.get(context.config.grafanaConfig, {
+        refresh: params.refresh,
+        onProgress: (done, total) => {
+          context.sendProgress(done, total, 'Indexing dashboards').catch(() => undefined);
+        },
+      });
+
+      const matchQuery = (query: IndexedQuery) => {
+        if (params.datasourceUid && query.datasource !== params.datasourceUid) return undefined;
+        const match = pattern.exec(query.text);
+        return match && {
+          refId: query.refId,
+          datasource: query.datasource,
+          query: querySnippet(query.text, match.index, match[0].length),
+        };
+      };
+      const matches = index.dashboards
+        .map(dashboard => ({
+          uid: dashboard.uid,
+          title: dashboard.title,
+          folder: dashboard.folder,
+          url: dashboard.url,
+          panels: dashboard.panels
+            .map(panel => ({
+              id: panel.id,
+              title: panel.title,
+              type: panel.type,
+              queries: panel.queries.map(matchQuery).filter(query => !!query),
+            }))
+            .filter(panel => panel.queries.length > 0),
+        }))
+        .filter(dashboard => dashboard.panels.length > 0);
+
+      return createToolResult({
+        indexedDashboards: index.dashboards.length,
+        indexAgeSeconds: Math.round((Date.now() - index.builtAt) / 1000),
+        failedDashboards: index.failed || undefined,
+        totalMatches: matches.length,
+        dashboards: matches.slice(0, limit),
+      });
     } catch (error: any) {
       return createErrorResult(error.message);
     }
@@ -34,4 +243,6 @@ export const searchDashboards: ToolDefinition = {
 
 export function registerSearchTools(server: any) {
   server.registerTool(searchDashboards);
+  server.registerTool(search);
+  server.registerTool(searchPanelQueries);
 }
\ No newline at end of file
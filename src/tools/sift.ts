@@ -1,6 +1,7 @@
 import { z } from 'zod';
 import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
-import axios from 'axios';
+import { createPluginClient } from '../clients/base-client';
+import { parseTimeRange } from '../utils/time';
 
 // Schema definitions
 const ListSiftInvestigationsSchema = z.object({
@@ -19,41 +20,68 @@ const GetSiftAnalysisSchema = z.object({
 const FindSlowRequestsSchema = z.object({
   name: z.string().describe('The name of the investigation'),
   labels: z.record(z.string()).describe('Labels to scope the analysis'),
-  start: z.string().optional().describe('Start time for the investigation'),
-  end: z.string().optional().describe('End time for the investigation'),
+  start: z.string().optional().describe('Start time for the investigation (RFC3339, unix epoch, or relative like "now-1h"; default: 30 minutes ago)'),
+  end: z.string().optional().describe('End time for the investigation (default: now)'),
 });
 
 const FindErrorPatternLogsSchema = z.object({
   name: z.string().describe('The name of the investigation'),
   labels: z.record(z.string()).describe('Labels to scope the analysis'),
-  start: z.string().optional().describe('Start time for the investigation'),
-  end: z.string().optional().describe('End time for the investigation'),
+  start: z.string().optional().describe('Start time for the investigation (RFC3339, unix epoch, or relative like "now-1h"; default: 30 minutes ago)'),
+  end: z.string().optional().describe('End time for the investigation (default: now)'),
+});
+
+const CreateSiftInvestigationSchema = z.object({
+  name: z.string().describe('The name of the investigation'),
+  labels: z.record(z.string()).describe('Labels to scope the investigation (e.g. cluster, namespace, service)'),
+  start: z.string().optional().describe('Start time (RFC3339, unix epoch, or relative like "now-1h"; default: 30 minutes ago)'),
+  end: z.string().optional().describe('End time (default: now)'),
+  wait: z.boolean().optional().describe('Whether to poll until all checks complete (default: true)'),
+  timeoutSeconds: z.number().optional().describe('Maximum time to wait for the checks to complete (default: 300)'),
 });
 
 // Helper function to create Sift client
 function createSiftClient(config: any) {
-  const headers: any = {
-    'User-Agent': 'mcp-grafana/1.0.0',
-    'Content-Type': 'application/json',
-  };
-  
-  if (config.serviceAccountToken) {
-    headers['Authorization'] = `Bearer ${config.serviceAccountToken}`;
-  } else if (config.apiKey) {
-    headers['Authorization'] = `Bearer ${config.apiKey}`;
-  }
-  
   // Sift uses a different base URL pattern
   const baseUrl = config.url.replace(/\/$/, '');
   const siftUrl = baseUrl.includes('grafana.net') 
     ? baseUrl.replace('grafana.net', 'sift.grafana.net')
     : `${baseUrl}/api/plugins/grafana-sift-app/resources`;
   
-  return axios.create({
-    baseURL: siftUrl,
-    headers,
-    timeout: 60000, // Longer timeout for investigations
-  });
+  return createPluginClient(config, siftUrl, 60000); // Longer timeout for investigations
+}
+
+// Investigation states after which no more analyses will run
+const TERMINAL_INVESTIGATION_STATES = ['finished', 'failed', 'cancelled'];
+
+// Helper function to poll an investigation until all of its analyses have finished
+async function pollInvestigation(
+  client: any,
+  investigationId: string,
+  timeoutMs: number,
+  context: ToolContext
+): Promise<any> {
+  const deadline = Date.now() + timeoutMs;
+  let investigation: any;
+  
+  for (;;) {
+    const response = await client.get(`/api/v1/investigations/${investigationId}`);
+    investigation = response.data;
+    
+    const analyses = investigation.analyses || [];
+    const done = analyses.filter((a: any) => TERMINAL_INVESTIGATION_STATES.includes(a.status));
+    await context.sendProgress(
+      done.length,
+      analyses.length || undefined,
+      `${done.length}/${analyses.length} checks complete`
+    );
+    
+    if (TERMINAL_INVESTIGATION_STATES.includes(investigation.status)) break;
+    if (Date.now() >= deadline || context.signal?.aborted) break;
+    await new Promise(resolve => setTimeout(resolve, 2000));
+  }
+  
+  return investigation;
 }
 
 // Tool definitions
@@ -131,12 +159,16 @@ export const findSlowRequests: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = createSiftClient(context.config.grafanaConfig);
+      const timeRange = parseTimeRange(params.start, params.end, {
+        timezone: context.timezone,
+        defaultStart: 'now-30m',
+      });
       
       // Create investigation
       const investigationData = {
         name: params.name,
-        start: params.start || new Date(Date.now() - 30 * 60 * 1000).toISOString(),
-        end: params.end || new Date().toISOString(),
+        start: timeRange.start.toISOString(),
+        end: timeRange.end.toISOString(),
         labels: params.labels,
         analyses: [
           {
@@ -175,12 +207,16 @@ export const findErrorPatternLogs: ToolDefinition = {
   handler: async (params, context: ToolContext) => {
     try {
       const client = createSiftClient(context.config.grafanaConfig);
+      const timeRange = parseTimeRange(params.start, params.end, {
+        timezone: context.timezone,
+        defaultStart: 'now-30m',
+      });
       
       // Create investigation
       const investigationData = {
         name: params.name,
-        start: params.start || new Date(Date.now() - 30 * 60 * 1000).toISOString(),
-        end: params.end || new Date().toISOString(),
+        start: timeRange.start.toISOString(),
+        end: timeRange.end.toISOString(),
         labels: params.labels,
         analyses: [
           {
@@ -212,10 +248,69 @@ export const findErrorPatternLogs: ToolDefinition = {
   },
 };
 
+export const createSiftInvestigation: ToolDefinition = {
+  name: 'create_sift_investigation',
+  description: 'Starts a new Sift investigation for the given labels and time range, running all applicable checks, and polls until the checks complete while reporting progress',
+  inputSchema: CreateSiftInvestigationSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createSiftClient(context.config.grafanaConfig);
+      const timeRange = parseTimeRange(params.start, params.end, {
+        timezone: context.timezone,
+        defaultStart: 'now-30m',
+      });
+      
+      const response = await client.post('/api/v1/investigations', {
+        name: params.name,
+        start: timeRange.start.toISOString(),
+        end: timeRange.end.toISOString(),
+        labels: params.labels,
+      });
+      const investigationId = response.data.id;
+      
+      if (params.wait === false) {
+        return createToolResult({
+          investigationId,
+          status: response.data.status,
+          message: 'Investigation started. Use get_sift_investigation to check results.',
+        });
+      }
+      
+      const investigation = await pollInvestigation(
+        client,
+        investigationId,
+        (params.timeoutSeconds || 300) * 1000,
+        context
+      );
+      const complete = TERMINAL_INVESTIGATION_STATES.includes(investigation.status);
+      
+      return createToolResult({
+        investigationId,
+        status: investigation.status,
+        complete,
+        analyses: (investigation.analyses || []).map((a: any) => ({
+          id: a.id,
+          name: a.name,
+          status: a.status,
+          interesting: a.result?.interesting,
+          message: a.result?.message,
+        })),
+        message: complete
+          ? 'Investigation complete. Use get_sift_analysis for details of interesting checks.'
+          : 'Timed out waiting for checks. Use get_sift_investigation to check results later.',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
 export function registerSiftTools(server: any) {
   server.registerTool(listSiftInvestigations);
   server.registerTool(getSiftInvestigation);
   server.registerTool(getSiftAnalysis);
   server.registerTool(findSlowRequests);
   server.registerTool(findErrorPatternLogs);
+  server.registerTool(createSiftInvestigation);
 }
\ No newline at end of file
@@ -0,0 +1,366 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { createPluginClient } from '../clients/base-client';
+import { PrometheusClient } from '../clients/prometheus-client';
+import { checkDatasourceAccess } from '../server/datasource-policy';
+import { formatTime, parseTime, toUnixSeconds } from '../utils/time';
+
+// Schema definitions
+const ListSlosSchema = z.object({
+  query: z.string().optional().describe('Filter SLOs whose name or description contains this string'),
+  labels: z.record(z.string()).optional().describe('Only include SLOs with these labels'),
+});
+
+const GetSloSchema = z.object({
+  uuid: z.string().describe('The UUID of the SLO'),
+});
+
+const GetSloStatusSchema = z.object({
+  uuid: z.string().describe('The UUID of the SLO'),
+});
+
+const SloDefinitionSchema = z.object({
+  name: z.string().describe('The name of the SLO'),
+  description: z.string().optional().describe('A description of the SLO'),
+  query: z.object({
+    type: z.enum(['ratio', 'freeform']).describe('The type of SLI query'),
+    ratio: z.object({
+      successMetric: z.string().describe('The metric counting successful events, e.g. http_requests_total{code!~"5.."}'),
+      totalMetric: z.string().describe('The metric counting all events, e.g. http_requests_total'),
+      groupByLabels: z.array(z.string()).optional().describe('Labels to group the SLI by'),
+    }).optional().describe('The ratio query (for type "ratio")'),
+    freeform: z.object({
+      query: z.string().describe('A PromQL expression returning the SLI as a ratio between 0 and 1'),
+    }).optional().describe('The freeform query (for type "freeform")'),
+  }).describe('The SLI query'),
+  objectives: z.array(z.object({
+    value: z.number().describe('The target, e.g. 0.995 for 99.5%'),
+    window: z.string().describe('The rolling window, e.g. "28d"'),
+  })).describe('The objectives of the SLO'),
+  destinationDatasourceUid: z.string().describe('The UID of the Prometheus/Mimir datasource where SLO recording rules are written'),
+  labels: z.array(z.object({
+    key: z.string(),
+    value: z.string(),
+  })).optional().describe('Labels to attach to the SLO'),
+  folderUid: z.string().optional().describe('The UID of the folder for the generated dashboards and alerts'),
+});
+
+const CreateSloSchema = SloDefinitionSchema;
+
+const UpdateSloSchema = SloDefinitionSchema.extend({
+  uuid: z.string().describe('The UUID of the SLO to update'),
+});
+
+const CalculateBurnRatesSchema = z.object({
+  uuid: z.string().optional().describe('The UUID of a Grafana SLO; its SLI, first objective, and destination datasource are used'),
+  datasourceUid: z.string().optional().describe('The UID of the Prometheus datasource to evaluate sliExpr on'),
+  sliExpr: z.string().optional().describe('PromQL returning the SLI (the ratio of good events, between 0 and 1) over $window, e.g. \'sum(rate(http_requests_total{code!~"5.."}[$window])) / sum(rate(http_requests_total[$window]))\''),
+  objective: z.number().optional().describe('The target for sliExpr, e.g. 0.999 for 99.9%'),
+  sloWindow: z.string().regex(/^\d+[mhdw]$/, 'Use a duration such as 28d').optional().describe('The rolling window of the objective for sliExpr (default: 30d)'),
+  time: z.string().optional().describe('The evaluation time (RFC3339, unix epoch, or relative like "now-1h"; default: now)'),
+});
+
+// Multiwindow, multi-burn-rate alerts of the Google SRE workbook. Each fires when the share of
+// the error budget the long window would consume is exceeded in both windows; the burn rate
+// thresholds follow from the SLO window, e.g. 14.4 for the fast page of a 30 day SLO.
+const BURN_RATE_ALERTS = [
+  { name: 'fast_page', severity: 'page', long: '1h', short: '5m', budget: 0.02 },
+  { name: 'slow_page', severity: 'page', long: '6h', short: '30m', budget: 0.05 },
+  { name: 'fast_ticket', severity: 'ticket', long: '1d', short: '2h', budget: 0.1 },
+  { name: 'slow_ticket', severity: 'ticket', long: '3d', short: '6h', budget: 0.1 },
+];
+
+// Helper to convert a duration such as 30d to milliseconds
+function durationMs(duration: string, now: Date): number {
+  return now.getTime() - parseTime(`now-${duration}`, { now }).getTime();
+}
+
+// Helper function to create SLO client
+function createSloClient(config: any) {
+  return createPluginClient(config, `${config.url}/api/plugins/grafana-slo-app/resources/v1`);
+}
+
+// Helper function to convert a tool input into the SLO API format
+function toSloBody(params: any) {
+  const body: any = {
+    name: params.name,
+    description: params.description || '',
+    query: params.query,
+    objectives: params.objectives,
+    destinationDatasource: { uid: params.destinationDatasourceUid },
+    labels: params.labels || [],
+  };
+  // The API takes the metrics of a ratio query as MetricDef objects
+  if (params.query?.ratio) {
+    const { successMetric, totalMetric, ...ratio } = params.query.ratio;
+    body.query = {
+      ...params.query,
+      ratio: {
+        ...ratio,
+        successMetric: { prometheusMetric: successMetric },
+        totalMetric: { prometheusMetric: totalMetric },
+      },
+    };
+  }
+  if (params.folderUid) body.folder = { uid: params.folderUid };
+  return body;
+}
+
+// Helper function to format an SLO
+function formatSlo(slo: any) {
+  return {
+    uuid: slo.uuid,
+    name: slo.name,
+    description: slo.description,
+    queryType: slo.query?.type,
+    objectives: slo.objectives,
+    labels: slo.labels || [],
+    destinationDatasourceUid: slo.destinationDatasource?.uid,
+    status: slo.readOnly?.status?.type,
+  };
+}
+
+// Tool definitions
+export const listSlos: ToolDefinition = {
+  name: 'list_slos',
+  description: 'List Grafana SLOs with their objectives and labels, optionally filtered by name or labels',
+  inputSchema: ListSlosSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createSloClient(context.config.grafanaConfig);
+      const response = await client.get('/slo');
+      
+      let slos = response.data.slos || [];
+      
+      if (params.query) {
+        const query = params.query.toLowerCase();
+        slos = slos.filter((slo: any) =>
+          slo.name?.toLowerCase().includes(query) ||
+          slo.description?.toLowerCase().includes(query)
+        );
+      }
+      
+      if (params.labels) {
+        slos = slos.filter((slo: any) =>
+          Object.entries(params.labels).every(([key, value]) =>
+            (slo.labels || []).some((l: any) => l.key === key && l.value === value)
+          )
+        );
+      }
+      
+      return createToolResult(slos.map(formatSlo));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const getSlo: ToolDefinition = {
+  name: 'get_slo',
+  description: 'Get the full definition of a Grafana SLO by its UUID',
+  inputSchema: GetSloSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createSloClient(context.config.grafanaConfig);
+      const response = await client.get(`/slo/${params.uuid}`);
+      return createToolResult(response.data);
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const getSloStatus: ToolDefinition = {
+  name: 'get_slo_status',
+  description: 'Get the current SLI value and remaining error budget of a Grafana SLO over each of its objective windows',
+  inputSchema: GetSloStatusSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createSloClient(context.config.grafanaConfig);
+      const response = await client.get(`/slo/${params.uuid}`);
+      const slo = response.data;
+      
+      const datasourceUid = slo.destinationDatasource?.uid;
+      if (!datasourceUid) {
+        return createErrorResult('SLO has no destination datasource');
+      }
+      await checkDatasourceAccess(context.config.grafanaConfig, [datasourceUid]);
+      const prometheus = new PrometheusClient(context.config.grafanaConfig, datasourceUid);
+      
+      const objectives = [];
+      for (const objective of slo.objectives || []) {
+        const result = await prometheus.query(
+          `avg(avg_over_time(grafana_slo_sli_window{grafana_slo_uuid="${slo.uuid}"}[${objective.window}]))`
+        );
+        const sli = result.length > 0 && result[0].value ? parseFloat(result[0].value[1]) : null;
+        const allowedErrorRate = 1 - objective.value;
+        const errorBudgetRemaining = sli !== null && allowedErrorRate > 0
+          ? 1 - (1 - sli) / allowedErrorRate
+          : null;
+        
+        objectives.push({
+          target: objective.value,
+          window: objective.window,
+          sli,
+          errorBudgetRemaining,
+          met: sli !== null ? sli >= objective.value : null,
+        });
+      }
+      
+      return createToolResult({
+        uuid: slo.uuid,
+        name: slo.name,
+        objectives,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const createSlo: ToolDefinition = {
+  name: 'create_slo',
+  description: 'Create a Grafana SLO from a ratio or freeform PromQL SLI query and one or more objectives',
+  inputSchema: CreateSloSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createSloClient(context.config.grafanaConfig);
+      const response = await client.post('/slo', toSloBody(params));
+      
+      return createToolResult({
+        uuid: response.data.uuid,
+        message: response.data.message || 'SLO created successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const updateSlo: ToolDefinition = {
+  name: 'update_slo',
+  description: 'Replace the definition of an existing Grafana SLO',
+  inputSchema: UpdateSloSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const client = createSloClient(context.config.grafanaConfig);
+      await client.put(`/slo/${params.uuid}`, { uuid: params.uuid, ...toSloBody(params) });
+      
+      return createToolResult({
+        uuid: params.uuid,
+        message: 'SLO updated successfully',
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const calculateBurnRates: ToolDefinition = {
+  name: 'calculate_slo_burn_rates',
+  description: 'Computes the error budget burn rates of an SLO over multiple windows (5m to 3d) and the budget remaining over the SLO window, and tells which multiwindow burn rate alerts from the Google SRE workbook would fire: fast and slow page alerts (2% of the budget in 1h, 5% in 6h) and ticket alerts (10% in 1d or 3d). Give a Grafana SLO UUID, or a Prometheus datasource with an SLI expression and objective',
+  inputSchema: CalculateBurnRatesSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      let datasourceUid: string;
+      let sliExpr: string;
+      let objective: number;
+      let sloWindow: string;
+      let name: string | undefined;
+      if (params.uuid) {
+        const response = await createSloClient(context.config.grafanaConfig).get(
+          `/slo/${params.uuid}`
+        );
+        const slo = response.data;
+        const first = slo.objectives?.[0];
+        if (!slo.destinationDatasource?.uid || !first) {
+          return createErrorResult('SLO has no destination datasource or objective');
+        }
+        datasourceUid = slo.destinationDatasource.uid;
+        await checkDatasourceAccess(context.config.grafanaConfig, [datasourceUid]);
+        // grafana_slo_sli_window already covers the whole SLO window, so each window is
+        // computed from the SLO's 5m success and total rates instead
+        const selector = `{grafana_slo_uuid="${slo.uuid}"}`;
+        sliExpr =
+          `sum(sum_over_time(grafana_slo_success_rate_5m${selector}[$window])) / ` +
+          `sum(sum_over_time(grafana_slo_total_rate_5m${selector}[$window]))`;
+        objective = first.value;
+        sloWindow = first.window;
+        name = slo.name;
+      } else {
+        if (!params.datasourceUid || !params.sliExpr || params.objective === undefined) {
+          return createErrorResult('Give an SLO uuid, or datasourceUid, sliExpr, and objective');
+        }
+        datasourceUid = params.datasourceUid;
+        sliExpr = params.sliExpr;
+        objective = params.objective;
+        sloWindow = params.sloWindow || '30d';
+      }
+      if (objective <= 0 || objective >= 1) {
+        return createErrorResult('The objective must be between 0 and 1, e.g. 0.999');
+      }
+
+      const prometheus = new PrometheusClient(context.config.grafanaConfig, datasourceUid);
+      const time = parseTime(params.time || 'now', { timezone: context.timezone });
+      const at = toUnixSeconds(time).toString();
+      const allowedErrorRatio = 1 - objective;
+      const windows = [
+        ...new Set([...BURN_RATE_ALERTS.flatMap(alert => [alert.short, alert.long]), sloWindow]),
+      ];
+
+      // Burn rate: the error ratio over a window relative to the ratio the objective allows
+      const burnRates: Record<string, number | null> = {};
+      await Promise.all(
+        windows.map(async window => {
+          const result = await prometheus.query(sliExpr.replace(/\$window/g, window), at);
+          const sli = parseFloat(result[0]?.value?.[1] ?? 'NaN');
+          burnRates[window] = Number.isFinite(sli) ? (1 - sli) / allowedErrorRatio : null;
+        })
+      );
+
+      const round = (value: number | null) =>
+        value === null ? null : Math.round(value * 1000) / 1000;
+      const sloWindowMs = durationMs(sloWindow, time);
+      const alerts = BURN_RATE_ALERTS.map(alert => {
+        const threshold = (alert.budget * sloWindowMs) / durationMs(alert.long, time);
+        const long = burnRates[alert.long];
+        const short = burnRates[alert.short];
+        return {
+          name: alert.name,
+          severity: alert.severity,
+          windows: [alert.long, alert.short],
+          threshold: round(threshold),
+          longBurnRate: round(long),
+          shortBurnRate: round(short),
+          firing: long !== null && short !== null && long >= threshold && short >= threshold,
+        };
+      });
+      const budgetBurn = burnRates[sloWindow];
+
+      return createToolResult({
+        uuid: params.uuid,
+        name,
+        time: formatTime(time, context.timezone),
+        objective,
+        sloWindow,
+        errorBudgetRemaining: budgetBurn === null ? null : round(1 - budgetBurn),
+        burnRates: Object.fromEntries(windows.map(window => [window, round(burnRates[window])])),
+        alerts,
+        firing: alerts.filter(alert => alert.firing).map(alert => alert.name),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export function registerSloTools(server: any) {
+  server.registerTool(listSlos);
+  server.registerTool(getSlo);
+  server.registerTool(getSloStatus);
+  server.registerTool(createSlo);
+  server.registerTool(updateSlo);
+  server.registerTool(calculateBurnRates);
+}
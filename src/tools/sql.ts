@@ -0,0 +1,172 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+import { SqlQueryConfig } from '../types/config';
+import { parseTimeRange } from '../utils/time';
+import { Table, renderTables, resultTables } from '../utils/table';
+
+// Schema definitions
+const QuerySqlDatasourceSchema = z.object({
+  datasourceUid: z.string().describe('The UID of the MySQL, PostgreSQL, Microsoft SQL Server, or ClickHouse datasource'),
+  sql: z.string().describe('The SQL query. Grafana macros such as $__timeFilter(column) are expanded with the time range'),
+  startRfc3339: z.string().optional().describe('The start of the time range used by the macros (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end of the time range (default: now)'),
+  limit: z.number().optional().describe('Maximum rows to return (default and maximum: SQL_MAX_ROWS, 1000 unless configured)'),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+const DEFAULT_SQL_QUERY: SqlQueryConfig = {
+  allowWrites: false,
+  maxRows: 1000,
+  maxBytes: 1024 * 1024,
+};
+
+// Statements and clauses that change data, schema, permissions, or server state
+const WRITE_KEYWORDS = [
+  'insert', 'update', 'delete', 'merge', 'upsert', 'replace', 'drop', 'alter', 'create',
+  'truncate', 'rename', 'grant', 'revoke', 'copy', 'call', 'exec', 'execute', 'do', 'lock',
+  'vacuum', 'analyze', 'attach', 'detach', 'optimize', 'kill', 'system', 'set', 'into',
+  'load', 'handler', 'reindex', 'cluster', 'refresh', 'comment', 'prepare', 'deallocate',
+];
+
+const READ_STATEMENTS = [
+  'select', 'with', 'show', 'describe', 'desc', 'explain', 'values', 'table',
+];
+
+// SQL dialects differ in how strings and comments are written: MySQL escapes quotes with
+// backslashes, PostgreSQL has dollar-quoted strings, and "--" needs a following space in MySQL.
+// Queries are checked under each reading, so text hidden from one is seen by another.
+const LITERALS = [
+  /'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.|"")*"|`[^`]*`/g,
+  /'(?:[^']|'')*'|"(?:[^"]|"")*"|`[^`]*`|\$(\w*)\$[\s\S]*?\$\1\$/g,
+];
+const COMMENTS = /--[^\n]*|\/\*[\s\S]*?\*\//g;
+
+// Helper to check that a query is a single read-only statement, without its comments, string
+// literals, and quoted identifiers. This guards against mistakes; a read-only database user is
+// the actual protection.
+export function checkReadOnlySql(sql: string): void {
+  for (const literals of LITERALS) {
+    for (const stripComments of [true, false]) {
+      const text = stripComments ? sql.replace(COMMENTS, ' ') : sql;
+      checkStatement(text.replace(literals, "''").toLowerCase(), stripComments);
+    }
+  }
+}
+
+// Leading comments are only skipped when looking for the first keyword
+function checkStatement(stripped: string, checkFirst: boolean): void {
+  const statements = stripped.split(';').filter(statement => statement.trim() !== '');
+  if (statements.length !== 1) {
+    throw new Error('Only a single SQL statement may be run');
+  }
+  const first = statements[0].trim().match(/^\(*\s*(\w+)/)?.[1];
+  if (checkFirst && (!first || !READ_STATEMENTS.includes(first))) {
+    throw new Error(
+      `Only read-only statements (${READ_STATEMENTS.join(', ').toUpperCase()}) may be run; ` +
+      'set SQL_ALLOW_WRITES=true to allow others'
+    );
+  }
+  // Functions such as REPLACE(...) share their names with statements
+  const words = [...statements[0].matchAll(/\b([a-z_]+)\b(?!\s*\()/g)].map(match => match[1]);
+  const writes = [...new Set(words.filter(word => WRITE_KEYWORDS.includes(word)))];
+  if (writes.length > 0) {
+    throw new Error(
+      `The query contains ${writes.join(', ').toUpperCase()}, which may change data; ` +
+      'set SQL_ALLOW_WRITES=true to allow it'
+    );
+  }
+}
+
+// Helper to build the query model of a SQL datasource for Grafana's query API
+function sqlQueryModel(datasource: { uid: string; type: string }, sql: string, limit: number) {
+  const base = { refId: 'A', datasource, maxDataPoints: limit };
+  if (datasource.type.includes('clickhouse')) {
+    // ClickHouse plugin 4.x: format 1 is a table
+    return { ...base, rawSql: sql, editorType: 'sql', queryType: 'table', format: 1 };
+  }
+  return { ...base, rawSql: sql, rawQuery: true, editorMode: 'code', format: 'table' };
+}
+
+// Helper to cut tables to a number of rows and an approximate size in bytes
+function limitTables(tables: Table[], maxRows: number, maxBytes: number) {
+  let rows = 0;
+  let bytes = 0;
+  let truncated = false;
+  const limited = tables.map(table => {
+    const kept: unknown[][] = [];
+    for (const row of table.rows) {
+      const size = JSON.stringify(row).length;
+      if (rows >= maxRows || bytes + size > maxBytes) {
+        truncated = true;
+        break;
+      }
+      kept.push(row);
+      rows++;
+      bytes += size;
+    }
+    return { ...table, rows: kept };
+  });
+  return { tables: limited, truncated };
+}
+
+// Tool definitions
+export const querySqlDatasource: ToolDefinition = {
+  name: 'query_sql_datasource',
+  description: 'Runs a SQL query against a MySQL, PostgreSQL, Microsoft SQL Server, or ClickHouse datasource through Grafana and returns the result rows. Only single read-only statements (SELECT, WITH, SHOW, DESCRIBE, EXPLAIN) are allowed unless the server permits writes. Results are cut off at a row and size limit; add LIMIT or aggregate in SQL for large tables',
+  inputSchema: QuerySqlDatasourceSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const settings = context.config.grafanaConfig.sqlQuery || DEFAULT_SQL_QUERY;
+      if (!settings.allowWrites) {
+        checkReadOnlySql(params.sql);
+      }
+
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const datasource = await client.getDatasourceByUid(params.datasourceUid);
+      const type = String(datasource.type);
+      if (!/mysql|postgres|mssql|clickhouse/.test(type)) {
+        return createErrorResult(
+          `Datasource ${params.datasourceUid} is a ${type} datasource, not a SQL datasource`
+        );
+      }
+
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+      const limit = Math.min(params.limit || settings.maxRows, settings.maxRows);
+      const result = await client.queryDatasources({
+        queries: [sqlQueryModel({ uid: datasource.uid, type }, params.sql, limit)],
+        from: timeRange.start.getTime().toString(),
+        to: timeRange.end.getTime().toString(),
+      });
+
+      const error = result?.results?.A?.error;
+      if (error) {
+        return createErrorResult(`SQL query failed: ${error}`);
+      }
+
+      const { tables, truncated } = limitTables(
+        resultTables(result, context.timezone),
+        limit,
+        settings.maxBytes
+      );
+      if (params.format && params.format !== 'json') {
+        const note = truncated ? `Truncated to the first ${limit} rows or size limit\n\n` : '';
+        return createToolResult(note + renderTables(tables, params.format));
+      }
+      return createToolResult({
+        datasource: { uid: datasource.uid, type },
+        tables: tables.map(table => ({ columns: table.columns, rows: table.rows })),
+        truncated,
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerSqlTools(server: any) {
+  server.registerTool(querySqlDatasource);
+}
@@ -0,0 +1,241 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { createPluginClient } from '../clients/base-client';
+import { GrafanaClient } from '../clients/grafana-client';
+import { PrometheusClient } from '../clients/prometheus-client';
+import { checkDatasourceAccess } from '../server/datasource-policy';
+
+const SM_DATASOURCE_TYPE = 'synthetic-monitoring-datasource';
+
+// Schema definitions
+const ListSyntheticChecksSchema = z.object({
+  type: z.enum(['http', 'ping', 'dns', 'tcp', 'traceroute', 'multihttp', 'browser', 'scripted', 'grpc'])
+    .optional()
+    .describe('Only include checks of this type'),
+  job: z.string().optional().describe('Only include checks whose job name contains this string'),
+});
+
+const ListSyntheticProbesSchema = z.object({});
+
+const GetSyntheticCheckResultsSchema = z.object({
+  checkId: z.number().describe('The ID of the check'),
+  range: z.string().optional().describe('The window to compute uptime and reachability over (default: "24h")'),
+});
+
+const SyntheticCheckSettingsSchema = z.object({
+  job: z.string().describe('The job name of the check'),
+  target: z.string().describe('The target: a URL for HTTP checks, a hostname for ping and DNS checks'),
+  type: z.enum(['http', 'ping', 'dns']).describe('The type of check'),
+  probeIds: z.array(z.number()).describe('The IDs of the probes that run the check'),
+  frequencySeconds: z.number().optional().describe('How often to run the check (default: 60)'),
+  timeoutSeconds: z.number().optional().describe('Timeout of each check run (default: 3)'),
+  enabled: z.boolean().optional().describe('Whether the check is enabled (default: true)'),
+  labels: z.array(z.object({
+    name: z.string(),
+    value: z.string(),
+  })).optional().describe('Custom labels of the check'),
+  settings: z.record(z.any()).optional().describe('Type-specific settings, e.g. { method: "GET", validStatusCodes: [200] } for HTTP'),
+});
+
+const CreateSyntheticCheckSchema = SyntheticCheckSettingsSchema;
+
+const UpdateSyntheticCheckSchema = SyntheticCheckSettingsSchema.extend({
+  checkId: z.number().describe('The ID of the check to update'),
+});
+
+// Helper function to create a Synthetic Monitoring client through its datasource proxy route
+async function createSyntheticsClient(config: any) {
+  const grafana = new GrafanaClient(config);
+  const datasources = await grafana.listDatasources(SM_DATASOURCE_TYPE);
+  if (datasources.length === 0) {
+    throw new Error('Synthetic Monitoring is not configured: no synthetic-monitoring-datasource found');
+  }
+  
+  const datasource = await grafana.getDatasourceByUid(datasources[0].uid);
+  return {
+    client: createPluginClient(config, `${config.url}/api/datasources/proxy/uid/${datasource.uid}/sm`),
+    metricsDatasourceUid: datasource.jsonData?.metrics?.uid as string | undefined,
+  };
+}
+
+// Helper function to convert a tool input into a Synthetic Monitoring check
+function toCheck(params: any) {
+  const defaults: Record<string, any> = {
+    http: { method: 'GET', ipVersion: 'V4', noFollowRedirects: false },
+    ping: { ipVersion: 'V4', dontFragment: false },
+    dns: { recordType: 'A', server: '8.8.8.8', ipVersion: 'V4', protocol: 'UDP', port: 53 },
+  };
+  
+  return {
+    job: params.job,
+    target: params.target,
+    probes: params.probeIds,
+    frequency: (params.frequencySeconds || 60) * 1000,
+    timeout: (params.timeoutSeconds || 3) * 1000,
+    enabled: params.enabled ?? true,
+    labels: params.labels || [],
+    settings: {
+      [params.type]: { ...defaults[params.type], ...params.settings },
+    },
+  };
+}
+
+// Helper function to format a check
+function formatCheck(check: any) {
+  return {
+    id: check.id,
+    job: check.job,
+    target: check.target,
+    type: Object.keys(check.settings || {})[0],
+    enabled: check.enabled,
+    frequencySeconds: check.frequency / 1000,
+    probes: check.probes,
+    labels: check.labels || [],
+  };
+}
+
+// Tool definitions
+export const listSyntheticChecks: ToolDefinition = {
+  name: 'list_synthetic_checks',
+  description: 'List Grafana Cloud Synthetic Monitoring checks, optionally filtered by type or job name',
+  inputSchema: ListSyntheticChecksSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const { client } = await createSyntheticsClient(context.config.grafanaConfig);
+      const response = await client.get('/check/list');
+      
+      let checks = (response.data || []).map(formatCheck);
+      if (params.type) {
+        checks = checks.filter((c: any) => c.type === params.type);
+      }
+      if (params.job) {
+        checks = checks.filter((c: any) => c.job.includes(params.job));
+      }
+      
+      return createToolResult(checks);
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.msg || error.message);
+    }
+  },
+};
+
+export const listSyntheticProbes: ToolDefinition = {
+  name: 'list_synthetic_probes',
+  description: 'List Grafana Cloud Synthetic Monitoring probes with their location and online status',
+  inputSchema: ListSyntheticProbesSchema,
+  handler: async (_params, context: ToolContext) => {
+    try {
+      const { client } = await createSyntheticsClient(context.config.grafanaConfig);
+      const response = await client.get('/probe/list');
+      
+      const formatted = (response.data || []).map((probe: any) => ({
+        id: probe.id,
+        name: probe.name,
+        region: probe.region,
+        public: probe.public,
+        online: probe.online,
+        latitude: probe.latitude,
+        longitude: probe.longitude,
+      }));
+      
+      return createToolResult(formatted);
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.msg || error.message);
+    }
+  },
+};
+
+export const getSyntheticCheckResults: ToolDefinition = {
+  name: 'get_synthetic_check_results',
+  description: 'Get the recent uptime, reachability per probe, and average latency of a Synthetic Monitoring check',
+  inputSchema: GetSyntheticCheckResultsSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const { client, metricsDatasourceUid } = await createSyntheticsClient(context.config.grafanaConfig);
+      if (!metricsDatasourceUid) {
+        return createErrorResult('Synthetic Monitoring datasource has no metrics datasource configured');
+      }
+      
+      const response = await client.get(`/check/${params.checkId}`);
+      const check = response.data;
+      const range = params.range || '24h';
+      const selector = `{job="${check.job}", instance="${check.target}"}`;
+      
+      await checkDatasourceAccess(context.config.grafanaConfig, [metricsDatasourceUid]);
+      const prometheus = new PrometheusClient(context.config.grafanaConfig, metricsDatasourceUid);
+      const uptime = await prometheus.query(
+        `sum(increase(probe_all_success_sum${selector}[${range}])) / sum(increase(probe_all_success_count${selector}[${range}]))`
+      );
+      const perProbe = await prometheus.query(
+        `sum by (probe) (increase(probe_all_success_sum${selector}[${range}])) / sum by (probe) (increase(probe_all_success_count${selector}[${range}]))`
+      );
+      const latency = await prometheus.query(
+        `sum(rate(probe_all_duration_seconds_sum${selector}[${range}])) / sum(rate(probe_all_duration_seconds_count${selector}[${range}]))`
+      );
+      
+      const value = (result: any[]) =>
+        result.length > 0 && result[0].value ? parseFloat(result[0].value[1]) : null;
+      
+      return createToolResult({
+        check: formatCheck(check),
+        range,
+        uptime: value(uptime),
+        averageLatencySeconds: value(latency),
+        reachabilityByProbe: perProbe.map(r => ({
+          probe: r.metric.probe,
+          reachability: r.value ? parseFloat(r.value[1]) : null,
+        })),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.msg || error.message);
+    }
+  },
+};
+
+export const createSyntheticCheck: ToolDefinition = {
+  name: 'create_synthetic_check',
+  description: 'Create a Synthetic Monitoring HTTP, ping, or DNS check running on the given probes',
+  inputSchema: CreateSyntheticCheckSchema,
+  annotations: { readOnlyHint: false, destructiveHint: false },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const { client } = await createSyntheticsClient(context.config.grafanaConfig);
+      const response = await client.post('/check/add', toCheck(params));
+      
+      return createToolResult(formatCheck(response.data));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.msg || error.message);
+    }
+  },
+};
+
+export const updateSyntheticCheck: ToolDefinition = {
+  name: 'update_synthetic_check',
+  description: 'Replace the configuration of an existing Synthetic Monitoring check',
+  inputSchema: UpdateSyntheticCheckSchema,
+  annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true },
+  handler: async (params, context: ToolContext) => {
+    try {
+      const { client } = await createSyntheticsClient(context.config.grafanaConfig);
+      const existing = await client.get(`/check/${params.checkId}`);
+      
+      const response = await client.post('/check/update', {
+        ...toCheck(params),
+        id: params.checkId,
+        tenantId: existing.data.tenantId,
+      });
+      
+      return createToolResult(formatCheck(response.data));
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.msg || error.message);
+    }
+  },
+};
+
+export function registerSyntheticsTools(server: any) {
+  server.registerTool(listSyntheticChecks);
+  server.registerTool(listSyntheticProbes);
+  server.registerTool(getSyntheticCheckResults);
+  server.registerTool(createSyntheticCheck);
+  server.registerTool(updateSyntheticCheck);
+}
@@ -0,0 +1,158 @@
+import { z } from 'zod';
+import { ToolDefinition, ToolContext, createToolResult, createErrorResult } from '../server/mcp-server';
+import { GrafanaClient } from '../clients/grafana-client';
+import { createPluginClient } from '../clients/base-client';
+import { checkDatasourceAccess, isDatasourceAllowed } from '../server/datasource-policy';
+import { parseTimeRange } from '../utils/time';
+import { DEFAULT_MAX_DATA_POINTS } from '../utils/downsample';
+import { renderTables, resultTables } from '../utils/table';
+
+// Schema definitions
+const ListTestDataScenariosSchema = z.object({
+  datasourceUid: z.string().optional().describe('The UID of the TestData datasource (default: the first TestData datasource)'),
+});
+
+const RunTestDataScenarioSchema = z.object({
+  datasourceUid: z.string().optional().describe('The UID of the TestData datasource (default: the first TestData datasource)'),
+  scenario: z.string().optional().describe('The scenario ID, e.g. random_walk, predictable_pulse, predictable_csv_wave, csv_content, no_data_points, or server_error_500; see list_testdata_scenarios (default: random_walk)'),
+  seriesCount: z.number().optional().describe('Number of series for random_walk (default: 1)'),
+  labels: z.string().optional().describe('Labels of the series, e.g. \'job="api", env="demo"\''),
+  alias: z.string().optional().describe('Name of the series'),
+  pulse: z.object({
+    timeStep: z.number().optional().describe('Seconds between datapoints (default: 60)'),
+    onCount: z.number().optional().describe('Datapoints with the on value per cycle (default: 3)'),
+    offCount: z.number().optional().describe('Datapoints with the off value per cycle (default: 6)'),
+    onValue: z.number().optional().describe('The on value (default: 2)'),
+    offValue: z.number().optional().describe('The off value (default: 1)'),
+  }).optional().describe('Settings of the predictable_pulse scenario, whose values repeat deterministically'),
+  csvContent: z.string().optional().describe('CSV returned by the csv_content scenario, with a header row'),
+  startRfc3339: z.string().optional().describe('The start time (RFC3339, unix epoch, or relative like "now-1h"; default: 1 hour ago)'),
+  endRfc3339: z.string().optional().describe('The end time (default: now)'),
+  maxDataPoints: z.number().optional().describe(`Maximum datapoints per series (default: ${DEFAULT_MAX_DATA_POINTS})`),
+  format: z.enum(['json', 'csv', 'markdown']).optional().describe('Output format: JSON, or a compact CSV or markdown table (default: json)'),
+});
+
+// Plugin IDs of the TestData datasource before and after Grafana 10
+const TESTDATA_TYPES = ['grafana-testdata-datasource', 'testdata'];
+
+// Helper to resolve the TestData datasource to use: the given one, else the first one the
+// datasource policy allows
+async function resolveTestData(
+  context: ToolContext,
+  datasourceUid?: string
+): Promise<{ uid: string; type: string }> {
+  const client = new GrafanaClient(context.config.grafanaConfig);
+  if (datasourceUid) {
+    await checkDatasourceAccess(context.config.grafanaConfig, [datasourceUid]);
+    const datasource = await client.getDatasourceByUid(datasourceUid);
+    return { uid: datasource.uid, type: datasource.type };
+  }
+  const policy = context.config.grafanaConfig.datasourcePolicy;
+  const datasource = (await client.listDatasources()).find(
+    candidate => TESTDATA_TYPES.includes(candidate.type) && isDatasourceAllowed(policy, candidate)
+  );
+  if (!datasource) {
+    throw new Error('No TestData datasource found; add one under Connections > Data sources');
+  }
+  return { uid: datasource.uid, type: datasource.type };
+}
+
+// Tool definitions
+export const listTestDataScenarios: ToolDefinition = {
+  name: 'list_testdata_scenarios',
+  description: 'Lists the scenarios of the TestData datasource, which generate synthetic data for demos and for testing queries and visualizations',
+  inputSchema: ListTestDataScenariosSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const datasource = await resolveTestData(context, params.datasourceUid);
+      const config = context.config.grafanaConfig;
+      const client = createPluginClient(
+        config,
+        `${config.url}/api/datasources/uid/${datasource.uid}/resources`
+      );
+      const response = await client.get('/scenarios');
+      return createToolResult({
+        datasourceUid: datasource.uid,
+        scenarios: (response.data || []).map((scenario: any) => ({
+          id: scenario.id,
+          name: scenario.name,
+          description: scenario.description || undefined,
+        })),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.response?.data?.message || error.message);
+    }
+  },
+};
+
+export const runTestDataScenario: ToolDefinition = {
+  name: 'run_testdata_scenario',
+  description: 'Runs a TestData scenario, such as a random walk or a predictable pulse, through Grafana\'s query API and returns the generated data. Use it for demos and to check the query and response pipeline without real data; error scenarios show how failures are reported',
+  inputSchema: RunTestDataScenarioSchema,
+  handler: async (params, context: ToolContext) => {
+    try {
+      const datasource = await resolveTestData(context, params.datasourceUid);
+      const timeRange = parseTimeRange(params.startRfc3339, params.endRfc3339, {
+        timezone: context.timezone,
+        defaultStart: 'now-1h',
+      });
+      const maxDataPoints = params.maxDataPoints || DEFAULT_MAX_DATA_POINTS;
+      const scenario = params.scenario || 'random_walk';
+
+      const query: any = {
+        refId: 'A',
+        datasource,
+        scenarioId: scenario,
+        seriesCount: params.seriesCount || 1,
+        labels: params.labels,
+        alias: params.alias,
+        maxDataPoints,
+        intervalMs: Math.max(
+          Math.ceil((timeRange.end.getTime() - timeRange.start.getTime()) / maxDataPoints),
+          1
+        ),
+      };
+      if (scenario === 'predictable_pulse') {
+        query.pulseWave = {
+          timeStep: 60,
+          onCount: 3,
+          offCount: 6,
+          onValue: 2,
+          offValue: 1,
+          ...params.pulse,
+        };
+      }
+      if (params.csvContent) {
+        query.csvContent = params.csvContent;
+      }
+
+      const client = new GrafanaClient(context.config.grafanaConfig);
+      const result = await client.queryDatasources({
+        queries: [query],
+        from: timeRange.start.getTime().toString(),
+        to: timeRange.end.getTime().toString(),
+      });
+      const error = result?.results?.A?.error;
+      if (error) {
+        return createErrorResult(`TestData scenario ${scenario} failed: ${error}`);
+      }
+
+      const tables = resultTables(result, context.timezone);
+      if (params.format && params.format !== 'json') {
+        return createToolResult(renderTables(tables, params.format));
+      }
+      return createToolResult({
+        datasourceUid: datasource.uid,
+        scenario,
+        tables: tables.map(table => ({ columns: table.columns, rows: table.rows })),
+      });
+    } catch (error: any) {
+      return createErrorResult(error.message);
+    }
+  },
+};
+
+export function registerTestDataTools(server: any) {
+  server.registerTool(listTestDataScenarios);
+  server.registerTool(runTestDataScenario);
+}
@@ -2,9 +2,51 @@ export interface TLSConfig {
   certFile?: string;
   keyFile?: string;
   caFile?: string;
+  // PEM blocks given directly, used instead of the corresponding files
+  cert?: string;
+  key?: string;
+  ca?: string;
+  // Trust the operating system's certificate store, plus the CA above if set
+  useSystemCa?: boolean;
   skipVerify?: boolean;
 }
 
+export interface ConnectionPoolConfig {
+  // Connections per Grafana host, including active ones (unlimited if unset)
+  maxConnsPerHost?: number;
+  // Idle connections kept open per host for reuse
+  maxIdleConnsPerHost?: number;
+  // Milliseconds after which idle connections are closed
+  idleTimeout?: number;
+  // Reuse connections between requests
+  keepAlive?: boolean;
+}
+
+export interface LogScrubConfig {
+  // Built-in rules to apply: email, token, credit_card
+  rules: string[];
+  // Additional regular expressions whose matches are redacted
+  patterns: string[];
+}
+
+export interface DatasourcePolicyConfig {
+  // When either allow list is set, only matching datasources may be queried
+  allowedUids: string[];
+  allowedTypes: string[];
+  // Denied datasources are refused even if they are allowed
+  deniedUids: string[];
+  deniedTypes: string[];
+}
+
+export interface SqlQueryConfig {
+  // Let query_sql_datasource run statements other than SELECT, such as INSERT or DROP
+  allowWrites: boolean;
+  // Rows returned per query; further rows are cut off
+  maxRows: number;
+  // Approximate size in bytes of the rows returned per query
+  maxBytes: number;
+}
+
 export interface GrafanaConfig {
   debug: boolean;
   includeArgumentsInSpans: boolean;
@@ -15,14 +57,75 @@ export interface GrafanaConfig {
   password?: string;
   accessToken?: string;
   idToken?: string;
+  // Static headers sent with every Grafana and plugin API request
+  extraHeaders?: Record<string, string>;
   tlsConfig?: TLSConfig;
+  connectionPool?: ConnectionPoolConfig;
+  timezone?: string;
+  logScrubbing?: LogScrubConfig;
+  datasourcePolicy?: DatasourcePolicyConfig;
+  sqlQuery?: SqlQueryConfig;
+  // Folders that dashboard write tools may change ("general" for the root level)
+  writeFolderUids?: string[];
+  cloudApiKey?: string;
+  cloudRegion?: string;
+  cloudApiUrl?: string;
+  fleetManagementUrl?: string;
+  fleetManagementUser?: string;
+  fleetManagementToken?: string;
+}
+
+export interface RateLimitConfig {
+  // Tool calls per minute per client and tool category
+  perMinute?: number;
+  // Limits overriding perMinute for individual categories
+  categories: Record<string, number>;
+}
+
+export interface CorsConfig {
+  // Origins browsers may connect from; "*" and wildcards such as https://*.example.com work
+  allowedOrigins: string[];
+  allowedHeaders?: string[];
+  allowedMethods?: string[];
+}
+
+export interface AlertWebhookConfig {
+  // Port of the receiver, which listens separately from the MCP transport
+  port: number;
+  address?: string;
+  // Bearer token the webhook contact point must send as its authorization credentials
+  token?: string;
 }
 
 export interface ServerConfig {
-  transport: 'stdio' | 'sse' | 'streamable-http';
+  transport: 'stdio' | 'sse' | 'streamable-http' | 'websocket';
   address?: string;
   path?: string;
   port?: number;
+  // Prefix of the HTTP endpoints behind a reverse proxy, e.g. /mcp/grafana
+  basePath?: string;
+  // CORS for browser-based clients of the HTTP transports
+  cors?: CorsConfig;
+  // Where streamable HTTP sessions are kept: "memory" (default) or a redis:// URL
+  sessionStore?: string;
+  // Minutes after which idle HTTP sessions expire
+  sessionTtl?: number;
+  // Streamable HTTP without sessions: every request is handled on its own
+  stateless?: boolean;
+  // Seconds between keep-alive pings on SSE event streams (0 disables them)
+  keepAlive?: number;
   enabledTools: Set<string>;
+  disableWrite?: boolean;
+  // Write tools report the requests they would send instead of sending them
+  dryRun?: boolean;
+  // Tool results larger than this many characters are returned as resources (0 disables)
+  maxResultSize?: number;
+  // Seconds to wait for in-flight tool calls when shutting down on SIGTERM/SIGINT
+  shutdownTimeout?: number;
+  rateLimit?: RateLimitConfig;
+  // Receiver of Grafana alert webhooks for list_recent_alert_events
+  alertWebhook?: AlertWebhookConfig;
+  // Instructions for clients describing this deployment, replacing the default ones
+  instructions?: string;
   grafanaConfig: GrafanaConfig;
 }
\ No newline at end of file
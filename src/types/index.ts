@@ -10,7 +10,7 @@ export const TOOL_CATEGORIES: ToolCategory[] = [
   {
     name: 'search',
     description: 'Search for dashboards and other resources',
-    tools: ['search_dashboards'],
+    tools: ['search_dashboards', 'search', 'search_panel_queries'],
   },
   {
     name: 'dashboard',
@@ -21,6 +21,20 @@ export const TOOL_CATEGORIES: ToolCategory[] = [
       'get_dashboard_property',
       'get_dashboard_panel_queries',
       'update_dashboard',
+      'list_public_dashboards',
+      'get_public_dashboard',
+      'enable_public_dashboard',
+      'disable_public_dashboard',
+      'create_dashboard_snapshot',
+      'list_dashboard_snapshots',
+      'delete_dashboard_snapshot',
+      'import_catalog_dashboard',
+      'export_dashboard',
+      'bulk_update_dashboards',
+      'list_starred_dashboards',
+      'star_dashboard',
+      'query_panel_data',
+      'compare_panel_renders',
     ],
   },
   {
@@ -37,6 +51,8 @@ export const TOOL_CATEGORIES: ToolCategory[] = [
       'list_prometheus_metric_metadata',
       'list_prometheus_label_names',
       'list_prometheus_label_values',
+      'check_recording_rule_drift',
+      'get_service_red_summary',
     ],
   },
   {
@@ -48,17 +64,49 @@ export const TOOL_CATEGORIES: ToolCategory[] = [
       'list_loki_label_names',
       'list_loki_label_values',
       'find_error_pattern_logs',
+      'tail_loki_logs',
     ],
   },
   {
     name: 'incident',
     description: 'Incident management tools',
-    tools: ['list_incidents', 'get_incident', 'create_incident', 'add_activity_to_incident'],
+    tools: [
+      'list_incidents',
+      'get_incident',
+      'create_incident',
+      'add_activity_to_incident',
+      'get_incident_timeline',
+      'generate_incident_retrospective',
+      'create_incident_from_alert',
+      'assign_incident_role',
+      'update_incident',
+      'add_incident_task',
+    ],
   },
   {
     name: 'alerting',
     description: 'Alerting and notification tools',
-    tools: ['list_alert_rules', 'get_alert_rule_by_uid', 'list_contact_points'],
+    tools: [
+      'list_alert_rules',
+      'get_alert_rule_by_uid',
+      'list_contact_points',
+      'list_active_alerts',
+      'list_alert_groups',
+      'get_alert_state_history',
+      'preview_alert_rule',
+      'test_contact_point',
+      'list_mute_timings',
+      'create_mute_timing',
+      'update_mute_timing',
+      'delete_mute_timing',
+      'export_alerting_configuration',
+      'list_ruler_rules',
+      'get_ruler_rule_group',
+      'set_ruler_rule_group',
+      'delete_ruler_rule_group',
+      'find_alert_dashboards',
+      'list_recent_alert_events',
+    ],
   },
   {
     name: 'oncall',
@@ -69,12 +117,44 @@ export const TOOL_CATEGORIES: ToolCategory[] = [
       'list_oncall_users',
       'get_current_oncall_users',
       'get_oncall_shift',
+      'list_oncall_escalation_chains',
+      'get_oncall_integration_routing',
+      'list_oncall_alert_groups',
+      'acknowledge_oncall_alert_group',
+      'resolve_oncall_alert_group',
+      'silence_oncall_alert_group',
+      'create_oncall_override',
+      'list_oncall_shift_swaps',
+      'create_oncall_shift_swap',
+      'take_oncall_shift_swap',
+      'get_oncall_summary',
     ],
   },
   {
     name: 'admin',
-    description: 'User and team administration',
-    tools: ['list_users_by_org', 'list_teams'],
+    description: 'User, team, org, service account, and RBAC administration',
+    tools: [
+      'list_users_by_org',
+      'list_teams',
+      'list_orgs',
+      'list_service_accounts',
+      'create_service_account',
+      'list_service_account_tokens',
+      'create_service_account_token',
+      'revoke_service_account_token',
+      'list_rbac_roles',
+      'get_user_permissions',
+      'get_resource_permissions',
+      'whoami',
+      'can_i',
+      'get_preferences',
+      'update_preferences',
+      'get_ldap_status',
+      'sync_ldap_user',
+      'get_sso_settings',
+      'get_server_stats',
+      'get_org_usage',
+    ],
   },
   {
     name: 'sift',
@@ -84,6 +164,7 @@ export const TOOL_CATEGORIES: ToolCategory[] = [
       'get_sift_investigation',
       'get_sift_analysis',
       'find_slow_requests',
+      'create_sift_investigation',
     ],
   },
   {
@@ -106,4 +187,158 @@ export const TOOL_CATEGORIES: ToolCategory[] = [
     description: 'Entity assertions and validation',
     tools: ['get_assertions'],
   },
-];
\ No newline at end of file
+  {
+    name: 'slo',
+    description: 'Grafana SLO definitions and error budgets',
+    tools: [
+      'list_slos',
+      'get_slo',
+      'get_slo_status',
+      'create_slo',
+      'update_slo',
+      'calculate_slo_burn_rates',
+    ],
+  },
+  {
+    name: 'synthetics',
+    description: 'Synthetic Monitoring checks and probes',
+    tools: [
+      'list_synthetic_checks',
+      'list_synthetic_probes',
+      'get_synthetic_check_results',
+      'create_synthetic_check',
+      'update_synthetic_check',
+    ],
+  },
+  {
+    name: 'k6',
+    description: 'k6 Cloud load tests and test runs',
+    tools: ['list_k6_tests', 'list_k6_test_runs', 'get_k6_test_run', 'start_k6_test'],
+  },
+  {
+    name: 'kubernetes',
+    description: 'Kubernetes Monitoring clusters, namespaces, and workloads',
+    tools: [
+      'list_k8s_clusters',
+      'list_k8s_namespaces',
+      'list_k8s_workloads',
+      'get_k8s_workload_health',
+      'get_k8s_namespace_summary',
+    ],
+  },
+  {
+    name: 'ml',
+    description: 'Machine Learning forecasts and outlier detection',
+    tools: ['list_ml_jobs', 'get_ml_forecast', 'detect_outliers'],
+  },
+  {
+    name: 'cloud',
+    description: 'Grafana Cloud stacks, access policies, and tokens',
+    tools: [
+      'list_cloud_stacks',
+      'list_cloud_access_policies',
+      'create_cloud_access_policy',
+      'list_cloud_tokens',
+      'create_cloud_token',
+      'get_cloud_usage',
+    ],
+  },
+  {
+    name: 'fleet',
+    description: 'Fleet Management collectors and pipelines',
+    tools: ['list_fleet_collectors', 'get_fleet_collector', 'list_fleet_pipelines'],
+  },
+  {
+    name: 'reporting',
+    description: 'Scheduled reports (Grafana Enterprise)',
+    tools: [
+      'list_reports',
+      'create_report',
+      'update_report',
+      'send_report',
+    ],
+  },
+  {
+    name: 'history',
+    description: 'Explore query history',
+    tools: ['search_query_history', 'star_query_history'],
+  },
+  {
+    name: 'instance',
+    description: 'Grafana instance information',
+    tools: ['get_grafana_info'],
+  },
+  {
+    name: 'query',
+    description: 'Queries across datasources',
+    tools: ['run_queries'],
+  },
+  {
+    name: 'investigation',
+    description: 'Investigations correlating metrics, logs, traces, and changes',
+    tools: ['investigate_entity', 'what_changed', 'resolve_time_range'],
+  },
+  {
+    name: 'elasticsearch',
+    description: 'Elasticsearch search and ES|QL query tools',
+    tools: ['query_elasticsearch', 'query_elasticsearch_esql', 'list_elasticsearch_fields'],
+  },
+  {
+    name: 'sql',
+    description: 'SQL datasource query tools',
+    tools: ['query_sql_datasource'],
+  },
+  {
+    name: 'cloudwatch',
+    description: 'CloudWatch metrics and Logs Insights tools',
+    tools: [
+      'list_cloudwatch_namespaces',
+      'list_cloudwatch_metrics',
+      'list_cloudwatch_dimensions',
+      'query_cloudwatch_metrics',
+      'query_cloudwatch_logs',
+    ],
+  },
+  {
+    name: 'azure',
+    description: 'Azure Monitor metrics and Log Analytics tools',
+    tools: [
+      'list_azure_subscriptions',
+      'list_azure_workspaces',
+      'list_azure_resources',
+      'list_azure_metric_definitions',
+      'query_azure_log_analytics',
+      'query_azure_metrics',
+    ],
+  },
+  {
+    name: 'cloudmonitoring',
+    description: 'Google Cloud Monitoring metric tools',
+    tools: ['list_gcm_metric_descriptors', 'query_gcm_time_series', 'query_gcm_mql'],
+  },
+  {
+    name: 'graphite',
+    description: 'Graphite query and metric discovery tools',
+    tools: ['query_graphite', 'find_graphite_metrics', 'list_graphite_functions'],
+  },
+  {
+    name: 'influxdb',
+    description: 'InfluxDB InfluxQL and Flux query tools',
+    tools: ['query_influxdb'],
+  },
+  {
+    name: 'testdata',
+    description: 'TestData scenarios for demos and pipeline checks',
+    tools: ['list_testdata_scenarios', 'run_testdata_scenario'],
+  },
+  {
+    name: 'live',
+    description: 'Grafana Live channel subscriptions',
+    tools: ['subscribe_grafana_live'],
+  },
+  {
+    name: 'scheduler',
+    description: 'Scheduled background checks with notifications',
+    tools: ['schedule_check', 'list_scheduled_checks', 'cancel_scheduled_check'],
+  },
+];
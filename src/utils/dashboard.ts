@@ -0,0 +1,16 @@
+// Shared helpers for reading dashboard JSON
+
+// Lists the panels of a dashboard, including those in collapsed rows, without the rows
+export function dashboardPanels(dashboard: any): any[] {
+  return (dashboard.panels || []).flatMap((panel: any) =>
+    panel.type === 'row' ? panel.panels || [] : [panel]
+  );
+}
+
+// Lists the PromQL or LogQL expressions of a panel's visible queries
+export function panelExpressions(panel: any): string[] {
+  return (panel.targets || [])
+    .filter((target: any) => !target.hide)
+    .map((target: any) => target.expr)
+    .filter((expr: any) => typeof expr === 'string' && expr !== '');
+}
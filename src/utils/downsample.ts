@@ -0,0 +1,128 @@
+// Server-side downsampling of range query results, so long ranges stay inside
+// LLM context budgets.
+
+export type Sample = [number, string];
+
+export type DownsampleMethod = 'step' | 'lttb' | 'none';
+
+export interface Resolution {
+  method: DownsampleMethod;
+  stepSeconds: number;
+  maxDataPoints: number;
+  downsampledSeries: number;
+  originalPoints: number;
+  returnedPoints: number;
+}
+
+export const DEFAULT_MAX_DATA_POINTS = 500;
+
+// Returns the smallest step (>= the requested one) that yields at most maxDataPoints samples
+export function widenStep(
+  startSeconds: number,
+  endSeconds: number,
+  stepSeconds: number,
+  maxDataPoints: number
+): number {
+  const minStep = Math.ceil((endSeconds - startSeconds) / Math.max(maxDataPoints - 1, 1));
+  return Math.max(stepSeconds, minStep, 1);
+}
+
+// Largest-Triangle-Three-Buckets: keeps the samples that best preserve the visual shape
+export function lttb(samples: Sample[], threshold: number): Sample[] {
+  if (threshold >= samples.length || threshold < 3) {
+    return samples;
+  }
+
+  const y = (sample: Sample) => {
+    const value = parseFloat(sample[1]);
+    return Number.isFinite(value) ? value : 0;
+  };
+
+  const sampled: Sample[] = [samples[0]];
+  const bucketSize = (samples.length - 2) / (threshold - 2);
+  let previous = 0;
+
+  for (let i = 0; i < threshold - 2; i++) {
+    // Average of the next bucket is the third point of the triangle
+    const nextStart = Math.floor((i + 1) * bucketSize) + 1;
+    const nextEnd = Math.min(Math.floor((i + 2) * bucketSize) + 1, samples.length);
+    let avgX = 0;
+    let avgY = 0;
+    for (let j = nextStart; j < nextEnd; j++) {
+      avgX += samples[j][0];
+      avgY += y(samples[j]);
+    }
+    avgX /= nextEnd - nextStart;
+    avgY /= nextEnd - nextStart;
+
+    // Pick the point in the current bucket forming the largest triangle
+    const start = Math.floor(i * bucketSize) + 1;
+    const end = Math.floor((i + 1) * bucketSize) + 1;
+    const [prevX] = samples[previous];
+    const prevY = y(samples[previous]);
+    let maxArea = -1;
+    let chosen = start;
+    for (let j = start; j < end; j++) {
+      const area = Math.abs(
+        (prevX - avgX) * (y(samples[j]) - prevY) - (prevX - samples[j][0]) * (avgY - prevY)
+      );
+      if (area > maxArea) {
+        maxArea = area;
+        chosen = j;
+      }
+    }
+
+    sampled.push(samples[chosen]);
+    previous = chosen;
+  }
+
+  sampled.push(samples[samples.length - 1]);
+  return sampled;
+}
+
+// Applies LTTB to every series with more than maxDataPoints samples
+export function downsampleSeries<T extends { values?: Sample[] }>(
+  series: T[],
+  method: DownsampleMethod,
+  stepSeconds: number,
+  maxDataPoints: number
+): { series: T[]; resolution: Resolution } {
+  let originalPoints = 0;
+  let returnedPoints = 0;
+  let downsampledSeries = 0;
+
+  const result = series.map(s => {
+    const values = s.values || [];
+    originalPoints += values.length;
+    if (method !== 'lttb' || values.length <= maxDataPoints) {
+      returnedPoints += values.length;
+      return s;
+    }
+
+    const sampled = lttb(values, maxDataPoints);
+    returnedPoints += sampled.length;
+    downsampledSeries++;
+    return { ...s, values: sampled };
+  });
+
+  return {
+    series: result,
+    resolution: {
+      method,
+      stepSeconds,
+      maxDataPoints,
+      downsampledSeries,
+      originalPoints,
+      returnedPoints,
+    },
+  };
+}
+
+// One-line description of the applied resolution, for outputs that have no room for metadata
+export function describeResolution(resolution: Resolution): string {
+  return (
+    `Resolution: ${resolution.stepSeconds}s step, ${resolution.returnedPoints} of ` +
+    `${resolution.originalPoints} points returned (method: ${resolution.method}, ` +
+    `max ${resolution.maxDataPoints} per series)`
+  );
+}
@@ -0,0 +1,51 @@
+// Sparse field selection for list and get tools, so clients that only need a few fields
+// (e.g. UIDs and titles) do not pay for the full objects.
+
+// Nested form of the requested dotted paths; true selects the whole value
+interface FieldTree {
+  [key: string]: FieldTree | true;
+}
+
+function buildTree(fields: string[]): FieldTree {
+  const tree: FieldTree = {};
+  for (const field of fields) {
+    const path = field.split('.').filter(segment => segment);
+    let node = tree;
+    for (let i = 0; i < path.length; i++) {
+      const child = node[path[i]];
+      if (child === true) break;
+      if (i === path.length - 1) {
+        node[path[i]] = true;
+      } else {
+        node = node[path[i]] = child || {};
+      }
+    }
+  }
+  return tree;
+}
+
+// Projects a value to the field tree, mapping over arrays met along the way
+function project(value: any, tree: FieldTree | true): any {
+  if (tree === true || value === undefined || value === null) return value;
+  if (Array.isArray(value)) {
+    return value.map(item => project(item, tree));
+  }
+  if (typeof value !== 'object') return undefined;
+
+  const projected: any = {};
+  for (const [key, subtree] of Object.entries(tree)) {
+    if (!(key in value)) continue;
+    const picked = project(value[key], subtree);
+    if (picked !== undefined) {
+      projected[key] = picked;
+    }
+  }
+  return projected;
+}
+
+// Projects an object, or each element of an array, to the given dotted field paths
+// (e.g. "uid", "dashboard.panels.title"). Returns the data unchanged when no fields are requested.
+export function projectFields(data: any, fields?: string[]): any {
+  if (!fields || fields.length === 0) return data;
+  return project(data, buildTree(fields));
+}
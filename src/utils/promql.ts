@@ -0,0 +1,123 @@
+// Lightweight analysis of PromQL (and LogQL) query text: the metric names and label matchers
+// a query uses. This is not a parser; it is meant for matching related queries, such as an
+// alert rule and the dashboard panels showing the same data. Selectors for generated queries
+// are built here as well.
+
+// Words of PromQL and LogQL queries that are not metric names
+const QUERY_KEYWORDS = new Set([
+  'by', 'without', 'on', 'ignoring', 'group_left', 'group_right', 'bool', 'offset', 'and', 'or',
+  'unless', 'inf', 'nan', 'json', 'logfmt', 'regexp', 'pattern', 'unpack', 'line_format',
+  'label_format', 'unwrap', 'drop', 'keep', 'decolorize',
+]);
+
+// Aggregation operators, and those of them taking a parameter before the expression
+const AGGREGATIONS = [
+  'sum', 'avg', 'min', 'max', 'count', 'group', 'stddev', 'stdvar', 'topk', 'bottomk', 'quantile',
+  'count_values', 'limitk', 'limit_ratio',
+];
+const PARAMETER_AGGREGATIONS = new Set([
+  'topk', 'bottomk', 'quantile', 'count_values', 'limitk', 'limit_ratio',
+]);
+
+// Removes Grafana template variables such as $job, ${job:regex}, and [[job]]
+function stripVariables(expr: string): string {
+  return expr.replace(/\$\{[^}]*\}|\$\w+|\[\[[^\]]*\]\]/g, '');
+}
+
+// Extracts the metric names a query reads, e.g. http_requests_total
+export function queryMetrics(expr: string): string[] {
+  const names = new Set<string>();
+  for (const match of expr.matchAll(/__name__\s*=\s*"([^"]*)"/g)) {
+    names.add(match[1]);
+  }
+  const bare = stripVariables(expr)
+    .replace(/"(?:[^"\\]|\\.)*"|`[^`]*`/g, '')
+    .replace(/\{[^}]*\}|\[[^\]]*\]/g, '')
+    .replace(/\b(by|without|on|ignoring|group_left|group_right)\s*\([^)]*\)/g, '');
+  // Identifiers followed by a parenthesis are functions or aggregations
+  for (const match of bare.matchAll(/(?<![\w:.])([a-zA-Z_:][\w:]*)(?!\s*\(|[\w:])/g)) {
+    if (!QUERY_KEYWORDS.has(match[1].toLowerCase())) {
+      names.add(match[1]);
+    }
+  }
+  return [...names];
+}
+
+// Extracts the equality label matchers of a query as name="value" strings
+export function queryMatchers(expr: string): string[] {
+  const matchers = new Set<string>();
+  for (const selector of expr.matchAll(/\{([^}]*)\}/g)) {
+    for (const match of selector[1].matchAll(/([a-zA-Z_]\w*)\s*=\s*"((?:[^"\\]|\\.)*)"/g)) {
+      if (match[1] !== '__name__') {
+        matchers.add(`${match[1]}="${match[2]}"`);
+      }
+    }
+  }
+  return [...matchers];
+}
+
+// Removes the whitespace of a query, so formatting differences do not prevent a match
+export function normalizeQuery(expr: string): string {
+  return expr.replace(/\s+/g, '');
+}
+
+// Removes the outer aggregation of a normalized query, e.g. sum by(job)(rate(x[5m])) becomes
+// rate(x[5m]); queries without one are returned as they are
+export function stripOuterAggregation(normalized: string): string {
+  const match = new RegExp(
+    `^(${AGGREGATIONS.join('|')})(?:(?:by|without)\\([^)]*\\))?\\(`
+  ).exec(normalized);
+  if (!match) return normalized;
+
+  // Finds the parenthesis closing the aggregation, skipping quoted label values
+  let depth = 1;
+  let end = match[0].length;
+  for (; end < normalized.length && depth > 0; end++) {
+    const char = normalized[end];
+    if (char === '"') {
+      for (end++; end < normalized.length && normalized[end] !== '"'; end++) {
+        if (normalized[end] === '\\') end++;
+      }
+    } else if (char === '(') {
+      depth++;
+    } else if (char === ')') {
+      depth--;
+    }
+  }
+  const rest = normalized.slice(end);
+  if (depth !== 0 || (rest !== '' && !/^(?:by|without)\([^)]*\)$/.test(rest))) {
+    return normalized;
+  }
+  const inner = normalized.slice(match[0].length, end - 1);
+  return PARAMETER_AGGREGATIONS.has(match[1]) ? inner.replace(/^[^,()]*,/, '') : inner;
+}
+
+// The structure of a normalized query without its label matchers and ranges, so queries over
+// the same metrics with the same functions compare equal
+export function queryShape(normalized: string): string {
+  return normalized
+    .replace(/"(?:[^"\\]|\\.)*"/g, '""')
+    .replace(/\{[^}]*\}/g, '')
+    .replace(/\[[^\]]*\]/g, '[]');
+}
+
+// Checks whether a query contains another one as a whole term, so rate(x) is not found in
+// irate(x)
+export function containsQuery(query: string, part: string): boolean {
+  for (let index = query.indexOf(part); index !== -1; index = query.indexOf(part, index + 1)) {
+    if (index === 0 || !/[\w:]/.test(query[index - 1])) return true;
+  }
+  return false;
+}
+
+// Escapes a label value for a double-quoted matcher
+export function escapeLabelValue(value: string): string {
+  return value.replace(/\\/g, '\\\\').replace(/"/g, '\\"');
+}
+
+// Equality matchers of a set of labels, e.g. namespace="shop",service="checkout"
+export function labelSelector(labels: Record<string, string>): string {
+  return Object.entries(labels)
+    .map(([name, value]) => `${name}="${escapeLabelValue(value)}"`)
+    .join(',');
+}
@@ -0,0 +1,41 @@
+// Central redaction of secrets from configuration objects (datasources, contact points,
+// SSO settings, ...) returned by tools.
+
+const REDACTED = '[REDACTED]';
+
+// Keys whose string values are credentials
+const SECRET_KEY_PATTERN = /secret|password|private|token|certificate|key$|^authorization$|^cookie$/i;
+
+// Keys holding encrypted settings, which are dropped entirely
+const SECURE_DATA_KEYS = new Set(['secureJsonData', 'secureSettings']);
+
+// Keys of header value settings, e.g. httpHeaderValue1 next to httpHeaderName1
+const HEADER_VALUE_KEY_PATTERN = /^httpHeaderValue\d+$/;
+
+// Recursively redacts secret values from a configuration object. Which secure settings are
+// set (e.g. secureJsonFields) is kept, so the model can still tell a credential is configured.
+export function redactSecrets<T>(value: T): T {
+  if (Array.isArray(value)) {
+    return value.map(item => redactSecrets(item)) as T;
+  }
+  if (!value || typeof value !== 'object') {
+    return value;
+  }
+
+  const redacted: Record<string, any> = {};
+  for (const [key, item] of Object.entries(value)) {
+    if (SECURE_DATA_KEYS.has(key)) {
+      continue;
+    }
+    if (
+      typeof item === 'string' &&
+      item !== '' &&
+      (SECRET_KEY_PATTERN.test(key) || HEADER_VALUE_KEY_PATTERN.test(key))
+    ) {
+      redacted[key] = REDACTED;
+    } else {
+      redacted[key] = redactSecrets(item);
+    }
+  }
+  return redacted as T;
+}
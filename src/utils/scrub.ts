@@ -0,0 +1,129 @@
+// Redaction of personal data and secrets from log lines before they are returned to the
+// model, for compliance-sensitive deployments.
+
+import { LogScrubConfig } from '../types/config';
+
+interface ScrubRule {
+  name: string;
+  pattern: RegExp;
+  // Extra check on a match, to avoid redacting look-alikes
+  accept?: (match: string) => boolean;
+}
+
+// Luhn checksum, so order IDs and other long numbers are not mistaken for card numbers
+function luhn(match: string): boolean {
+  const digits = match.replace(/\D/g, '');
+  let sum = 0;
+  for (let i = 0; i < digits.length; i++) {
+    let digit = Number(digits[digits.length - 1 - i]);
+    if (i % 2 === 1) {
+      digit *= 2;
+      if (digit > 9) digit -= 9;
+    }
+    sum += digit;
+  }
+  return sum % 10 === 0;
+}
+
+export const BUILTIN_SCRUB_RULES: Record<string, ScrubRule[]> = {
+  email: [{ name: 'email', pattern: /[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}/g }],
+  token: [
+    // JWTs, Grafana service account and Cloud tokens, GitHub, AWS, and Slack tokens
+    {
+      name: 'token',
+      pattern:
+        /\b(?:eyJ[\w-]+\.[\w-]+\.[\w-]+|gl(?:sa|c)_[\w+/=-]{20,}|gh[pousr]_\w{36,}|AKIA[0-9A-Z]{16}|xox[abprs]-[\w-]{10,})\b/g,
+    },
+    // Authorization headers and key=value credentials
+    {
+      name: 'token',
+      pattern: /\b(bearer|basic)\s+[\w+/=.-]{8,}/gi,
+    },
+    {
+      name: 'token',
+      pattern: /\b(?:password|passwd|secret|token|api[_-]?key)\s*[=:]\s*"?[^\s",;&]+/gi,
+    },
+  ],
+  credit_card: [{ name: 'credit_card', pattern: /\b\d(?:[ -]?\d){12,18}\b/g, accept: luhn }],
+};
+
+// Returns a function redacting the configured rules and patterns, or undefined if none are set
+export function createLogScrubber(config?: LogScrubConfig): ((text: string) => string) | undefined {
+  if (!config || (config.rules.length === 0 && config.patterns.length === 0)) {
+    return undefined;
+  }
+
+  const rules: ScrubRule[] = [
+    ...config.rules.flatMap(name => BUILTIN_SCRUB_RULES[name] || []),
+    ...config.patterns.map(pattern => ({ name: 'custom', pattern: new RegExp(pattern, 'g') })),
+  ];
+
+  return (text: string) =>
+    rules.reduce(
+      (scrubbed, rule) =>
+        scrubbed.replace(rule.pattern, match =>
+          !rule.accept || rule.accept(match) ? `[REDACTED:${rule.name}]` : match
+        ),
+      text
+    );
+}
+
+// Redacts the string values and labels of the data frames in a Grafana query result (per refId),
+// such as the log lines of Loki and Elasticsearch queries
+export function scrubFrames(result: any, scrub: (text: string) => string): any {
+  const scrubLabels = (labels?: Record<string, string>) =>
+    labels &&
+    Object.fromEntries(Object.entries(labels).map(([name, value]) => [name, scrub(value)]));
+  const scrubValue = (value: unknown): unknown => {
+    if (typeof value === 'string') return scrub(value);
+    // Loki frames carry the labels of each line as objects
+    if (value && typeof value === 'object' && !Array.isArray(value)) {
+      return scrubLabels(value as Record<string, string>);
+    }
+    return value;
+  };
+  const results = Object.fromEntries(
+    Object.entries<any>(result?.results || {}).map(([refId, query]) => [
+      refId,
+      {
+        ...query,
+        frames: (query.frames || []).map((frame: any) => ({
+          ...frame,
+          schema: frame.schema && {
+            ...frame.schema,
+            fields: (frame.schema.fields || []).map((field: any) => ({
+              ...field,
+              labels: scrubLabels(field.labels),
+            })),
+          },
+          data: frame.data && {
+            ...frame.data,
+            values: (frame.data.values || []).map((column: unknown[]) =>
+              Array.isArray(column) ? column.map(scrubValue) : column
+            ),
+          },
+        })),
+      },
+    ])
+  );
+  return { ...result, results };
+}
+
+// Throws if the config names an unknown rule or contains an invalid pattern
+export function validateLogScrubConfig(config: LogScrubConfig): void {
+  for (const name of config.rules) {
+    if (!BUILTIN_SCRUB_RULES[name]) {
+      throw new Error(
+        `Unknown log scrubbing rule "${name}": expected one of ` +
+          Object.keys(BUILTIN_SCRUB_RULES).join(', ')
+      );
+    }
+  }
+  for (const pattern of config.patterns) {
+    try {
+      new RegExp(pattern);
+    } catch (error: any) {
+      throw new Error(`Invalid log scrubbing pattern "${pattern}": ${error.message}`);
+    }
+  }
+}
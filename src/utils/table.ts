@@ -0,0 +1,129 @@
+// Shared conversion of query results (Grafana data frames, Prometheus-style series, and
+// plain records) into tables rendered as CSV or markdown, which are far more compact than
+// JSON for the model to read.
+
+import { formatTime } from './time';
+
+export type OutputFormat = 'json' | 'csv' | 'markdown';
+
+export interface Table {
+  name?: string;
+  columns: string[];
+  rows: unknown[][];
+}
+
+interface Series {
+  metric: Record<string, string>;
+  value?: [number | string, string];
+  values?: [number | string, string][];
+}
+
+// Formats a label set the way Prometheus and Loki print it: {job="api", env="prod"}
+export function formatLabels(labels: Record<string, string> | undefined): string {
+  const entries = Object.entries(labels || {});
+  if (entries.length === 0) return '';
+  return `{${entries.map(([name, value]) => `${name}="${value}"`).join(', ')}}`;
+}
+
+// Converts a Grafana data frame (schema.fields plus columnar data.values) to a table
+export function frameToTable(frame: any, timezone?: string): Table {
+  const fields: any[] = frame?.schema?.fields || [];
+  const values: any[][] = frame?.data?.values || [];
+
+  const columns = fields.map(
+    field => (field.config?.displayNameFromDS || field.name || '') + formatLabels(field.labels)
+  );
+  const length = Math.max(0, ...values.map(column => column?.length || 0));
+  const rows: unknown[][] = [];
+  for (let i = 0; i < length; i++) {
+    rows.push(fields.map((field, j) => {
+      const value = values[j]?.[i];
+      return field.type === 'time' && typeof value === 'number' ? formatTime(value, timezone) : value;
+    }));
+  }
+
+  return { name: frame?.schema?.name || frame?.schema?.refId, columns, rows };
+}
+
+// Collects the data frames of a query or alert evaluation result (per refId), or of a backtest
+// result, as tables
+export function resultTables(result: any, timezone?: string): Table[] {
+  if (result?.results) {
+    return Object.entries(result.results).flatMap(([refId, query]: [string, any]) =>
+      (query.frames || []).map((frame: any) => ({ ...frameToTable(frame, timezone), name: refId }))
+    );
+  }
+  return result?.schema ? [frameToTable(result, timezone)] : [];
+}
+
+// Converts Prometheus-style series to a long-format table: one row per sample, one column per label
+export function seriesToTable(series: Series[], timezone?: string): Table {
+  const labelNames = new Set<string>();
+  for (const s of series) {
+    Object.keys(s.metric || {}).forEach(name => labelNames.add(name));
+  }
+  const labels = [...labelNames].sort();
+
+  const rows: unknown[][] = [];
+  for (const s of series) {
+    const samples = s.values || (s.value ? [s.value] : []);
+    for (const [time, value] of samples) {
+      // Numeric timestamps are unix seconds; strings are already formatted
+      const formatted = typeof time === 'number' ? formatTime(time * 1000, timezone) : time;
+      rows.push([...labels.map(name => s.metric?.[name]), formatted, value]);
+    }
+  }
+
+  return { columns: [...labels, 'time', 'value'], rows };
+}
+
+// Converts a list of flat records to a table with the union of their keys as columns
+export function recordsToTable(records: Record<string, unknown>[]): Table {
+  const columns: string[] = [];
+  for (const record of records) {
+    for (const key of Object.keys(record)) {
+      if (!columns.includes(key)) columns.push(key);
+    }
+  }
+
+  return { columns, rows: records.map(record => columns.map(column => record[column])) };
+}
+
+function cellText(value: unknown): string {
+  if (value === undefined || value === null) return '';
+  if (typeof value === 'object') return JSON.stringify(value);
+  return String(value);
+}
+
+function toCsv(table: Table): string {
+  const escape = (value: unknown) => {
+    const text = cellText(value);
+    return /[",\r\n]|^\s|\s$/.test(text) ? `"${text.replace(/"/g, '""')}"` : text;
+  };
+  return [table.columns, ...table.rows].map(row => row.map(escape).join(',')).join('\n');
+}
+
+function toMarkdown(table: Table): string {
+  const escape = (value: unknown) =>
+    cellText(value).replace(/\|/g, '\\|').replace(/\r?\n/g, '<br>');
+  const lines = [
+    `| ${table.columns.map(escape).join(' | ')} |`,
+    `|${table.columns.map(() => '---').join('|')}|`,
+    ...table.rows.map(row => `| ${row.map(escape).join(' | ')} |`),
+  ];
+  return lines.join('\n');
+}
+
+// Renders one or more tables as CSV or markdown; multiple tables are separated by their names
+export function renderTables(tables: Table[], format: 'csv' | 'markdown'): string {
+  if (tables.length === 0) return '';
+
+  return tables
+    .map((table, i) => {
+      const body = format === 'csv' ? toCsv(table) : toMarkdown(table);
+      if (tables.length === 1) return body;
+      const title = table.name || `Table ${i + 1}`;
+      return format === 'csv' ? `# ${title}\n${body}` : `### ${title}\n\n${body}`;
+    })
+    .join('\n\n');
+}
@@ -0,0 +1,207 @@
+import { TimeRange, formatTime, parseTime, parseTimeRange } from './time';
+
+// Resolution of everyday time phrases such as "last night", "the past 2 hours", or "around
+// 14:05" into concrete ranges, and recognition of references to events such as "the deploy".
+// Wall-clock phrases use the given time zone.
+
+export interface PhraseOptions {
+  timezone?: string;
+  now?: Date;
+}
+
+export interface PhraseRange extends TimeRange {
+  // How the phrase was understood, e.g. "yesterday 18:00 to today 06:00"
+  interpretation: string;
+}
+
+// An event the phrase refers to, and how the range relates to it
+export interface PhraseEvent {
+  keyword: string;
+  // Matches annotation tags and texts of the event
+  pattern: RegExp;
+  relation: 'during' | 'around' | 'since' | 'before' | 'after';
+}
+
+const NUMBER_WORDS: Record<string, number> = {
+  a: 1,
+  an: 1,
+  one: 1,
+  two: 2,
+  three: 3,
+  four: 4,
+  five: 5,
+  six: 6,
+  seven: 7,
+  eight: 8,
+  nine: 9,
+  ten: 10,
+  twelve: 12,
+  few: 3,
+  couple: 2,
+};
+
+const UNITS: Record<string, string> = {
+  minute: 'm',
+  min: 'm',
+  hour: 'h',
+  day: 'd',
+  week: 'w',
+  month: 'M',
+};
+
+// Parts of the day as hours from midnight; the night runs into the next day
+const DAY_PARTS: Record<string, [number, number]> = {
+  morning: [6, 12],
+  afternoon: [12, 18],
+  evening: [18, 24],
+  night: [18, 30],
+};
+
+const WEEKDAYS = ['sunday', 'monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday'];
+
+// Events that annotations commonly mark, with the words matching them
+const EVENTS: Record<string, string> = {
+  deploy: 'deploy|deployment|deployed|release|released|rollout|roll-out|rolled out',
+  upgrade: 'upgrade|upgraded|update|updated',
+  migration: 'migration|migrated|migrate',
+  incident: 'incident|outage',
+  restart: 'restart|restarted|reboot',
+  maintenance: 'maintenance',
+};
+
+// Window around a clock time such as "around 14:05"
+const CLOCK_WINDOW_MS = 30 * 60 * 1000;
+
+// Local date (YYYY-MM-DD) of a time, days before or after it
+function localDate(time: Date, days: number, timezone?: string): string {
+  const shifted = parseTime(`now${days < 0 ? '-' : '+'}${Math.abs(days)}d`, {
+    now: time,
+    timezone,
+  });
+  return formatTime(shifted, timezone).slice(0, 10);
+}
+
+// Time of an hour from midnight of a local date; hours beyond 24 fall on the next day
+function atHour(date: string, hours: number, minutes: number, timezone?: string): Date {
+  const day = parseTime(date, { timezone });
+  const extraDays = Math.floor(hours / 24);
+  const base = extraDays > 0 ? localDate(day, extraDays, timezone) : date;
+  const clock = `${String(hours % 24).padStart(2, '0')}:${String(minutes).padStart(2, '0')}`;
+  return parseTime(`${base} ${clock}`, { timezone });
+}
+
+// Resolves the calendar part of a phrase to a range; undefined if the phrase names none
+export function resolvePhraseRange(
+  phrase: string,
+  options: PhraseOptions = {}
+): PhraseRange | undefined {
+  const text = phrase.toLowerCase();
+  const now = options.now || new Date();
+  const timezone = options.timezone;
+  const relative = (start: string, end: string, interpretation: string): PhraseRange => ({
+    ...parseTimeRange(start, end, { now, timezone }),
+    interpretation,
+  });
+  const today = localDate(now, 0, timezone);
+  const yesterday = localDate(now, -1, timezone);
+
+  // Grafana-style ranges, e.g. "now-6h to now"
+  const explicit = /^\s*(\S+)\s+to\s+(\S+)\s*$/.exec(text);
+  if (explicit && /^now|^\d/.test(explicit[1])) {
+    return relative(explicit[1], explicit[2], `${explicit[1]} to ${explicit[2]}`);
+  }
+
+  const recent = new RegExp(
+    `\\b(?:last|past|previous|recent)\\s+(\\d+|${Object.keys(NUMBER_WORDS).join('|')})?\\s*` +
+      `(?:of\\s+)?(minute|min|hour|day|week|month)s?\\b`
+  ).exec(text);
+  if (recent && (recent[1] || /hour|minute|min|day/.test(recent[2]))) {
+    const count = recent[1] ? parseInt(recent[1], 10) || NUMBER_WORDS[recent[1]] : 1;
+    const unit = UNITS[recent[2]];
+    const name = `${recent[2] === 'min' ? 'minute' : recent[2]}${count === 1 ? '' : 's'}`;
+    return relative(`now-${count}${unit}`, 'now', `the past ${count} ${name}`);
+  }
+  const calendar = /\b(last|this)\s+(week|month)\b/.exec(text);
+  if (calendar) {
+    const unit = UNITS[calendar[2]];
+    return calendar[1] === 'this'
+      ? relative(`now/${unit}`, 'now', `this ${calendar[2]} so far`)
+      : relative(`now-1${unit}/${unit}`, `now-1${unit}/${unit}`, `the previous ${calendar[2]}`);
+  }
+
+  const part = /\b(this|yesterday|last|tonight)\s*(morning|afternoon|evening|night)?\b/.exec(text);
+  if (part && (part[2] || part[1] === 'tonight')) {
+    const name = part[2] || 'evening';
+    const [from, to] = DAY_PARTS[name];
+    const previous = part[1] === 'yesterday' || (part[1] === 'last' && name === 'night');
+    let start = atHour(previous ? yesterday : today, from, 0, timezone);
+    let end = atHour(previous ? yesterday : today, to, 0, timezone);
+    // A part of today that has not begun yet means the one of yesterday
+    if (start > now) {
+      start = atHour(yesterday, from, 0, timezone);
+      end = atHour(yesterday, to, 0, timezone);
+    }
+    const label = part[1] === 'tonight' ? 'tonight' : `${part[1]} ${name}`;
+    return {
+      start,
+      end: end > now ? now : end,
+      interpretation: `${label}: ${formatTime(start, timezone)} to ${formatTime(end, timezone)}`,
+    };
+  }
+
+  if (/\byesterday\b/.test(text)) {
+    return relative('now-1d/d', 'now-1d/d', 'yesterday');
+  }
+  if (/\btoday\b/.test(text)) {
+    return relative('now/d', 'now', 'today so far');
+  }
+
+  const weekday = new RegExp(`\\b(${WEEKDAYS.join('|')})\\b`).exec(text);
+  if (weekday) {
+    // The most recent such day before today
+    const current = new Date(`${today}T00:00:00Z`).getUTCDay();
+    const days = (current - WEEKDAYS.indexOf(weekday[1]) + 7) % 7 || 7;
+    const date = localDate(now, -days, timezone);
+    return {
+      start: atHour(date, 0, 0, timezone),
+      end: new Date(atHour(date, 24, 0, timezone).getTime() - 1),
+      interpretation: `${weekday[1]} ${date}`,
+    };
+  }
+
+  const clock = /\b(?:around|at|about)\s+(\d{1,2})(?::(\d{2}))?\s*(am|pm)?\b/.exec(text);
+  if (clock && (clock[2] || clock[3])) {
+    let hours = parseInt(clock[1], 10) % 24;
+    if (clock[3] === 'pm' && hours < 12) hours += 12;
+    if (clock[3] === 'am' && hours === 12) hours = 0;
+    let center = atHour(today, hours, parseInt(clock[2] || '0', 10), timezone);
+    // A clock time later than now means the same time yesterday
+    if (center > now) {
+      center = atHour(yesterday, hours, parseInt(clock[2] || '0', 10), timezone);
+    }
+    return {
+      start: new Date(center.getTime() - CLOCK_WINDOW_MS),
+      end: new Date(Math.min(center.getTime() + CLOCK_WINDOW_MS, now.getTime())),
+      interpretation: `30 minutes around ${formatTime(center, timezone)}`,
+    };
+  }
+
+  return undefined;
+}
+
+// Finds the event a phrase refers to, e.g. "deploy" in "during last night's deploy"
+export function resolvePhraseEvent(phrase: string): PhraseEvent | undefined {
+  const text = phrase.toLowerCase();
+  for (const [keyword, words] of Object.entries(EVENTS)) {
+    const match = new RegExp(`\\b(${words})\\b`).exec(text);
+    if (!match) continue;
+    const before = text.slice(0, match.index);
+    let relation: PhraseEvent['relation'] = 'during';
+    if (/\b(since)\b/.test(before)) relation = 'since';
+    else if (/\b(after|following)\b/.test(before)) relation = 'after';
+    else if (/\b(before|prior to|leading up to)\b/.test(before)) relation = 'before';
+    else if (/\b(around|near|about)\b/.test(before)) relation = 'around';
+    return { keyword, pattern: new RegExp(`\\b(${words})`, 'i'), relation };
+  }
+  return undefined;
+}
@@ -0,0 +1,232 @@
+// Shared parsing of time parameters accepted by query tools: "now", relative
+// expressions like "now-6h" or "now-1d/d", RFC3339 timestamps, and unix epochs.
+
+export interface TimeParseOptions {
+  // IANA time zone used for timestamps without an offset and for "/d"-style rounding (default: UTC)
+  timezone?: string;
+  // Round "/unit" expressions up to the end of the unit instead of down to its start
+  roundUp?: boolean;
+  // Reference time for relative expressions (default: the current time)
+  now?: Date;
+}
+
+export interface TimeRangeOptions extends TimeParseOptions {
+  defaultStart?: string;
+  defaultEnd?: string;
+}
+
+export interface TimeRange {
+  start: Date;
+  end: Date;
+}
+
+const RELATIVE_OFFSET = /^([+-])(\d+)([smhdwMy])/;
+const ROUNDING = /^\/([smhdwMy])$/;
+const NAIVE_DATETIME = /^\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?)?$/;
+const EPOCH = /^\d+(?:\.\d+)?$/;
+
+const UNIT_MS: Record<string, number> = {
+  s: 1000,
+  m: 60 * 1000,
+  h: 60 * 60 * 1000,
+  d: 24 * 60 * 60 * 1000,
+  w: 7 * 24 * 60 * 60 * 1000,
+};
+
+function invalidTime(input: string): Error {
+  return new Error(
+    `Invalid time "${input}": expected "now", a relative time like "now-6h" or "now-1d/d", ` +
+      'an RFC3339 timestamp, or a unix epoch'
+  );
+}
+
+// Returns the offset of a time zone from UTC at the given instant, in milliseconds
+export function timezoneOffsetMs(timezone: string | undefined, date: Date): number {
+  if (!timezone || timezone.toLowerCase() === 'utc') return 0;
+
+  const parts = new Intl.DateTimeFormat('en-US', {
+    timeZone: timezone,
+    hourCycle: 'h23',
+    year: 'numeric',
+    month: '2-digit',
+    day: '2-digit',
+    hour: '2-digit',
+    minute: '2-digit',
+    second: '2-digit',
+  }).formatToParts(date);
+  const get = (type: string) => Number(parts.find(part => part.type === type)?.value);
+
+  const asUtc = Date.UTC(
+    get('year'),
+    get('month') - 1,
+    get('day'),
+    get('hour'),
+    get('minute'),
+    get('second')
+  );
+  return asUtc - Math.floor(date.getTime() / 1000) * 1000;
+}
+
+// Throws if the time zone is not a valid IANA time zone name
+export function validateTimezone(timezone: string): void {
+  try {
+    timezoneOffsetMs(timezone, new Date());
+  } catch {
+    throw new Error(
+      `Invalid time zone "${timezone}": expected an IANA name like "Europe/Berlin" or "UTC"`
+    );
+  }
+}
+
+// Converts wall-clock time in a time zone (expressed as a UTC timestamp) to the real instant
+function fromWallClock(wallClockMs: number, timezone?: string): Date {
+  const guess = wallClockMs - timezoneOffsetMs(timezone, new Date(wallClockMs));
+  // Re-evaluate once so instants near a DST change use the offset in effect at that time
+  return new Date(wallClockMs - timezoneOffsetMs(timezone, new Date(guess)));
+}
+
+function addCalendarUnits(date: Date, amount: number, unit: string, timezone?: string): Date {
+  if (UNIT_MS[unit]) {
+    return new Date(date.getTime() + amount * UNIT_MS[unit]);
+  }
+
+  const wallClock = new Date(date.getTime() + timezoneOffsetMs(timezone, date));
+  const day = wallClock.getUTCDate();
+  wallClock.setUTCDate(1);
+  if (unit === 'M') {
+    wallClock.setUTCMonth(wallClock.getUTCMonth() + amount);
+  } else {
+    wallClock.setUTCFullYear(wallClock.getUTCFullYear() + amount);
+  }
+  // Clamp to the last day of the month, so "now-1M" on March 31 is the end of February
+  const daysInMonth = new Date(
+    Date.UTC(wallClock.getUTCFullYear(), wallClock.getUTCMonth() + 1, 0)
+  ).getUTCDate();
+  wallClock.setUTCDate(Math.min(day, daysInMonth));
+  return fromWallClock(wallClock.getTime(), timezone);
+}
+
+function roundToUnit(date: Date, unit: string, roundUp: boolean, timezone?: string): Date {
+  const wallClock = new Date(date.getTime() + timezoneOffsetMs(timezone, date));
+
+  switch (unit) {
+    case 'y':
+      wallClock.setUTCMonth(0, 1);
+      wallClock.setUTCHours(0, 0, 0, 0);
+      break;
+    case 'M':
+      wallClock.setUTCDate(1);
+      wallClock.setUTCHours(0, 0, 0, 0);
+      break;
+    case 'w':
+      // Weeks start on Monday
+      wallClock.setUTCDate(wallClock.getUTCDate() - ((wallClock.getUTCDay() + 6) % 7));
+      wallClock.setUTCHours(0, 0, 0, 0);
+      break;
+    case 'd':
+      wallClock.setUTCHours(0, 0, 0, 0);
+      break;
+    case 'h':
+      wallClock.setUTCMinutes(0, 0, 0);
+      break;
+    case 'm':
+      wallClock.setUTCSeconds(0, 0);
+      break;
+    case 's':
+      wallClock.setUTCMilliseconds(0);
+      break;
+  }
+
+  const start = fromWallClock(wallClock.getTime(), timezone);
+  if (!roundUp) return start;
+  return new Date(addCalendarUnits(start, 1, unit, timezone).getTime() - 1);
+}
+
+// Parses a time parameter into a Date
+export function parseTime(input: string, options: TimeParseOptions = {}): Date {
+  const value = input.trim();
+  const now = options.now || new Date();
+
+  if (value.startsWith('now')) {
+    let rest = value.slice(3);
+    let date = now;
+
+    let match = rest.match(RELATIVE_OFFSET);
+    while (match) {
+      const amount = parseInt(match[2], 10) * (match[1] === '-' ? -1 : 1);
+      date = addCalendarUnits(date, amount, match[3], options.timezone);
+      rest = rest.slice(match[0].length);
+      match = rest.match(RELATIVE_OFFSET);
+    }
+
+    if (rest === '') return date;
+
+    const rounding = rest.match(ROUNDING);
+    if (!rounding) throw invalidTime(input);
+    return roundToUnit(date, rounding[1], options.roundUp || false, options.timezone);
+  }
+
+  if (EPOCH.test(value)) {
+    const epoch = parseFloat(value);
+    // Distinguish seconds, milliseconds, and nanoseconds by magnitude
+    if (epoch >= 1e17) return new Date(epoch / 1e6);
+    if (epoch >= 1e11) return new Date(epoch);
+    return new Date(epoch * 1000);
+  }
+
+  if (NAIVE_DATETIME.test(value)) {
+    const iso = value.length === 10 ? `${value}T00:00:00` : value.replace(' ', 'T');
+    const wallClockMs = Date.parse(`${iso}Z`);
+    if (isNaN(wallClockMs)) throw invalidTime(input);
+    return fromWallClock(wallClockMs, options.timezone);
+  }
+
+  const ms = Date.parse(value);
+  if (isNaN(ms)) throw invalidTime(input);
+  return new Date(ms);
+}
+
+// Parses an optional start/end pair, applying defaults and checking the range is not inverted
+export function parseTimeRange(
+  start: string | undefined,
+  end: string | undefined,
+  options: TimeRangeOptions = {}
+): TimeRange {
+  const now = options.now || new Date();
+  const startInput = start || options.defaultStart;
+  if (!startInput) {
+    throw new Error('A start time is required');
+  }
+
+  const range = {
+    start: parseTime(startInput, { ...options, now, roundUp: false }),
+    end: parseTime(end || options.defaultEnd || 'now', { ...options, now, roundUp: true }),
+  };
+
+  if (range.end < range.start) {
+    throw new Error(
+      `Invalid time range: end (${range.end.toISOString()}) is before start (${range.start.toISOString()})`
+    );
+  }
+  return range;
+}
+
+export function toUnixSeconds(date: Date): number {
+  return Math.floor(date.getTime() / 1000);
+}
+
+// Formats a time as RFC3339 with the offset of the given time zone (UTC when unset)
+export function formatTime(time: Date | number, timezone?: string): string {
+  const date = typeof time === 'number' ? new Date(time) : time;
+  const offsetMs = timezoneOffsetMs(timezone, date);
+  if (offsetMs === 0) {
+    return date.toISOString();
+  }
+
+  const wallClock = new Date(date.getTime() + offsetMs).toISOString().slice(0, -1);
+  const offsetMinutes = Math.abs(offsetMs) / 60000;
+  const sign = offsetMs > 0 ? '+' : '-';
+  const hours = String(Math.floor(offsetMinutes / 60)).padStart(2, '0');
+  const minutes = String(offsetMinutes % 60).padStart(2, '0');
+  return `${wallClock}${sign}${hours}:${minutes}`;
+}
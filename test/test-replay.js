@@ -0,0 +1,76 @@
+#!/usr/bin/env node
+
+/**
+ * Replays the recorded Grafana responses in test/fixtures through the tools,
+ * so they can be checked without a Grafana instance. Run `npm run build` first.
+ */
+
+const path = require('path');
+const {
+  MCPServer,
+  Cassette,
+  withCassette,
+  createInProcessClient,
+  registerTools,
+} = require('../dist');
+
+const fixtures = path.join(__dirname, 'fixtures');
+
+const cases = [
+  {
+    fixture: 'search-dashboards.json',
+    tool: 'search_dashboards',
+    args: { query: 'checkout' },
+    expect: text => text.includes('checkout-overview'),
+  },
+  {
+    fixture: 'get-dashboard-by-uid.json',
+    tool: 'get_dashboard_by_uid',
+    args: { uid: 'checkout-overview' },
+    expect: text => text.includes('Request rate by status'),
+  },
+  {
+    fixture: 'query-prometheus-instant.json',
+    tool: 'query_prometheus',
+    args: { datasourceUid: 'prometheus', expr: 'up', queryType: 'instant', startTime: 'now' },
+    // The instant query time is computed from "now", so it is not matched
+    ignoreParams: ['time'],
+    expect: text => text.includes('checkout:8080'),
+  },
+];
+
+async function main() {
+  const server = new MCPServer({
+    transport: 'stdio',
+    enabledTools: new Set(['search', 'dashboard', 'prometheus']),
+    disableWrite: true,
+    grafanaConfig: { url: 'http://grafana.replay', serviceAccountToken: 'replay' },
+  });
+  registerTools(server, 'search', 'dashboard', 'prometheus');
+  const client = await createInProcessClient(server);
+
+  let failed = 0;
+  for (const testCase of cases) {
+    const cassette = await Cassette.load(path.join(fixtures, testCase.fixture), {
+      ignoreParams: testCase.ignoreParams,
+    });
+    const result = await withCassette(cassette, () =>
+      client.callTool({ name: testCase.tool, arguments: testCase.args })
+    );
+    const text = (result.content || []).map(content => content.text || '').join('\n');
+    if (!result.isError && testCase.expect(text)) {
+      console.log(`✅ ${testCase.tool}`);
+    } else {
+      failed++;
+      console.log(`❌ ${testCase.tool}: ${text.slice(0, 500)}`);
+    }
+  }
+
+  await client.close();
+  process.exit(failed ? 1 : 0);
+}
+
+main().catch(error => {
+  console.error('❌ Replay failed:', error.message);
+  process.exit(1);
+});
@@ -0,0 +1,244 @@
+#!/usr/bin/env node
+
+/**
+ * Table-driven checks of the guards that keep tools within their configured
+ * limits: the datasource policy, the folder policy for write tools, the
+ * read-only SQL check, and the redaction of secrets and log lines. No Grafana
+ * instance is needed. Run `npm run build` first.
+ */
+
+const { collectDatasourceUids, isDatasourceAllowed } = require('../dist/server/datasource-policy');
+const {
+  isFolderWritable,
+  checkFolderWrite,
+  checkOverwriteWrite,
+} = require('../dist/server/folder-policy');
+const { checkReadOnlySql } = require('../dist/tools/sql');
+const { redactSecrets } = require('../dist/utils/redact');
+const { createLogScrubber, scrubFrames } = require('../dist/utils/scrub');
+
+let failed = 0;
+
+function check(name, actual, expected) {
+  const ok = JSON.stringify(actual) === JSON.stringify(expected);
+  if (ok) {
+    console.log(`✅ ${name}`);
+  } else {
+    failed++;
+    console.log(`❌ ${name}: expected ${JSON.stringify(expected)}, got ${JSON.stringify(actual)}`);
+  }
+}
+
+// Returns the error message of a call, or undefined if it did not throw
+function errorOf(fn) {
+  try {
+    fn();
+    return undefined;
+  } catch (error) {
+    return error.message;
+  }
+}
+
+async function asyncErrorOf(fn) {
+  try {
+    await fn();
+    return undefined;
+  } catch (error) {
+    return error.message;
+  }
+}
+
+function policy(overrides) {
+  return { allowedUids: [], allowedTypes: [], deniedUids: [], deniedTypes: [], ...overrides };
+}
+
+function testDatasourcePolicy() {
+  const cases = [
+    ['no policy allows all', undefined, { uid: 'prom' }, true],
+    ['empty policy allows all', policy({}), { uid: 'prom', type: 'prometheus' }, true],
+    ['allowed uid', policy({ allowedUids: ['prom'] }), { uid: 'prom' }, true],
+    ['uid not on allow list', policy({ allowedUids: ['prom'] }), { uid: 'loki' }, false],
+    [
+      'allowed type',
+      policy({ allowedTypes: ['prometheus'] }),
+      { uid: 'prom', type: 'prometheus' },
+      true,
+    ],
+    ['type unknown', policy({ allowedTypes: ['prometheus'] }), { uid: 'x' }, false],
+    ['denied uid', policy({ deniedUids: ['prom'] }), { uid: 'prom' }, false],
+    [
+      'deny wins over allow',
+      policy({ allowedUids: ['prom'], deniedTypes: ['prometheus'] }),
+      { uid: 'prom', type: 'prometheus' },
+      false,
+    ],
+    ['expressions always allowed', policy({ allowedUids: ['prom'] }), { uid: '__expr__' }, true],
+  ];
+  for (const [name, config, datasource, expected] of cases) {
+    check(`isDatasourceAllowed: ${name}`, isDatasourceAllowed(config, datasource), expected);
+  }
+
+  const collectCases = [
+    ['top-level uid', { datasourceUid: 'prom' }, ['prom']],
+    ['uid list', { datasourceUids: ['prom', 'loki'] }, ['prom', 'loki']],
+    ['snake case', { data_source_uid: 'tempo' }, ['tempo']],
+    [
+      'nested queries, deduplicated',
+      { queries: ['prom', 'loki', 'prom'].map(uid => ({ datasourceUid: uid })) },
+      ['prom', 'loki'],
+    ],
+    ['other keys ignored', { uid: 'dashboard', datasource: { uid: 'prom' } }, []],
+    ['non-strings ignored', { datasourceUid: 42 }, []],
+  ];
+  for (const [name, args, expected] of collectCases) {
+    check(`collectDatasourceUids: ${name}`, collectDatasourceUids(args), expected);
+  }
+}
+
+async function testFolderPolicy() {
+  const restricted = { url: 'http://grafana', writeFolderUids: ['team-a', 'general'] };
+  const unrestricted = { url: 'http://grafana' };
+  const cases = [
+    ['unrestricted', unrestricted, 'any', true],
+    ['allowed folder', restricted, 'team-a', true],
+    ['other folder', restricted, 'team-b', false],
+    ['root level as general', restricted, undefined, true],
+    ['root level not allowed', { ...restricted, writeFolderUids: ['team-a'] }, undefined, false],
+  ];
+  for (const [name, config, folderUid, expected] of cases) {
+    check(`isFolderWritable: ${name}`, isFolderWritable(config, folderUid), expected);
+  }
+  check(
+    'checkFolderWrite: names the folder',
+    /team-b/.test(errorOf(() => checkFolderWrite(restricted, 'team-b', 'dashboard "x"')) || ''),
+    true
+  );
+
+  // A client knowing dashboard "a" in team-a and dashboard 7 ("b") in team-b
+  const client = {
+    getDashboardWithMeta: async uid => {
+      if (uid === 'a') return { dashboard: { uid }, meta: { folderUid: 'team-a' } };
+      if (uid === 'b') return { dashboard: { uid }, meta: { folderUid: 'team-b' } };
+      throw new Error('Dashboard not found (404)');
+    },
+    search: async params =>
+      params.dashboardIds.includes(7) ? [{ uid: 'b', folderUid: 'team-b' }] : [],
+  };
+  const overwriteCases = [
+    ['new dashboard', {}, undefined],
+    ['unknown uid', { uid: 'new' }, undefined],
+    ['uid in allowed folder', { uid: 'a' }, undefined],
+    ['uid in other folder', { uid: 'b' }, 'team-b'],
+    ['id of dashboard in other folder', { uid: 'a', id: 7 }, 'team-b'],
+  ];
+  for (const [name, ref, folder] of overwriteCases) {
+    const message = await asyncErrorOf(() => checkOverwriteWrite(client, restricted, ref));
+    let outcome = message;
+    if (message === undefined) outcome = 'allowed';
+    else if (message.includes(`"${folder}"`)) outcome = 'refused';
+    check(`checkOverwriteWrite: ${name}`, outcome, folder ? 'refused' : 'allowed');
+  }
+}
+
+function testReadOnlySql() {
+  const allowed = [
+    'SELECT * FROM orders',
+    'select count(*) from orders where status = \'delete\'',
+    'WITH recent AS (SELECT * FROM orders) SELECT * FROM recent',
+    'SELECT replace(name, \'a\', \'b\') FROM users',
+    '-- latest\nSELECT 1;',
+    'EXPLAIN SELECT * FROM orders',
+  ];
+  for (const sql of allowed) {
+    const name = sql.replace(/\n/g, ' ');
+    check(`checkReadOnlySql allows: ${name}`, errorOf(() => checkReadOnlySql(sql)), undefined);
+  }
+
+  const rejected = [
+    'DELETE FROM orders',
+    'SELECT 1; DROP TABLE orders',
+    'SELECT * INTO backup FROM orders',
+    'UPDATE orders SET status = \'x\'',
+    'SELECT 1 /* ; */ ; DELETE FROM orders',
+    // MySQL reads the backslash as an escape, so the DELETE is outside the string there
+    'SELECT \'\\\'; DELETE FROM orders; -- \'',
+    // PostgreSQL dollar quoting hides the statement from the other reading
+    'SELECT $$; DROP TABLE orders; $$',
+    'WITH gone AS (DELETE FROM orders RETURNING *) SELECT * FROM gone',
+  ];
+  for (const sql of rejected) {
+    const refused = errorOf(() => checkReadOnlySql(sql)) !== undefined;
+    check(`checkReadOnlySql rejects: ${sql}`, refused, true);
+  }
+}
+
+function testRedaction() {
+  const redactCases = [
+    ['password', { password: 'hunter2' }, { password: '[REDACTED]' }],
+    [
+      'nested token',
+      { jsonData: { accessToken: 'abc' } },
+      { jsonData: { accessToken: '[REDACTED]' } },
+    ],
+    ['secure data dropped', { secureJsonData: { apiKey: 'abc' }, name: 'x' }, { name: 'x' }],
+    [
+      'secure fields kept',
+      { secureJsonFields: { apiKey: true } },
+      { secureJsonFields: { apiKey: true } },
+    ],
+    [
+      'header values',
+      { httpHeaderName1: 'X-Key', httpHeaderValue1: 'abc' },
+      { httpHeaderName1: 'X-Key', httpHeaderValue1: '[REDACTED]' },
+    ],
+    ['empty secret kept', { password: '' }, { password: '' }],
+    ['arrays', [{ clientSecret: 'abc' }], [{ clientSecret: '[REDACTED]' }]],
+    ['other keys kept', { url: 'http://db', user: 'x' }, { url: 'http://db', user: 'x' }],
+  ];
+  for (const [name, input, expected] of redactCases) {
+    check(`redactSecrets: ${name}`, redactSecrets(input), expected);
+  }
+
+  const none = createLogScrubber({ rules: [], patterns: [] });
+  check('createLogScrubber: none configured', none, undefined);
+  const scrub = createLogScrubber({
+    rules: ['email', 'token', 'credit_card'],
+    patterns: ['user-\\d+'],
+  });
+  const scrubCases = [
+    ['email', 'mail from jane@example.com', 'mail from [REDACTED:email]'],
+    ['bearer token', 'Authorization: Bearer abcdef123456', 'Authorization: [REDACTED:token]'],
+    ['key=value secret', 'password=hunter2 ok', '[REDACTED:token] ok'],
+    ['card number', 'card 4111 1111 1111 1111', 'card [REDACTED:credit_card]'],
+    ['order id not a card', 'order 1234567890123', 'order 1234567890123'],
+    ['custom pattern', 'user-42 logged in', '[REDACTED:custom] logged in'],
+  ];
+  for (const [name, input, expected] of scrubCases) {
+    check(`log scrubber: ${name}`, scrub(input), expected);
+  }
+
+  const email = 'jane@example.com';
+  const logFrame = {
+    schema: { fields: [{ name: 'labels' }, { name: 'Line', labels: { user: email } }] },
+    data: { values: [[{ user: email }], [`login by ${email}`], [1]] },
+  };
+  const frames = scrubFrames({ results: { A: { frames: [logFrame] } } }, scrub);
+  const frame = frames.results.A.frames[0];
+  check('scrubFrames: field labels', frame.schema.fields[1].labels.user, '[REDACTED:email]');
+  check('scrubFrames: label values', frame.data.values[0][0].user, '[REDACTED:email]');
+  check('scrubFrames: lines', frame.data.values[1][0], 'login by [REDACTED:email]');
+  check('scrubFrames: numbers kept', frame.data.values[2][0], 1);
+}
+
+async function main() {
+  testDatasourcePolicy();
+  await testFolderPolicy();
+  testReadOnlySql();
+  testRedaction();
+  process.exit(failed ? 1 : 0);
+}
+
+main().catch(error => {
+  console.error('❌ Security guard tests failed:', error.message);
+  process.exit(1);
+});
@@ -0,0 +1,123 @@
+#!/usr/bin/env node
+
+/**
+ * Checks that a streamable HTTP session started on one server instance can be
+ * resumed on another sharing its session store. Resuming marks the SDK's
+ * transport as initialized through a private field, so run this after every
+ * SDK upgrade. Run `npm run build` first.
+ */
+
+const pino = require('pino');
+const { Server } = require('@modelcontextprotocol/sdk/server/index.js');
+const { ListToolsRequestSchema } = require('@modelcontextprotocol/sdk/types.js');
+const { HttpTransportServer } = require('../dist/server/http-transport');
+const { MemorySessionStore } = require('../dist/server/session-store');
+
+const FIRST_PORT = 38231;
+const SECOND_PORT = 38232;
+
+function createSession() {
+  const server = new Server(
+    { name: 'session-restore-test', version: '1.0.0' },
+    { capabilities: { tools: {} } }
+  );
+  server.setRequestHandler(ListToolsRequestSchema, async () => ({
+    tools: [{ name: 'ping', description: 'Ping', inputSchema: { type: 'object' } }],
+  }));
+  return server;
+}
+
+function startInstance(port, sessionStore) {
+  const instance = new HttpTransportServer(
+    {
+      transport: 'streamable-http',
+      address: '127.0.0.1',
+      port,
+      path: '/sse',
+      sessionStore,
+      sessionTtlMs: 60 * 1000,
+    },
+    createSession,
+    pino({ level: 'silent' })
+  );
+  return instance.listen().then(() => instance);
+}
+
+async function post(port, body, sessionId) {
+  const headers = {
+    'Content-Type': 'application/json',
+    Accept: 'application/json, text/event-stream',
+  };
+  if (sessionId) headers['mcp-session-id'] = sessionId;
+  const response = await fetch(`http://127.0.0.1:${port}/mcp`, {
+    method: 'POST',
+    headers,
+    body: JSON.stringify(body),
+  });
+  return { response, text: await response.text() };
+}
+
+async function main() {
+  const sessionStore = new MemorySessionStore(60 * 1000);
+  let failed = 0;
+  const check = (name, ok, detail) => {
+    if (ok) {
+      console.log(`✅ ${name}`);
+    } else {
+      failed++;
+      console.log(`❌ ${name}: ${detail}`);
+    }
+  };
+
+  const first = await startInstance(FIRST_PORT, sessionStore);
+  const initialized = await post(FIRST_PORT, {
+    jsonrpc: '2.0',
+    id: 1,
+    method: 'initialize',
+    params: {
+      protocolVersion: '2025-03-26',
+      capabilities: {},
+      clientInfo: { name: 'test', version: '1.0.0' },
+    },
+  });
+  const sessionId = initialized.response.headers.get('mcp-session-id');
+  check('session started', !!sessionId, initialized.text.slice(0, 500));
+  // Closing keeps the session in the store, as when an instance shuts down
+  await first.close();
+
+  const second = await startInstance(SECOND_PORT, sessionStore);
+  const listed = await post(
+    SECOND_PORT,
+    { jsonrpc: '2.0', id: 2, method: 'tools/list' },
+    sessionId
+  );
+  check(
+    'session resumed on another instance',
+    listed.response.status === 200 && listed.text.includes('"ping"'),
+    `${listed.response.status} ${listed.text.slice(0, 500)}`
+  );
+
+  const oversized = await post(
+    SECOND_PORT,
+    {
+      jsonrpc: '2.0',
+      id: 3,
+      method: 'tools/list',
+      params: { padding: 'x'.repeat(6 * 1024 * 1024) },
+    },
+    sessionId
+  ).catch(error => ({ error }));
+  check(
+    'oversized body rejected',
+    oversized.error || oversized.response.status >= 400,
+    oversized.response && oversized.response.status
+  );
+
+  await second.close();
+  process.exit(failed ? 1 : 0);
+}
+
+main().catch(error => {
+  console.error('❌ Session restore test failed:', error.message);
+  process.exit(1);
+});